@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/storage"
+)
+
+// runCommand runs a maintenance command instead of the server.
+func runCommand(cfg *config.Config, name string, args []string) error {
+	switch name {
+	case "migrate":
+		return migrate(cfg, args)
+	case "copy-sqlite":
+		return copySQLite(cfg, args)
+	case "storage":
+		return storageCommand(cfg, args)
+	default:
+		return fmt.Errorf("unknown command %q, available: migrate, copy-sqlite, storage", name)
+	}
+}
+
+const migrateUsage = "usage: mailgress migrate status | up | down [count] | to <version>"
+
+// migrate shows, applies or reverts schema migrations of the configured
+// database.
+func migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	conn, _, err := database.NewConnection(cfg.DBDriver, cfg.DBDsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := database.NewMigrator(conn, cfg.DBDriver)
+	if err != nil {
+		return err
+	}
+	migrator.Logf = log.Printf
+
+	ctx := context.Background()
+	var done []database.Migration
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		return printMigrationStatus(ctx, migrator)
+	case args[0] == "up" && len(args) == 1:
+		done, err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) <= 2:
+		count := 1
+		if len(args) == 2 {
+			if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+				return fmt.Errorf("count must be a positive number")
+			}
+		}
+		done, err = migrator.Down(ctx, count)
+	case args[0] == "to" && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			return fmt.Errorf("version must be a migration number, such as 28")
+		}
+		done, err = migrator.To(ctx, version)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		log.Println("Nothing to migrate")
+	}
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMIGRATION\tSTATE\tAPPLIED AT\tDOWN")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		if status.Modified {
+			state = "modified since applied"
+		}
+		if status.Unknown {
+			state = "unknown to this build"
+		}
+		down := "no"
+		if status.Reversible() {
+			down = "yes"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt, down)
+	}
+	return w.Flush()
+}
+
+// copySQLite copies the SQLite database at args[0] into the configured
+// Postgres database. The source is opened read-only and must be fully
+// migrated, the target is migrated first.
+func copySQLite(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: mailgress copy-sqlite <path to sqlite database>")
+	}
+	if cfg.DBDriver != "postgres" {
+		return fmt.Errorf("copy-sqlite copies into Postgres, set DB_DRIVER=postgres and DB_DSN to the target database")
+	}
+
+	src, err := database.OpenSQLiteReadOnly(args[0])
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := database.CheckMigrations(src, "sqlite"); err != nil {
+		return fmt.Errorf("cannot copy %s: %w", args[0], err)
+	}
+
+	dst, _, err := database.NewConnection(cfg.DBDriver, cfg.DBDsn)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	if err := database.RunMigrations(dst, cfg.DBDriver); err != nil {
+		return fmt.Errorf("failed to migrate postgres: %w", err)
+	}
+
+	err = database.CopySQLiteToPostgres(context.Background(), src, dst, func(table string, rows int64) {
+		log.Printf("Copied %d rows of %s", rows, table)
+	})
+	if err != nil {
+		return err
+	}
+	log.Println("Copy completed")
+	return nil
+}
+
+const storageUsage = "usage: mailgress storage gc [--delete-orphans] | migrate <from> <to> [--delete-source]"
+
+// storageCommand checks stored files against the database, or moves them
+// between storage drivers.
+func storageCommand(cfg *config.Config, args []string) error {
+	switch {
+	case len(args) >= 1 && args[0] == "gc":
+		return storageGC(cfg, args[1:])
+	case len(args) >= 1 && args[0] == "migrate":
+		return storageMigrate(cfg, args[1:])
+	default:
+		return errors.New(storageUsage)
+	}
+}
+
+func storageGC(cfg *config.Config, args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "--delete-orphans") {
+		return errors.New(storageUsage)
+	}
+	deleteOrphans := len(args) == 1
+
+	conn, queries, err := database.NewConnection(cfg.DBDriver, cfg.DBDsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := database.CheckMigrations(conn, cfg.DBDriver); err != nil {
+		return err
+	}
+
+	store, err := storage.New(cfg.StorageDriver, cfg)
+	if err != nil {
+		return err
+	}
+	storageService := service.NewStorageService(queries, store)
+
+	ctx := context.Background()
+	purged, err := storageService.PurgeTombstones(ctx)
+	if err != nil {
+		return err
+	}
+	report, err := storageService.Reconcile(ctx, deleteOrphans)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d stored files of deleted emails\n", purged)
+	fmt.Printf("Checked %d stored files, %d still queued for removal\n", report.Files, report.PendingTombstones)
+
+	fmt.Printf("\nFiles without an email or attachment: %d", len(report.OrphanFiles))
+	if deleteOrphans {
+		fmt.Printf(" (%d deleted)", report.DeletedOrphans)
+	}
+	fmt.Println()
+	for _, path := range report.OrphanFiles {
+		fmt.Printf("  %s\n", path)
+	}
+
+	fmt.Printf("\nEmails and attachments without a stored file: %d\n", len(report.MissingFiles))
+	if len(report.MissingFiles) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  KIND\tID\tEMAIL\tPATH")
+		for _, missing := range report.MissingFiles {
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%s\n", missing.Kind, missing.ID, missing.EmailID, missing.Path)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// storageMigrate copies every stored file from one storage driver to
+// another, both configured from the environment, keeping their paths.
+func storageMigrate(cfg *config.Config, args []string) error {
+	if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "--delete-source") {
+		return errors.New(storageUsage)
+	}
+	if args[0] == args[1] {
+		return fmt.Errorf("source and target storage drivers are the same")
+	}
+	deleteSource := len(args) == 3
+
+	src, err := storage.New(args[0], cfg)
+	if err != nil {
+		return fmt.Errorf("source storage: %w", err)
+	}
+	dst, err := storage.New(args[1], cfg)
+	if err != nil {
+		return fmt.Errorf("target storage: %w", err)
+	}
+
+	stats, err := storage.Copy(src, dst, deleteSource, func(info storage.ObjectInfo, err error) {
+		if err != nil {
+			log.Printf("Failed to migrate %s: %v", info.Path, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Copied %d files (%d bytes), %d already in %s, %d failed", stats.Copied, stats.Bytes, stats.Skipped, args[1], stats.Failed)
+	if stats.Failed > 0 {
+		return fmt.Errorf("%d files could not be migrated, run the command again to retry them", stats.Failed)
+	}
+	if cfg.StorageDriver != args[1] {
+		log.Printf("Set STORAGE_DRIVER=%s to use the migrated files", args[1])
+	}
+	return nil
+}
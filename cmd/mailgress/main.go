@@ -11,7 +11,9 @@ import (
 	"github.com/jr-k/mailgress/internal/buildinfo"
 	"github.com/jr-k/mailgress/internal/config"
 	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/events"
 	httpserver "github.com/jr-k/mailgress/internal/http"
+	"github.com/jr-k/mailgress/internal/mailer"
 	"github.com/jr-k/mailgress/internal/service"
 	smtpserver "github.com/jr-k/mailgress/internal/smtp"
 	"github.com/jr-k/mailgress/internal/storage"
@@ -21,6 +23,13 @@ import (
 func main() {
 	cfg := config.Load()
 
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	log.Printf("Starting Mailgress in %s mode", cfg.AppEnv)
 
 	if cfg.SafeMode {
@@ -34,30 +43,61 @@ func main() {
 	}
 	defer db.Close()
 
-	if err := database.RunMigrations(db, cfg.DBDriver); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if cfg.DBAutoMigrate {
+		if err := database.RunMigrations(db, cfg.DBDriver); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+		log.Println("Database migrations completed")
+	} else if err := database.CheckMigrations(db, cfg.DBDriver); err != nil {
+		log.Fatalf("Database schema is not up to date: %v", err)
 	}
-	log.Println("Database migrations completed")
 
-	store, err := storage.NewStorage(cfg.StoragePath)
+	store, err := storage.New(cfg.StorageDriver, cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 
+	bus := events.NewBus()
+	tx := database.NewTransactor(db, cfg.DBDriver)
+
 	settingsService := service.NewSettingsService(queries)
 	authService := service.NewAuthService(queries)
 	userService := service.NewUserService(queries)
-	mailboxService := service.NewMailboxService(queries)
-	emailService := service.NewEmailService(queries)
-	webhookService := service.NewWebhookService(queries)
+	mailboxService := service.NewMailboxService(queries, bus)
+	emailService := service.NewEmailService(queries, bus, cfg.DBDriver)
+	webhookService := service.NewWebhookService(queries, tx)
 	deliveryService := service.NewDeliveryService(queries)
-	domainService := service.NewDomainService(queries)
+	webhookJobService := service.NewWebhookJobService(queries, tx)
+	domainService := service.NewDomainService(queries, tx, bus)
 	tagService := service.NewTagService(queries)
+	apiTokenService := service.NewAPITokenService(queries)
+	storageService := service.NewStorageService(queries, store)
+
+	urlSigner := service.NewURLSigner(cfg.AppURL, cfg.AppKey, time.Duration(cfg.SignedURLTTLMinutes)*time.Minute)
+
+	relayFrom := cfg.SMTPRelayFrom
+	if relayFrom == "" {
+		relayFrom = "mailgress@" + cfg.SMTPHostname
+	}
+	relay := mailer.NewMailer(cfg.SMTPRelayAddr, cfg.SMTPRelayUsername, cfg.SMTPRelayPassword, relayFrom)
+	notificationService := service.NewNotificationService(queries, relay, cfg.AppURL)
+
+	if err := webhook.SetAllowlist(cfg.WebhookAllowlist); err != nil {
+		log.Fatalf("Invalid WEBHOOK_ALLOWLIST: %v", err)
+	}
 
-	dispatcher := webhook.NewDispatcher(cfg, webhookService, deliveryService, emailService)
+	signingKeys, err := webhook.LoadSigningKeys(context.Background(), settingsService)
+	if err != nil {
+		log.Fatalf("Failed to load webhook signing keys: %v", err)
+	}
+
+	dispatcher := webhook.NewDispatcher(cfg, webhookService, deliveryService, webhookJobService, emailService, mailboxService, domainService, notificationService, store, urlSigner, signingKeys, bus, webhook.DefaultBrokerDrivers())
 	dispatcher.Start()
 
-	smtpServer := smtpserver.NewServer(cfg, mailboxService, emailService, domainService, store, dispatcher)
+	smtpServer, err := smtpserver.NewServer(cfg, mailboxService, emailService, domainService, store, dispatcher)
+	if err != nil {
+		log.Fatalf("Failed to create SMTP server: %v", err)
+	}
 
 	httpServer, err := httpserver.NewServer(
 		cfg,
@@ -71,7 +111,9 @@ func main() {
 		deliveryService,
 		domainService,
 		tagService,
+		apiTokenService,
 		store,
+		urlSigner,
 		dispatcher,
 	)
 	if err != nil {
@@ -109,7 +151,7 @@ func main() {
 					continue
 				}
 				before := time.Now().AddDate(0, 0, -mb.RetentionDays)
-				if err := emailService.DeleteOldEmailsByMailbox(context.Background(), mb.ID, before); err != nil {
+				if _, err := emailService.DeleteOldEmailsByMailbox(context.Background(), mb.ID, before); err != nil {
 					log.Printf("Failed to cleanup old emails for mailbox %d: %v", mb.ID, err)
 				}
 			}
@@ -128,6 +170,39 @@ func main() {
 		}
 	}()
 
+	// Stored files of deleted emails and attachments
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		var reconciled time.Time
+		gcInterval := time.Duration(cfg.StorageGCIntervalHours) * time.Hour
+
+		for {
+			if purged, err := storageService.PurgeTombstones(context.Background()); err != nil {
+				log.Printf("Failed to remove stored files of deleted emails: %v", err)
+			} else if purged > 0 {
+				log.Printf("Removed %d stored files of deleted emails", purged)
+			}
+
+			if gcInterval > 0 && time.Since(reconciled) >= gcInterval {
+				reconciled = time.Now()
+				report, err := storageService.Reconcile(context.Background(), cfg.StorageGCDeleteOrphans)
+				if err != nil {
+					log.Printf("Failed to check storage: %v", err)
+				} else {
+					log.Printf("Storage check completed: %d files, %d orphaned (%d deleted), %d missing", report.Files, len(report.OrphanFiles), report.DeletedOrphans, len(report.MissingFiles))
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	go func() {
 		ticker := time.NewTicker(time.Hour)
 		defer ticker.Stop()
@@ -138,6 +213,9 @@ func main() {
 				return
 			case <-ticker.C:
 				authService.CleanupExpiredSessions(context.Background())
+				if err := webhookService.CleanupExpiredSecrets(context.Background()); err != nil {
+					log.Printf("Failed to cleanup expired webhook secrets: %v", err)
+				}
 			}
 		}
 	}()
@@ -148,6 +226,9 @@ func main() {
 	log.Println("Mailgress is ready")
 	log.Printf("HTTP: %s", cfg.HTTPListenAddr)
 	log.Printf("SMTP: %s", cfg.SMTPListenAddr)
+	if cfg.SMTPTLSEnabled() && cfg.SMTPTLSListenAddr != "" {
+		log.Printf("SMTPS: %s", cfg.SMTPTLSListenAddr)
+	}
 
 	<-sigChan
 	log.Println("Shutting down...")
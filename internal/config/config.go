@@ -13,12 +13,50 @@ type Config struct {
 	SMTPListenAddr string
 	HTTPListenAddr string
 
-	DBDriver string
-	DBDsn    string
+	SMTPTLSCertFile   string
+	SMTPTLSKeyFile    string
+	SMTPTLSListenAddr string
 
-	WebhookWorkers int
+	SMTPHostname   string
+	SMTPAuthChecks bool
 
-	StoragePath string
+	DBDriver      string
+	DBDsn         string
+	DBAutoMigrate bool
+
+	WebhookWorkers  int
+	WebhookQueueMax int
+
+	WebhookCircuitThreshold    int
+	WebhookCircuitProbeSeconds int
+	WebhookDisableAfterHours   int
+
+	WebhookSecretGraceHours int
+
+	WebhookAllowlist     string
+	WebhookDirectoryRoot string
+
+	SMTPRelayAddr     string
+	SMTPRelayUsername string
+	SMTPRelayPassword string
+	SMTPRelayFrom     string
+
+	StorageDriver string
+	StoragePath   string
+
+	StorageS3Endpoint        string
+	StorageS3Region          string
+	StorageS3Bucket          string
+	StorageS3Prefix          string
+	StorageS3AccessKeyID     string
+	StorageS3SecretAccessKey string
+	StorageS3PathStyle       bool
+	StorageS3Presign         bool
+
+	StorageGCIntervalHours int
+	StorageGCDeleteOrphans bool
+
+	SignedURLTTLMinutes int
 
 	SafeMode bool
 }
@@ -32,17 +70,59 @@ func Load() *Config {
 		SMTPListenAddr: getEnv("SMTP_LISTEN_ADDR", ":2525"),
 		HTTPListenAddr: getEnv("HTTP_LISTEN_ADDR", ":8080"),
 
-		DBDriver: getEnv("DB_DRIVER", "sqlite"),
-		DBDsn:    getEnv("DB_DSN", "mailgress.db"),
+		SMTPTLSCertFile:   getEnv("SMTP_TLS_CERT_FILE", ""),
+		SMTPTLSKeyFile:    getEnv("SMTP_TLS_KEY_FILE", ""),
+		SMTPTLSListenAddr: getEnv("SMTP_TLS_LISTEN_ADDR", ""),
+
+		SMTPHostname:   getEnv("SMTP_HOSTNAME", "mailgress"),
+		SMTPAuthChecks: getEnvBool("SMTP_AUTH_CHECKS", true),
 
-		WebhookWorkers: getEnvInt("WEBHOOK_WORKERS", 5),
+		DBDriver:      getEnv("DB_DRIVER", "sqlite"),
+		DBDsn:         getEnv("DB_DSN", "mailgress.db"),
+		DBAutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
 
-		StoragePath: getEnv("STORAGE_PATH", "./data/attachments"),
+		WebhookWorkers:  getEnvInt("WEBHOOK_WORKERS", 5),
+		WebhookQueueMax: getEnvInt("WEBHOOK_QUEUE_MAX", 10000),
+
+		WebhookCircuitThreshold:    getEnvInt("WEBHOOK_CIRCUIT_THRESHOLD", 5),
+		WebhookCircuitProbeSeconds: getEnvInt("WEBHOOK_CIRCUIT_PROBE_SECONDS", 60),
+		WebhookDisableAfterHours:   getEnvInt("WEBHOOK_DISABLE_AFTER_HOURS", 24),
+
+		WebhookSecretGraceHours: getEnvInt("WEBHOOK_SECRET_GRACE_HOURS", 24),
+
+		WebhookAllowlist:     getEnv("WEBHOOK_ALLOWLIST", ""),
+		WebhookDirectoryRoot: getEnv("WEBHOOK_DIRECTORY_ROOT", ""),
+
+		SMTPRelayAddr:     getEnv("SMTP_RELAY_ADDR", ""),
+		SMTPRelayUsername: getEnv("SMTP_RELAY_USERNAME", ""),
+		SMTPRelayPassword: getEnv("SMTP_RELAY_PASSWORD", ""),
+		SMTPRelayFrom:     getEnv("SMTP_RELAY_FROM", ""),
+
+		StorageDriver: getEnv("STORAGE_DRIVER", "local"),
+		StoragePath:   getEnv("STORAGE_PATH", "./data/attachments"),
+
+		StorageS3Endpoint:        getEnv("STORAGE_S3_ENDPOINT", ""),
+		StorageS3Region:          getEnv("STORAGE_S3_REGION", "us-east-1"),
+		StorageS3Bucket:          getEnv("STORAGE_S3_BUCKET", ""),
+		StorageS3Prefix:          getEnv("STORAGE_S3_PREFIX", ""),
+		StorageS3AccessKeyID:     getEnv("STORAGE_S3_ACCESS_KEY_ID", ""),
+		StorageS3SecretAccessKey: getEnv("STORAGE_S3_SECRET_ACCESS_KEY", ""),
+		StorageS3PathStyle:       getEnvBool("STORAGE_S3_PATH_STYLE", true),
+		StorageS3Presign:         getEnvBool("STORAGE_S3_PRESIGN", false),
+
+		StorageGCIntervalHours: getEnvInt("STORAGE_GC_INTERVAL_HOURS", 24),
+		StorageGCDeleteOrphans: getEnvBool("STORAGE_GC_DELETE_ORPHANS", false),
+
+		SignedURLTTLMinutes: getEnvInt("SIGNED_URL_TTL_MINUTES", 60),
 
 		SafeMode: getEnvBool("SAFE_MODE", false),
 	}
 }
 
+func (c *Config) SMTPTLSEnabled() bool {
+	return c.SMTPTLSCertFile != "" && c.SMTPTLSKeyFile != ""
+}
+
 func (c *Config) IsDevelopment() bool {
 	return c.AppEnv == "development" || c.AppEnv == "dev"
 }
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// copyTable is a table copied from SQLite to Postgres. Tables are listed so
+// that rows they reference are copied first. Deferred columns point at rows
+// copied later and are filled in once every table is copied.
+type copyTable struct {
+	name     string
+	deferred []string
+	// noID is set for tables keyed on other columns, which have no sequence
+	noID bool
+}
+
+var copyTables = []copyTable{
+	{name: "users"},
+	{name: "sessions"},
+	{name: "api_tokens"},
+	{name: "settings", noID: true},
+	{name: "domains", deferred: []string{"catch_all_mailbox_id"}},
+	{name: "tags"},
+	{name: "domain_tags", noID: true},
+	{name: "mailboxes"},
+	{name: "mailbox_tags", noID: true},
+	{name: "mailbox_aliases"},
+	{name: "domain_routes"},
+	{name: "emails"},
+	{name: "attachments"},
+	{name: "webhooks"},
+	{name: "webhook_rules"},
+	{name: "webhook_secrets"},
+	{name: "webhook_deliveries"},
+	{name: "webhook_jobs"},
+	{name: "storage_tombstones"},
+}
+
+// CopySQLiteToPostgres copies every row of a SQLite database into an empty
+// Postgres database, both migrated to the latest version. The copy runs in
+// a single transaction, keeps ids and moves the id sequences past the
+// copied rows. progress is called after each table with the number of rows
+// copied. Full-text indexes are not copied, Postgres computes its own.
+func CopySQLiteToPostgres(ctx context.Context, src, dst *sql.DB, progress func(table string, rows int64)) error {
+	var existing int64
+	if err := dst.QueryRowContext(ctx, "SELECT (SELECT COUNT(*) FROM users) + (SELECT COUNT(*) FROM mailboxes)").Scan(&existing); err != nil {
+		return fmt.Errorf("failed to check target database: %w", err)
+	}
+	if existing > 0 {
+		return fmt.Errorf("target database already has users or mailboxes")
+	}
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Migrations seed default settings, which the copy replaces
+	if _, err := tx.ExecContext(ctx, "DELETE FROM settings"); err != nil {
+		return fmt.Errorf("failed to clear settings: %w", err)
+	}
+
+	for _, table := range copyTables {
+		n, err := copyRows(ctx, src, tx, table)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", table.name, err)
+		}
+		if progress != nil {
+			progress(table.name, n)
+		}
+	}
+
+	for _, table := range copyTables {
+		for _, column := range table.deferred {
+			if err := copyDeferred(ctx, src, tx, table.name, column); err != nil {
+				return fmt.Errorf("failed to copy %s.%s: %w", table.name, column, err)
+			}
+		}
+	}
+
+	for _, table := range copyTables {
+		if table.noID {
+			continue
+		}
+		query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)", table.name, table.name)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to reset id sequence of %s: %w", table.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func copyRows(ctx context.Context, src *sql.DB, tx *sql.Tx, table copyTable) (int64, error) {
+	rows, err := src.QueryContext(ctx, "SELECT * FROM "+table.name)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table.name, strings.Join(columns, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var n int64
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return n, err
+		}
+		for i, column := range columns {
+			for _, deferred := range table.deferred {
+				if column == deferred {
+					values[i] = nil
+				}
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+func copyDeferred(ctx context.Context, src *sql.DB, tx *sql.Tx, table, column string) error {
+	rows, err := src.QueryContext(ctx, fmt.Sprintf("SELECT id, %s FROM %s WHERE %s IS NOT NULL", column, table, column))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var value interface{}
+		if err := rows.Scan(&id, &value); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = $1 WHERE id = $2", table, column), value, id); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -1,18 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"embed"
 	"fmt"
-	"strings"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jr-k/mailgress/internal/database/db"
 	_ "modernc.org/sqlite"
 )
 
-//go:embed migrations/*.sql
-var migrationsFS embed.FS
-
 func NewConnection(driver, dsn string) (*sql.DB, *db.Queries, error) {
 	switch driver {
 	case "sqlite":
@@ -29,76 +26,54 @@ func NewConnection(driver, dsn string) (*sql.DB, *db.Queries, error) {
 		}
 		database.SetMaxOpenConns(25)
 		database.SetMaxIdleConns(5)
-		return database, db.New(database), nil
+		return database, db.New(newPostgresDBTX(database)), nil
 	default:
 		return nil, nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
 }
 
-func RunMigrations(database *sql.DB, driver string) error {
-	// Create migrations tracking table
-	_, err := database.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
-		version TEXT PRIMARY KEY,
-		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	)`)
-	if err != nil {
-		return fmt.Errorf("failed to create schema_migrations table: %w", err)
-	}
+// Transactor runs queries in transactions on a connection opened by
+// NewConnection.
+type Transactor struct {
+	conn   *sql.DB
+	driver string
+}
+
+func NewTransactor(conn *sql.DB, driver string) *Transactor {
+	return &Transactor{conn: conn, driver: driver}
+}
 
-	entries, err := migrationsFS.ReadDir("migrations")
+// InTx runs fn with queries bound to a single transaction, which is
+// committed when fn returns nil and rolled back otherwise. fn must not use
+// any other queries: SQLite connections hold one transaction at a time.
+func (t *Transactor) InTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := t.conn.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+		return err
 	}
+	defer tx.Rollback()
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-
-		// Check if migration was already applied
-		var count int
-		err := database.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", entry.Name()).Scan(&count)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status %s: %w", entry.Name(), err)
-		}
-		if count > 0 {
-			continue // Already applied
-		}
-
-		content, err := migrationsFS.ReadFile("migrations/" + entry.Name())
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
-		}
-
-		migration := string(content)
-		if driver == "postgres" {
-			migration = convertToPostgres(migration)
-		}
-
-		statements := strings.Split(migration, ";")
-		for _, stmt := range statements {
-			stmt = strings.TrimSpace(stmt)
-			if stmt == "" {
-				continue
-			}
-			if _, err := database.Exec(stmt); err != nil {
-				return fmt.Errorf("failed to execute migration %s: %w", entry.Name(), err)
-			}
-		}
-
-		// Mark migration as applied
-		_, err = database.Exec("INSERT INTO schema_migrations (version) VALUES (?)", entry.Name())
-		if err != nil {
-			return fmt.Errorf("failed to record migration %s: %w", entry.Name(), err)
-		}
+	queries := db.New(tx)
+	if t.driver == "postgres" {
+		queries = db.New(newPostgresDBTX(tx))
 	}
-
-	return nil
+	if err := fn(queries); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func convertToPostgres(sql string) string {
-	sql = strings.ReplaceAll(sql, "INTEGER PRIMARY KEY AUTOINCREMENT", "SERIAL PRIMARY KEY")
-	sql = strings.ReplaceAll(sql, "DATETIME", "TIMESTAMP")
-	sql = strings.ReplaceAll(sql, "IF NOT EXISTS ", "")
-	return sql
+// OpenSQLiteReadOnly opens an existing SQLite database without writing to
+// it, for copying out of.
+func OpenSQLiteReadOnly(path string) (*sql.DB, error) {
+	database, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	database.SetMaxOpenConns(1)
+	if err := database.Ping(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return database, nil
 }
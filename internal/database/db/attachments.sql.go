@@ -81,6 +81,39 @@ func (q *Queries) GetAttachmentByID(ctx context.Context, id int64) (Attachment,
 	return i, err
 }
 
+const listAttachmentPaths = `-- name: ListAttachmentPaths :many
+SELECT id, email_id, storage_path FROM attachments ORDER BY id
+`
+
+type ListAttachmentPathsRow struct {
+	ID          int64  `json:"id"`
+	EmailID     int64  `json:"email_id"`
+	StoragePath string `json:"storage_path"`
+}
+
+func (q *Queries) ListAttachmentPaths(ctx context.Context) ([]ListAttachmentPathsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAttachmentPaths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAttachmentPathsRow{}
+	for rows.Next() {
+		var i ListAttachmentPathsRow
+		if err := rows.Scan(&i.ID, &i.EmailID, &i.StoragePath); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAttachmentsByEmail = `-- name: ListAttachmentsByEmail :many
 SELECT id, email_id, filename, content_type, size, storage_path, created_at FROM attachments WHERE email_id = ? ORDER BY id
 `
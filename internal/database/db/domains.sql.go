@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const countDomains = `-- name: CountDomains :one
@@ -23,7 +24,7 @@ func (q *Queries) CountDomains(ctx context.Context) (int64, error) {
 const createDomain = `-- name: CreateDomain :one
 INSERT INTO domains (name, is_verified, is_active)
 VALUES (?, ?, ?)
-RETURNING id, name, is_verified, is_active, created_at, updated_at
+RETURNING id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id
 `
 
 type CreateDomainParams struct {
@@ -42,6 +43,8 @@ func (q *Queries) CreateDomain(ctx context.Context, arg CreateDomainParams) (Dom
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTls,
+		&i.CatchAllMailboxID,
 	)
 	return i, err
 }
@@ -67,7 +70,7 @@ func (q *Queries) DomainExistsByName(ctx context.Context, name string) (int64, e
 }
 
 const getDomainByID = `-- name: GetDomainByID :one
-SELECT id, name, is_verified, is_active, created_at, updated_at FROM domains WHERE id = ?
+SELECT id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id FROM domains WHERE id = ?
 `
 
 func (q *Queries) GetDomainByID(ctx context.Context, id int64) (Domain, error) {
@@ -80,12 +83,14 @@ func (q *Queries) GetDomainByID(ctx context.Context, id int64) (Domain, error) {
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTls,
+		&i.CatchAllMailboxID,
 	)
 	return i, err
 }
 
 const getDomainByName = `-- name: GetDomainByName :one
-SELECT id, name, is_verified, is_active, created_at, updated_at FROM domains WHERE name = ?
+SELECT id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id FROM domains WHERE name = ?
 `
 
 func (q *Queries) GetDomainByName(ctx context.Context, name string) (Domain, error) {
@@ -98,12 +103,14 @@ func (q *Queries) GetDomainByName(ctx context.Context, name string) (Domain, err
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTls,
+		&i.CatchAllMailboxID,
 	)
 	return i, err
 }
 
 const listActiveDomains = `-- name: ListActiveDomains :many
-SELECT id, name, is_verified, is_active, created_at, updated_at FROM domains WHERE is_active = 1 ORDER BY name ASC
+SELECT id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id FROM domains WHERE is_active = 1 ORDER BY name ASC
 `
 
 func (q *Queries) ListActiveDomains(ctx context.Context) ([]Domain, error) {
@@ -122,6 +129,8 @@ func (q *Queries) ListActiveDomains(ctx context.Context) ([]Domain, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RequireTls,
+			&i.CatchAllMailboxID,
 		); err != nil {
 			return nil, err
 		}
@@ -137,7 +146,7 @@ func (q *Queries) ListActiveDomains(ctx context.Context) ([]Domain, error) {
 }
 
 const listDomains = `-- name: ListDomains :many
-SELECT id, name, is_verified, is_active, created_at, updated_at FROM domains ORDER BY name ASC
+SELECT id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id FROM domains ORDER BY name ASC
 `
 
 func (q *Queries) ListDomains(ctx context.Context) ([]Domain, error) {
@@ -156,6 +165,8 @@ func (q *Queries) ListDomains(ctx context.Context) ([]Domain, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RequireTls,
+			&i.CatchAllMailboxID,
 		); err != nil {
 			return nil, err
 		}
@@ -170,17 +181,46 @@ func (q *Queries) ListDomains(ctx context.Context) ([]Domain, error) {
 	return items, nil
 }
 
+const setDomainCatchAll = `-- name: SetDomainCatchAll :one
+UPDATE domains
+SET catch_all_mailbox_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id
+`
+
+type SetDomainCatchAllParams struct {
+	CatchAllMailboxID sql.NullInt64 `json:"catch_all_mailbox_id"`
+	ID                int64         `json:"id"`
+}
+
+func (q *Queries) SetDomainCatchAll(ctx context.Context, arg SetDomainCatchAllParams) (Domain, error) {
+	row := q.db.QueryRowContext(ctx, setDomainCatchAll, arg.CatchAllMailboxID, arg.ID)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.IsVerified,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RequireTls,
+		&i.CatchAllMailboxID,
+	)
+	return i, err
+}
+
 const updateDomain = `-- name: UpdateDomain :one
 UPDATE domains
-SET name = ?, is_verified = ?, is_active = ?, updated_at = CURRENT_TIMESTAMP
+SET name = ?, is_verified = ?, is_active = ?, require_tls = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, name, is_verified, is_active, created_at, updated_at
+RETURNING id, name, is_verified, is_active, created_at, updated_at, require_tls, catch_all_mailbox_id
 `
 
 type UpdateDomainParams struct {
 	Name       string `json:"name"`
 	IsVerified int64  `json:"is_verified"`
 	IsActive   int64  `json:"is_active"`
+	RequireTls int64  `json:"require_tls"`
 	ID         int64  `json:"id"`
 }
 
@@ -189,6 +229,7 @@ func (q *Queries) UpdateDomain(ctx context.Context, arg UpdateDomainParams) (Dom
 		arg.Name,
 		arg.IsVerified,
 		arg.IsActive,
+		arg.RequireTls,
 		arg.ID,
 	)
 	var i Domain
@@ -199,6 +240,8 @@ func (q *Queries) UpdateDomain(ctx context.Context, arg UpdateDomainParams) (Dom
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTls,
+		&i.CatchAllMailboxID,
 	)
 	return i, err
 }
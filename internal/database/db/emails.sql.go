@@ -11,6 +11,20 @@ import (
 	"time"
 )
 
+const addEmailAttachmentName = `-- name: AddEmailAttachmentName :exec
+UPDATE emails SET attachment_names = TRIM(attachment_names || ' ' || ?1) WHERE id = ?2
+`
+
+type AddEmailAttachmentNameParams struct {
+	Filename string `json:"filename"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) AddEmailAttachmentName(ctx context.Context, arg AddEmailAttachmentNameParams) error {
+	_, err := q.db.ExecContext(ctx, addEmailAttachmentName, arg.Filename, arg.ID)
+	return err
+}
+
 const countAllEmails = `-- name: CountAllEmails :one
 SELECT COUNT(*) FROM emails
 `
@@ -33,20 +47,60 @@ func (q *Queries) CountEmailsByMailbox(ctx context.Context, mailboxID int64) (in
 	return count, err
 }
 
+const countEmailsByMailboxAndSubaddress = `-- name: CountEmailsByMailboxAndSubaddress :one
+SELECT COUNT(*) FROM emails WHERE mailbox_id = ? AND subaddress = ?
+`
+
+type CountEmailsByMailboxAndSubaddressParams struct {
+	MailboxID  int64  `json:"mailbox_id"`
+	Subaddress string `json:"subaddress"`
+}
+
+func (q *Queries) CountEmailsByMailboxAndSubaddress(ctx context.Context, arg CountEmailsByMailboxAndSubaddressParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEmailsByMailboxAndSubaddress, arg.MailboxID, arg.Subaddress)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countSearchEmails = `-- name: CountSearchEmails :one
 SELECT COUNT(*) FROM emails
-WHERE mailbox_id = ?
-AND (subject LIKE ? OR from_address LIKE ?)
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (CAST(?1 AS INTEGER) IS NULL OR emails.mailbox_id = ?1)
+AND (CAST(?2 AS INTEGER) IS NULL OR mailboxes.owner_id = ?2)
+AND (CAST(?3 AS TEXT) IS NULL OR mailboxes.slug = ?3)
+AND (CAST(?4 AS TEXT) IS NULL OR emails.id IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH ?4))
+AND (CAST(?5 AS TEXT) IS NULL OR emails.id NOT IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH ?5))
+AND (CAST(?6 AS INTEGER) IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = ?6)
+AND (CAST(?7 AS INTEGER) IS NULL OR emails.is_read = ?7)
+AND (CAST(?8 AS DATETIME) IS NULL OR emails.received_at < ?8)
+AND (CAST(?9 AS DATETIME) IS NULL OR emails.received_at >= ?9)
 `
 
 type CountSearchEmailsParams struct {
-	MailboxID   int64          `json:"mailbox_id"`
-	Subject     sql.NullString `json:"subject"`
-	FromAddress string         `json:"from_address"`
+	MailboxID      sql.NullInt64  `json:"mailbox_id"`
+	OwnerID        sql.NullInt64  `json:"owner_id"`
+	MailboxSlug    sql.NullString `json:"mailbox_slug"`
+	Match          sql.NullString `json:"match"`
+	Exclude        sql.NullString `json:"exclude"`
+	HasAttachment  sql.NullInt64  `json:"has_attachment"`
+	IsRead         sql.NullInt64  `json:"is_read"`
+	ReceivedBefore sql.NullTime   `json:"received_before"`
+	ReceivedAfter  sql.NullTime   `json:"received_after"`
 }
 
 func (q *Queries) CountSearchEmails(ctx context.Context, arg CountSearchEmailsParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countSearchEmails, arg.MailboxID, arg.Subject, arg.FromAddress)
+	row := q.db.QueryRowContext(ctx, countSearchEmails,
+		arg.MailboxID,
+		arg.OwnerID,
+		arg.MailboxSlug,
+		arg.Match,
+		arg.Exclude,
+		arg.HasAttachment,
+		arg.IsRead,
+		arg.ReceivedBefore,
+		arg.ReceivedAfter,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -66,23 +120,33 @@ func (q *Queries) CountUnreadByMailbox(ctx context.Context, mailboxID int64) (in
 const createEmail = `-- name: CreateEmail :one
 INSERT INTO emails (
     mailbox_id, message_id, from_address, to_address, subject,
-    date, headers, text_body, html_body, raw_size, is_read, received_at
+    date, headers, text_body, html_body, raw_size,
+    spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy,
+    alias_address, subaddress, is_read, received_at
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
-RETURNING id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
+RETURNING id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names
 `
 
 type CreateEmailParams struct {
-	MailboxID   int64          `json:"mailbox_id"`
-	MessageID   sql.NullString `json:"message_id"`
-	FromAddress string         `json:"from_address"`
-	ToAddress   string         `json:"to_address"`
-	Subject     sql.NullString `json:"subject"`
-	Date        sql.NullString `json:"date"`
-	Headers     sql.NullString `json:"headers"`
-	TextBody    sql.NullString `json:"text_body"`
-	HtmlBody    sql.NullString `json:"html_body"`
-	RawSize     int64          `json:"raw_size"`
+	MailboxID    int64          `json:"mailbox_id"`
+	MessageID    sql.NullString `json:"message_id"`
+	FromAddress  string         `json:"from_address"`
+	ToAddress    string         `json:"to_address"`
+	Subject      sql.NullString `json:"subject"`
+	Date         sql.NullString `json:"date"`
+	Headers      sql.NullString `json:"headers"`
+	TextBody     sql.NullString `json:"text_body"`
+	HtmlBody     sql.NullString `json:"html_body"`
+	RawSize      int64          `json:"raw_size"`
+	SpfResult    string         `json:"spf_result"`
+	SpfDomain    string         `json:"spf_domain"`
+	DkimResult   string         `json:"dkim_result"`
+	DkimDomain   string         `json:"dkim_domain"`
+	DmarcResult  string         `json:"dmarc_result"`
+	DmarcPolicy  string         `json:"dmarc_policy"`
+	AliasAddress string         `json:"alias_address"`
+	Subaddress   string         `json:"subaddress"`
 }
 
 func (q *Queries) CreateEmail(ctx context.Context, arg CreateEmailParams) (Email, error) {
@@ -97,6 +161,14 @@ func (q *Queries) CreateEmail(ctx context.Context, arg CreateEmailParams) (Email
 		arg.TextBody,
 		arg.HtmlBody,
 		arg.RawSize,
+		arg.SpfResult,
+		arg.SpfDomain,
+		arg.DkimResult,
+		arg.DkimDomain,
+		arg.DmarcResult,
+		arg.DmarcPolicy,
+		arg.AliasAddress,
+		arg.Subaddress,
 	)
 	var i Email
 	err := row.Scan(
@@ -113,6 +185,16 @@ func (q *Queries) CreateEmail(ctx context.Context, arg CreateEmailParams) (Email
 		&i.RawSize,
 		&i.ReceivedAt,
 		&i.IsRead,
+		&i.RawPath,
+		&i.SpfResult,
+		&i.SpfDomain,
+		&i.DkimResult,
+		&i.DkimDomain,
+		&i.DmarcResult,
+		&i.DmarcPolicy,
+		&i.AliasAddress,
+		&i.Subaddress,
+		&i.AttachmentNames,
 	)
 	return i, err
 }
@@ -135,7 +217,7 @@ func (q *Queries) DeleteOldEmails(ctx context.Context, receivedAt time.Time) err
 	return err
 }
 
-const deleteOldEmailsByMailbox = `-- name: DeleteOldEmailsByMailbox :exec
+const deleteOldEmailsByMailbox = `-- name: DeleteOldEmailsByMailbox :execrows
 DELETE FROM emails WHERE mailbox_id = ? AND received_at < ?
 `
 
@@ -144,13 +226,16 @@ type DeleteOldEmailsByMailboxParams struct {
 	ReceivedAt time.Time `json:"received_at"`
 }
 
-func (q *Queries) DeleteOldEmailsByMailbox(ctx context.Context, arg DeleteOldEmailsByMailboxParams) error {
-	_, err := q.db.ExecContext(ctx, deleteOldEmailsByMailbox, arg.MailboxID, arg.ReceivedAt)
-	return err
+func (q *Queries) DeleteOldEmailsByMailbox(ctx context.Context, arg DeleteOldEmailsByMailboxParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldEmailsByMailbox, arg.MailboxID, arg.ReceivedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getEmailByID = `-- name: GetEmailByID :one
-SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read FROM emails WHERE id = ? LIMIT 1
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetEmailByID(ctx context.Context, id int64) (Email, error) {
@@ -170,6 +255,16 @@ func (q *Queries) GetEmailByID(ctx context.Context, id int64) (Email, error) {
 		&i.RawSize,
 		&i.ReceivedAt,
 		&i.IsRead,
+		&i.RawPath,
+		&i.SpfResult,
+		&i.SpfDomain,
+		&i.DkimResult,
+		&i.DkimDomain,
+		&i.DmarcResult,
+		&i.DmarcPolicy,
+		&i.AliasAddress,
+		&i.Subaddress,
+		&i.AttachmentNames,
 	)
 	return i, err
 }
@@ -178,7 +273,7 @@ const getMailboxStats = `-- name: GetMailboxStats :one
 SELECT
     COUNT(*) as email_count,
     MAX(received_at) as last_email_at,
-    (SELECT COUNT(*) FROM webhooks WHERE mailbox_id = ?1) as webhook_count
+    (SELECT COUNT(*) FROM webhooks w WHERE w.mailbox_id = ?1) as webhook_count
 FROM emails WHERE mailbox_id = ?1
 `
 
@@ -189,14 +284,46 @@ type GetMailboxStatsRow struct {
 }
 
 func (q *Queries) GetMailboxStats(ctx context.Context, mailboxID int64) (GetMailboxStatsRow, error) {
-	row := q.db.QueryRowContext(ctx, getMailboxStats, mailboxID, mailboxID)
+	row := q.db.QueryRowContext(ctx, getMailboxStats, mailboxID)
 	var i GetMailboxStatsRow
 	err := row.Scan(&i.EmailCount, &i.LastEmailAt, &i.WebhookCount)
 	return i, err
 }
 
+const listEmailRawPaths = `-- name: ListEmailRawPaths :many
+SELECT id, raw_path FROM emails WHERE raw_path IS NOT NULL AND raw_path != '' ORDER BY id
+`
+
+type ListEmailRawPathsRow struct {
+	ID      int64          `json:"id"`
+	RawPath sql.NullString `json:"raw_path"`
+}
+
+func (q *Queries) ListEmailRawPaths(ctx context.Context) ([]ListEmailRawPathsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEmailRawPaths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEmailRawPathsRow{}
+	for rows.Next() {
+		var i ListEmailRawPathsRow
+		if err := rows.Scan(&i.ID, &i.RawPath); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEmailsByMailbox = `-- name: ListEmailsByMailbox :many
-SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read FROM emails
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails
 WHERE mailbox_id = ?
 ORDER BY received_at DESC
 LIMIT ? OFFSET ?
@@ -231,6 +358,16 @@ func (q *Queries) ListEmailsByMailbox(ctx context.Context, arg ListEmailsByMailb
 			&i.RawSize,
 			&i.ReceivedAt,
 			&i.IsRead,
+			&i.RawPath,
+			&i.SpfResult,
+			&i.SpfDomain,
+			&i.DkimResult,
+			&i.DkimDomain,
+			&i.DmarcResult,
+			&i.DmarcPolicy,
+			&i.AliasAddress,
+			&i.Subaddress,
+			&i.AttachmentNames,
 		); err != nil {
 			return nil, err
 		}
@@ -245,6 +382,107 @@ func (q *Queries) ListEmailsByMailbox(ctx context.Context, arg ListEmailsByMailb
 	return items, nil
 }
 
+const listEmailsByMailboxAndSubaddress = `-- name: ListEmailsByMailboxAndSubaddress :many
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails
+WHERE mailbox_id = ? AND subaddress = ?
+ORDER BY received_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListEmailsByMailboxAndSubaddressParams struct {
+	MailboxID  int64  `json:"mailbox_id"`
+	Subaddress string `json:"subaddress"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+}
+
+func (q *Queries) ListEmailsByMailboxAndSubaddress(ctx context.Context, arg ListEmailsByMailboxAndSubaddressParams) ([]Email, error) {
+	rows, err := q.db.QueryContext(ctx, listEmailsByMailboxAndSubaddress,
+		arg.MailboxID,
+		arg.Subaddress,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Email{}
+	for rows.Next() {
+		var i Email
+		if err := rows.Scan(
+			&i.ID,
+			&i.MailboxID,
+			&i.MessageID,
+			&i.FromAddress,
+			&i.ToAddress,
+			&i.Subject,
+			&i.Date,
+			&i.Headers,
+			&i.TextBody,
+			&i.HtmlBody,
+			&i.RawSize,
+			&i.ReceivedAt,
+			&i.IsRead,
+			&i.RawPath,
+			&i.SpfResult,
+			&i.SpfDomain,
+			&i.DkimResult,
+			&i.DkimDomain,
+			&i.DmarcResult,
+			&i.DmarcPolicy,
+			&i.AliasAddress,
+			&i.Subaddress,
+			&i.AttachmentNames,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMailboxSubaddresses = `-- name: ListMailboxSubaddresses :many
+SELECT subaddress, COUNT(*) AS email_count FROM emails
+WHERE mailbox_id = ? AND subaddress != ''
+GROUP BY subaddress
+ORDER BY subaddress ASC
+`
+
+type ListMailboxSubaddressesRow struct {
+	Subaddress string `json:"subaddress"`
+	EmailCount int64  `json:"email_count"`
+}
+
+func (q *Queries) ListMailboxSubaddresses(ctx context.Context, mailboxID int64) ([]ListMailboxSubaddressesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMailboxSubaddresses, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMailboxSubaddressesRow{}
+	for rows.Next() {
+		var i ListMailboxSubaddressesRow
+		if err := rows.Scan(&i.Subaddress, &i.EmailCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markEmailAsRead = `-- name: MarkEmailAsRead :exec
 UPDATE emails SET is_read = 1 WHERE id = ?
 `
@@ -264,28 +502,48 @@ func (q *Queries) MarkEmailAsUnread(ctx context.Context, id int64) error {
 }
 
 const searchEmails = `-- name: SearchEmails :many
-SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read FROM emails
-WHERE mailbox_id = ?
-AND (subject LIKE ? OR from_address LIKE ?)
-ORDER BY received_at DESC
-LIMIT ? OFFSET ?
+SELECT emails.id, emails.mailbox_id, emails.message_id, emails.from_address, emails.to_address, emails.subject, emails.date, emails.headers, emails.text_body, emails.html_body, emails.raw_size, emails.received_at, emails.is_read, emails.raw_path, emails.spf_result, emails.spf_domain, emails.dkim_result, emails.dkim_domain, emails.dmarc_result, emails.dmarc_policy, emails.alias_address, emails.subaddress, emails.attachment_names FROM emails
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (CAST(?1 AS INTEGER) IS NULL OR emails.mailbox_id = ?1)
+AND (CAST(?2 AS INTEGER) IS NULL OR mailboxes.owner_id = ?2)
+AND (CAST(?3 AS TEXT) IS NULL OR mailboxes.slug = ?3)
+AND (CAST(?4 AS TEXT) IS NULL OR emails.id IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH ?4))
+AND (CAST(?5 AS TEXT) IS NULL OR emails.id NOT IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH ?5))
+AND (CAST(?6 AS INTEGER) IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = ?6)
+AND (CAST(?7 AS INTEGER) IS NULL OR emails.is_read = ?7)
+AND (CAST(?8 AS DATETIME) IS NULL OR emails.received_at < ?8)
+AND (CAST(?9 AS DATETIME) IS NULL OR emails.received_at >= ?9)
+ORDER BY emails.received_at DESC
+LIMIT ?11 OFFSET ?10
 `
 
 type SearchEmailsParams struct {
-	MailboxID   int64          `json:"mailbox_id"`
-	Subject     sql.NullString `json:"subject"`
-	FromAddress string         `json:"from_address"`
-	Limit       int64          `json:"limit"`
-	Offset      int64          `json:"offset"`
+	MailboxID      sql.NullInt64  `json:"mailbox_id"`
+	OwnerID        sql.NullInt64  `json:"owner_id"`
+	MailboxSlug    sql.NullString `json:"mailbox_slug"`
+	Match          sql.NullString `json:"match"`
+	Exclude        sql.NullString `json:"exclude"`
+	HasAttachment  sql.NullInt64  `json:"has_attachment"`
+	IsRead         sql.NullInt64  `json:"is_read"`
+	ReceivedBefore sql.NullTime   `json:"received_before"`
+	ReceivedAfter  sql.NullTime   `json:"received_after"`
+	Offset         int64          `json:"offset"`
+	Limit          int64          `json:"limit"`
 }
 
 func (q *Queries) SearchEmails(ctx context.Context, arg SearchEmailsParams) ([]Email, error) {
 	rows, err := q.db.QueryContext(ctx, searchEmails,
 		arg.MailboxID,
-		arg.Subject,
-		arg.FromAddress,
-		arg.Limit,
+		arg.OwnerID,
+		arg.MailboxSlug,
+		arg.Match,
+		arg.Exclude,
+		arg.HasAttachment,
+		arg.IsRead,
+		arg.ReceivedBefore,
+		arg.ReceivedAfter,
 		arg.Offset,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -308,6 +566,16 @@ func (q *Queries) SearchEmails(ctx context.Context, arg SearchEmailsParams) ([]E
 			&i.RawSize,
 			&i.ReceivedAt,
 			&i.IsRead,
+			&i.RawPath,
+			&i.SpfResult,
+			&i.SpfDomain,
+			&i.DkimResult,
+			&i.DkimDomain,
+			&i.DmarcResult,
+			&i.DmarcPolicy,
+			&i.AliasAddress,
+			&i.Subaddress,
+			&i.AttachmentNames,
 		); err != nil {
 			return nil, err
 		}
@@ -321,3 +589,17 @@ func (q *Queries) SearchEmails(ctx context.Context, arg SearchEmailsParams) ([]E
 	}
 	return items, nil
 }
+
+const updateEmailRawPath = `-- name: UpdateEmailRawPath :exec
+UPDATE emails SET raw_path = ? WHERE id = ?
+`
+
+type UpdateEmailRawPathParams struct {
+	RawPath sql.NullString `json:"raw_path"`
+	ID      int64          `json:"id"`
+}
+
+func (q *Queries) UpdateEmailRawPath(ctx context.Context, arg UpdateEmailRawPathParams) error {
+	_, err := q.db.ExecContext(ctx, updateEmailRawPath, arg.RawPath, arg.ID)
+	return err
+}
@@ -9,6 +9,18 @@ import (
 	"time"
 )
 
+type ApiToken struct {
+	ID          int64        `json:"id"`
+	UserID      int64        `json:"user_id"`
+	Name        string       `json:"name"`
+	TokenHash   string       `json:"token_hash"`
+	TokenPrefix string       `json:"token_prefix"`
+	Scopes      string       `json:"scopes"`
+	ExpiresAt   sql.NullTime `json:"expires_at"`
+	LastUsedAt  sql.NullTime `json:"last_used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
 type Attachment struct {
 	ID          int64     `json:"id"`
 	EmailID     int64     `json:"email_id"`
@@ -20,12 +32,24 @@ type Attachment struct {
 }
 
 type Domain struct {
-	ID         int64     `json:"id"`
-	Name       string    `json:"name"`
-	IsVerified int64     `json:"is_verified"`
-	IsActive   int64     `json:"is_active"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                int64         `json:"id"`
+	Name              string        `json:"name"`
+	IsVerified        int64         `json:"is_verified"`
+	IsActive          int64         `json:"is_active"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+	RequireTls        int64         `json:"require_tls"`
+	CatchAllMailboxID sql.NullInt64 `json:"catch_all_mailbox_id"`
+}
+
+type DomainRoute struct {
+	ID          int64     `json:"id"`
+	DomainID    int64     `json:"domain_id"`
+	MailboxID   int64     `json:"mailbox_id"`
+	PatternType string    `json:"pattern_type"`
+	Pattern     string    `json:"pattern"`
+	Position    int64     `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type DomainTag struct {
@@ -34,19 +58,37 @@ type DomainTag struct {
 }
 
 type Email struct {
-	ID          int64          `json:"id"`
-	MailboxID   int64          `json:"mailbox_id"`
-	MessageID   sql.NullString `json:"message_id"`
-	FromAddress string         `json:"from_address"`
-	ToAddress   string         `json:"to_address"`
-	Subject     sql.NullString `json:"subject"`
-	Date        sql.NullString `json:"date"`
-	Headers     sql.NullString `json:"headers"`
-	TextBody    sql.NullString `json:"text_body"`
-	HtmlBody    sql.NullString `json:"html_body"`
-	RawSize     int64          `json:"raw_size"`
-	ReceivedAt  time.Time      `json:"received_at"`
-	IsRead      int64          `json:"is_read"`
+	ID              int64          `json:"id"`
+	MailboxID       int64          `json:"mailbox_id"`
+	MessageID       sql.NullString `json:"message_id"`
+	FromAddress     string         `json:"from_address"`
+	ToAddress       string         `json:"to_address"`
+	Subject         sql.NullString `json:"subject"`
+	Date            sql.NullString `json:"date"`
+	Headers         sql.NullString `json:"headers"`
+	TextBody        sql.NullString `json:"text_body"`
+	HtmlBody        sql.NullString `json:"html_body"`
+	RawSize         int64          `json:"raw_size"`
+	ReceivedAt      time.Time      `json:"received_at"`
+	IsRead          int64          `json:"is_read"`
+	RawPath         sql.NullString `json:"raw_path"`
+	SpfResult       string         `json:"spf_result"`
+	SpfDomain       string         `json:"spf_domain"`
+	DkimResult      string         `json:"dkim_result"`
+	DkimDomain      string         `json:"dkim_domain"`
+	DmarcResult     string         `json:"dmarc_result"`
+	DmarcPolicy     string         `json:"dmarc_policy"`
+	AliasAddress    string         `json:"alias_address"`
+	Subaddress      string         `json:"subaddress"`
+	AttachmentNames string         `json:"attachment_names"`
+}
+
+type EmailsFt struct {
+	Subject         string `json:"subject"`
+	FromAddress     string `json:"from_address"`
+	ToAddress       string `json:"to_address"`
+	TextBody        string `json:"text_body"`
+	AttachmentNames string `json:"attachment_names"`
 }
 
 type Mailbox struct {
@@ -63,6 +105,14 @@ type Mailbox struct {
 	RetentionDays       int64          `json:"retention_days"`
 }
 
+type MailboxAlias struct {
+	ID        int64     `json:"id"`
+	MailboxID int64     `json:"mailbox_id"`
+	DomainID  int64     `json:"domain_id"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type MailboxTag struct {
 	MailboxID int64 `json:"mailbox_id"`
 	TagID     int64 `json:"tag_id"`
@@ -82,6 +132,12 @@ type Setting struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type StorageTombstone struct {
+	ID        int64     `json:"id"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Tag struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
@@ -106,36 +162,76 @@ type User struct {
 }
 
 type Webhook struct {
-	ID                 int64          `json:"id"`
-	MailboxID          int64          `json:"mailbox_id"`
-	Name               string         `json:"name"`
-	Url                string         `json:"url"`
-	Method             string         `json:"method"`
-	Headers            sql.NullString `json:"headers"`
-	PayloadType        string         `json:"payload_type"`
-	CustomPayload      sql.NullString `json:"custom_payload"`
-	HmacSecret         sql.NullString `json:"hmac_secret"`
-	TimeoutSec         int64          `json:"timeout_sec"`
-	MaxRetries         int64          `json:"max_retries"`
-	IncludeBody        int64          `json:"include_body"`
-	IncludeAttachments int64          `json:"include_attachments"`
-	IsActive           int64          `json:"is_active"`
-	CreatedAt          time.Time      `json:"created_at"`
-	UpdatedAt          time.Time      `json:"updated_at"`
+	ID                       int64          `json:"id"`
+	MailboxID                int64          `json:"mailbox_id"`
+	Name                     string         `json:"name"`
+	Url                      string         `json:"url"`
+	Headers                  sql.NullString `json:"headers"`
+	HmacSecret               sql.NullString `json:"hmac_secret"`
+	TimeoutSec               int64          `json:"timeout_sec"`
+	MaxRetries               int64          `json:"max_retries"`
+	IncludeBody              int64          `json:"include_body"`
+	IncludeAttachments       int64          `json:"include_attachments"`
+	IsActive                 int64          `json:"is_active"`
+	CreatedAt                time.Time      `json:"created_at"`
+	UpdatedAt                time.Time      `json:"updated_at"`
+	Method                   string         `json:"method"`
+	PayloadType              string         `json:"payload_type"`
+	CustomPayload            sql.NullString `json:"custom_payload"`
+	RawMode                  string         `json:"raw_mode"`
+	AttachmentMode           string         `json:"attachment_mode"`
+	AttachmentMaxInlineBytes int64          `json:"attachment_max_inline_bytes"`
+	RetryBaseDelaySec        int64          `json:"retry_base_delay_sec"`
+	RetryMaxDelaySec         int64          `json:"retry_max_delay_sec"`
+	RetryJitterPercent       int64          `json:"retry_jitter_percent"`
+	CircuitState             string         `json:"circuit_state"`
+	ConsecutiveFailures      int64          `json:"consecutive_failures"`
+	FailingSince             sql.NullTime   `json:"failing_since"`
+	CircuitChangedAt         sql.NullTime   `json:"circuit_changed_at"`
+	Events                   string         `json:"events"`
+	Encoding                 string         `json:"encoding"`
+	QueryFields              string         `json:"query_fields"`
+	SignEd25519              int64          `json:"sign_ed25519"`
+	TlsClientCert            string         `json:"tls_client_cert"`
+	TlsClientKey             string         `json:"tls_client_key"`
+	TlsCaBundle              string         `json:"tls_ca_bundle"`
+	TlsSkipVerify            int64          `json:"tls_skip_verify"`
+	ProxyUrl                 string         `json:"proxy_url"`
+	RedirectPolicy           string         `json:"redirect_policy"`
+	Target                   string         `json:"target"`
+	TargetConfig             string         `json:"target_config"`
 }
 
 type WebhookDelivery struct {
-	ID           int64          `json:"id"`
-	WebhookID    int64          `json:"webhook_id"`
-	EmailID      int64          `json:"email_id"`
-	Attempt      int64          `json:"attempt"`
-	Status       string         `json:"status"`
-	StatusCode   sql.NullInt64  `json:"status_code"`
-	RequestBody  sql.NullString `json:"request_body"`
-	ResponseBody sql.NullString `json:"response_body"`
-	ErrorMessage sql.NullString `json:"error_message"`
-	DurationMs   sql.NullInt64  `json:"duration_ms"`
-	CreatedAt    time.Time      `json:"created_at"`
+	ID             int64          `json:"id"`
+	WebhookID      int64          `json:"webhook_id"`
+	EmailID        sql.NullInt64  `json:"email_id"`
+	Attempt        int64          `json:"attempt"`
+	Status         string         `json:"status"`
+	StatusCode     sql.NullInt64  `json:"status_code"`
+	RequestBody    sql.NullString `json:"request_body"`
+	ResponseBody   sql.NullString `json:"response_body"`
+	ErrorMessage   sql.NullString `json:"error_message"`
+	DurationMs     sql.NullInt64  `json:"duration_ms"`
+	CreatedAt      time.Time      `json:"created_at"`
+	NextAttemptAt  sql.NullTime   `json:"next_attempt_at"`
+	Event          string         `json:"event"`
+	IdempotencyKey string         `json:"idempotency_key"`
+}
+
+type WebhookJob struct {
+	ID             int64          `json:"id"`
+	WebhookID      int64          `json:"webhook_id"`
+	EmailID        sql.NullInt64  `json:"email_id"`
+	Attempt        int64          `json:"attempt"`
+	LastDeliveryID sql.NullInt64  `json:"last_delivery_id"`
+	AvailableAt    time.Time      `json:"available_at"`
+	LeaseOwner     string         `json:"lease_owner"`
+	LeaseExpiresAt sql.NullTime   `json:"lease_expires_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Event          string         `json:"event"`
+	Payload        sql.NullString `json:"payload"`
+	IdempotencyKey string         `json:"idempotency_key"`
 }
 
 type WebhookRule struct {
@@ -148,3 +244,11 @@ type WebhookRule struct {
 	HeaderName sql.NullString `json:"header_name"`
 	CreatedAt  time.Time      `json:"created_at"`
 }
+
+type WebhookSecret struct {
+	ID        int64     `json:"id"`
+	WebhookID int64     `json:"webhook_id"`
+	Secret    string    `json:"secret"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -10,7 +10,7 @@ import (
 )
 
 const addTagToDomain = `-- name: AddTagToDomain :exec
-INSERT OR IGNORE INTO domain_tags (domain_id, tag_id) VALUES (?, ?)
+INSERT INTO domain_tags (domain_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING
 `
 
 type AddTagToDomainParams struct {
@@ -24,7 +24,7 @@ func (q *Queries) AddTagToDomain(ctx context.Context, arg AddTagToDomainParams)
 }
 
 const addTagToMailbox = `-- name: AddTagToMailbox :exec
-INSERT OR IGNORE INTO mailbox_tags (mailbox_id, tag_id) VALUES (?, ?)
+INSERT INTO mailbox_tags (mailbox_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING
 `
 
 type AddTagToMailboxParams struct {
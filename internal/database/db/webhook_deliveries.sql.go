@@ -10,6 +10,17 @@ import (
 	"database/sql"
 )
 
+const cancelRetryingByWebhook = `-- name: CancelRetryingByWebhook :exec
+UPDATE webhook_deliveries
+SET status = 'failed', error_message = 'Cancelled by user', next_attempt_at = NULL
+WHERE webhook_id = ? AND status = 'retrying'
+`
+
+func (q *Queries) CancelRetryingByWebhook(ctx context.Context, webhookID int64) error {
+	_, err := q.db.ExecContext(ctx, cancelRetryingByWebhook, webhookID)
+	return err
+}
+
 const countDeliveriesByStatus = `-- name: CountDeliveriesByStatus :one
 SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ? AND status = ?
 `
@@ -39,28 +50,32 @@ func (q *Queries) CountDeliveriesByWebhook(ctx context.Context, webhookID int64)
 
 const createDelivery = `-- name: CreateDelivery :one
 INSERT INTO webhook_deliveries (
-    webhook_id, email_id, attempt, status,
-    request_body, created_at
+    webhook_id, email_id, event, attempt, status,
+    request_body, idempotency_key, created_at
 )
-VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-RETURNING id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at
+VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key
 `
 
 type CreateDeliveryParams struct {
-	WebhookID   int64          `json:"webhook_id"`
-	EmailID     int64          `json:"email_id"`
-	Attempt     int64          `json:"attempt"`
-	Status      string         `json:"status"`
-	RequestBody sql.NullString `json:"request_body"`
+	WebhookID      int64          `json:"webhook_id"`
+	EmailID        sql.NullInt64  `json:"email_id"`
+	Event          string         `json:"event"`
+	Attempt        int64          `json:"attempt"`
+	Status         string         `json:"status"`
+	RequestBody    sql.NullString `json:"request_body"`
+	IdempotencyKey string         `json:"idempotency_key"`
 }
 
 func (q *Queries) CreateDelivery(ctx context.Context, arg CreateDeliveryParams) (WebhookDelivery, error) {
 	row := q.db.QueryRowContext(ctx, createDelivery,
 		arg.WebhookID,
 		arg.EmailID,
+		arg.Event,
 		arg.Attempt,
 		arg.Status,
 		arg.RequestBody,
+		arg.IdempotencyKey,
 	)
 	var i WebhookDelivery
 	err := row.Scan(
@@ -75,12 +90,35 @@ func (q *Queries) CreateDelivery(ctx context.Context, arg CreateDeliveryParams)
 		&i.ErrorMessage,
 		&i.DurationMs,
 		&i.CreatedAt,
+		&i.NextAttemptAt,
+		&i.Event,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
 
+const deleteAllByWebhook = `-- name: DeleteAllByWebhook :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?
+`
+
+func (q *Queries) DeleteAllByWebhook(ctx context.Context, webhookID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAllByWebhook, webhookID)
+	return err
+}
+
+const failUnfinishedDelivery = `-- name: FailUnfinishedDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'failed', next_attempt_at = NULL
+WHERE id = ? AND (status = 'pending' OR status = 'retrying')
+`
+
+func (q *Queries) FailUnfinishedDelivery(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, failUnfinishedDelivery, id)
+	return err
+}
+
 const getDeliveryByID = `-- name: GetDeliveryByID :one
-SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at FROM webhook_deliveries WHERE id = ? LIMIT 1
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key FROM webhook_deliveries WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetDeliveryByID(ctx context.Context, id int64) (WebhookDelivery, error) {
@@ -98,6 +136,9 @@ func (q *Queries) GetDeliveryByID(ctx context.Context, id int64) (WebhookDeliver
 		&i.ErrorMessage,
 		&i.DurationMs,
 		&i.CreatedAt,
+		&i.NextAttemptAt,
+		&i.Event,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
@@ -105,17 +146,17 @@ func (q *Queries) GetDeliveryByID(ctx context.Context, id int64) (WebhookDeliver
 const getWebhookDeliveryStats = `-- name: GetWebhookDeliveryStats :one
 SELECT
     COUNT(*) as total,
-    SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
-    SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed_count,
-    SUM(CASE WHEN status = 'pending' OR status = 'retrying' THEN 1 ELSE 0 END) as pending_count
+    CAST(COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0) AS INTEGER) as success_count,
+    CAST(COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0) AS INTEGER) as failed_count,
+    CAST(COALESCE(SUM(CASE WHEN status = 'pending' OR status = 'retrying' THEN 1 ELSE 0 END), 0) AS INTEGER) as pending_count
 FROM webhook_deliveries WHERE webhook_id = ?
 `
 
 type GetWebhookDeliveryStatsRow struct {
-	Total        int64           `json:"total"`
-	SuccessCount sql.NullFloat64 `json:"success_count"`
-	FailedCount  sql.NullFloat64 `json:"failed_count"`
-	PendingCount sql.NullFloat64 `json:"pending_count"`
+	Total        int64 `json:"total"`
+	SuccessCount int64 `json:"success_count"`
+	FailedCount  int64 `json:"failed_count"`
+	PendingCount int64 `json:"pending_count"`
 }
 
 func (q *Queries) GetWebhookDeliveryStats(ctx context.Context, webhookID int64) (GetWebhookDeliveryStatsRow, error) {
@@ -131,12 +172,12 @@ func (q *Queries) GetWebhookDeliveryStats(ctx context.Context, webhookID int64)
 }
 
 const listDeliveriesByEmail = `-- name: ListDeliveriesByEmail :many
-SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at FROM webhook_deliveries
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key FROM webhook_deliveries
 WHERE email_id = ?
 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListDeliveriesByEmail(ctx context.Context, emailID int64) ([]WebhookDelivery, error) {
+func (q *Queries) ListDeliveriesByEmail(ctx context.Context, emailID sql.NullInt64) ([]WebhookDelivery, error) {
 	rows, err := q.db.QueryContext(ctx, listDeliveriesByEmail, emailID)
 	if err != nil {
 		return nil, err
@@ -157,6 +198,9 @@ func (q *Queries) ListDeliveriesByEmail(ctx context.Context, emailID int64) ([]W
 			&i.ErrorMessage,
 			&i.DurationMs,
 			&i.CreatedAt,
+			&i.NextAttemptAt,
+			&i.Event,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -172,7 +216,7 @@ func (q *Queries) ListDeliveriesByEmail(ctx context.Context, emailID int64) ([]W
 }
 
 const listDeliveriesByWebhook = `-- name: ListDeliveriesByWebhook :many
-SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at FROM webhook_deliveries
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key FROM webhook_deliveries
 WHERE webhook_id = ?
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
@@ -205,6 +249,9 @@ func (q *Queries) ListDeliveriesByWebhook(ctx context.Context, arg ListDeliverie
 			&i.ErrorMessage,
 			&i.DurationMs,
 			&i.CreatedAt,
+			&i.NextAttemptAt,
+			&i.Event,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -220,7 +267,7 @@ func (q *Queries) ListDeliveriesByWebhook(ctx context.Context, arg ListDeliverie
 }
 
 const listPendingDeliveries = `-- name: ListPendingDeliveries :many
-SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at FROM webhook_deliveries
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key FROM webhook_deliveries
 WHERE status = 'pending' OR status = 'retrying'
 ORDER BY created_at ASC
 LIMIT ?
@@ -247,6 +294,9 @@ func (q *Queries) ListPendingDeliveries(ctx context.Context, limit int64) ([]Web
 			&i.ErrorMessage,
 			&i.DurationMs,
 			&i.CreatedAt,
+			&i.NextAttemptAt,
+			&i.Event,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -263,18 +313,19 @@ func (q *Queries) ListPendingDeliveries(ctx context.Context, limit int64) ([]Web
 
 const updateDelivery = `-- name: UpdateDelivery :one
 UPDATE webhook_deliveries
-SET status = ?, status_code = ?, response_body = ?, error_message = ?, duration_ms = ?
+SET status = ?, status_code = ?, response_body = ?, error_message = ?, duration_ms = ?, next_attempt_at = ?
 WHERE id = ?
-RETURNING id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at
+RETURNING id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at, event, idempotency_key
 `
 
 type UpdateDeliveryParams struct {
-	Status       string         `json:"status"`
-	StatusCode   sql.NullInt64  `json:"status_code"`
-	ResponseBody sql.NullString `json:"response_body"`
-	ErrorMessage sql.NullString `json:"error_message"`
-	DurationMs   sql.NullInt64  `json:"duration_ms"`
-	ID           int64          `json:"id"`
+	Status        string         `json:"status"`
+	StatusCode    sql.NullInt64  `json:"status_code"`
+	ResponseBody  sql.NullString `json:"response_body"`
+	ErrorMessage  sql.NullString `json:"error_message"`
+	DurationMs    sql.NullInt64  `json:"duration_ms"`
+	NextAttemptAt sql.NullTime   `json:"next_attempt_at"`
+	ID            int64          `json:"id"`
 }
 
 func (q *Queries) UpdateDelivery(ctx context.Context, arg UpdateDeliveryParams) (WebhookDelivery, error) {
@@ -284,6 +335,7 @@ func (q *Queries) UpdateDelivery(ctx context.Context, arg UpdateDeliveryParams)
 		arg.ResponseBody,
 		arg.ErrorMessage,
 		arg.DurationMs,
+		arg.NextAttemptAt,
 		arg.ID,
 	)
 	var i WebhookDelivery
@@ -299,26 +351,9 @@ func (q *Queries) UpdateDelivery(ctx context.Context, arg UpdateDeliveryParams)
 		&i.ErrorMessage,
 		&i.DurationMs,
 		&i.CreatedAt,
+		&i.NextAttemptAt,
+		&i.Event,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
-
-const cancelRetryingByWebhook = `-- name: CancelRetryingByWebhook :exec
-UPDATE webhook_deliveries
-SET status = 'failed', error_message = 'Cancelled by user'
-WHERE webhook_id = ? AND status = 'retrying'
-`
-
-func (q *Queries) CancelRetryingByWebhook(ctx context.Context, webhookID int64) error {
-	_, err := q.db.ExecContext(ctx, cancelRetryingByWebhook, webhookID)
-	return err
-}
-
-const deleteAllByWebhook = `-- name: DeleteAllByWebhook :exec
-DELETE FROM webhook_deliveries WHERE webhook_id = ?
-`
-
-func (q *Queries) DeleteAllByWebhook(ctx context.Context, webhookID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAllByWebhook, webhookID)
-	return err
-}
@@ -10,6 +10,34 @@ import (
 	"database/sql"
 )
 
+const claimWebhookProbe = `-- name: ClaimWebhookProbe :one
+UPDATE webhooks
+SET circuit_state = 'half_open', circuit_changed_at = ?1
+WHERE id = ?2
+  AND ((circuit_state = 'open' AND circuit_changed_at <= ?3)
+    OR (circuit_state = 'half_open' AND circuit_changed_at <= ?4))
+RETURNING id
+`
+
+type ClaimWebhookProbeParams struct {
+	Now         sql.NullTime `json:"now"`
+	ID          int64        `json:"id"`
+	OpenBefore  sql.NullTime `json:"open_before"`
+	ProbeBefore sql.NullTime `json:"probe_before"`
+}
+
+func (q *Queries) ClaimWebhookProbe(ctx context.Context, arg ClaimWebhookProbeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, claimWebhookProbe,
+		arg.Now,
+		arg.ID,
+		arg.OpenBefore,
+		arg.ProbeBefore,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const countWebhooksByMailbox = `-- name: CountWebhooksByMailbox :one
 SELECT COUNT(*) FROM webhooks WHERE mailbox_id = ?
 `
@@ -24,27 +52,48 @@ func (q *Queries) CountWebhooksByMailbox(ctx context.Context, mailboxID int64) (
 const createWebhook = `-- name: CreateWebhook :one
 INSERT INTO webhooks (
     mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret,
-    timeout_sec, max_retries, include_body, include_attachments, is_active,
-    created_at, updated_at
+    timeout_sec, max_retries, include_body, include_attachments, is_active, raw_mode,
+    attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec,
+    retry_jitter_percent, events, encoding, query_fields, sign_ed25519, tls_client_cert,
+    tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target,
+    target_config, created_at, updated_at
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-RETURNING id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config
 `
 
 type CreateWebhookParams struct {
-	MailboxID          int64          `json:"mailbox_id"`
-	Name               string         `json:"name"`
-	Url                string         `json:"url"`
-	Method             string         `json:"method"`
-	Headers            sql.NullString `json:"headers"`
-	PayloadType        string         `json:"payload_type"`
-	CustomPayload      sql.NullString `json:"custom_payload"`
-	HmacSecret         sql.NullString `json:"hmac_secret"`
-	TimeoutSec         int64          `json:"timeout_sec"`
-	MaxRetries         int64          `json:"max_retries"`
-	IncludeBody        int64          `json:"include_body"`
-	IncludeAttachments int64          `json:"include_attachments"`
-	IsActive           int64          `json:"is_active"`
+	MailboxID                int64          `json:"mailbox_id"`
+	Name                     string         `json:"name"`
+	Url                      string         `json:"url"`
+	Method                   string         `json:"method"`
+	Headers                  sql.NullString `json:"headers"`
+	PayloadType              string         `json:"payload_type"`
+	CustomPayload            sql.NullString `json:"custom_payload"`
+	HmacSecret               sql.NullString `json:"hmac_secret"`
+	TimeoutSec               int64          `json:"timeout_sec"`
+	MaxRetries               int64          `json:"max_retries"`
+	IncludeBody              int64          `json:"include_body"`
+	IncludeAttachments       int64          `json:"include_attachments"`
+	IsActive                 int64          `json:"is_active"`
+	RawMode                  string         `json:"raw_mode"`
+	AttachmentMode           string         `json:"attachment_mode"`
+	AttachmentMaxInlineBytes int64          `json:"attachment_max_inline_bytes"`
+	RetryBaseDelaySec        int64          `json:"retry_base_delay_sec"`
+	RetryMaxDelaySec         int64          `json:"retry_max_delay_sec"`
+	RetryJitterPercent       int64          `json:"retry_jitter_percent"`
+	Events                   string         `json:"events"`
+	Encoding                 string         `json:"encoding"`
+	QueryFields              string         `json:"query_fields"`
+	SignEd25519              int64          `json:"sign_ed25519"`
+	TlsClientCert            string         `json:"tls_client_cert"`
+	TlsClientKey             string         `json:"tls_client_key"`
+	TlsCaBundle              string         `json:"tls_ca_bundle"`
+	TlsSkipVerify            int64          `json:"tls_skip_verify"`
+	ProxyUrl                 string         `json:"proxy_url"`
+	RedirectPolicy           string         `json:"redirect_policy"`
+	Target                   string         `json:"target"`
+	TargetConfig             string         `json:"target_config"`
 }
 
 func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
@@ -62,6 +111,24 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		arg.IncludeBody,
 		arg.IncludeAttachments,
 		arg.IsActive,
+		arg.RawMode,
+		arg.AttachmentMode,
+		arg.AttachmentMaxInlineBytes,
+		arg.RetryBaseDelaySec,
+		arg.RetryMaxDelaySec,
+		arg.RetryJitterPercent,
+		arg.Events,
+		arg.Encoding,
+		arg.QueryFields,
+		arg.SignEd25519,
+		arg.TlsClientCert,
+		arg.TlsClientKey,
+		arg.TlsCaBundle,
+		arg.TlsSkipVerify,
+		arg.ProxyUrl,
+		arg.RedirectPolicy,
+		arg.Target,
+		arg.TargetConfig,
 	)
 	var i Webhook
 	err := row.Scan(
@@ -69,10 +136,7 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		&i.MailboxID,
 		&i.Name,
 		&i.Url,
-		&i.Method,
 		&i.Headers,
-		&i.PayloadType,
-		&i.CustomPayload,
 		&i.HmacSecret,
 		&i.TimeoutSec,
 		&i.MaxRetries,
@@ -81,6 +145,31 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.PayloadType,
+		&i.CustomPayload,
+		&i.RawMode,
+		&i.AttachmentMode,
+		&i.AttachmentMaxInlineBytes,
+		&i.RetryBaseDelaySec,
+		&i.RetryMaxDelaySec,
+		&i.RetryJitterPercent,
+		&i.CircuitState,
+		&i.ConsecutiveFailures,
+		&i.FailingSince,
+		&i.CircuitChangedAt,
+		&i.Events,
+		&i.Encoding,
+		&i.QueryFields,
+		&i.SignEd25519,
+		&i.TlsClientCert,
+		&i.TlsClientKey,
+		&i.TlsCaBundle,
+		&i.TlsSkipVerify,
+		&i.ProxyUrl,
+		&i.RedirectPolicy,
+		&i.Target,
+		&i.TargetConfig,
 	)
 	return i, err
 }
@@ -94,8 +183,25 @@ func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
 	return err
 }
 
+const disableWebhook = `-- name: DisableWebhook :exec
+UPDATE webhooks
+SET is_active = 0, circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type DisableWebhookParams struct {
+	CircuitChangedAt sql.NullTime `json:"circuit_changed_at"`
+	ID               int64        `json:"id"`
+}
+
+func (q *Queries) DisableWebhook(ctx context.Context, arg DisableWebhookParams) error {
+	_, err := q.db.ExecContext(ctx, disableWebhook, arg.CircuitChangedAt, arg.ID)
+	return err
+}
+
 const getWebhookByID = `-- name: GetWebhookByID :one
-SELECT id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at FROM webhooks WHERE id = ? LIMIT 1
+SELECT id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config FROM webhooks WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetWebhookByID(ctx context.Context, id int64) (Webhook, error) {
@@ -106,10 +212,7 @@ func (q *Queries) GetWebhookByID(ctx context.Context, id int64) (Webhook, error)
 		&i.MailboxID,
 		&i.Name,
 		&i.Url,
-		&i.Method,
 		&i.Headers,
-		&i.PayloadType,
-		&i.CustomPayload,
 		&i.HmacSecret,
 		&i.TimeoutSec,
 		&i.MaxRetries,
@@ -118,16 +221,45 @@ func (q *Queries) GetWebhookByID(ctx context.Context, id int64) (Webhook, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.PayloadType,
+		&i.CustomPayload,
+		&i.RawMode,
+		&i.AttachmentMode,
+		&i.AttachmentMaxInlineBytes,
+		&i.RetryBaseDelaySec,
+		&i.RetryMaxDelaySec,
+		&i.RetryJitterPercent,
+		&i.CircuitState,
+		&i.ConsecutiveFailures,
+		&i.FailingSince,
+		&i.CircuitChangedAt,
+		&i.Events,
+		&i.Encoding,
+		&i.QueryFields,
+		&i.SignEd25519,
+		&i.TlsClientCert,
+		&i.TlsClientKey,
+		&i.TlsCaBundle,
+		&i.TlsSkipVerify,
+		&i.ProxyUrl,
+		&i.RedirectPolicy,
+		&i.Target,
+		&i.TargetConfig,
 	)
 	return i, err
 }
 
-const listActiveWebhooksByMailbox = `-- name: ListActiveWebhooksByMailbox :many
-SELECT id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at FROM webhooks WHERE mailbox_id = ? AND is_active = 1 ORDER BY created_at DESC
+const listActiveAdminWebhooksByDomain = `-- name: ListActiveAdminWebhooksByDomain :many
+SELECT w.id, w.mailbox_id, w.name, w.url, w.headers, w.hmac_secret, w.timeout_sec, w.max_retries, w.include_body, w.include_attachments, w.is_active, w.created_at, w.updated_at, w.method, w.payload_type, w.custom_payload, w.raw_mode, w.attachment_mode, w.attachment_max_inline_bytes, w.retry_base_delay_sec, w.retry_max_delay_sec, w.retry_jitter_percent, w.circuit_state, w.consecutive_failures, w.failing_since, w.circuit_changed_at, w.events, w.encoding, w.query_fields, w.sign_ed25519, w.tls_client_cert, w.tls_client_key, w.tls_ca_bundle, w.tls_skip_verify, w.proxy_url, w.redirect_policy, w.target, w.target_config FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+LEFT JOIN users u ON u.id = m.owner_id
+WHERE m.domain_id = ? AND (m.owner_id IS NULL OR u.is_admin = 1) AND w.is_active = 1
+ORDER BY w.created_at DESC
 `
 
-func (q *Queries) ListActiveWebhooksByMailbox(ctx context.Context, mailboxID int64) ([]Webhook, error) {
-	rows, err := q.db.QueryContext(ctx, listActiveWebhooksByMailbox, mailboxID)
+func (q *Queries) ListActiveAdminWebhooksByDomain(ctx context.Context, domainID sql.NullInt64) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveAdminWebhooksByDomain, domainID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,10 +272,147 @@ func (q *Queries) ListActiveWebhooksByMailbox(ctx context.Context, mailboxID int
 			&i.MailboxID,
 			&i.Name,
 			&i.Url,
+			&i.Headers,
+			&i.HmacSecret,
+			&i.TimeoutSec,
+			&i.MaxRetries,
+			&i.IncludeBody,
+			&i.IncludeAttachments,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.Method,
+			&i.PayloadType,
+			&i.CustomPayload,
+			&i.RawMode,
+			&i.AttachmentMode,
+			&i.AttachmentMaxInlineBytes,
+			&i.RetryBaseDelaySec,
+			&i.RetryMaxDelaySec,
+			&i.RetryJitterPercent,
+			&i.CircuitState,
+			&i.ConsecutiveFailures,
+			&i.FailingSince,
+			&i.CircuitChangedAt,
+			&i.Events,
+			&i.Encoding,
+			&i.QueryFields,
+			&i.SignEd25519,
+			&i.TlsClientCert,
+			&i.TlsClientKey,
+			&i.TlsCaBundle,
+			&i.TlsSkipVerify,
+			&i.ProxyUrl,
+			&i.RedirectPolicy,
+			&i.Target,
+			&i.TargetConfig,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveWebhooksByDomainOwner = `-- name: ListActiveWebhooksByDomainOwner :many
+SELECT w.id, w.mailbox_id, w.name, w.url, w.headers, w.hmac_secret, w.timeout_sec, w.max_retries, w.include_body, w.include_attachments, w.is_active, w.created_at, w.updated_at, w.method, w.payload_type, w.custom_payload, w.raw_mode, w.attachment_mode, w.attachment_max_inline_bytes, w.retry_base_delay_sec, w.retry_max_delay_sec, w.retry_jitter_percent, w.circuit_state, w.consecutive_failures, w.failing_since, w.circuit_changed_at, w.events, w.encoding, w.query_fields, w.sign_ed25519, w.tls_client_cert, w.tls_client_key, w.tls_ca_bundle, w.tls_skip_verify, w.proxy_url, w.redirect_policy, w.target, w.target_config FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+WHERE m.domain_id = ? AND m.owner_id = ? AND w.is_active = 1
+ORDER BY w.created_at DESC
+`
+
+type ListActiveWebhooksByDomainOwnerParams struct {
+	DomainID sql.NullInt64 `json:"domain_id"`
+	OwnerID  sql.NullInt64 `json:"owner_id"`
+}
+
+func (q *Queries) ListActiveWebhooksByDomainOwner(ctx context.Context, arg ListActiveWebhooksByDomainOwnerParams) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveWebhooksByDomainOwner, arg.DomainID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.MailboxID,
+			&i.Name,
+			&i.Url,
 			&i.Headers,
+			&i.HmacSecret,
+			&i.TimeoutSec,
+			&i.MaxRetries,
+			&i.IncludeBody,
+			&i.IncludeAttachments,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Method,
 			&i.PayloadType,
 			&i.CustomPayload,
+			&i.RawMode,
+			&i.AttachmentMode,
+			&i.AttachmentMaxInlineBytes,
+			&i.RetryBaseDelaySec,
+			&i.RetryMaxDelaySec,
+			&i.RetryJitterPercent,
+			&i.CircuitState,
+			&i.ConsecutiveFailures,
+			&i.FailingSince,
+			&i.CircuitChangedAt,
+			&i.Events,
+			&i.Encoding,
+			&i.QueryFields,
+			&i.SignEd25519,
+			&i.TlsClientCert,
+			&i.TlsClientKey,
+			&i.TlsCaBundle,
+			&i.TlsSkipVerify,
+			&i.ProxyUrl,
+			&i.RedirectPolicy,
+			&i.Target,
+			&i.TargetConfig,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveWebhooksByMailbox = `-- name: ListActiveWebhooksByMailbox :many
+SELECT id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config FROM webhooks WHERE mailbox_id = ? AND is_active = 1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveWebhooksByMailbox(ctx context.Context, mailboxID int64) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveWebhooksByMailbox, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.MailboxID,
+			&i.Name,
+			&i.Url,
+			&i.Headers,
 			&i.HmacSecret,
 			&i.TimeoutSec,
 			&i.MaxRetries,
@@ -152,6 +421,31 @@ func (q *Queries) ListActiveWebhooksByMailbox(ctx context.Context, mailboxID int
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Method,
+			&i.PayloadType,
+			&i.CustomPayload,
+			&i.RawMode,
+			&i.AttachmentMode,
+			&i.AttachmentMaxInlineBytes,
+			&i.RetryBaseDelaySec,
+			&i.RetryMaxDelaySec,
+			&i.RetryJitterPercent,
+			&i.CircuitState,
+			&i.ConsecutiveFailures,
+			&i.FailingSince,
+			&i.CircuitChangedAt,
+			&i.Events,
+			&i.Encoding,
+			&i.QueryFields,
+			&i.SignEd25519,
+			&i.TlsClientCert,
+			&i.TlsClientKey,
+			&i.TlsCaBundle,
+			&i.TlsSkipVerify,
+			&i.ProxyUrl,
+			&i.RedirectPolicy,
+			&i.Target,
+			&i.TargetConfig,
 		); err != nil {
 			return nil, err
 		}
@@ -167,7 +461,7 @@ func (q *Queries) ListActiveWebhooksByMailbox(ctx context.Context, mailboxID int
 }
 
 const listWebhooksByMailbox = `-- name: ListWebhooksByMailbox :many
-SELECT id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at FROM webhooks WHERE mailbox_id = ? ORDER BY created_at DESC
+SELECT id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config FROM webhooks WHERE mailbox_id = ? ORDER BY created_at DESC
 `
 
 func (q *Queries) ListWebhooksByMailbox(ctx context.Context, mailboxID int64) ([]Webhook, error) {
@@ -184,10 +478,7 @@ func (q *Queries) ListWebhooksByMailbox(ctx context.Context, mailboxID int64) ([
 			&i.MailboxID,
 			&i.Name,
 			&i.Url,
-			&i.Method,
 			&i.Headers,
-			&i.PayloadType,
-			&i.CustomPayload,
 			&i.HmacSecret,
 			&i.TimeoutSec,
 			&i.MaxRetries,
@@ -196,6 +487,31 @@ func (q *Queries) ListWebhooksByMailbox(ctx context.Context, mailboxID int64) ([
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Method,
+			&i.PayloadType,
+			&i.CustomPayload,
+			&i.RawMode,
+			&i.AttachmentMode,
+			&i.AttachmentMaxInlineBytes,
+			&i.RetryBaseDelaySec,
+			&i.RetryMaxDelaySec,
+			&i.RetryJitterPercent,
+			&i.CircuitState,
+			&i.ConsecutiveFailures,
+			&i.FailingSince,
+			&i.CircuitChangedAt,
+			&i.Events,
+			&i.Encoding,
+			&i.QueryFields,
+			&i.SignEd25519,
+			&i.TlsClientCert,
+			&i.TlsClientKey,
+			&i.TlsCaBundle,
+			&i.TlsSkipVerify,
+			&i.ProxyUrl,
+			&i.RedirectPolicy,
+			&i.Target,
+			&i.TargetConfig,
 		); err != nil {
 			return nil, err
 		}
@@ -210,8 +526,98 @@ func (q *Queries) ListWebhooksByMailbox(ctx context.Context, mailboxID int64) ([
 	return items, nil
 }
 
+const recordWebhookFailure = `-- name: RecordWebhookFailure :one
+UPDATE webhooks
+SET consecutive_failures = consecutive_failures + 1,
+    failing_since = COALESCE(failing_since, ?1),
+    circuit_state = CASE
+        WHEN circuit_state != 'closed' OR (?2 > 0 AND consecutive_failures + 1 >= ?2) THEN 'open'
+        ELSE 'closed'
+    END,
+    circuit_changed_at = CASE
+        WHEN circuit_state != 'closed' OR (?2 > 0 AND consecutive_failures + 1 >= ?2) THEN ?1
+        ELSE circuit_changed_at
+    END
+WHERE id = ?3
+RETURNING id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config
+`
+
+type RecordWebhookFailureParams struct {
+	Now       sql.NullTime `json:"now"`
+	Threshold interface{}  `json:"threshold"`
+	ID        int64        `json:"id"`
+}
+
+func (q *Queries) RecordWebhookFailure(ctx context.Context, arg RecordWebhookFailureParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, recordWebhookFailure, arg.Now, arg.Threshold, arg.ID)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.MailboxID,
+		&i.Name,
+		&i.Url,
+		&i.Headers,
+		&i.HmacSecret,
+		&i.TimeoutSec,
+		&i.MaxRetries,
+		&i.IncludeBody,
+		&i.IncludeAttachments,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Method,
+		&i.PayloadType,
+		&i.CustomPayload,
+		&i.RawMode,
+		&i.AttachmentMode,
+		&i.AttachmentMaxInlineBytes,
+		&i.RetryBaseDelaySec,
+		&i.RetryMaxDelaySec,
+		&i.RetryJitterPercent,
+		&i.CircuitState,
+		&i.ConsecutiveFailures,
+		&i.FailingSince,
+		&i.CircuitChangedAt,
+		&i.Events,
+		&i.Encoding,
+		&i.QueryFields,
+		&i.SignEd25519,
+		&i.TlsClientCert,
+		&i.TlsClientKey,
+		&i.TlsCaBundle,
+		&i.TlsSkipVerify,
+		&i.ProxyUrl,
+		&i.RedirectPolicy,
+		&i.Target,
+		&i.TargetConfig,
+	)
+	return i, err
+}
+
+const recordWebhookSuccess = `-- name: RecordWebhookSuccess :exec
+UPDATE webhooks
+SET circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = CASE WHEN circuit_state = 'closed' THEN circuit_changed_at ELSE ?1 END
+WHERE id = ?2
+`
+
+type RecordWebhookSuccessParams struct {
+	Now sql.NullTime `json:"now"`
+	ID  int64        `json:"id"`
+}
+
+func (q *Queries) RecordWebhookSuccess(ctx context.Context, arg RecordWebhookSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordWebhookSuccess, arg.Now, arg.ID)
+	return err
+}
+
 const toggleWebhookActive = `-- name: ToggleWebhookActive :one
-UPDATE webhooks SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at
+UPDATE webhooks
+SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END,
+    circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config
 `
 
 func (q *Queries) ToggleWebhookActive(ctx context.Context, id int64) (Webhook, error) {
@@ -222,10 +628,7 @@ func (q *Queries) ToggleWebhookActive(ctx context.Context, id int64) (Webhook, e
 		&i.MailboxID,
 		&i.Name,
 		&i.Url,
-		&i.Method,
 		&i.Headers,
-		&i.PayloadType,
-		&i.CustomPayload,
 		&i.HmacSecret,
 		&i.TimeoutSec,
 		&i.MaxRetries,
@@ -234,6 +637,31 @@ func (q *Queries) ToggleWebhookActive(ctx context.Context, id int64) (Webhook, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.PayloadType,
+		&i.CustomPayload,
+		&i.RawMode,
+		&i.AttachmentMode,
+		&i.AttachmentMaxInlineBytes,
+		&i.RetryBaseDelaySec,
+		&i.RetryMaxDelaySec,
+		&i.RetryJitterPercent,
+		&i.CircuitState,
+		&i.ConsecutiveFailures,
+		&i.FailingSince,
+		&i.CircuitChangedAt,
+		&i.Events,
+		&i.Encoding,
+		&i.QueryFields,
+		&i.SignEd25519,
+		&i.TlsClientCert,
+		&i.TlsClientKey,
+		&i.TlsCaBundle,
+		&i.TlsSkipVerify,
+		&i.ProxyUrl,
+		&i.RedirectPolicy,
+		&i.Target,
+		&i.TargetConfig,
 	)
 	return i, err
 }
@@ -242,25 +670,48 @@ const updateWebhook = `-- name: UpdateWebhook :one
 UPDATE webhooks
 SET name = ?, url = ?, method = ?, headers = ?, payload_type = ?, custom_payload = ?, hmac_secret = ?,
     timeout_sec = ?, max_retries = ?, include_body = ?, include_attachments = ?,
-    is_active = ?, updated_at = CURRENT_TIMESTAMP
+    is_active = ?, raw_mode = ?, attachment_mode = ?, attachment_max_inline_bytes = ?,
+    retry_base_delay_sec = ?, retry_max_delay_sec = ?, retry_jitter_percent = ?, events = ?,
+    encoding = ?, query_fields = ?, sign_ed25519 = ?, tls_client_cert = ?, tls_client_key = ?,
+    tls_ca_bundle = ?, tls_skip_verify = ?, proxy_url = ?, redirect_policy = ?, target = ?,
+    target_config = ?,
+    updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at
+RETURNING id, mailbox_id, name, url, headers, hmac_secret, timeout_sec, max_retries, include_body, include_attachments, is_active, created_at, updated_at, method, payload_type, custom_payload, raw_mode, attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec, retry_jitter_percent, circuit_state, consecutive_failures, failing_since, circuit_changed_at, events, encoding, query_fields, sign_ed25519, tls_client_cert, tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target, target_config
 `
 
 type UpdateWebhookParams struct {
-	Name               string         `json:"name"`
-	Url                string         `json:"url"`
-	Method             string         `json:"method"`
-	Headers            sql.NullString `json:"headers"`
-	PayloadType        string         `json:"payload_type"`
-	CustomPayload      sql.NullString `json:"custom_payload"`
-	HmacSecret         sql.NullString `json:"hmac_secret"`
-	TimeoutSec         int64          `json:"timeout_sec"`
-	MaxRetries         int64          `json:"max_retries"`
-	IncludeBody        int64          `json:"include_body"`
-	IncludeAttachments int64          `json:"include_attachments"`
-	IsActive           int64          `json:"is_active"`
-	ID                 int64          `json:"id"`
+	Name                     string         `json:"name"`
+	Url                      string         `json:"url"`
+	Method                   string         `json:"method"`
+	Headers                  sql.NullString `json:"headers"`
+	PayloadType              string         `json:"payload_type"`
+	CustomPayload            sql.NullString `json:"custom_payload"`
+	HmacSecret               sql.NullString `json:"hmac_secret"`
+	TimeoutSec               int64          `json:"timeout_sec"`
+	MaxRetries               int64          `json:"max_retries"`
+	IncludeBody              int64          `json:"include_body"`
+	IncludeAttachments       int64          `json:"include_attachments"`
+	IsActive                 int64          `json:"is_active"`
+	RawMode                  string         `json:"raw_mode"`
+	AttachmentMode           string         `json:"attachment_mode"`
+	AttachmentMaxInlineBytes int64          `json:"attachment_max_inline_bytes"`
+	RetryBaseDelaySec        int64          `json:"retry_base_delay_sec"`
+	RetryMaxDelaySec         int64          `json:"retry_max_delay_sec"`
+	RetryJitterPercent       int64          `json:"retry_jitter_percent"`
+	Events                   string         `json:"events"`
+	Encoding                 string         `json:"encoding"`
+	QueryFields              string         `json:"query_fields"`
+	SignEd25519              int64          `json:"sign_ed25519"`
+	TlsClientCert            string         `json:"tls_client_cert"`
+	TlsClientKey             string         `json:"tls_client_key"`
+	TlsCaBundle              string         `json:"tls_ca_bundle"`
+	TlsSkipVerify            int64          `json:"tls_skip_verify"`
+	ProxyUrl                 string         `json:"proxy_url"`
+	RedirectPolicy           string         `json:"redirect_policy"`
+	Target                   string         `json:"target"`
+	TargetConfig             string         `json:"target_config"`
+	ID                       int64          `json:"id"`
 }
 
 func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
@@ -277,6 +728,24 @@ func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (W
 		arg.IncludeBody,
 		arg.IncludeAttachments,
 		arg.IsActive,
+		arg.RawMode,
+		arg.AttachmentMode,
+		arg.AttachmentMaxInlineBytes,
+		arg.RetryBaseDelaySec,
+		arg.RetryMaxDelaySec,
+		arg.RetryJitterPercent,
+		arg.Events,
+		arg.Encoding,
+		arg.QueryFields,
+		arg.SignEd25519,
+		arg.TlsClientCert,
+		arg.TlsClientKey,
+		arg.TlsCaBundle,
+		arg.TlsSkipVerify,
+		arg.ProxyUrl,
+		arg.RedirectPolicy,
+		arg.Target,
+		arg.TargetConfig,
 		arg.ID,
 	)
 	var i Webhook
@@ -285,10 +754,7 @@ func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (W
 		&i.MailboxID,
 		&i.Name,
 		&i.Url,
-		&i.Method,
 		&i.Headers,
-		&i.PayloadType,
-		&i.CustomPayload,
 		&i.HmacSecret,
 		&i.TimeoutSec,
 		&i.MaxRetries,
@@ -297,6 +763,45 @@ func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (W
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.PayloadType,
+		&i.CustomPayload,
+		&i.RawMode,
+		&i.AttachmentMode,
+		&i.AttachmentMaxInlineBytes,
+		&i.RetryBaseDelaySec,
+		&i.RetryMaxDelaySec,
+		&i.RetryJitterPercent,
+		&i.CircuitState,
+		&i.ConsecutiveFailures,
+		&i.FailingSince,
+		&i.CircuitChangedAt,
+		&i.Events,
+		&i.Encoding,
+		&i.QueryFields,
+		&i.SignEd25519,
+		&i.TlsClientCert,
+		&i.TlsClientKey,
+		&i.TlsCaBundle,
+		&i.TlsSkipVerify,
+		&i.ProxyUrl,
+		&i.RedirectPolicy,
+		&i.Target,
+		&i.TargetConfig,
 	)
 	return i, err
 }
+
+const updateWebhookSecret = `-- name: UpdateWebhookSecret :exec
+UPDATE webhooks SET hmac_secret = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+type UpdateWebhookSecretParams struct {
+	HmacSecret sql.NullString `json:"hmac_secret"`
+	ID         int64          `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookSecret(ctx context.Context, arg UpdateWebhookSecretParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookSecret, arg.HmacSecret, arg.ID)
+	return err
+}
@@ -0,0 +1,67 @@
+//go:build ignore
+
+// gen_postgres_queries writes postgres_queries.go, which maps the name of
+// every query sqlc generates into the pgdb package to its SQL.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	files, err := filepath.Glob("pgdb/*.sql.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var names []string
+	fset := token.NewFileSet()
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if name.IsExported() {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_postgres_queries.go. DO NOT EDIT.\n\n")
+	b.WriteString("package database\n\n")
+	b.WriteString("import \"github.com/jr-k/mailgress/internal/database/pgdb\"\n\n")
+	b.WriteString("// postgresQueries maps each query name to the query sqlc generated for\n")
+	b.WriteString("// Postgres from queries/postgres.\n")
+	b.WriteString("var postgresQueries = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\t%q: pgdb.%s,\n", name, name)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("postgres_queries.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,419 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each driver has its own migration set under migrations/<driver>, with the
+// same versions in both. NNN_name.sql upgrades the schema and the optional
+// NNN_name.down.sql reverts it.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationsFS embed.FS
+
+// ErrMigrationModified is returned when an applied migration no longer
+// matches the checksum recorded when it was applied.
+var ErrMigrationModified = errors.New("applied migration was modified")
+
+// Migration is one schema version. Name is the file name of its up
+// migration, which schema_migrations records.
+type Migration struct {
+	Version  int
+	Name     string
+	Checksum string
+	up       string
+	down     string
+}
+
+// Reversible reports whether the migration has a down migration.
+func (m Migration) Reversible() bool {
+	return m.down != ""
+}
+
+// MigrationStatus is a migration and its state in the database. Migrations
+// recorded in the database but unknown to this build have no checksum.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set when the checksum recorded on apply differs
+	Modified bool
+	// Unknown is set for applied migrations this build does not have
+	Unknown bool
+}
+
+// Migrator applies and reverts migrations. Each migration runs in its own
+// transaction together with its schema_migrations record, so a failure
+// leaves the schema at the previous version.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+	// Logf, when set, is called for each migration applied or reverted
+	Logf func(format string, args ...interface{})
+}
+
+// NewMigrator returns a migrator for the database. Nothing is written to
+// it until migrations are applied or reverted, so Status and
+// CheckMigrations work on read-only connections.
+func NewMigrator(database *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := loadMigrations(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: database, driver: driver, migrations: migrations}, nil
+}
+
+// RunMigrations applies every pending migration.
+func RunMigrations(database *sql.DB, driver string) error {
+	m, err := NewMigrator(database, driver)
+	if err != nil {
+		return err
+	}
+	m.Logf = log.Printf
+	_, err = m.Up(context.Background())
+	return err
+}
+
+// CheckMigrations returns an error unless every migration is applied and
+// unmodified, for deployments that migrate with mailgress migrate.
+func CheckMigrations(database *sql.DB, driver string) error {
+	m, err := NewMigrator(database, driver)
+	if err != nil {
+		return err
+	}
+	statuses, err := m.checkedStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			return fmt.Errorf("migration %s is pending, run mailgress migrate up", status.Name)
+		}
+	}
+	return nil
+}
+
+func loadMigrations(driver string) ([]Migration, error) {
+	dir := "migrations/" + driver
+	entries, err := migrationsFS.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %s: %w", driver, err)
+	}
+
+	byName := make(map[string]*Migration)
+	var migrations []*Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+		content, err := migrationsFS.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", name, err)
+		}
+		sum := sha256.Sum256(content)
+		migration := &Migration{Version: version, Name: name, Checksum: hex.EncodeToString(sum[:]), up: string(content)}
+		byName[name] = migration
+		migrations = append(migrations, migration)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		migration, ok := byName[strings.TrimSuffix(name, ".down.sql")+".sql"]
+		if !ok {
+			return nil, fmt.Errorf("down migration %s has no up migration", name)
+		}
+		content, err := migrationsFS.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", name, err)
+		}
+		migration.down = string(content)
+	}
+
+	result := make([]Migration, len(migrations))
+	for i, migration := range migrations {
+		if i > 0 && migration.Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", migrations[i-1].Name, migration.Name)
+		}
+		result[i] = *migration
+	}
+	return result, nil
+}
+
+// param returns the placeholder for the nth parameter of a query.
+func (m *Migrator) param(n int) string {
+	if m.driver == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// prepare runs before migrations are applied or reverted. It creates
+// schema_migrations and adds the checksum column to tables created by
+// earlier releases. Migrations recorded without a checksum get
+// the checksum of their current file.
+func (m *Migrator) prepare() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		checksum TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if _, err := m.db.Exec("SELECT checksum FROM schema_migrations LIMIT 1"); err != nil {
+		if _, err := m.db.Exec("ALTER TABLE schema_migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add checksums to schema_migrations: %w", err)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		_, err := m.db.Exec("UPDATE schema_migrations SET checksum = "+m.param(1)+" WHERE version = "+m.param(2)+" AND checksum = ''", migration.Checksum, migration.Name)
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of %s: %w", migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Status returns every migration, known or applied, by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	query := "SELECT version, applied_at, checksum FROM schema_migrations"
+	if _, err := m.db.ExecContext(ctx, "SELECT version FROM schema_migrations LIMIT 1"); err != nil {
+		// Nothing was ever applied
+		query = ""
+	} else if _, err := m.db.ExecContext(ctx, "SELECT checksum FROM schema_migrations LIMIT 1"); err != nil {
+		// Tables of earlier releases have no checksums until prepare runs
+		query = "SELECT version, applied_at, '' FROM schema_migrations"
+	}
+	if query == "" {
+		statuses := make([]MigrationStatus, len(m.migrations))
+		for i, migration := range m.migrations {
+			statuses[i] = MigrationStatus{Migration: migration}
+		}
+		return statuses, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	type record struct {
+		appliedAt time.Time
+		checksum  string
+	}
+	applied := make(map[string]record)
+	for rows.Next() {
+		var name, checksum string
+		var appliedAt time.Time
+		if err := rows.Scan(&name, &appliedAt, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied[name] = record{appliedAt: appliedAt, checksum: checksum}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if r, ok := applied[migration.Name]; ok {
+			status.Applied = true
+			status.AppliedAt = r.appliedAt
+			// Versions recorded without a checksum get the current one
+			// from prepare, so they are not taken as modified
+			status.Modified = r.checksum != "" && r.checksum != migration.Checksum
+			delete(applied, migration.Name)
+		}
+		statuses = append(statuses, status)
+	}
+	for name, r := range applied {
+		version, _ := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: version, Name: name},
+			Applied:   true,
+			AppliedAt: r.appliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Latest returns the newest version this build has.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns those it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last n applied migrations and returns them.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	statuses, err := m.checkedStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < n; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		if err := m.revert(ctx, statuses[i].Migration); err != nil {
+			return done, err
+		}
+		done = append(done, statuses[i].Migration)
+	}
+	return done, nil
+}
+
+// To applies pending migrations up to version and reverts applied
+// migrations above it, and returns the migrations it applied or reverted.
+func (m *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	statuses, err := m.checkedStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0; i-- {
+		status := statuses[i]
+		if !status.Applied || status.Version <= version {
+			continue
+		}
+		if err := m.revert(ctx, status.Migration); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+
+	for _, status := range statuses {
+		if status.Applied || status.Version > version {
+			continue
+		}
+		if err := m.apply(ctx, status.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	if !migration.Reversible() {
+		return fmt.Errorf("migration %s cannot be reverted, it has no down migration", migration.Name)
+	}
+	return m.apply(ctx, migration, false)
+}
+
+// checkedStatus returns the status, or an error when an applied migration
+// was modified or is unknown to this build.
+func (m *Migrator) checkedStatus(ctx context.Context) ([]MigrationStatus, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		if status.Modified {
+			return nil, fmt.Errorf("%w: %s changed since it was applied", ErrMigrationModified, status.Name)
+		}
+		if status.Unknown {
+			return nil, fmt.Errorf("migration %s is applied but unknown to this build, the database was migrated by a newer release", status.Name)
+		}
+	}
+	return statuses, nil
+}
+
+// apply runs the up or down migration and records it in one transaction.
+// On SQLite, foreign keys are off during the transaction so that tables
+// can be rebuilt, and are checked before committing.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.driver == "sqlite" {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, record, args := migration.up, "INSERT INTO schema_migrations (version, checksum) VALUES ("+m.param(1)+", "+m.param(2)+")", []interface{}{migration.Name, migration.Checksum}
+	action, done := "apply", "Applied"
+	if !up {
+		script, record, args = migration.down, "DELETE FROM schema_migrations WHERE version = "+m.param(1), []interface{}{migration.Name}
+		action, done = "revert", "Reverted"
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to %s migration %s: %w", action, migration.Name, err)
+	}
+
+	if m.driver == "sqlite" {
+		var table string
+		var rowid sql.NullInt64
+		var parent string
+		var fkid int64
+		err := tx.QueryRowContext(ctx, "PRAGMA foreign_key_check").Scan(&table, &rowid, &parent, &fkid)
+		if err == nil {
+			return fmt.Errorf("failed to %s migration %s: row %d of %s references a missing %s row", action, migration.Name, rowid.Int64, table, parent)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to check foreign keys after %s: %w", migration.Name, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+	}
+	if m.Logf != nil {
+		m.Logf("%s migration %s", done, migration.Name)
+	}
+	return nil
+}
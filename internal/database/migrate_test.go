@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigratorUpDown(t *testing.T) {
+	ctx := context.Background()
+	conn, _, err := NewConnection("sqlite", filepath.Join(t.TempDir(), "mailgress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m, err := NewMigrator(conn, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status on an empty database: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Fatalf("%s is applied on an empty database", status.Name)
+		}
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(statuses) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(statuses))
+	}
+	if err := CheckMigrations(conn, "sqlite"); err != nil {
+		t.Fatalf("CheckMigrations after Up: %v", err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != m.Latest() {
+		t.Fatalf("reverted %v, want the latest migration", reverted)
+	}
+	if err := CheckMigrations(conn, "sqlite"); err == nil || !strings.Contains(err.Error(), "pending") {
+		t.Fatalf("CheckMigrations after Down = %v, want a pending migration", err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("reapplying: %v", err)
+	}
+
+	if _, err := conn.Exec("UPDATE schema_migrations SET checksum = 'edited' WHERE version = ?", reverted[0].Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMigrations(conn, "sqlite"); !errors.Is(err, ErrMigrationModified) {
+		t.Fatalf("CheckMigrations with an edited checksum = %v, want ErrMigrationModified", err)
+	}
+}
+
+func TestCheckMigrationsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	migrated := filepath.Join(dir, "migrated.db")
+	conn, _, err := NewConnection("sqlite", migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunMigrations(conn, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	empty := filepath.Join(dir, "empty.db")
+	conn, _, err = NewConnection("sqlite", empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	ro, err := OpenSQLiteReadOnly(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	if err := CheckMigrations(ro, "sqlite"); err != nil {
+		t.Errorf("CheckMigrations on a migrated read-only database: %v", err)
+	}
+
+	ro, err = OpenSQLiteReadOnly(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	if err := CheckMigrations(ro, "sqlite"); err == nil {
+		t.Error("CheckMigrations accepted an unmigrated database")
+	}
+	if _, err := ro.Exec("SELECT 1 FROM schema_migrations"); err == nil {
+		t.Error("checking created schema_migrations in a read-only database")
+	}
+
+	if _, err := OpenSQLiteReadOnly(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("OpenSQLiteReadOnly opened a missing database")
+	}
+}
@@ -0,0 +1,8 @@
+DROP TABLE sessions;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_rules;
+DROP TABLE webhooks;
+DROP TABLE attachments;
+DROP TABLE emails;
+DROP TABLE mailboxes;
+DROP TABLE users;
@@ -0,0 +1,9 @@
+-- Application settings (key-value store)
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Insert default onboarding status
+INSERT INTO settings (key, value) VALUES ('onboarding_completed', 'false') ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,4 @@
+-- Add advanced settings to mailboxes
+ALTER TABLE mailboxes ADD COLUMN max_email_size_mb BIGINT NOT NULL DEFAULT 25;
+ALTER TABLE mailboxes ADD COLUMN max_attachment_size_mb BIGINT NOT NULL DEFAULT 10;
+ALTER TABLE mailboxes ADD COLUMN retention_days BIGINT NOT NULL DEFAULT 90;
@@ -0,0 +1,4 @@
+-- Add TOTP fields to users table for two-factor authentication
+ALTER TABLE users ADD COLUMN totp_secret TEXT DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_backup_codes TEXT DEFAULT '';
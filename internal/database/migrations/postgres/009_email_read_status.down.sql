@@ -0,0 +1 @@
+ALTER TABLE emails DROP COLUMN is_read;
@@ -0,0 +1,2 @@
+-- Add read status to emails
+ALTER TABLE emails ADD COLUMN is_read BIGINT NOT NULL DEFAULT 0;
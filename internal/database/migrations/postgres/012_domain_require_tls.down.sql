@@ -0,0 +1 @@
+ALTER TABLE domains DROP COLUMN require_tls;
@@ -0,0 +1,5 @@
+-- Keep a reference to the original RFC 5322 message in storage
+ALTER TABLE emails ADD COLUMN raw_path TEXT;
+
+-- How webhooks carry the original message: none, inline (base64) or url (signed link)
+ALTER TABLE webhooks ADD COLUMN raw_mode TEXT NOT NULL DEFAULT 'none';
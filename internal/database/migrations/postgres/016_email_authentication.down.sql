@@ -0,0 +1,6 @@
+ALTER TABLE emails DROP COLUMN spf_result;
+ALTER TABLE emails DROP COLUMN spf_domain;
+ALTER TABLE emails DROP COLUMN dkim_result;
+ALTER TABLE emails DROP COLUMN dkim_domain;
+ALTER TABLE emails DROP COLUMN dmarc_result;
+ALTER TABLE emails DROP COLUMN dmarc_policy;
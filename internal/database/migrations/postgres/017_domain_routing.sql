@@ -0,0 +1,15 @@
+-- Mailbox that receives mail for unknown recipients of a domain
+ALTER TABLE domains ADD COLUMN catch_all_mailbox_id BIGINT REFERENCES mailboxes(id) ON DELETE SET NULL;
+
+-- Ordered local-part patterns (glob or regex) routed to mailboxes
+CREATE TABLE IF NOT EXISTS domain_routes (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    mailbox_id BIGINT NOT NULL REFERENCES mailboxes(id) ON DELETE CASCADE,
+    pattern_type TEXT NOT NULL DEFAULT 'glob',
+    pattern TEXT NOT NULL,
+    position BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_routes_domain_id ON domain_routes(domain_id, position);
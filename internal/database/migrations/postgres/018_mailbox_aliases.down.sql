@@ -0,0 +1,3 @@
+ALTER TABLE emails DROP COLUMN alias_address;
+
+DROP TABLE mailbox_aliases;
@@ -0,0 +1,8 @@
+-- Plus-address tag of the recipient (inbox+tag@domain), empty when absent
+ALTER TABLE emails ADD COLUMN subaddress TEXT NOT NULL DEFAULT '';
+
+UPDATE emails
+SET subaddress = substr(to_address, strpos(to_address, '+') + 1, strpos(to_address, '@') - strpos(to_address, '+') - 1)
+WHERE strpos(to_address, '+') > 0 AND strpos(to_address, '+') < strpos(to_address, '@');
+
+CREATE INDEX IF NOT EXISTS idx_emails_mailbox_subaddress ON emails(mailbox_id, subaddress);
@@ -0,0 +1,2 @@
+-- Queued deliveries are lost
+DROP TABLE webhook_jobs;
@@ -0,0 +1,13 @@
+-- Jobs and deliveries of events other than email.received have no email
+-- and cannot be kept once email_id is required again
+DELETE FROM webhook_jobs WHERE email_id IS NULL;
+DELETE FROM webhook_deliveries WHERE email_id IS NULL;
+
+ALTER TABLE webhook_jobs DROP COLUMN payload;
+ALTER TABLE webhook_jobs DROP COLUMN event;
+ALTER TABLE webhook_jobs ALTER COLUMN email_id SET NOT NULL;
+
+ALTER TABLE webhook_deliveries DROP COLUMN event;
+ALTER TABLE webhook_deliveries ALTER COLUMN email_id SET NOT NULL;
+
+ALTER TABLE webhooks DROP COLUMN events;
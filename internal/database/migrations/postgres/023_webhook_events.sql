@@ -0,0 +1,11 @@
+-- Webhooks subscribe to a comma-separated list of events. Events other than
+-- email.received carry a payload snapshot instead of pointing at a stored
+-- email, so jobs and deliveries get a nullable email_id.
+ALTER TABLE webhooks ADD COLUMN events TEXT NOT NULL DEFAULT 'email.received';
+
+ALTER TABLE webhook_deliveries ALTER COLUMN email_id DROP NOT NULL;
+ALTER TABLE webhook_deliveries ADD COLUMN event TEXT NOT NULL DEFAULT 'email.received';
+
+ALTER TABLE webhook_jobs ALTER COLUMN email_id DROP NOT NULL;
+ALTER TABLE webhook_jobs ADD COLUMN event TEXT NOT NULL DEFAULT 'email.received';
+ALTER TABLE webhook_jobs ADD COLUMN payload TEXT;
@@ -0,0 +1,5 @@
+-- Request body encoding per webhook: json, form (urlencoded), multipart or
+-- rfc822 (the original message). query_fields lists the payload fields a
+-- GET webhook sends as query parameters, comma-separated.
+ALTER TABLE webhooks ADD COLUMN encoding TEXT NOT NULL DEFAULT 'json';
+ALTER TABLE webhooks ADD COLUMN query_fields TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+DROP TABLE webhook_secrets;
+
+ALTER TABLE webhooks DROP COLUMN sign_ed25519;
+ALTER TABLE webhook_deliveries DROP COLUMN idempotency_key;
+ALTER TABLE webhook_jobs DROP COLUMN idempotency_key;
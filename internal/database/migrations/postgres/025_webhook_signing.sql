@@ -0,0 +1,20 @@
+-- Every queued event gets a key that stays the same across its retries and
+-- is sent as X-Mailgress-Delivery-Id, so receivers can drop duplicates.
+ALTER TABLE webhook_jobs ADD COLUMN idempotency_key TEXT NOT NULL DEFAULT '';
+UPDATE webhook_jobs SET idempotency_key = md5(random()::text || clock_timestamp()::text) WHERE idempotency_key = '';
+ALTER TABLE webhook_deliveries ADD COLUMN idempotency_key TEXT NOT NULL DEFAULT '';
+
+-- Optional Ed25519 signature with the installation key
+ALTER TABLE webhooks ADD COLUMN sign_ed25519 BIGINT NOT NULL DEFAULT 0;
+
+-- Secrets replaced by a rotation keep signing deliveries until they expire,
+-- next to the current webhooks.hmac_secret
+CREATE TABLE IF NOT EXISTS webhook_secrets (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_secrets_webhook_id ON webhook_secrets(webhook_id);
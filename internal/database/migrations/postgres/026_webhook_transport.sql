@@ -0,0 +1,8 @@
+-- Per-webhook transport settings: a PEM client certificate and key for
+-- mTLS, a PEM bundle of extra trusted CAs, skipping certificate
+-- verification, and an http(s) or socks5 proxy URL.
+ALTER TABLE webhooks ADD COLUMN tls_client_cert TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN tls_client_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN tls_ca_bundle TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN tls_skip_verify BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN proxy_url TEXT NOT NULL DEFAULT '';
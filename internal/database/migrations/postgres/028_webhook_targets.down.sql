@@ -0,0 +1,2 @@
+ALTER TABLE webhooks DROP COLUMN target;
+ALTER TABLE webhooks DROP COLUMN target_config;
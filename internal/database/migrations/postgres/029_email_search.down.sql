@@ -0,0 +1,3 @@
+DROP INDEX idx_emails_search_vector;
+ALTER TABLE emails DROP COLUMN search_vector;
+ALTER TABLE emails DROP COLUMN attachment_names;
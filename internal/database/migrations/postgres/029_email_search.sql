@@ -0,0 +1,17 @@
+-- Attachment filenames of each email, space separated, kept for search
+ALTER TABLE emails ADD COLUMN attachment_names TEXT NOT NULL DEFAULT '';
+
+UPDATE emails
+SET attachment_names = COALESCE((SELECT string_agg(filename, ' ') FROM attachments WHERE email_id = emails.id), '');
+
+-- Full-text index over emails. Weights let field filters pick columns:
+-- A subject, B sender, C recipients, D body and attachment filenames.
+-- Addresses are split on @ and dots so from:acme matches billing@acme.com
+ALTER TABLE emails ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(subject, '')), 'A') ||
+    setweight(to_tsvector('simple', translate(from_address, '@.', '  ')), 'B') ||
+    setweight(to_tsvector('simple', translate(to_address, '@.', '  ')), 'C') ||
+    setweight(to_tsvector('simple', COALESCE(text_body, '') || ' ' || attachment_names), 'D')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_emails_search_vector ON emails USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP TRIGGER emails_storage_tombstone ON emails;
+DROP TRIGGER attachments_storage_tombstone ON attachments;
+DROP FUNCTION queue_storage_tombstone();
+DROP TABLE storage_tombstones;
@@ -0,0 +1,26 @@
+-- Stored files whose rows were deleted, waiting to be removed from storage.
+-- Triggers queue them in the transaction that deletes the row, including
+-- rows removed by ON DELETE CASCADE.
+CREATE TABLE IF NOT EXISTS storage_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    path TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Queues the path in the deleted row's column named by the trigger argument
+CREATE OR REPLACE FUNCTION queue_storage_tombstone() RETURNS trigger AS $$
+DECLARE
+    stored_path TEXT := to_jsonb(OLD) ->> TG_ARGV[0];
+BEGIN
+    IF stored_path IS NOT NULL AND stored_path != '' THEN
+        INSERT INTO storage_tombstones (path) VALUES (stored_path);
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_storage_tombstone AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION queue_storage_tombstone('storage_path');
+
+CREATE TRIGGER emails_storage_tombstone AFTER DELETE ON emails
+    FOR EACH ROW EXECUTE FUNCTION queue_storage_tombstone('raw_path');
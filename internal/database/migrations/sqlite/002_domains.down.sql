@@ -0,0 +1,4 @@
+DROP INDEX idx_mailboxes_domain_id;
+ALTER TABLE mailboxes DROP COLUMN domain_id;
+
+DROP TABLE domains;
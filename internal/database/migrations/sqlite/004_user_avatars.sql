@@ -0,0 +1,2 @@
+-- Add avatar_path column to users table
+ALTER TABLE users ADD COLUMN avatar_path TEXT;
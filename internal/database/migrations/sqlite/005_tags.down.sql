@@ -0,0 +1,3 @@
+DROP TABLE mailbox_tags;
+DROP TABLE domain_tags;
+DROP TABLE tags;
@@ -0,0 +1,3 @@
+ALTER TABLE mailboxes DROP COLUMN max_email_size_mb;
+ALTER TABLE mailboxes DROP COLUMN max_attachment_size_mb;
+ALTER TABLE mailboxes DROP COLUMN retention_days;
@@ -0,0 +1,2 @@
+ALTER TABLE webhooks DROP COLUMN payload_type;
+ALTER TABLE webhooks DROP COLUMN custom_payload;
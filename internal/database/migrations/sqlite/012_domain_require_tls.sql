@@ -0,0 +1,2 @@
+-- Add per-domain TLS requirement for inbound SMTP
+ALTER TABLE domains ADD COLUMN require_tls INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE emails DROP COLUMN raw_path;
+ALTER TABLE webhooks DROP COLUMN raw_mode;
@@ -0,0 +1,2 @@
+ALTER TABLE webhooks DROP COLUMN attachment_mode;
+ALTER TABLE webhooks DROP COLUMN attachment_max_inline_bytes;
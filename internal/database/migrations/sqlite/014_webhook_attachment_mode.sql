@@ -0,0 +1,3 @@
+-- How webhooks carry attachments: metadata only, inline (base64, capped) or url (signed link)
+ALTER TABLE webhooks ADD COLUMN attachment_mode TEXT NOT NULL DEFAULT 'metadata';
+ALTER TABLE webhooks ADD COLUMN attachment_max_inline_bytes INTEGER NOT NULL DEFAULT 5242880;
@@ -0,0 +1,7 @@
+-- Sender authentication verdicts computed when the message was received
+ALTER TABLE emails ADD COLUMN spf_result TEXT NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN spf_domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN dkim_result TEXT NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN dkim_domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN dmarc_result TEXT NOT NULL DEFAULT '';
+ALTER TABLE emails ADD COLUMN dmarc_policy TEXT NOT NULL DEFAULT '';
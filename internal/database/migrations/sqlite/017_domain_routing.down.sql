@@ -0,0 +1,3 @@
+DROP TABLE domain_routes;
+
+ALTER TABLE domains DROP COLUMN catch_all_mailbox_id;
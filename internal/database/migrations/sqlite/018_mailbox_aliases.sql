@@ -0,0 +1,14 @@
+-- Extra addresses delivering to a mailbox, on its own domain or another one
+CREATE TABLE IF NOT EXISTS mailbox_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    mailbox_id INTEGER NOT NULL REFERENCES mailboxes(id) ON DELETE CASCADE,
+    domain_id INTEGER NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    slug TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (slug, domain_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mailbox_aliases_mailbox_id ON mailbox_aliases(mailbox_id);
+
+-- Alias address the email was delivered through, empty for the primary address
+ALTER TABLE emails ADD COLUMN alias_address TEXT NOT NULL DEFAULT '';
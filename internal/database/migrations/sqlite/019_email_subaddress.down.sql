@@ -0,0 +1,2 @@
+DROP INDEX idx_emails_mailbox_subaddress;
+ALTER TABLE emails DROP COLUMN subaddress;
@@ -0,0 +1,22 @@
+-- Durable webhook queue. A row is one delivery still to be attempted. Workers
+-- lease it while sending and delete it once it needs no further attempts.
+CREATE TABLE IF NOT EXISTS webhook_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    email_id INTEGER NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    last_delivery_id INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    available_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lease_owner TEXT NOT NULL DEFAULT '',
+    lease_expires_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_available_at ON webhook_jobs(available_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_webhook_id ON webhook_jobs(webhook_id);
+
+-- Retries that were only held in memory by the previous dispatcher
+INSERT INTO webhook_jobs (webhook_id, email_id, attempt, last_delivery_id, available_at, created_at)
+SELECT webhook_id, email_id, attempt + 1, id, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM webhook_deliveries
+WHERE status = 'pending' OR status = 'retrying';
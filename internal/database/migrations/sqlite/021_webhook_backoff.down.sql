@@ -0,0 +1,4 @@
+ALTER TABLE webhook_deliveries DROP COLUMN next_attempt_at;
+ALTER TABLE webhooks DROP COLUMN retry_base_delay_sec;
+ALTER TABLE webhooks DROP COLUMN retry_max_delay_sec;
+ALTER TABLE webhooks DROP COLUMN retry_jitter_percent;
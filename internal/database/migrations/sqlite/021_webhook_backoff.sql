@@ -0,0 +1,8 @@
+-- Per-webhook retry schedule: exponential backoff from a base delay up to a cap,
+-- shortened by a random jitter of up to retry_jitter_percent
+ALTER TABLE webhooks ADD COLUMN retry_base_delay_sec INTEGER NOT NULL DEFAULT 30;
+ALTER TABLE webhooks ADD COLUMN retry_max_delay_sec INTEGER NOT NULL DEFAULT 3600;
+ALTER TABLE webhooks ADD COLUMN retry_jitter_percent INTEGER NOT NULL DEFAULT 20;
+
+-- When a retrying delivery will be attempted again
+ALTER TABLE webhook_deliveries ADD COLUMN next_attempt_at DATETIME;
@@ -0,0 +1,4 @@
+ALTER TABLE webhooks DROP COLUMN circuit_state;
+ALTER TABLE webhooks DROP COLUMN consecutive_failures;
+ALTER TABLE webhooks DROP COLUMN failing_since;
+ALTER TABLE webhooks DROP COLUMN circuit_changed_at;
@@ -0,0 +1,7 @@
+-- Circuit breaker state per webhook. The circuit opens after too many
+-- consecutive failures and is half open while a single probe is in flight.
+-- failing_since is the first failure of the current streak.
+ALTER TABLE webhooks ADD COLUMN circuit_state TEXT NOT NULL DEFAULT 'closed';
+ALTER TABLE webhooks ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN failing_since DATETIME;
+ALTER TABLE webhooks ADD COLUMN circuit_changed_at DATETIME;
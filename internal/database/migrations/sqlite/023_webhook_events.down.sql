@@ -0,0 +1,58 @@
+-- Jobs and deliveries of events other than email.received have no email
+-- and cannot be kept once email_id is required again
+UPDATE webhook_jobs SET last_delivery_id = NULL
+WHERE last_delivery_id IN (SELECT id FROM webhook_deliveries WHERE email_id IS NULL);
+DELETE FROM webhook_jobs WHERE email_id IS NULL;
+DELETE FROM webhook_deliveries WHERE email_id IS NULL;
+
+CREATE TABLE webhook_deliveries_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    email_id INTEGER NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'pending',
+    status_code INTEGER,
+    request_body TEXT,
+    response_body TEXT,
+    error_message TEXT,
+    duration_ms INTEGER,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    next_attempt_at DATETIME
+);
+
+INSERT INTO webhook_deliveries_old (id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at)
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at
+FROM webhook_deliveries;
+
+DROP TABLE webhook_deliveries;
+
+ALTER TABLE webhook_deliveries_old RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_email_id ON webhook_deliveries(email_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status);
+
+CREATE TABLE webhook_jobs_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    email_id INTEGER NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    last_delivery_id INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    available_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lease_owner TEXT NOT NULL DEFAULT '',
+    lease_expires_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO webhook_jobs_old (id, webhook_id, email_id, attempt, last_delivery_id, available_at, lease_owner, lease_expires_at, created_at)
+SELECT id, webhook_id, email_id, attempt, last_delivery_id, available_at, lease_owner, lease_expires_at, created_at
+FROM webhook_jobs;
+
+DROP TABLE webhook_jobs;
+
+ALTER TABLE webhook_jobs_old RENAME TO webhook_jobs;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_available_at ON webhook_jobs(available_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_webhook_id ON webhook_jobs(webhook_id);
+
+ALTER TABLE webhooks DROP COLUMN events;
@@ -0,0 +1,63 @@
+-- Webhooks subscribe to a comma-separated list of events. Events other than
+-- email.received carry a payload snapshot instead of pointing at a stored
+-- email, so jobs and deliveries are rebuilt with a nullable email_id.
+ALTER TABLE webhooks ADD COLUMN events TEXT NOT NULL DEFAULT 'email.received';
+
+-- Foreign keys stay off while the tables are swapped, otherwise dropping
+-- webhook_deliveries would clear webhook_jobs.last_delivery_id
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE webhook_deliveries_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    email_id INTEGER REFERENCES emails(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'pending',
+    status_code INTEGER,
+    request_body TEXT,
+    response_body TEXT,
+    error_message TEXT,
+    duration_ms INTEGER,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    next_attempt_at DATETIME,
+    event TEXT NOT NULL DEFAULT 'email.received'
+);
+
+INSERT INTO webhook_deliveries_new (id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at)
+SELECT id, webhook_id, email_id, attempt, status, status_code, request_body, response_body, error_message, duration_ms, created_at, next_attempt_at
+FROM webhook_deliveries;
+
+DROP TABLE webhook_deliveries;
+
+ALTER TABLE webhook_deliveries_new RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_email_id ON webhook_deliveries(email_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status);
+
+CREATE TABLE webhook_jobs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    email_id INTEGER REFERENCES emails(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    last_delivery_id INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    available_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lease_owner TEXT NOT NULL DEFAULT '',
+    lease_expires_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event TEXT NOT NULL DEFAULT 'email.received',
+    payload TEXT
+);
+
+INSERT INTO webhook_jobs_new (id, webhook_id, email_id, attempt, last_delivery_id, available_at, lease_owner, lease_expires_at, created_at)
+SELECT id, webhook_id, email_id, attempt, last_delivery_id, available_at, lease_owner, lease_expires_at, created_at
+FROM webhook_jobs;
+
+DROP TABLE webhook_jobs;
+
+ALTER TABLE webhook_jobs_new RENAME TO webhook_jobs;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_available_at ON webhook_jobs(available_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_jobs_webhook_id ON webhook_jobs(webhook_id);
+
+PRAGMA foreign_keys = ON;
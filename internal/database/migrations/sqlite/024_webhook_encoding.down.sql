@@ -0,0 +1,2 @@
+ALTER TABLE webhooks DROP COLUMN encoding;
+ALTER TABLE webhooks DROP COLUMN query_fields;
@@ -0,0 +1,5 @@
+ALTER TABLE webhooks DROP COLUMN tls_client_cert;
+ALTER TABLE webhooks DROP COLUMN tls_client_key;
+ALTER TABLE webhooks DROP COLUMN tls_ca_bundle;
+ALTER TABLE webhooks DROP COLUMN tls_skip_verify;
+ALTER TABLE webhooks DROP COLUMN proxy_url;
@@ -0,0 +1,3 @@
+-- How a webhook handles redirects: follow them, follow only those to the
+-- same host, or treat the redirect response as the answer.
+ALTER TABLE webhooks ADD COLUMN redirect_policy TEXT NOT NULL DEFAULT 'follow';
@@ -0,0 +1,4 @@
+-- Where a webhook delivers to: http (the default), smtp, broker or
+-- directory. target_config holds the JSON settings of non-HTTP targets.
+ALTER TABLE webhooks ADD COLUMN target TEXT NOT NULL DEFAULT 'http';
+ALTER TABLE webhooks ADD COLUMN target_config TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+DROP TRIGGER emails_fts_update;
+DROP TRIGGER emails_fts_delete;
+DROP TRIGGER emails_fts_insert;
+DROP TABLE emails_fts;
+
+ALTER TABLE emails DROP COLUMN attachment_names;
@@ -0,0 +1,31 @@
+-- Attachment filenames of each email, space separated, kept for search
+ALTER TABLE emails ADD COLUMN attachment_names TEXT NOT NULL DEFAULT '';
+
+UPDATE emails
+SET attachment_names = COALESCE((SELECT group_concat(filename, ' ') FROM attachments WHERE email_id = emails.id), '');
+
+-- Full-text index over emails, kept in sync by the triggers below
+CREATE VIRTUAL TABLE IF NOT EXISTS emails_fts USING fts5(
+    subject, from_address, to_address, text_body, attachment_names,
+    content='emails', content_rowid='id',
+    tokenize='unicode61 remove_diacritics 2'
+);
+
+INSERT INTO emails_fts(emails_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS emails_fts_insert AFTER INSERT ON emails BEGIN
+    INSERT INTO emails_fts(rowid, subject, from_address, to_address, text_body, attachment_names)
+    VALUES (new.id, new.subject, new.from_address, new.to_address, new.text_body, new.attachment_names);
+END;
+
+CREATE TRIGGER IF NOT EXISTS emails_fts_delete AFTER DELETE ON emails BEGIN
+    INSERT INTO emails_fts(emails_fts, rowid, subject, from_address, to_address, text_body, attachment_names)
+    VALUES ('delete', old.id, old.subject, old.from_address, old.to_address, old.text_body, old.attachment_names);
+END;
+
+CREATE TRIGGER IF NOT EXISTS emails_fts_update AFTER UPDATE OF subject, from_address, to_address, text_body, attachment_names ON emails BEGIN
+    INSERT INTO emails_fts(emails_fts, rowid, subject, from_address, to_address, text_body, attachment_names)
+    VALUES ('delete', old.id, old.subject, old.from_address, old.to_address, old.text_body, old.attachment_names);
+    INSERT INTO emails_fts(rowid, subject, from_address, to_address, text_body, attachment_names)
+    VALUES (new.id, new.subject, new.from_address, new.to_address, new.text_body, new.attachment_names);
+END;
@@ -0,0 +1,3 @@
+DROP TRIGGER emails_storage_tombstone;
+DROP TRIGGER attachments_storage_tombstone;
+DROP TABLE storage_tombstones;
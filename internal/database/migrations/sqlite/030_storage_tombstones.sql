@@ -0,0 +1,17 @@
+-- Stored files whose rows were deleted, waiting to be removed from storage.
+-- Triggers queue them in the transaction that deletes the row, including
+-- rows removed by ON DELETE CASCADE.
+CREATE TABLE IF NOT EXISTS storage_tombstones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER IF NOT EXISTS attachments_storage_tombstone AFTER DELETE ON attachments BEGIN
+    INSERT INTO storage_tombstones (path) VALUES (old.storage_path);
+END;
+
+CREATE TRIGGER IF NOT EXISTS emails_storage_tombstone AFTER DELETE ON emails
+WHEN old.raw_path IS NOT NULL AND old.raw_path != '' BEGIN
+    INSERT INTO storage_tombstones (path) VALUES (old.raw_path);
+END;
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+)
+
+//go:generate go run gen_postgres_queries.go
+
+// queryName returns the name in the "-- name: Name :kind" header sqlc puts
+// at the top of every generated query.
+func queryName(query string) string {
+	header, _, _ := strings.Cut(query, "\n")
+	fields := strings.Fields(strings.TrimPrefix(header, "-- name: "))
+	if !strings.HasPrefix(header, "-- name: ") || len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// postgresDBTX runs db.Queries on Postgres by swapping each query for the
+// one of the same name sqlc generated from queries/postgres. Both take the
+// same parameters and return the same columns, which
+// TestPostgresQueriesMatch checks.
+type postgresDBTX struct {
+	db db.DBTX
+}
+
+func newPostgresDBTX(dbtx db.DBTX) *postgresDBTX {
+	return &postgresDBTX{db: dbtx}
+}
+
+func (p *postgresDBTX) query(query string) string {
+	name := queryName(query)
+	converted, ok := postgresQueries[name]
+	if !ok {
+		panic(fmt.Sprintf("database: no Postgres query named %q", name))
+	}
+	return converted
+}
+
+func (p *postgresDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.db.ExecContext(ctx, p.query(query), args...)
+}
+
+func (p *postgresDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, p.query(query))
+}
+
+func (p *postgresDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.db.QueryContext(ctx, p.query(query), args...)
+}
+
+func (p *postgresDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.db.QueryRowContext(ctx, p.query(query), args...)
+}
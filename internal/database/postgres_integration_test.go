@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/jr-k/mailgress/internal/database/db"
+)
+
+// startPostgres returns the DSN of an empty Postgres database: the one in
+// MAILGRESS_TEST_POSTGRES_DSN, or else an embedded server, which downloads
+// its binaries on first use. The test is skipped when neither is available.
+func startPostgres(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv("MAILGRESS_TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+	if testing.Short() {
+		t.Skip("embedded Postgres is not started in short mode")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := uint32(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	dir := t.TempDir()
+	server := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("mailgress").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		StartTimeout(time.Minute).
+		Logger(io.Discard))
+	if err := server.Start(); err != nil {
+		t.Skipf("embedded Postgres unavailable, set MAILGRESS_TEST_POSTGRES_DSN to run: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := server.Stop(); err != nil {
+			t.Errorf("stopping embedded Postgres: %v", err)
+		}
+	})
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres password=postgres dbname=mailgress sslmode=disable", port)
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	ctx := context.Background()
+	conn, _, err := NewConnection("postgres", startPostgres(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m, err := NewMigrator(conn, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMigrations(conn, "postgres"); err != nil {
+		t.Fatalf("CheckMigrations after Up: %v", err)
+	}
+	if _, err := m.Down(ctx, len(applied)); err != nil {
+		t.Fatalf("reverting every migration: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("reapplying: %v", err)
+	}
+}
+
+// TestPostgresQueries runs the generated queries whose Postgres versions
+// differ the most from the SQLite ones through db.Queries, the way the
+// services run them.
+func TestPostgresQueries(t *testing.T) {
+	ctx := context.Background()
+	conn, queries, err := NewConnection("postgres", startPostgres(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := RunMigrations(conn, "postgres"); err != nil {
+		t.Fatal(err)
+	}
+
+	mailbox, err := queries.CreateMailbox(ctx, db.CreateMailboxParams{Slug: "inbox", IsActive: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := queries.MailboxExistsBySlug(ctx, "inbox")
+	if err != nil || exists != 1 {
+		t.Fatalf("MailboxExistsBySlug = %d, %v, want 1", exists, err)
+	}
+
+	invoice, err := queries.CreateEmail(ctx, db.CreateEmailParams{
+		MailboxID:   mailbox.ID,
+		FromAddress: "billing@example.com",
+		ToAddress:   "inbox@example.org",
+		Subject:     sql.NullString{String: "Your invoice", Valid: true},
+		TextBody:    sql.NullString{String: "The invoice is attached.", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.CreateEmail(ctx, db.CreateEmailParams{
+		MailboxID:   mailbox.ID,
+		FromAddress: "news@example.com",
+		ToAddress:   "inbox@example.org",
+		Subject:     sql.NullString{String: "Weekly news", Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		EmailID:     invoice.ID,
+		Filename:    "invoice.pdf",
+		ContentType: "application/pdf",
+		Size:        3,
+		StoragePath: "attachments/invoice.pdf",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.AddEmailAttachmentName(ctx, db.AddEmailAttachmentNameParams{Filename: "invoice.pdf", ID: invoice.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	emails, err := queries.ListEmailsByMailbox(ctx, db.ListEmailsByMailboxParams{MailboxID: mailbox.ID, Limit: 10})
+	if err != nil || len(emails) != 2 {
+		t.Fatalf("ListEmailsByMailbox = %d emails, %v, want 2", len(emails), err)
+	}
+	got, err := queries.GetEmailByID(ctx, invoice.ID)
+	if err != nil || got.AttachmentNames != "invoice.pdf" {
+		t.Fatalf("GetEmailByID = %q attachment names, %v, want invoice.pdf", got.AttachmentNames, err)
+	}
+	stats, err := queries.GetMailboxStats(ctx, mailbox.ID)
+	if err != nil || stats.EmailCount != 2 {
+		t.Fatalf("GetMailboxStats = %d emails, %v, want 2", stats.EmailCount, err)
+	}
+
+	tests := []struct {
+		name   string
+		params db.SearchEmailsParams
+		want   int
+	}{
+		{"everything", db.SearchEmailsParams{}, 2},
+		{"mailbox", db.SearchEmailsParams{MailboxID: sql.NullInt64{Int64: mailbox.ID, Valid: true}}, 2},
+		{"slug", db.SearchEmailsParams{MailboxSlug: sql.NullString{String: "other", Valid: true}}, 0},
+		{"match", db.SearchEmailsParams{Match: sql.NullString{String: "invoice", Valid: true}}, 1},
+		{"exclude", db.SearchEmailsParams{Exclude: sql.NullString{String: "invoice", Valid: true}}, 1},
+		{"has attachment", db.SearchEmailsParams{HasAttachment: sql.NullInt64{Int64: 1, Valid: true}}, 1},
+		{"no attachment", db.SearchEmailsParams{HasAttachment: sql.NullInt64{Int64: 0, Valid: true}}, 1},
+		{"unread", db.SearchEmailsParams{IsRead: sql.NullInt64{Int64: 0, Valid: true}}, 2},
+		{"received after", db.SearchEmailsParams{ReceivedAfter: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run("search "+tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Limit = 10
+			emails, err := queries.SearchEmails(ctx, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := queries.CountSearchEmails(ctx, db.CountSearchEmailsParams{
+				MailboxID:      params.MailboxID,
+				OwnerID:        params.OwnerID,
+				MailboxSlug:    params.MailboxSlug,
+				Match:          params.Match,
+				Exclude:        params.Exclude,
+				HasAttachment:  params.HasAttachment,
+				IsRead:         params.IsRead,
+				ReceivedBefore: params.ReceivedBefore,
+				ReceivedAfter:  params.ReceivedAfter,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(emails) != tt.want || count != int64(tt.want) {
+				t.Errorf("got %d emails, count %d, want %d", len(emails), count, tt.want)
+			}
+		})
+	}
+
+	webhook, err := queries.CreateWebhook(ctx, db.CreateWebhookParams{
+		MailboxID: mailbox.ID,
+		Name:      "hook",
+		Url:       "https://example.com/hook",
+		Method:    "POST",
+		IsActive:  1,
+		Events:    "email.received",
+		Target:    "http",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	job, err := queries.EnqueueWebhookJob(ctx, db.EnqueueWebhookJobParams{
+		WebhookID:      webhook.ID,
+		EmailID:        sql.NullInt64{Int64: invoice.ID, Valid: true},
+		Event:          "email.received",
+		AvailableAt:    now,
+		IdempotencyKey: "key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := queries.ClaimWebhookJob(ctx, db.ClaimWebhookJobParams{
+		LeaseOwner:       "worker",
+		LeaseExpiresAt:   sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+		AvailableAt:      now.Add(time.Second),
+		LeaseExpiresAt_2: sql.NullTime{Time: now.Add(time.Second), Valid: true},
+	})
+	if err != nil || claimed.ID != job.ID || claimed.LeaseOwner != "worker" {
+		t.Fatalf("ClaimWebhookJob = job %d owned by %q, %v, want job %d", claimed.ID, claimed.LeaseOwner, err, job.ID)
+	}
+	deliveryStats, err := queries.GetWebhookDeliveryStats(ctx, webhook.ID)
+	if err != nil || deliveryStats.Total != 0 || deliveryStats.SuccessCount != 0 {
+		t.Fatalf("GetWebhookDeliveryStats = %+v, %v, want no deliveries", deliveryStats, err)
+	}
+
+	if _, err := queries.UpsertSetting(ctx, db.UpsertSettingParams{Key: "name", Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	setting, err := queries.UpsertSetting(ctx, db.UpsertSettingParams{Key: "name", Value: "b"})
+	if err != nil || setting.Value != "b" {
+		t.Fatalf("UpsertSetting = %q, %v, want b", setting.Value, err)
+	}
+
+	if err := queries.DeleteEmail(ctx, invoice.ID); err != nil {
+		t.Fatal(err)
+	}
+	tombstones, err := queries.ListStorageTombstones(ctx, 10)
+	if err != nil || len(tombstones) == 0 {
+		t.Fatalf("ListStorageTombstones = %d tombstones, %v, want the deleted email's files", len(tombstones), err)
+	}
+}
@@ -0,0 +1,156 @@
+// Code generated by gen_postgres_queries.go. DO NOT EDIT.
+
+package database
+
+import "github.com/jr-k/mailgress/internal/database/pgdb"
+
+// postgresQueries maps each query name to the query sqlc generated for
+// Postgres from queries/postgres.
+var postgresQueries = map[string]string{
+	"AddEmailAttachmentName":             pgdb.AddEmailAttachmentName,
+	"AddTagToDomain":                     pgdb.AddTagToDomain,
+	"AddTagToMailbox":                    pgdb.AddTagToMailbox,
+	"CancelRetryingByWebhook":            pgdb.CancelRetryingByWebhook,
+	"ClaimWebhookJob":                    pgdb.ClaimWebhookJob,
+	"ClaimWebhookProbe":                  pgdb.ClaimWebhookProbe,
+	"ClearDomainTags":                    pgdb.ClearDomainTags,
+	"ClearMailboxTags":                   pgdb.ClearMailboxTags,
+	"CountAllEmails":                     pgdb.CountAllEmails,
+	"CountDeliveriesByStatus":            pgdb.CountDeliveriesByStatus,
+	"CountDeliveriesByWebhook":           pgdb.CountDeliveriesByWebhook,
+	"CountDomains":                       pgdb.CountDomains,
+	"CountEmailsByMailbox":               pgdb.CountEmailsByMailbox,
+	"CountEmailsByMailboxAndSubaddress":  pgdb.CountEmailsByMailboxAndSubaddress,
+	"CountMailboxes":                     pgdb.CountMailboxes,
+	"CountQueuedWebhookJobsByWebhook":    pgdb.CountQueuedWebhookJobsByWebhook,
+	"CountSearchEmails":                  pgdb.CountSearchEmails,
+	"CountStorageTombstones":             pgdb.CountStorageTombstones,
+	"CountTagUsage":                      pgdb.CountTagUsage,
+	"CountUnreadByMailbox":               pgdb.CountUnreadByMailbox,
+	"CountUsers":                         pgdb.CountUsers,
+	"CountWebhookJobs":                   pgdb.CountWebhookJobs,
+	"CountWebhooksByMailbox":             pgdb.CountWebhooksByMailbox,
+	"CreateApiToken":                     pgdb.CreateApiToken,
+	"CreateAttachment":                   pgdb.CreateAttachment,
+	"CreateDelivery":                     pgdb.CreateDelivery,
+	"CreateDomain":                       pgdb.CreateDomain,
+	"CreateDomainRoute":                  pgdb.CreateDomainRoute,
+	"CreateEmail":                        pgdb.CreateEmail,
+	"CreateMailbox":                      pgdb.CreateMailbox,
+	"CreateMailboxAlias":                 pgdb.CreateMailboxAlias,
+	"CreateSession":                      pgdb.CreateSession,
+	"CreateTag":                          pgdb.CreateTag,
+	"CreateUser":                         pgdb.CreateUser,
+	"CreateWebhook":                      pgdb.CreateWebhook,
+	"CreateWebhookRule":                  pgdb.CreateWebhookRule,
+	"CreateWebhookSecret":                pgdb.CreateWebhookSecret,
+	"DeleteAllByWebhook":                 pgdb.DeleteAllByWebhook,
+	"DeleteApiToken":                     pgdb.DeleteApiToken,
+	"DeleteAttachment":                   pgdb.DeleteAttachment,
+	"DeleteAttachmentsByEmail":           pgdb.DeleteAttachmentsByEmail,
+	"DeleteDomain":                       pgdb.DeleteDomain,
+	"DeleteDomainRoute":                  pgdb.DeleteDomainRoute,
+	"DeleteEmail":                        pgdb.DeleteEmail,
+	"DeleteExpiredSessions":              pgdb.DeleteExpiredSessions,
+	"DeleteExpiredWebhookSecrets":        pgdb.DeleteExpiredWebhookSecrets,
+	"DeleteMailbox":                      pgdb.DeleteMailbox,
+	"DeleteMailboxAlias":                 pgdb.DeleteMailboxAlias,
+	"DeleteOldEmails":                    pgdb.DeleteOldEmails,
+	"DeleteOldEmailsByMailbox":           pgdb.DeleteOldEmailsByMailbox,
+	"DeleteRetryWebhookJobsByWebhook":    pgdb.DeleteRetryWebhookJobsByWebhook,
+	"DeleteRulesByWebhook":               pgdb.DeleteRulesByWebhook,
+	"DeleteSession":                      pgdb.DeleteSession,
+	"DeleteSessionsByUser":               pgdb.DeleteSessionsByUser,
+	"DeleteSetting":                      pgdb.DeleteSetting,
+	"DeleteStorageTombstone":             pgdb.DeleteStorageTombstone,
+	"DeleteTag":                          pgdb.DeleteTag,
+	"DeleteUser":                         pgdb.DeleteUser,
+	"DeleteWebhook":                      pgdb.DeleteWebhook,
+	"DeleteWebhookJob":                   pgdb.DeleteWebhookJob,
+	"DeleteWebhookRule":                  pgdb.DeleteWebhookRule,
+	"DeleteWebhookSecretsByWebhook":      pgdb.DeleteWebhookSecretsByWebhook,
+	"DisableUserTOTP":                    pgdb.DisableUserTOTP,
+	"DisableWebhook":                     pgdb.DisableWebhook,
+	"DomainExistsByName":                 pgdb.DomainExistsByName,
+	"EnqueueWebhookJob":                  pgdb.EnqueueWebhookJob,
+	"FailUnfinishedDelivery":             pgdb.FailUnfinishedDelivery,
+	"GetApiTokenByHash":                  pgdb.GetApiTokenByHash,
+	"GetAttachmentByID":                  pgdb.GetAttachmentByID,
+	"GetDeliveryByID":                    pgdb.GetDeliveryByID,
+	"GetDomainByID":                      pgdb.GetDomainByID,
+	"GetDomainByName":                    pgdb.GetDomainByName,
+	"GetDomainRouteByID":                 pgdb.GetDomainRouteByID,
+	"GetEmailByID":                       pgdb.GetEmailByID,
+	"GetMailboxAliasBySlugAndDomain":     pgdb.GetMailboxAliasBySlugAndDomain,
+	"GetMailboxAliasBySlugAndDomainName": pgdb.GetMailboxAliasBySlugAndDomainName,
+	"GetMailboxByID":                     pgdb.GetMailboxByID,
+	"GetMailboxBySlug":                   pgdb.GetMailboxBySlug,
+	"GetMailboxBySlugAndDomain":          pgdb.GetMailboxBySlugAndDomain,
+	"GetMailboxBySlugAndDomainName":      pgdb.GetMailboxBySlugAndDomainName,
+	"GetMailboxStats":                    pgdb.GetMailboxStats,
+	"GetSessionByToken":                  pgdb.GetSessionByToken,
+	"GetSetting":                         pgdb.GetSetting,
+	"GetSettings":                        pgdb.GetSettings,
+	"GetTagByID":                         pgdb.GetTagByID,
+	"GetTagByName":                       pgdb.GetTagByName,
+	"GetTagsForDomain":                   pgdb.GetTagsForDomain,
+	"GetTagsForMailbox":                  pgdb.GetTagsForMailbox,
+	"GetUserByEmail":                     pgdb.GetUserByEmail,
+	"GetUserByID":                        pgdb.GetUserByID,
+	"GetWebhookByID":                     pgdb.GetWebhookByID,
+	"GetWebhookDeliveryStats":            pgdb.GetWebhookDeliveryStats,
+	"GetWebhookRuleByID":                 pgdb.GetWebhookRuleByID,
+	"ListActiveAdminWebhooksByDomain":    pgdb.ListActiveAdminWebhooksByDomain,
+	"ListActiveDomains":                  pgdb.ListActiveDomains,
+	"ListActiveWebhookSecrets":           pgdb.ListActiveWebhookSecrets,
+	"ListActiveWebhooksByDomainOwner":    pgdb.ListActiveWebhooksByDomainOwner,
+	"ListActiveWebhooksByMailbox":        pgdb.ListActiveWebhooksByMailbox,
+	"ListApiTokensByUser":                pgdb.ListApiTokensByUser,
+	"ListAttachmentPaths":                pgdb.ListAttachmentPaths,
+	"ListAttachmentsByEmail":             pgdb.ListAttachmentsByEmail,
+	"ListDeliveriesByEmail":              pgdb.ListDeliveriesByEmail,
+	"ListDeliveriesByWebhook":            pgdb.ListDeliveriesByWebhook,
+	"ListDomainRoutes":                   pgdb.ListDomainRoutes,
+	"ListDomains":                        pgdb.ListDomains,
+	"ListEmailRawPaths":                  pgdb.ListEmailRawPaths,
+	"ListEmailsByMailbox":                pgdb.ListEmailsByMailbox,
+	"ListEmailsByMailboxAndSubaddress":   pgdb.ListEmailsByMailboxAndSubaddress,
+	"ListMailboxAliases":                 pgdb.ListMailboxAliases,
+	"ListMailboxSubaddresses":            pgdb.ListMailboxSubaddresses,
+	"ListMailboxes":                      pgdb.ListMailboxes,
+	"ListMailboxesByDomain":              pgdb.ListMailboxesByDomain,
+	"ListMailboxesByOwner":               pgdb.ListMailboxesByOwner,
+	"ListPendingDeliveries":              pgdb.ListPendingDeliveries,
+	"ListRulesByWebhook":                 pgdb.ListRulesByWebhook,
+	"ListStorageTombstones":              pgdb.ListStorageTombstones,
+	"ListTags":                           pgdb.ListTags,
+	"ListUsers":                          pgdb.ListUsers,
+	"ListWebhooksByMailbox":              pgdb.ListWebhooksByMailbox,
+	"MailboxAliasExistsBySlugAndDomain":  pgdb.MailboxAliasExistsBySlugAndDomain,
+	"MailboxExistsBySlug":                pgdb.MailboxExistsBySlug,
+	"MailboxExistsBySlugAndDomain":       pgdb.MailboxExistsBySlugAndDomain,
+	"MarkEmailAsRead":                    pgdb.MarkEmailAsRead,
+	"MarkEmailAsUnread":                  pgdb.MarkEmailAsUnread,
+	"RecordWebhookFailure":               pgdb.RecordWebhookFailure,
+	"RecordWebhookSuccess":               pgdb.RecordWebhookSuccess,
+	"RemoveTagFromDomain":                pgdb.RemoveTagFromDomain,
+	"RemoveTagFromMailbox":               pgdb.RemoveTagFromMailbox,
+	"RescheduleWebhookJob":               pgdb.RescheduleWebhookJob,
+	"SearchEmails":                       pgdb.SearchEmails,
+	"SetDomainCatchAll":                  pgdb.SetDomainCatchAll,
+	"ToggleMailboxActive":                pgdb.ToggleMailboxActive,
+	"ToggleWebhookActive":                pgdb.ToggleWebhookActive,
+	"TouchApiToken":                      pgdb.TouchApiToken,
+	"UpdateDelivery":                     pgdb.UpdateDelivery,
+	"UpdateDomain":                       pgdb.UpdateDomain,
+	"UpdateDomainRoutePosition":          pgdb.UpdateDomainRoutePosition,
+	"UpdateEmailRawPath":                 pgdb.UpdateEmailRawPath,
+	"UpdateMailbox":                      pgdb.UpdateMailbox,
+	"UpdateTag":                          pgdb.UpdateTag,
+	"UpdateUser":                         pgdb.UpdateUser,
+	"UpdateUserAvatar":                   pgdb.UpdateUserAvatar,
+	"UpdateUserTOTP":                     pgdb.UpdateUserTOTP,
+	"UpdateWebhook":                      pgdb.UpdateWebhook,
+	"UpdateWebhookSecret":                pgdb.UpdateWebhookSecret,
+	"UpsertSetting":                      pgdb.UpsertSetting,
+}
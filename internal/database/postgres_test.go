@@ -0,0 +1,125 @@
+package database
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/database/pgdb"
+)
+
+// TestPostgresQueriesMatch checks that every query generated for SQLite has
+// a Postgres query of the same name that db.Queries can run in its place:
+// the same parameters in the same order and the same result columns.
+func TestPostgresQueriesMatch(t *testing.T) {
+	sqliteType := reflect.TypeOf(&db.Queries{})
+	postgresType := reflect.TypeOf(&pgdb.Queries{})
+
+	for i := 0; i < sqliteType.NumMethod(); i++ {
+		method := sqliteType.Method(i)
+		if method.Name == "WithTx" {
+			continue
+		}
+		t.Run(method.Name, func(t *testing.T) {
+			if _, ok := postgresQueries[method.Name]; !ok {
+				t.Fatalf("no Postgres query named %s", method.Name)
+			}
+			other, ok := postgresType.MethodByName(method.Name)
+			if !ok {
+				t.Fatalf("pgdb has no %s method", method.Name)
+			}
+			compareSignatures(t, method.Type, other.Type)
+		})
+	}
+
+	for name := range postgresQueries {
+		if _, ok := sqliteType.MethodByName(name); !ok {
+			t.Errorf("Postgres query %s has no SQLite counterpart", name)
+		}
+	}
+}
+
+// TestPostgresQueriesGenerated checks that postgres_queries.go lists every
+// query in the pgdb package, so a new query cannot be forgotten after
+// running sqlc.
+func TestPostgresQueriesGenerated(t *testing.T) {
+	files, err := filepath.Glob("pgdb/*.sql.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	count := 0
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for i, name := range spec.(*ast.ValueSpec).Names {
+					lit := spec.(*ast.ValueSpec).Values[i].(*ast.BasicLit)
+					query, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatal(err)
+					}
+					count++
+					if postgresQueries[name.Name] != query {
+						t.Errorf("postgres_queries.go is out of date for %s, run go generate ./internal/database", name.Name)
+					}
+				}
+			}
+		}
+	}
+	if count != len(postgresQueries) {
+		t.Errorf("postgres_queries.go has %d queries, pgdb has %d, run go generate ./internal/database", len(postgresQueries), count)
+	}
+}
+
+func compareSignatures(t *testing.T, sqlite, postgres reflect.Type) {
+	t.Helper()
+	if sqlite.NumIn() != postgres.NumIn() || sqlite.NumOut() != postgres.NumOut() {
+		t.Fatalf("signature %v, Postgres has %v", sqlite, postgres)
+	}
+	for i := 1; i < sqlite.NumIn(); i++ {
+		compareTypes(t, "parameter "+strconv.Itoa(i), sqlite.In(i), postgres.In(i))
+	}
+	for i := 0; i < sqlite.NumOut(); i++ {
+		compareTypes(t, "result "+strconv.Itoa(i), sqlite.Out(i), postgres.Out(i))
+	}
+}
+
+// compareTypes compares generated types by shape, since each package has
+// its own models and params structs: structs must have the same fields in
+// the same order, which is also the order of the placeholders and of the
+// scanned columns.
+func compareTypes(t *testing.T, what string, sqlite, postgres reflect.Type) {
+	t.Helper()
+	if sqlite.Kind() == reflect.Slice && postgres.Kind() == reflect.Slice {
+		sqlite, postgres = sqlite.Elem(), postgres.Elem()
+	}
+	if sqlite.Kind() != reflect.Struct || postgres.Kind() != reflect.Struct || sqlite.PkgPath() != reflect.TypeOf(db.Queries{}).PkgPath() {
+		if sqlite != postgres {
+			t.Errorf("%s is %v, Postgres has %v", what, sqlite, postgres)
+		}
+		return
+	}
+	if sqlite.NumField() != postgres.NumField() {
+		t.Errorf("%s %v has %d fields, Postgres %v has %d", what, sqlite, sqlite.NumField(), postgres, postgres.NumField())
+		return
+	}
+	for i := 0; i < sqlite.NumField(); i++ {
+		a, b := sqlite.Field(i), postgres.Field(i)
+		if a.Name != b.Name || a.Type != b.Type {
+			t.Errorf("%s field %d is %s %v, Postgres has %s %v", what, i, a.Name, a.Type, b.Name, b.Type)
+		}
+	}
+}
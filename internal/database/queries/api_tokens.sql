@@ -0,0 +1,18 @@
+-- name: GetApiTokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+LIMIT 1;
+
+-- name: ListApiTokensByUser :many
+SELECT * FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC;
+
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteApiToken :exec
+DELETE FROM api_tokens WHERE id = ? AND user_id = ?;
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?;
@@ -14,3 +14,6 @@ DELETE FROM attachments WHERE id = ?;
 
 -- name: DeleteAttachmentsByEmail :exec
 DELETE FROM attachments WHERE email_id = ?;
+
+-- name: ListAttachmentPaths :many
+SELECT id, email_id, storage_path FROM attachments ORDER BY id;
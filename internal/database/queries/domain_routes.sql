@@ -0,0 +1,18 @@
+-- name: GetDomainRouteByID :one
+SELECT * FROM domain_routes WHERE id = ? LIMIT 1;
+
+-- name: ListDomainRoutes :many
+SELECT * FROM domain_routes
+WHERE domain_id = ?
+ORDER BY position ASC, id ASC;
+
+-- name: CreateDomainRoute :one
+INSERT INTO domain_routes (domain_id, mailbox_id, pattern_type, pattern, position, created_at)
+VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(r.position), 0) + 1 FROM domain_routes r WHERE r.domain_id = ?), CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateDomainRoutePosition :exec
+UPDATE domain_routes SET position = ? WHERE id = ? AND domain_id = ?;
+
+-- name: DeleteDomainRoute :exec
+DELETE FROM domain_routes WHERE id = ? AND domain_id = ?;
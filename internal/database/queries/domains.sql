@@ -17,7 +17,7 @@ RETURNING *;
 
 -- name: UpdateDomain :one
 UPDATE domains
-SET name = ?, is_verified = ?, is_active = ?, updated_at = CURRENT_TIMESTAMP
+SET name = ?, is_verified = ?, is_active = ?, require_tls = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
@@ -29,3 +29,9 @@ SELECT COUNT(*) FROM domains;
 
 -- name: DomainExistsByName :one
 SELECT COUNT(*) FROM domains WHERE name = ?;
+
+-- name: SetDomainCatchAll :one
+UPDATE domains
+SET catch_all_mailbox_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
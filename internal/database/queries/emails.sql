@@ -7,24 +7,57 @@ WHERE mailbox_id = ?
 ORDER BY received_at DESC
 LIMIT ? OFFSET ?;
 
--- name: SearchEmails :many
+-- name: ListEmailsByMailboxAndSubaddress :many
 SELECT * FROM emails
-WHERE mailbox_id = ?
-AND (subject LIKE ? OR from_address LIKE ?)
+WHERE mailbox_id = ? AND subaddress = ?
 ORDER BY received_at DESC
 LIMIT ? OFFSET ?;
 
+-- name: CountEmailsByMailboxAndSubaddress :one
+SELECT COUNT(*) FROM emails WHERE mailbox_id = ? AND subaddress = ?;
+
+-- name: ListMailboxSubaddresses :many
+SELECT subaddress, COUNT(*) AS email_count FROM emails
+WHERE mailbox_id = ? AND subaddress != ''
+GROUP BY subaddress
+ORDER BY subaddress ASC;
+
+-- name: SearchEmails :many
+SELECT emails.* FROM emails
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (CAST(sqlc.narg(mailbox_id) AS INTEGER) IS NULL OR emails.mailbox_id = sqlc.narg(mailbox_id))
+AND (CAST(sqlc.narg(owner_id) AS INTEGER) IS NULL OR mailboxes.owner_id = sqlc.narg(owner_id))
+AND (CAST(sqlc.narg(mailbox_slug) AS TEXT) IS NULL OR mailboxes.slug = sqlc.narg(mailbox_slug))
+AND (CAST(sqlc.narg(match) AS TEXT) IS NULL OR emails.id IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH sqlc.narg(match)))
+AND (CAST(sqlc.narg(exclude) AS TEXT) IS NULL OR emails.id NOT IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH sqlc.narg(exclude)))
+AND (CAST(sqlc.narg(has_attachment) AS INTEGER) IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = sqlc.narg(has_attachment))
+AND (CAST(sqlc.narg(is_read) AS INTEGER) IS NULL OR emails.is_read = sqlc.narg(is_read))
+AND (CAST(sqlc.narg(received_before) AS DATETIME) IS NULL OR emails.received_at < sqlc.narg(received_before))
+AND (CAST(sqlc.narg(received_after) AS DATETIME) IS NULL OR emails.received_at >= sqlc.narg(received_after))
+ORDER BY emails.received_at DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
 -- name: CountSearchEmails :one
 SELECT COUNT(*) FROM emails
-WHERE mailbox_id = ?
-AND (subject LIKE ? OR from_address LIKE ?);
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (CAST(sqlc.narg(mailbox_id) AS INTEGER) IS NULL OR emails.mailbox_id = sqlc.narg(mailbox_id))
+AND (CAST(sqlc.narg(owner_id) AS INTEGER) IS NULL OR mailboxes.owner_id = sqlc.narg(owner_id))
+AND (CAST(sqlc.narg(mailbox_slug) AS TEXT) IS NULL OR mailboxes.slug = sqlc.narg(mailbox_slug))
+AND (CAST(sqlc.narg(match) AS TEXT) IS NULL OR emails.id IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH sqlc.narg(match)))
+AND (CAST(sqlc.narg(exclude) AS TEXT) IS NULL OR emails.id NOT IN (SELECT rowid FROM emails_fts WHERE emails_fts MATCH sqlc.narg(exclude)))
+AND (CAST(sqlc.narg(has_attachment) AS INTEGER) IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = sqlc.narg(has_attachment))
+AND (CAST(sqlc.narg(is_read) AS INTEGER) IS NULL OR emails.is_read = sqlc.narg(is_read))
+AND (CAST(sqlc.narg(received_before) AS DATETIME) IS NULL OR emails.received_at < sqlc.narg(received_before))
+AND (CAST(sqlc.narg(received_after) AS DATETIME) IS NULL OR emails.received_at >= sqlc.narg(received_after));
 
 -- name: CreateEmail :one
 INSERT INTO emails (
     mailbox_id, message_id, from_address, to_address, subject,
-    date, headers, text_body, html_body, raw_size, is_read, received_at
+    date, headers, text_body, html_body, raw_size,
+    spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy,
+    alias_address, subaddress, is_read, received_at
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: DeleteEmail :exec
@@ -33,7 +66,7 @@ DELETE FROM emails WHERE id = ?;
 -- name: DeleteOldEmails :exec
 DELETE FROM emails WHERE received_at < ?;
 
--- name: DeleteOldEmailsByMailbox :exec
+-- name: DeleteOldEmailsByMailbox :execrows
 DELETE FROM emails WHERE mailbox_id = ? AND received_at < ?;
 
 -- name: CountEmailsByMailbox :one
@@ -46,7 +79,7 @@ SELECT COUNT(*) FROM emails;
 SELECT
     COUNT(*) as email_count,
     MAX(received_at) as last_email_at,
-    (SELECT COUNT(*) FROM webhooks WHERE mailbox_id = sqlc.arg(mailbox_id)) as webhook_count
+    (SELECT COUNT(*) FROM webhooks w WHERE w.mailbox_id = sqlc.arg(mailbox_id)) as webhook_count
 FROM emails WHERE mailbox_id = sqlc.arg(mailbox_id);
 
 -- name: MarkEmailAsRead :exec
@@ -57,3 +90,12 @@ UPDATE emails SET is_read = 0 WHERE id = ?;
 
 -- name: CountUnreadByMailbox :one
 SELECT COUNT(*) FROM emails WHERE mailbox_id = ? AND is_read = 0;
+
+-- name: UpdateEmailRawPath :exec
+UPDATE emails SET raw_path = ? WHERE id = ?;
+
+-- name: AddEmailAttachmentName :exec
+UPDATE emails SET attachment_names = TRIM(attachment_names || ' ' || sqlc.arg(filename)) WHERE id = sqlc.arg(id);
+
+-- name: ListEmailRawPaths :many
+SELECT id, raw_path FROM emails WHERE raw_path IS NOT NULL AND raw_path != '' ORDER BY id;
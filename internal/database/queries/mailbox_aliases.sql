@@ -0,0 +1,24 @@
+-- name: CreateMailboxAlias :one
+INSERT INTO mailbox_aliases (mailbox_id, domain_id, slug, created_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteMailboxAlias :exec
+DELETE FROM mailbox_aliases WHERE id = ? AND mailbox_id = ?;
+
+-- name: GetMailboxAliasBySlugAndDomain :one
+SELECT * FROM mailbox_aliases WHERE slug = ? AND domain_id = ? LIMIT 1;
+
+-- name: GetMailboxAliasBySlugAndDomainName :one
+SELECT a.* FROM mailbox_aliases a
+JOIN domains d ON a.domain_id = d.id
+WHERE a.slug = ? AND d.name = ? LIMIT 1;
+
+-- name: ListMailboxAliases :many
+SELECT a.*, d.name AS domain_name FROM mailbox_aliases a
+JOIN domains d ON a.domain_id = d.id
+WHERE a.mailbox_id = ?
+ORDER BY d.name ASC, a.slug ASC;
+
+-- name: MailboxAliasExistsBySlugAndDomain :one
+SELECT EXISTS(SELECT 1 FROM mailbox_aliases WHERE slug = ? AND domain_id = ?);
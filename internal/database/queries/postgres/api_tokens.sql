@@ -0,0 +1,18 @@
+-- name: GetApiTokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+LIMIT 1;
+
+-- name: ListApiTokensByUser :many
+SELECT * FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC;
+
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteApiToken :exec
+DELETE FROM api_tokens WHERE id = $1 AND user_id = $2;
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1;
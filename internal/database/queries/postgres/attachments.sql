@@ -0,0 +1,19 @@
+-- name: GetAttachmentByID :one
+SELECT * FROM attachments WHERE id = $1 LIMIT 1;
+
+-- name: ListAttachmentsByEmail :many
+SELECT * FROM attachments WHERE email_id = $1 ORDER BY id;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (email_id, filename, content_type, size, storage_path, created_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteAttachment :exec
+DELETE FROM attachments WHERE id = $1;
+
+-- name: DeleteAttachmentsByEmail :exec
+DELETE FROM attachments WHERE email_id = $1;
+
+-- name: ListAttachmentPaths :many
+SELECT id, email_id, storage_path FROM attachments ORDER BY id;
@@ -0,0 +1,18 @@
+-- name: GetDomainRouteByID :one
+SELECT * FROM domain_routes WHERE id = $1 LIMIT 1;
+
+-- name: ListDomainRoutes :many
+SELECT * FROM domain_routes
+WHERE domain_id = $1
+ORDER BY position ASC, id ASC;
+
+-- name: CreateDomainRoute :one
+INSERT INTO domain_routes (domain_id, mailbox_id, pattern_type, pattern, position, created_at)
+VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(r.position), 0) + 1 FROM domain_routes r WHERE r.domain_id = $5), CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateDomainRoutePosition :exec
+UPDATE domain_routes SET position = $1 WHERE id = $2 AND domain_id = $3;
+
+-- name: DeleteDomainRoute :exec
+DELETE FROM domain_routes WHERE id = $1 AND domain_id = $2;
@@ -0,0 +1,37 @@
+-- name: GetDomainByID :one
+SELECT * FROM domains WHERE id = $1;
+
+-- name: GetDomainByName :one
+SELECT * FROM domains WHERE name = $1;
+
+-- name: ListDomains :many
+SELECT * FROM domains ORDER BY name ASC;
+
+-- name: ListActiveDomains :many
+SELECT * FROM domains WHERE is_active = 1 ORDER BY name ASC;
+
+-- name: CreateDomain :one
+INSERT INTO domains (name, is_verified, is_active)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UpdateDomain :one
+UPDATE domains
+SET name = $1, is_verified = $2, is_active = $3, require_tls = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $5
+RETURNING *;
+
+-- name: DeleteDomain :exec
+DELETE FROM domains WHERE id = $1;
+
+-- name: CountDomains :one
+SELECT COUNT(*) FROM domains;
+
+-- name: DomainExistsByName :one
+SELECT COUNT(*) FROM domains WHERE name = $1;
+
+-- name: SetDomainCatchAll :one
+UPDATE domains
+SET catch_all_mailbox_id = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING *;
@@ -0,0 +1,104 @@
+-- name: GetEmailByID :one
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails WHERE id = $1 LIMIT 1;
+
+-- name: ListEmailsByMailbox :many
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails
+WHERE mailbox_id = $1
+ORDER BY received_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListEmailsByMailboxAndSubaddress :many
+SELECT id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names FROM emails
+WHERE mailbox_id = $1 AND subaddress = $2
+ORDER BY received_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: CountEmailsByMailboxAndSubaddress :one
+SELECT COUNT(*) FROM emails WHERE mailbox_id = $1 AND subaddress = $2;
+
+-- name: ListMailboxSubaddresses :many
+SELECT subaddress, COUNT(*) AS email_count FROM emails
+WHERE mailbox_id = $1 AND subaddress != ''
+GROUP BY subaddress
+ORDER BY subaddress ASC;
+
+-- Full-text search goes through the search_vector column, filled with the
+-- tsquery syntax instead of FTS5 match expressions.
+
+-- name: SearchEmails :many
+SELECT emails.id, emails.mailbox_id, emails.message_id, emails.from_address, emails.to_address, emails.subject, emails.date, emails.headers, emails.text_body, emails.html_body, emails.raw_size, emails.received_at, emails.is_read, emails.raw_path, emails.spf_result, emails.spf_domain, emails.dkim_result, emails.dkim_domain, emails.dmarc_result, emails.dmarc_policy, emails.alias_address, emails.subaddress, emails.attachment_names FROM emails
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (sqlc.narg(mailbox_id)::bigint IS NULL OR emails.mailbox_id = sqlc.narg(mailbox_id))
+AND (sqlc.narg(owner_id)::bigint IS NULL OR mailboxes.owner_id = sqlc.narg(owner_id))
+AND (sqlc.narg(mailbox_slug)::text IS NULL OR mailboxes.slug = sqlc.narg(mailbox_slug))
+AND (sqlc.narg(match)::text IS NULL OR emails.search_vector @@ to_tsquery('simple', sqlc.narg(match)))
+AND (sqlc.narg(exclude)::text IS NULL OR NOT emails.search_vector @@ to_tsquery('simple', sqlc.narg(exclude)))
+AND (sqlc.narg(has_attachment)::bigint IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = (sqlc.narg(has_attachment) = 1))
+AND (sqlc.narg(is_read)::bigint IS NULL OR emails.is_read = sqlc.narg(is_read))
+AND (sqlc.narg(received_before)::timestamptz IS NULL OR emails.received_at < sqlc.narg(received_before))
+AND (sqlc.narg(received_after)::timestamptz IS NULL OR emails.received_at >= sqlc.narg(received_after))
+ORDER BY emails.received_at DESC
+LIMIT sqlc.arg('limit')::bigint OFFSET sqlc.arg('offset')::bigint;
+
+-- name: CountSearchEmails :one
+SELECT COUNT(*) FROM emails
+JOIN mailboxes ON mailboxes.id = emails.mailbox_id
+WHERE (sqlc.narg(mailbox_id)::bigint IS NULL OR emails.mailbox_id = sqlc.narg(mailbox_id))
+AND (sqlc.narg(owner_id)::bigint IS NULL OR mailboxes.owner_id = sqlc.narg(owner_id))
+AND (sqlc.narg(mailbox_slug)::text IS NULL OR mailboxes.slug = sqlc.narg(mailbox_slug))
+AND (sqlc.narg(match)::text IS NULL OR emails.search_vector @@ to_tsquery('simple', sqlc.narg(match)))
+AND (sqlc.narg(exclude)::text IS NULL OR NOT emails.search_vector @@ to_tsquery('simple', sqlc.narg(exclude)))
+AND (sqlc.narg(has_attachment)::bigint IS NULL OR EXISTS (SELECT 1 FROM attachments WHERE attachments.email_id = emails.id) = (sqlc.narg(has_attachment) = 1))
+AND (sqlc.narg(is_read)::bigint IS NULL OR emails.is_read = sqlc.narg(is_read))
+AND (sqlc.narg(received_before)::timestamptz IS NULL OR emails.received_at < sqlc.narg(received_before))
+AND (sqlc.narg(received_after)::timestamptz IS NULL OR emails.received_at >= sqlc.narg(received_after));
+
+-- name: CreateEmail :one
+INSERT INTO emails (
+    mailbox_id, message_id, from_address, to_address, subject,
+    date, headers, text_body, html_body, raw_size,
+    spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy,
+    alias_address, subaddress, is_read, received_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, 0, CURRENT_TIMESTAMP)
+RETURNING id, mailbox_id, message_id, from_address, to_address, subject, date, headers, text_body, html_body, raw_size, received_at, is_read, raw_path, spf_result, spf_domain, dkim_result, dkim_domain, dmarc_result, dmarc_policy, alias_address, subaddress, attachment_names;
+
+-- name: DeleteEmail :exec
+DELETE FROM emails WHERE id = $1;
+
+-- name: DeleteOldEmails :exec
+DELETE FROM emails WHERE received_at < $1;
+
+-- name: DeleteOldEmailsByMailbox :execrows
+DELETE FROM emails WHERE mailbox_id = $1 AND received_at < $2;
+
+-- name: CountEmailsByMailbox :one
+SELECT COUNT(*) FROM emails WHERE mailbox_id = $1;
+
+-- name: CountAllEmails :one
+SELECT COUNT(*) FROM emails;
+
+-- name: GetMailboxStats :one
+SELECT
+    COUNT(*) as email_count,
+    MAX(received_at) as last_email_at,
+    (SELECT COUNT(*) FROM webhooks w WHERE w.mailbox_id = sqlc.arg(mailbox_id)) as webhook_count
+FROM emails WHERE mailbox_id = sqlc.arg(mailbox_id);
+
+-- name: MarkEmailAsRead :exec
+UPDATE emails SET is_read = 1 WHERE id = $1;
+
+-- name: MarkEmailAsUnread :exec
+UPDATE emails SET is_read = 0 WHERE id = $1;
+
+-- name: CountUnreadByMailbox :one
+SELECT COUNT(*) FROM emails WHERE mailbox_id = $1 AND is_read = 0;
+
+-- name: UpdateEmailRawPath :exec
+UPDATE emails SET raw_path = $1 WHERE id = $2;
+
+-- name: AddEmailAttachmentName :exec
+UPDATE emails SET attachment_names = TRIM(attachment_names || ' ' || sqlc.arg(filename)) WHERE id = sqlc.arg(id);
+
+-- name: ListEmailRawPaths :many
+SELECT id, raw_path FROM emails WHERE raw_path IS NOT NULL AND raw_path != '' ORDER BY id;
@@ -0,0 +1,50 @@
+-- name: GetMailboxByID :one
+SELECT * FROM mailboxes WHERE id = $1 LIMIT 1;
+
+-- name: GetMailboxBySlug :one
+SELECT * FROM mailboxes WHERE slug = $1 LIMIT 1;
+
+-- name: GetMailboxBySlugAndDomain :one
+SELECT * FROM mailboxes WHERE slug = $1 AND domain_id = $2 LIMIT 1;
+
+-- name: ListMailboxes :many
+SELECT * FROM mailboxes ORDER BY created_at DESC;
+
+-- name: ListMailboxesByOwner :many
+SELECT * FROM mailboxes WHERE owner_id = $1 ORDER BY created_at DESC;
+
+-- name: ListMailboxesByDomain :many
+SELECT * FROM mailboxes WHERE domain_id = $1 ORDER BY created_at DESC;
+
+-- name: CreateMailbox :one
+INSERT INTO mailboxes (slug, owner_id, domain_id, description, is_active, max_email_size_mb, max_attachment_size_mb, retention_days, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateMailbox :one
+UPDATE mailboxes
+SET slug = $1, owner_id = $2, domain_id = $3, description = $4, is_active = $5,
+    max_email_size_mb = $6, max_attachment_size_mb = $7, retention_days = $8,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $9
+RETURNING *;
+
+-- name: ToggleMailboxActive :one
+UPDATE mailboxes SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END, updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING *;
+
+-- name: DeleteMailbox :exec
+DELETE FROM mailboxes WHERE id = $1;
+
+-- name: CountMailboxes :one
+SELECT COUNT(*) FROM mailboxes;
+
+-- name: MailboxExistsBySlug :one
+SELECT EXISTS(SELECT 1 FROM mailboxes WHERE slug = $1 AND is_active = 1)::bigint;
+
+-- name: MailboxExistsBySlugAndDomain :one
+SELECT EXISTS(SELECT 1 FROM mailboxes WHERE slug = $1 AND domain_id = $2 AND is_active = 1)::bigint;
+
+-- name: GetMailboxBySlugAndDomainName :one
+SELECT m.* FROM mailboxes m
+JOIN domains d ON m.domain_id = d.id
+WHERE m.slug = $1 AND d.name = $2 LIMIT 1;
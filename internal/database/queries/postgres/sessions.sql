@@ -0,0 +1,16 @@
+-- name: GetSessionByToken :one
+SELECT * FROM sessions WHERE token = $1 AND expires_at > CURRENT_TIMESTAMP LIMIT 1;
+
+-- name: CreateSession :one
+INSERT INTO sessions (token, user_id, expires_at, created_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token = $1;
+
+-- name: DeleteSessionsByUser :exec
+DELETE FROM sessions WHERE user_id = $1;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at < CURRENT_TIMESTAMP;
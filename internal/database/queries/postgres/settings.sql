@@ -0,0 +1,14 @@
+-- name: GetSetting :one
+SELECT * FROM settings WHERE key = $1;
+
+-- name: GetSettings :many
+SELECT * FROM settings ORDER BY key;
+
+-- name: UpsertSetting :one
+INSERT INTO settings (key, value, updated_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteSetting :exec
+DELETE FROM settings WHERE key = $1;
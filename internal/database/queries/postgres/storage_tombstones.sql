@@ -0,0 +1,8 @@
+-- name: ListStorageTombstones :many
+SELECT * FROM storage_tombstones ORDER BY id LIMIT $1;
+
+-- name: DeleteStorageTombstone :exec
+DELETE FROM storage_tombstones WHERE id = $1;
+
+-- name: CountStorageTombstones :one
+SELECT COUNT(*) FROM storage_tombstones;
@@ -0,0 +1,57 @@
+-- name: GetTagByID :one
+SELECT * FROM tags WHERE id = $1 LIMIT 1;
+
+-- name: GetTagByName :one
+SELECT * FROM tags WHERE name = $1 LIMIT 1;
+
+-- name: ListTags :many
+SELECT * FROM tags ORDER BY name ASC;
+
+-- name: CreateTag :one
+INSERT INTO tags (name, color, created_at, updated_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateTag :one
+UPDATE tags
+SET name = $1, color = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $3
+RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE FROM tags WHERE id = $1;
+
+-- name: GetTagsForDomain :many
+SELECT t.* FROM tags t
+JOIN domain_tags dt ON dt.tag_id = t.id
+WHERE dt.domain_id = $1
+ORDER BY t.name ASC;
+
+-- name: GetTagsForMailbox :many
+SELECT t.* FROM tags t
+JOIN mailbox_tags mt ON mt.tag_id = t.id
+WHERE mt.mailbox_id = $1
+ORDER BY t.name ASC;
+
+-- name: AddTagToDomain :exec
+INSERT INTO domain_tags (domain_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveTagFromDomain :exec
+DELETE FROM domain_tags WHERE domain_id = $1 AND tag_id = $2;
+
+-- name: ClearDomainTags :exec
+DELETE FROM domain_tags WHERE domain_id = $1;
+
+-- name: AddTagToMailbox :exec
+INSERT INTO mailbox_tags (mailbox_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveTagFromMailbox :exec
+DELETE FROM mailbox_tags WHERE mailbox_id = $1 AND tag_id = $2;
+
+-- name: ClearMailboxTags :exec
+DELETE FROM mailbox_tags WHERE mailbox_id = $1;
+
+-- name: CountTagUsage :one
+SELECT
+    ((SELECT COUNT(*) FROM domain_tags dt WHERE dt.tag_id = $1) +
+    (SELECT COUNT(*) FROM mailbox_tags mt WHERE mt.tag_id = $2))::bigint AS total;
@@ -0,0 +1,43 @@
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1 LIMIT 1;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY created_at DESC;
+
+-- name: CreateUser :one
+INSERT INTO users (email, password_hash, is_admin, first_name, last_name, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = $1, password_hash = $2, is_admin = $3, first_name = $4, last_name = $5, updated_at = CURRENT_TIMESTAMP
+WHERE id = $6
+RETURNING *;
+
+-- name: UpdateUserAvatar :one
+UPDATE users
+SET avatar_path = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
+
+-- name: UpdateUserTOTP :one
+UPDATE users
+SET totp_secret = $1, totp_enabled = $2, totp_backup_codes = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $4
+RETURNING *;
+
+-- name: DisableUserTOTP :one
+UPDATE users
+SET totp_secret = '', totp_enabled = 0, totp_backup_codes = '', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
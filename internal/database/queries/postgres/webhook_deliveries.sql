@@ -0,0 +1,60 @@
+-- name: GetDeliveryByID :one
+SELECT * FROM webhook_deliveries WHERE id = $1 LIMIT 1;
+
+-- name: ListDeliveriesByWebhook :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListDeliveriesByEmail :many
+SELECT * FROM webhook_deliveries
+WHERE email_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListPendingDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending' OR status = 'retrying'
+ORDER BY created_at ASC
+LIMIT $1;
+
+-- name: CreateDelivery :one
+INSERT INTO webhook_deliveries (
+    webhook_id, email_id, event, attempt, status,
+    request_body, idempotency_key, created_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateDelivery :one
+UPDATE webhook_deliveries
+SET status = $1, status_code = $2, response_body = $3, error_message = $4, duration_ms = $5, next_attempt_at = $6
+WHERE id = $7
+RETURNING *;
+
+-- name: CountDeliveriesByWebhook :one
+SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: CountDeliveriesByStatus :one
+SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1 AND status = $2;
+
+-- name: GetWebhookDeliveryStats :one
+SELECT
+    COUNT(*) as total,
+    COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0)::bigint as success_count,
+    COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0)::bigint as failed_count,
+    COALESCE(SUM(CASE WHEN status = 'pending' OR status = 'retrying' THEN 1 ELSE 0 END), 0)::bigint as pending_count
+FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: CancelRetryingByWebhook :exec
+UPDATE webhook_deliveries
+SET status = 'failed', error_message = 'Cancelled by user', next_attempt_at = NULL
+WHERE webhook_id = $1 AND status = 'retrying';
+
+-- name: DeleteAllByWebhook :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: FailUnfinishedDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'failed', next_attempt_at = NULL
+WHERE id = $1 AND (status = 'pending' OR status = 'retrying');
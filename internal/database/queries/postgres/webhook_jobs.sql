@@ -0,0 +1,36 @@
+-- name: EnqueueWebhookJob :one
+INSERT INTO webhook_jobs (webhook_id, email_id, event, payload, attempt, available_at, idempotency_key, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- Several connections may claim at once on Postgres, so the candidate row
+-- is locked and rows another worker is claiming are skipped.
+
+-- name: ClaimWebhookJob :one
+UPDATE webhook_jobs
+SET lease_owner = $1, lease_expires_at = $2
+WHERE id = (
+    SELECT j.id FROM webhook_jobs j
+    WHERE j.available_at <= $3 AND (j.lease_expires_at IS NULL OR j.lease_expires_at < $4)
+    ORDER BY j.available_at ASC, j.id ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RescheduleWebhookJob :exec
+UPDATE webhook_jobs
+SET attempt = $1, last_delivery_id = $2, available_at = $3, lease_owner = '', lease_expires_at = NULL
+WHERE id = $4;
+
+-- name: DeleteWebhookJob :exec
+DELETE FROM webhook_jobs WHERE id = $1;
+
+-- name: DeleteRetryWebhookJobsByWebhook :exec
+DELETE FROM webhook_jobs WHERE webhook_id = $1 AND last_delivery_id IS NOT NULL;
+
+-- name: CountWebhookJobs :one
+SELECT COUNT(*) FROM webhook_jobs;
+
+-- name: CountQueuedWebhookJobsByWebhook :one
+SELECT COUNT(*) FROM webhook_jobs WHERE webhook_id = $1 AND last_delivery_id IS NULL;
@@ -0,0 +1,16 @@
+-- name: GetWebhookRuleByID :one
+SELECT * FROM webhook_rules WHERE id = $1 LIMIT 1;
+
+-- name: ListRulesByWebhook :many
+SELECT * FROM webhook_rules WHERE webhook_id = $1 ORDER BY rule_group, id;
+
+-- name: CreateWebhookRule :one
+INSERT INTO webhook_rules (webhook_id, rule_group, field, operator, value, header_name, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteWebhookRule :exec
+DELETE FROM webhook_rules WHERE id = $1;
+
+-- name: DeleteRulesByWebhook :exec
+DELETE FROM webhook_rules WHERE webhook_id = $1;
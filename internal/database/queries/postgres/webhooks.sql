@@ -0,0 +1,98 @@
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooksByMailbox :many
+SELECT * FROM webhooks WHERE mailbox_id = $1 ORDER BY created_at DESC;
+
+-- name: ListActiveWebhooksByMailbox :many
+SELECT * FROM webhooks WHERE mailbox_id = $1 AND is_active = 1 ORDER BY created_at DESC;
+
+-- name: ListActiveAdminWebhooksByDomain :many
+SELECT w.* FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+LEFT JOIN users u ON u.id = m.owner_id
+WHERE m.domain_id = $1 AND (m.owner_id IS NULL OR u.is_admin = 1) AND w.is_active = 1
+ORDER BY w.created_at DESC;
+
+-- name: ListActiveWebhooksByDomainOwner :many
+SELECT w.* FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+WHERE m.domain_id = $1 AND m.owner_id = $2 AND w.is_active = 1
+ORDER BY w.created_at DESC;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret,
+    timeout_sec, max_retries, include_body, include_attachments, is_active, raw_mode,
+    attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec,
+    retry_jitter_percent, events, encoding, query_fields, sign_ed25519, tls_client_cert,
+    tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target,
+    target_config, created_at, updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET name = $1, url = $2, method = $3, headers = $4, payload_type = $5, custom_payload = $6, hmac_secret = $7,
+    timeout_sec = $8, max_retries = $9, include_body = $10, include_attachments = $11,
+    is_active = $12, raw_mode = $13, attachment_mode = $14, attachment_max_inline_bytes = $15,
+    retry_base_delay_sec = $16, retry_max_delay_sec = $17, retry_jitter_percent = $18, events = $19,
+    encoding = $20, query_fields = $21, sign_ed25519 = $22, tls_client_cert = $23, tls_client_key = $24,
+    tls_ca_bundle = $25, tls_skip_verify = $26, proxy_url = $27, redirect_policy = $28, target = $29,
+    target_config = $30,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $31
+RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = $1;
+
+-- name: ToggleWebhookActive :one
+UPDATE webhooks
+SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END,
+    circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: CountWebhooksByMailbox :one
+SELECT COUNT(*) FROM webhooks WHERE mailbox_id = $1;
+
+-- name: RecordWebhookSuccess :exec
+UPDATE webhooks
+SET circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = CASE WHEN circuit_state = 'closed' THEN circuit_changed_at ELSE sqlc.arg(now) END
+WHERE id = sqlc.arg(id);
+
+-- name: RecordWebhookFailure :one
+UPDATE webhooks
+SET consecutive_failures = consecutive_failures + 1,
+    failing_since = COALESCE(failing_since, sqlc.arg(now)),
+    circuit_state = CASE
+        WHEN circuit_state != 'closed' OR (sqlc.arg(threshold) > 0 AND consecutive_failures + 1 >= sqlc.arg(threshold)) THEN 'open'
+        ELSE 'closed'
+    END,
+    circuit_changed_at = CASE
+        WHEN circuit_state != 'closed' OR (sqlc.arg(threshold) > 0 AND consecutive_failures + 1 >= sqlc.arg(threshold)) THEN sqlc.arg(now)
+        ELSE circuit_changed_at
+    END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ClaimWebhookProbe :one
+UPDATE webhooks
+SET circuit_state = 'half_open', circuit_changed_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id)
+  AND ((circuit_state = 'open' AND circuit_changed_at <= sqlc.arg(open_before))
+    OR (circuit_state = 'half_open' AND circuit_changed_at <= sqlc.arg(probe_before)))
+RETURNING id;
+
+-- name: DisableWebhook :exec
+UPDATE webhooks
+SET is_active = 0, circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: UpdateWebhookSecret :exec
+UPDATE webhooks SET hmac_secret = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;
@@ -34,7 +34,7 @@ WHERE mt.mailbox_id = ?
 ORDER BY t.name ASC;
 
 -- name: AddTagToDomain :exec
-INSERT OR IGNORE INTO domain_tags (domain_id, tag_id) VALUES (?, ?);
+INSERT INTO domain_tags (domain_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING;
 
 -- name: RemoveTagFromDomain :exec
 DELETE FROM domain_tags WHERE domain_id = ? AND tag_id = ?;
@@ -43,7 +43,7 @@ DELETE FROM domain_tags WHERE domain_id = ? AND tag_id = ?;
 DELETE FROM domain_tags WHERE domain_id = ?;
 
 -- name: AddTagToMailbox :exec
-INSERT OR IGNORE INTO mailbox_tags (mailbox_id, tag_id) VALUES (?, ?);
+INSERT INTO mailbox_tags (mailbox_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING;
 
 -- name: RemoveTagFromMailbox :exec
 DELETE FROM mailbox_tags WHERE mailbox_id = ? AND tag_id = ?;
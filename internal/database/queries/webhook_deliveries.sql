@@ -20,15 +20,15 @@ LIMIT ?;
 
 -- name: CreateDelivery :one
 INSERT INTO webhook_deliveries (
-    webhook_id, email_id, attempt, status,
-    request_body, created_at
+    webhook_id, email_id, event, attempt, status,
+    request_body, idempotency_key, created_at
 )
-VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: UpdateDelivery :one
 UPDATE webhook_deliveries
-SET status = ?, status_code = ?, response_body = ?, error_message = ?, duration_ms = ?
+SET status = ?, status_code = ?, response_body = ?, error_message = ?, duration_ms = ?, next_attempt_at = ?
 WHERE id = ?
 RETURNING *;
 
@@ -41,15 +41,20 @@ SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ? AND status = ?;
 -- name: GetWebhookDeliveryStats :one
 SELECT
     COUNT(*) as total,
-    SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
-    SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed_count,
-    SUM(CASE WHEN status = 'pending' OR status = 'retrying' THEN 1 ELSE 0 END) as pending_count
+    CAST(COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0) AS INTEGER) as success_count,
+    CAST(COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0) AS INTEGER) as failed_count,
+    CAST(COALESCE(SUM(CASE WHEN status = 'pending' OR status = 'retrying' THEN 1 ELSE 0 END), 0) AS INTEGER) as pending_count
 FROM webhook_deliveries WHERE webhook_id = ?;
 
 -- name: CancelRetryingByWebhook :exec
 UPDATE webhook_deliveries
-SET status = 'failed', error_message = 'Cancelled by user'
+SET status = 'failed', error_message = 'Cancelled by user', next_attempt_at = NULL
 WHERE webhook_id = ? AND status = 'retrying';
 
 -- name: DeleteAllByWebhook :exec
 DELETE FROM webhook_deliveries WHERE webhook_id = ?;
+
+-- name: FailUnfinishedDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'failed', next_attempt_at = NULL
+WHERE id = ? AND (status = 'pending' OR status = 'retrying');
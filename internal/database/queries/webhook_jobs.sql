@@ -0,0 +1,32 @@
+-- name: EnqueueWebhookJob :one
+INSERT INTO webhook_jobs (webhook_id, email_id, event, payload, attempt, available_at, idempotency_key, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: ClaimWebhookJob :one
+UPDATE webhook_jobs
+SET lease_owner = ?, lease_expires_at = ?
+WHERE id = (
+    SELECT j.id FROM webhook_jobs j
+    WHERE j.available_at <= ? AND (j.lease_expires_at IS NULL OR j.lease_expires_at < ?)
+    ORDER BY j.available_at ASC, j.id ASC
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: RescheduleWebhookJob :exec
+UPDATE webhook_jobs
+SET attempt = ?, last_delivery_id = ?, available_at = ?, lease_owner = '', lease_expires_at = NULL
+WHERE id = ?;
+
+-- name: DeleteWebhookJob :exec
+DELETE FROM webhook_jobs WHERE id = ?;
+
+-- name: DeleteRetryWebhookJobsByWebhook :exec
+DELETE FROM webhook_jobs WHERE webhook_id = ? AND last_delivery_id IS NOT NULL;
+
+-- name: CountWebhookJobs :one
+SELECT COUNT(*) FROM webhook_jobs;
+
+-- name: CountQueuedWebhookJobsByWebhook :one
+SELECT COUNT(*) FROM webhook_jobs WHERE webhook_id = ? AND last_delivery_id IS NULL;
@@ -0,0 +1,15 @@
+-- name: ListActiveWebhookSecrets :many
+SELECT * FROM webhook_secrets
+WHERE webhook_id = ? AND expires_at > ?
+ORDER BY created_at DESC, id DESC;
+
+-- name: CreateWebhookSecret :one
+INSERT INTO webhook_secrets (webhook_id, secret, expires_at, created_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: DeleteWebhookSecretsByWebhook :exec
+DELETE FROM webhook_secrets WHERE webhook_id = ?;
+
+-- name: DeleteExpiredWebhookSecrets :exec
+DELETE FROM webhook_secrets WHERE expires_at <= ?;
@@ -7,20 +7,41 @@ SELECT * FROM webhooks WHERE mailbox_id = ? ORDER BY created_at DESC;
 -- name: ListActiveWebhooksByMailbox :many
 SELECT * FROM webhooks WHERE mailbox_id = ? AND is_active = 1 ORDER BY created_at DESC;
 
+-- name: ListActiveAdminWebhooksByDomain :many
+SELECT w.* FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+LEFT JOIN users u ON u.id = m.owner_id
+WHERE m.domain_id = ? AND (m.owner_id IS NULL OR u.is_admin = 1) AND w.is_active = 1
+ORDER BY w.created_at DESC;
+
+-- name: ListActiveWebhooksByDomainOwner :many
+SELECT w.* FROM webhooks w
+JOIN mailboxes m ON m.id = w.mailbox_id
+WHERE m.domain_id = ? AND m.owner_id = ? AND w.is_active = 1
+ORDER BY w.created_at DESC;
+
 -- name: CreateWebhook :one
 INSERT INTO webhooks (
     mailbox_id, name, url, method, headers, payload_type, custom_payload, hmac_secret,
-    timeout_sec, max_retries, include_body, include_attachments, is_active,
-    created_at, updated_at
+    timeout_sec, max_retries, include_body, include_attachments, is_active, raw_mode,
+    attachment_mode, attachment_max_inline_bytes, retry_base_delay_sec, retry_max_delay_sec,
+    retry_jitter_percent, events, encoding, query_fields, sign_ed25519, tls_client_cert,
+    tls_client_key, tls_ca_bundle, tls_skip_verify, proxy_url, redirect_policy, target,
+    target_config, created_at, updated_at
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: UpdateWebhook :one
 UPDATE webhooks
 SET name = ?, url = ?, method = ?, headers = ?, payload_type = ?, custom_payload = ?, hmac_secret = ?,
     timeout_sec = ?, max_retries = ?, include_body = ?, include_attachments = ?,
-    is_active = ?, updated_at = CURRENT_TIMESTAMP
+    is_active = ?, raw_mode = ?, attachment_mode = ?, attachment_max_inline_bytes = ?,
+    retry_base_delay_sec = ?, retry_max_delay_sec = ?, retry_jitter_percent = ?, events = ?,
+    encoding = ?, query_fields = ?, sign_ed25519 = ?, tls_client_cert = ?, tls_client_key = ?,
+    tls_ca_bundle = ?, tls_skip_verify = ?, proxy_url = ?, redirect_policy = ?, target = ?,
+    target_config = ?,
+    updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
@@ -28,7 +49,50 @@ RETURNING *;
 DELETE FROM webhooks WHERE id = ?;
 
 -- name: ToggleWebhookActive :one
-UPDATE webhooks SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+UPDATE webhooks
+SET is_active = CASE WHEN is_active = 1 THEN 0 ELSE 1 END,
+    circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
 
 -- name: CountWebhooksByMailbox :one
 SELECT COUNT(*) FROM webhooks WHERE mailbox_id = ?;
+
+-- name: RecordWebhookSuccess :exec
+UPDATE webhooks
+SET circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = CASE WHEN circuit_state = 'closed' THEN circuit_changed_at ELSE sqlc.arg(now) END
+WHERE id = sqlc.arg(id);
+
+-- name: RecordWebhookFailure :one
+UPDATE webhooks
+SET consecutive_failures = consecutive_failures + 1,
+    failing_since = COALESCE(failing_since, sqlc.arg(now)),
+    circuit_state = CASE
+        WHEN circuit_state != 'closed' OR (sqlc.arg(threshold) > 0 AND consecutive_failures + 1 >= sqlc.arg(threshold)) THEN 'open'
+        ELSE 'closed'
+    END,
+    circuit_changed_at = CASE
+        WHEN circuit_state != 'closed' OR (sqlc.arg(threshold) > 0 AND consecutive_failures + 1 >= sqlc.arg(threshold)) THEN sqlc.arg(now)
+        ELSE circuit_changed_at
+    END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ClaimWebhookProbe :one
+UPDATE webhooks
+SET circuit_state = 'half_open', circuit_changed_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id)
+  AND ((circuit_state = 'open' AND circuit_changed_at <= sqlc.arg(open_before))
+    OR (circuit_state = 'half_open' AND circuit_changed_at <= sqlc.arg(probe_before)))
+RETURNING id;
+
+-- name: DisableWebhook :exec
+UPDATE webhooks
+SET is_active = 0, circuit_state = 'closed', consecutive_failures = 0, failing_since = NULL,
+    circuit_changed_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateWebhookSecret :exec
+UPDATE webhooks SET hmac_secret = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// API token scopes. A write scope implies the matching read scope.
+const (
+	ScopeMailboxesRead  = "mailboxes:read"
+	ScopeMailboxesWrite = "mailboxes:write"
+	ScopeEmailsRead     = "emails:read"
+	ScopeEmailsWrite    = "emails:write"
+	ScopeWebhooksRead   = "webhooks:read"
+	ScopeWebhooksWrite  = "webhooks:write"
+	ScopeDomainsRead    = "domains:read"
+	ScopeDomainsWrite   = "domains:write"
+	ScopeTagsRead       = "tags:read"
+	ScopeTagsWrite      = "tags:write"
+)
+
+var APIScopes = []string{
+	ScopeMailboxesRead,
+	ScopeMailboxesWrite,
+	ScopeEmailsRead,
+	ScopeEmailsWrite,
+	ScopeWebhooksRead,
+	ScopeWebhooksWrite,
+	ScopeDomainsRead,
+	ScopeDomainsWrite,
+	ScopeTagsRead,
+	ScopeTagsWrite,
+}
+
+type APIToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+		if len(scope) > 5 && scope[len(scope)-5:] == ":read" && s == scope[:len(scope)-5]+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
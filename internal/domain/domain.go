@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -9,12 +11,47 @@ type Domain struct {
 	Name       string    `json:"name"`
 	IsVerified bool      `json:"is_verified"`
 	IsActive   bool      `json:"is_active"`
+	RequireTLS bool      `json:"require_tls"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 
+	CatchAllMailboxID *int64 `json:"catch_all_mailbox_id"`
+
 	MailboxCount int64 `json:"mailbox_count,omitempty"`
 }
 
+const (
+	RoutePatternGlob  = "glob"
+	RoutePatternRegex = "regex"
+)
+
+// DomainRoute sends recipients whose local part matches Pattern to a
+// mailbox. Routes are tried in Position order after exact slug matches and
+// before the domain's catch-all mailbox.
+type DomainRoute struct {
+	ID          int64     `json:"id"`
+	DomainID    int64     `json:"domain_id"`
+	MailboxID   int64     `json:"mailbox_id"`
+	PatternType string    `json:"pattern_type"`
+	Pattern     string    `json:"pattern"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Compile turns the route pattern into a case-insensitive regexp. Globs
+// must match the whole local part and support * and ?; regexes are used
+// as written, so they need ^ and $ to be anchored.
+func (r *DomainRoute) Compile() (*regexp.Regexp, error) {
+	if r.PatternType == RoutePatternRegex {
+		return regexp.Compile("(?i)" + r.Pattern)
+	}
+
+	quoted := regexp.QuoteMeta(r.Pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.Compile("(?i)^" + quoted + "$")
+}
+
 type DNSRecord struct {
 	Type     string `json:"type"`
 	Name     string `json:"name"`
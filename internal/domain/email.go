@@ -19,11 +19,49 @@ type Email struct {
 	RawSize     int64             `json:"raw_size"`
 	ReceivedAt  time.Time         `json:"received_at"`
 	IsRead      bool              `json:"is_read"`
+	RawPath     string            `json:"-"`
+	HasRaw      bool              `json:"has_raw"`
+	Auth        EmailAuth         `json:"auth"`
+	Alias       string            `json:"alias"`
+	Subaddress  string            `json:"subaddress"`
 
 	Attachments    []Attachment `json:"attachments,omitempty"`
 	HasAttachments bool         `json:"has_attachments"`
 }
 
+// Sender authentication results, as defined by RFC 8601. An empty result
+// means the check was not run (e.g. mail received before it existed).
+const (
+	AuthResultNone      = "none"
+	AuthResultPass      = "pass"
+	AuthResultFail      = "fail"
+	AuthResultSoftFail  = "softfail"
+	AuthResultNeutral   = "neutral"
+	AuthResultPolicy    = "policy"
+	AuthResultTempError = "temperror"
+	AuthResultPermError = "permerror"
+)
+
+// EmailAuth holds the SPF, DKIM and DMARC verdicts for a received message.
+// SPFDomain is the domain SPF was evaluated for (MAIL FROM or HELO),
+// DKIMDomain the d= of the signature that decided the DKIM result and
+// DMARCPolicy the p= of the sender's published record.
+type EmailAuth struct {
+	SPF         string `json:"spf"`
+	SPFDomain   string `json:"spf_domain"`
+	DKIM        string `json:"dkim"`
+	DKIMDomain  string `json:"dkim_domain"`
+	DMARC       string `json:"dmarc"`
+	DMARCPolicy string `json:"dmarc_policy"`
+}
+
+// SubaddressCount is a plus-address tag seen in a mailbox and how many
+// emails carry it.
+type SubaddressCount struct {
+	Subaddress string `json:"subaddress"`
+	EmailCount int64  `json:"email_count"`
+}
+
 func (e *Email) HeadersJSON() string {
 	if e.Headers == nil {
 		return "{}"
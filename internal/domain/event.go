@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Event types webhooks can subscribe to. New webhooks subscribe to
+// email.received only.
+const (
+	EventEmailReceived   = "email.received"
+	EventEmailDeleted    = "email.deleted"
+	EventEmailRead       = "email.read"
+	EventMailboxCreated  = "mailbox.created"
+	EventMailboxUpdated  = "mailbox.updated"
+	EventMailboxDeleted  = "mailbox.deleted"
+	EventDomainVerified  = "domain.verified"
+	EventDeliveryFailed  = "delivery.failed"
+	EventRetentionPurged = "retention.purged"
+)
+
+var EventTypes = []string{
+	EventEmailReceived,
+	EventEmailDeleted,
+	EventEmailRead,
+	EventMailboxCreated,
+	EventMailboxUpdated,
+	EventMailboxDeleted,
+	EventDomainVerified,
+	EventDeliveryFailed,
+	EventRetentionPurged,
+}
+
+func IsValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is published on the internal event bus after a mutation has been
+// stored. The subject field matching Type is set: Email for email.*,
+// Mailbox for mailbox.*, Domain for domain.verified, Delivery for
+// delivery.failed and Retention for retention.purged.
+type Event struct {
+	Type       string
+	OccurredAt time.Time
+
+	Email     *Email
+	Mailbox   *Mailbox
+	Domain    *Domain
+	Delivery  *WebhookDelivery
+	Retention *RetentionPurge
+}
+
+// RetentionPurge describes one retention run over a mailbox.
+type RetentionPurge struct {
+	MailboxID    int64     `json:"mailbox_id"`
+	Before       time.Time `json:"before"`
+	DeletedCount int64     `json:"deleted_count"`
+}
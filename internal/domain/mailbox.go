@@ -17,10 +17,26 @@ type Mailbox struct {
 	MaxAttachmentSizeMB int `json:"max_attachment_size_mb"`
 	RetentionDays       int `json:"retention_days"`
 
-	Owner      *User         `json:"owner,omitempty"`
-	Domain     *Domain       `json:"domain,omitempty"`
-	EmailCount int64         `json:"email_count,omitempty"`
-	Stats      *MailboxStats `json:"stats,omitempty"`
+	Owner      *User          `json:"owner,omitempty"`
+	Domain     *Domain        `json:"domain,omitempty"`
+	EmailCount int64          `json:"email_count,omitempty"`
+	Stats      *MailboxStats  `json:"stats,omitempty"`
+	Aliases    []MailboxAlias `json:"aliases,omitempty"`
+}
+
+// MailboxAlias is an additional address delivering to a mailbox. Its domain
+// may differ from the mailbox's own.
+type MailboxAlias struct {
+	ID         int64     `json:"id"`
+	MailboxID  int64     `json:"mailbox_id"`
+	DomainID   int64     `json:"domain_id"`
+	Slug       string    `json:"slug"`
+	DomainName string    `json:"domain_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (a *MailboxAlias) Address() string {
+	return a.Slug + "@" + a.DomainName
 }
 
 type MailboxStats struct {
@@ -2,9 +2,81 @@ package domain
 
 import (
 	"encoding/json"
+	"net/url"
 	"time"
 )
 
+// Raw modes control how the original .eml is attached to webhook payloads.
+const (
+	WebhookRawModeNone   = "none"
+	WebhookRawModeInline = "inline"
+	WebhookRawModeURL    = "url"
+)
+
+// Attachment modes control what webhook payloads carry for each attachment
+// when IncludeAttachments is enabled.
+const (
+	WebhookAttachmentModeMetadata = "metadata"
+	WebhookAttachmentModeInline   = "inline"
+	WebhookAttachmentModeURL      = "url"
+)
+
+// Encodings control how the payload is sent by methods with a request body.
+// Form and multipart bodies carry the flattened payload, rfc822 sends the
+// original message instead.
+const (
+	WebhookEncodingJSON      = "json"
+	WebhookEncodingForm      = "form"
+	WebhookEncodingMultipart = "multipart"
+	WebhookEncodingRFC822    = "rfc822"
+)
+
+// Redirect policies control which redirects a webhook request follows.
+// Every redirect target is checked against the network allowlist again.
+// With WebhookRedirectNone the redirect response itself is the answer.
+const (
+	WebhookRedirectFollow   = "follow"
+	WebhookRedirectSameHost = "same_host"
+	WebhookRedirectNone     = "none"
+)
+
+// Targets are where a webhook delivers to. HTTP sends the request described
+// by the webhook's URL, method and encoding; the others take their settings
+// from WebhookTargetConfig.
+const (
+	WebhookTargetHTTP      = "http"
+	WebhookTargetSMTP      = "smtp"
+	WebhookTargetBroker    = "broker"
+	WebhookTargetDirectory = "directory"
+)
+
+// WebhookTargetConfig holds the settings of non-HTTP targets.
+type WebhookTargetConfig struct {
+	// SMTP: the original message is re-sent to ForwardTo through SMTPServer
+	// (host or host:port), or through the recipients' MX hosts when empty.
+	ForwardTo  []string `json:"forward_to,omitempty"`
+	SMTPServer string   `json:"smtp_server,omitempty"`
+
+	// Broker: the payload is published to BrokerTopic (a subject, list or
+	// queue name, depending on the driver) of the broker at BrokerURL.
+	// Webhooks are serialized with the credentials of BrokerURL masked.
+	BrokerDriver string `json:"broker_driver,omitempty"`
+	BrokerURL    string `json:"broker_url,omitempty"`
+	BrokerTopic  string `json:"broker_topic,omitempty"`
+
+	// Directory: .eml and .json files are written to this directory,
+	// relative to WEBHOOK_DIRECTORY_ROOT.
+	Directory string `json:"directory,omitempty"`
+}
+
+// Circuit states. An open circuit holds deliveries back; half open means a
+// single probe request is in flight.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
 type Webhook struct {
 	ID                 int64             `json:"id"`
 	MailboxID          int64             `json:"mailbox_id"`
@@ -14,19 +86,83 @@ type Webhook struct {
 	Headers            map[string]string `json:"headers"`
 	PayloadType        string            `json:"payload_type"`
 	CustomPayload      string            `json:"custom_payload,omitempty"`
-	HMACSecret         string            `json:"hmac_secret,omitempty"`
+	HMACSecret         string            `json:"-"`
+	HasHMACSecret      bool              `json:"has_hmac_secret"`
 	TimeoutSec         int               `json:"timeout_sec"`
 	MaxRetries         int               `json:"max_retries"`
 	IncludeBody        bool              `json:"include_body"`
 	IncludeAttachments bool              `json:"include_attachments"`
-	IsActive           bool              `json:"is_active"`
-	CreatedAt          time.Time         `json:"created_at"`
-	UpdatedAt          time.Time         `json:"updated_at"`
+	RawMode            string            `json:"raw_mode"`
+	AttachmentMode     string            `json:"attachment_mode"`
+	// AttachmentMaxInlineBytes caps inline attachments; larger files are
+	// sent as signed URLs instead.
+	AttachmentMaxInlineBytes int64 `json:"attachment_max_inline_bytes"`
+	// Failed deliveries are retried after RetryBaseDelaySec, doubling with
+	// each attempt up to RetryMaxDelaySec. Each delay is shortened by a
+	// random amount of up to RetryJitterPercent.
+	RetryBaseDelaySec  int       `json:"retry_base_delay_sec"`
+	RetryMaxDelaySec   int       `json:"retry_max_delay_sec"`
+	RetryJitterPercent int       `json:"retry_jitter_percent"`
+	IsActive           bool      `json:"is_active"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Circuit breaker state, maintained by the dispatcher. FailingSince is
+	// the first failure of the current streak.
+	CircuitState        string     `json:"circuit_state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailingSince        *time.Time `json:"failing_since"`
+	CircuitChangedAt    *time.Time `json:"circuit_changed_at"`
+
+	// Events lists the event types the webhook is subscribed to.
+	Events []string `json:"events"`
+
+	// Encoding is one of the WebhookEncoding constants. GET webhooks send
+	// no body; QueryFields lists the payload fields they send as query
+	// parameters, either as a field name or as param=field.
+	Encoding    string   `json:"encoding"`
+	QueryFields []string `json:"query_fields"`
+
+	// SignEd25519 adds an Ed25519 signature made with the installation key
+	// to the signature header. PreviousSecrets are secrets replaced by a
+	// rotation that still sign deliveries until they expire.
+	SignEd25519     bool            `json:"sign_ed25519"`
+	PreviousSecrets []WebhookSecret `json:"previous_secrets,omitempty"`
+
+	// Transport settings. TLSClientCert and TLSClientKey are the PEM
+	// certificate and key presented to mTLS receivers, TLSCABundle adds PEM
+	// CAs to the trusted system roots. ProxyURL is an http, https or socks5
+	// proxy, serialized with its credentials masked. RedirectPolicy is one
+	// of the WebhookRedirect constants. Like the HMAC secret, the key is
+	// never serialized, only whether it is set.
+	TLSClientCert       string `json:"tls_client_cert,omitempty"`
+	TLSClientKey        string `json:"-"`
+	HasClientKey        bool   `json:"has_client_key"`
+	TLSCABundle         string `json:"tls_ca_bundle,omitempty"`
+	TLSSkipVerify       bool   `json:"tls_skip_verify"`
+	ProxyURL            string `json:"proxy_url"`
+	HasProxyCredentials bool   `json:"has_proxy_credentials"`
+	RedirectPolicy      string `json:"redirect_policy"`
+
+	// Target is one of the WebhookTarget constants.
+	Target               string              `json:"target"`
+	TargetConfig         WebhookTargetConfig `json:"target_config"`
+	HasBrokerCredentials bool                `json:"has_broker_credentials"`
 
 	Rules         []WebhookRule         `json:"rules,omitempty"`
 	DeliveryStats *WebhookDeliveryStats `json:"delivery_stats,omitempty"`
 }
 
+// MarshalJSON masks the credentials in the proxy and broker URLs, webhooks
+// are sent to the UI and API clients as is.
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type plain Webhook
+	out := plain(w)
+	out.ProxyURL = RedactURL(w.ProxyURL)
+	out.TargetConfig.BrokerURL = RedactURL(w.TargetConfig.BrokerURL)
+	return json.Marshal(out)
+}
+
 func (w *Webhook) HeadersJSON() string {
 	if w.Headers == nil {
 		return "{}"
@@ -35,6 +171,72 @@ func (w *Webhook) HeadersJSON() string {
 	return string(data)
 }
 
+// Subscribes reports whether the webhook receives events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SigningSecrets returns the HMAC secrets deliveries are signed with: the
+// current secret first, then the rotated ones still in their grace period.
+func (w *Webhook) SigningSecrets() []string {
+	var secrets []string
+	if w.HMACSecret != "" {
+		secrets = append(secrets, w.HMACSecret)
+	}
+	for _, s := range w.PreviousSecrets {
+		secrets = append(secrets, s.Secret)
+	}
+	return secrets
+}
+
+// redactedUserinfo stands for the credentials of redacted URLs.
+const redactedUserinfo = "xxxxx"
+
+// RedactURL replaces the user and password of raw with a mask. Unparsable
+// URLs are dropped entirely since they may hold credentials too.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if u.User == nil {
+		return raw
+	}
+	u.User = url.User(redactedUserinfo)
+	return u.String()
+}
+
+// RestoreURLCredentials puts the credentials of stored back into submitted
+// when submitted still carries the mask of RedactURL, so a URL edited from
+// its redacted form keeps them.
+func RestoreURLCredentials(submitted, stored string) string {
+	u, err := url.Parse(submitted)
+	if err != nil || u.User == nil || u.User.String() != redactedUserinfo {
+		return submitted
+	}
+	s, err := url.Parse(stored)
+	if err != nil || s.User == nil {
+		return submitted
+	}
+	u.User = s.User
+	return u.String()
+}
+
+// WebhookSecret is a rotated HMAC secret that stays valid until ExpiresAt.
+// The secret itself is never serialized.
+type WebhookSecret struct {
+	ID        int64     `json:"id"`
+	WebhookID int64     `json:"webhook_id"`
+	Secret    string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type WebhookRule struct {
 	ID         int64     `json:"id"`
 	WebhookID  int64     `json:"webhook_id"`
@@ -47,9 +249,14 @@ type WebhookRule struct {
 }
 
 type WebhookDelivery struct {
-	ID           int64     `json:"id"`
-	WebhookID    int64     `json:"webhook_id"`
-	EmailID      int64     `json:"email_id"`
+	ID        int64  `json:"id"`
+	WebhookID int64  `json:"webhook_id"`
+	Event     string `json:"event"`
+	// IdempotencyKey is shared by all attempts at delivering the same event
+	// and sent as X-Mailgress-Delivery-Id.
+	IdempotencyKey string `json:"idempotency_key"`
+	// EmailID is nil for events that do not concern a stored email.
+	EmailID      *int64    `json:"email_id"`
 	Attempt      int       `json:"attempt"`
 	Status       string    `json:"status"`
 	StatusCode   *int      `json:"status_code"`
@@ -58,11 +265,32 @@ type WebhookDelivery struct {
 	ErrorMessage string    `json:"error_message,omitempty"`
 	DurationMs   *int      `json:"duration_ms"`
 	CreatedAt    time.Time `json:"created_at"`
+	// NextAttemptAt is when a retrying delivery will be attempted again.
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
 
 	Email   *Email   `json:"email,omitempty"`
 	Webhook *Webhook `json:"webhook,omitempty"`
 }
 
+// WebhookJob is a queued delivery of an event to a webhook. Attempt is the
+// number the next delivery record will carry and LastDeliveryID the record of
+// the previous, failed attempt. email.received jobs are built from the
+// stored email when sent; other events carry their payload snapshot.
+type WebhookJob struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	EmailID        *int64     `json:"email_id"`
+	Payload        string     `json:"payload,omitempty"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Attempt        int        `json:"attempt"`
+	LastDeliveryID *int64     `json:"last_delivery_id"`
+	AvailableAt    time.Time  `json:"available_at"`
+	LeaseOwner     string     `json:"lease_owner"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
 type WebhookDeliveryStats struct {
 	Total        int64 `json:"total"`
 	SuccessCount int64 `json:"success_count"`
@@ -85,6 +313,10 @@ const (
 	RuleFieldBody           = "body"
 	RuleFieldHasAttachments = "has_attachments"
 	RuleFieldSize           = "size"
+	RuleFieldSPF            = "spf"
+	RuleFieldDKIM           = "dkim"
+	RuleFieldDMARC          = "dmarc"
+	RuleFieldSubaddress     = "subaddress"
 )
 
 const (
@@ -0,0 +1,48 @@
+// Package events is the in-process bus services publish domain events on
+// after a mutation, for subscribers such as the webhook dispatcher.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+type Handler func(ctx context.Context, event domain.Event)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish runs every subscriber before returning, so an event a subscriber
+// stores is durable by the time the publishing request completes. Publishing
+// on a nil Bus is a no-op.
+func (b *Bus) Publish(ctx context.Context, event domain.Event) {
+	if b == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
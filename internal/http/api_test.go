@@ -0,0 +1,341 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
+	"github.com/jr-k/mailgress/internal/http/handler"
+	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/storage"
+	"github.com/jr-k/mailgress/internal/webhook"
+)
+
+// apiTestEnv serves the API routes over a migrated SQLite database with two
+// users, alice and bob, each owning one mailbox.
+type apiTestEnv struct {
+	router   chi.Router
+	webhooks *service.WebhookService
+	tokens   map[string]string
+
+	aliceMailbox, bobMailbox *domain.Mailbox
+	bobEmail                 *domain.Email
+	bobAttachment            *domain.Attachment
+	bobWebhook               *domain.Webhook
+	bobDelivery              *domain.WebhookDelivery
+}
+
+func newAPITestEnv(t *testing.T) *apiTestEnv {
+	t.Helper()
+	ctx := context.Background()
+
+	sqlDB, queries, err := database.NewConnection("sqlite", filepath.Join(t.TempDir(), "mailgress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.RunMigrations(sqlDB, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus()
+	tx := database.NewTransactor(sqlDB, "sqlite")
+	store := storage.NewMemory()
+	users := service.NewUserService(queries)
+	apiTokens := service.NewAPITokenService(queries)
+	mailboxes := service.NewMailboxService(queries, bus)
+	emails := service.NewEmailService(queries, bus, "sqlite")
+	webhooks := service.NewWebhookService(queries, tx)
+	deliveries := service.NewDeliveryService(queries)
+	domains := service.NewDomainService(queries, tx, bus)
+
+	keys, err := webhook.LoadSigningKeys(ctx, service.NewSettingsService(queries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher := webhook.NewDispatcher(&config.Config{SMTPHostname: "mx.mailgress.test"}, webhooks, deliveries,
+		service.NewWebhookJobService(queries, tx), emails, mailboxes, domains, service.NewNotificationService(queries, nil, ""),
+		store, service.NewURLSigner("http://localhost", "test-key", time.Hour), keys, bus, webhook.DefaultBrokerDrivers())
+	t.Cleanup(dispatcher.Stop)
+
+	env := &apiTestEnv{router: chi.NewRouter(), webhooks: webhooks, tokens: map[string]string{}}
+	apiRoutes(env.router, mw.NewAPIAuthMiddleware(apiTokens, users),
+		handler.NewAPIHandler(mailboxes, emails, webhooks, deliveries, domains, service.NewTagService(queries), store, dispatcher))
+
+	readScopes := []string{domain.ScopeMailboxesRead, domain.ScopeEmailsRead, domain.ScopeWebhooksRead, domain.ScopeDomainsRead, domain.ScopeTagsRead}
+	for _, u := range []struct {
+		name    string
+		isAdmin bool
+	}{{"admin", true}, {"alice", false}, {"bob", false}} {
+		user, err := users.Create(ctx, u.name+"@mailgress.test", "correct horse battery", u.isAdmin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, plaintext, err := apiTokens.Create(ctx, user.ID, "full", domain.APIScopes, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.tokens[u.name] = plaintext
+		_, plaintext, err = apiTokens.Create(ctx, user.ID, "read-only", readScopes, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.tokens[u.name+"-read"] = plaintext
+
+		mailbox, err := mailboxes.Create(ctx, u.name, &user.ID, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch u.name {
+		case "alice":
+			env.aliceMailbox = mailbox
+		case "bob":
+			env.bobMailbox = mailbox
+		}
+	}
+
+	if env.bobEmail, err = emails.Create(ctx, service.CreateEmailParams{
+		MailboxID:   env.bobMailbox.ID,
+		FromAddress: "carol@example.com",
+		ToAddress:   "bob@mailgress.test",
+		Subject:     "For Bob only",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if env.bobAttachment, err = emails.CreateAttachment(ctx, env.bobEmail.ID, "secret.pdf", "application/pdf", 3, "attachments/secret.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if env.bobWebhook, err = webhooks.Create(ctx, service.CreateWebhookParams{MailboxID: env.bobMailbox.ID, Name: "bob", URL: "https://example.com/bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if env.bobDelivery, err = deliveries.Create(ctx, env.bobWebhook.ID, domain.EventEmailReceived, &env.bobEmail.ID, 1, "{}", ""); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// do sends an API request with the named token, or without one if token is
+// empty, and returns the response status and body.
+func (env *apiTestEnv) do(t *testing.T, token, method, path, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+env.tokens[token])
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	env := newAPITestEnv(t)
+
+	for _, header := range []string{"", "Bearer", "Bearer ", "Basic YWxpY2U6c2VjcmV0", "Bearer mgs_not-a-token"} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		env.router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("Authorization %q: status %d, WWW-Authenticate %q, want 401 with a Bearer challenge", header, rec.Code, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	status, body := env.do(t, "alice", http.MethodGet, "/me", "")
+	if status != http.StatusOK || !strings.Contains(body, "alice@mailgress.test") {
+		t.Errorf("GET /me = %d %s, want alice", status, body)
+	}
+}
+
+func TestAPIOwnership(t *testing.T) {
+	env := newAPITestEnv(t)
+	bobMailbox := fmt.Sprintf("/mailboxes/%d", env.bobMailbox.ID)
+	bobEmail := fmt.Sprintf("%s/emails/%d", bobMailbox, env.bobEmail.ID)
+	bobAttachment := fmt.Sprintf("/attachments/%d", env.bobAttachment.ID)
+	bobWebhook := fmt.Sprintf("/webhooks/%d", env.bobWebhook.ID)
+	bobRetry := fmt.Sprintf("/deliveries/%d/retry", env.bobDelivery.ID)
+	// Bob's email through Alice's mailbox
+	aliceBobEmail := fmt.Sprintf("/mailboxes/%d/emails/%d", env.aliceMailbox.ID, env.bobEmail.ID)
+
+	tests := []struct {
+		token, method, path string
+		want                int
+	}{
+		{"alice", http.MethodGet, bobMailbox, http.StatusForbidden},
+		{"alice", http.MethodGet, bobMailbox + "/emails", http.StatusForbidden},
+		{"alice", http.MethodGet, bobEmail, http.StatusForbidden},
+		{"alice", http.MethodGet, bobEmail + "/raw", http.StatusForbidden},
+		{"alice", http.MethodPost, bobEmail + "/read", http.StatusForbidden},
+		{"alice", http.MethodDelete, bobEmail, http.StatusForbidden},
+		{"alice", http.MethodGet, aliceBobEmail, http.StatusNotFound},
+		{"alice", http.MethodDelete, aliceBobEmail, http.StatusNotFound},
+		{"alice", http.MethodGet, bobAttachment, http.StatusForbidden},
+		{"alice", http.MethodGet, bobAttachment + "/download", http.StatusForbidden},
+		{"alice", http.MethodGet, bobMailbox + "/webhooks", http.StatusForbidden},
+		{"alice", http.MethodGet, bobWebhook, http.StatusForbidden},
+		{"alice", http.MethodGet, bobWebhook + "/deliveries", http.StatusForbidden},
+		{"alice", http.MethodPut, bobWebhook, http.StatusForbidden},
+		{"alice", http.MethodPost, bobWebhook + "/rotate-secret", http.StatusForbidden},
+		{"alice", http.MethodDelete, bobWebhook, http.StatusForbidden},
+		{"alice", http.MethodPost, bobRetry, http.StatusForbidden},
+		{"alice", http.MethodGet, "/webhooks/9999", http.StatusNotFound},
+		{"alice", http.MethodGet, "/attachments/9999", http.StatusNotFound},
+		{"alice", http.MethodPost, "/deliveries/9999/retry", http.StatusNotFound},
+
+		// Search only covers the caller's mailboxes
+		{"alice", http.MethodGet, "/emails/search?q=bob", http.StatusOK},
+
+		// Owners and admins get through
+		{"bob", http.MethodGet, bobEmail, http.StatusOK},
+		{"bob", http.MethodGet, bobAttachment, http.StatusOK},
+		{"bob", http.MethodGet, bobWebhook + "/deliveries", http.StatusOK},
+		{"admin", http.MethodGet, bobEmail, http.StatusOK},
+		{"admin", http.MethodGet, bobWebhook, http.StatusOK},
+
+		// Admin-only routes
+		{"alice", http.MethodPost, "/mailboxes", http.StatusForbidden},
+		{"alice", http.MethodDelete, bobMailbox, http.StatusForbidden},
+		{"alice", http.MethodGet, "/domains", http.StatusForbidden},
+		{"alice", http.MethodGet, "/tags", http.StatusForbidden},
+		{"admin", http.MethodGet, "/domains", http.StatusOK},
+	}
+	for _, tt := range tests {
+		status, body := env.do(t, tt.token, tt.method, tt.path, "")
+		if status != tt.want {
+			t.Errorf("%s %s %s = %d %s, want %d", tt.token, tt.method, tt.path, status, body, tt.want)
+		}
+	}
+
+	status, body := env.do(t, "alice", http.MethodGet, "/emails/search", "")
+	if status != http.StatusOK || strings.Contains(body, "For Bob only") {
+		t.Errorf("alice's search = %d %s, want no email of Bob", status, body)
+	}
+	if _, err := env.webhooks.GetByID(context.Background(), env.bobWebhook.ID); err != nil {
+		t.Errorf("bob's webhook after alice's delete: %v", err)
+	}
+}
+
+func TestAPIScopes(t *testing.T) {
+	env := newAPITestEnv(t)
+	aliceMailbox := fmt.Sprintf("/mailboxes/%d", env.aliceMailbox.ID)
+	webhookBody := `{"name": "alice", "url": "https://example.com/alice"}`
+
+	tests := []struct {
+		token, method, path, body string
+		want                      int
+	}{
+		{"alice-read", http.MethodGet, aliceMailbox, "", http.StatusOK},
+		{"alice-read", http.MethodGet, aliceMailbox + "/emails", "", http.StatusOK},
+		{"alice-read", http.MethodGet, aliceMailbox + "/webhooks", "", http.StatusOK},
+		{"alice-read", http.MethodPost, aliceMailbox + "/webhooks", webhookBody, http.StatusForbidden},
+		{"admin-read", http.MethodPost, "/mailboxes", `{"slug": "new"}`, http.StatusForbidden},
+		{"admin-read", http.MethodPost, "/domains", `{"name": "example.org"}`, http.StatusForbidden},
+		{"admin-read", http.MethodPost, "/tags", `{"name": "new"}`, http.StatusForbidden},
+		{"bob-read", http.MethodDelete, fmt.Sprintf("/mailboxes/%d/emails/%d", env.bobMailbox.ID, env.bobEmail.ID), "", http.StatusForbidden},
+		{"bob-read", http.MethodDelete, fmt.Sprintf("/webhooks/%d", env.bobWebhook.ID), "", http.StatusForbidden},
+		{"bob-read", http.MethodPost, fmt.Sprintf("/deliveries/%d/retry", env.bobDelivery.ID), "", http.StatusForbidden},
+		{"alice", http.MethodPost, aliceMailbox + "/webhooks", webhookBody, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		status, body := env.do(t, tt.token, tt.method, tt.path, tt.body)
+		if status != tt.want {
+			t.Errorf("%s %s %s = %d %s, want %d", tt.token, tt.method, tt.path, status, body, tt.want)
+		}
+	}
+
+	// Nothing was written with the read-only tokens
+	status, body := env.do(t, "bob", http.MethodGet, fmt.Sprintf("/mailboxes/%d/emails/%d", env.bobMailbox.ID, env.bobEmail.ID), "")
+	if status != http.StatusOK {
+		t.Errorf("bob's email after a read-only delete = %d %s", status, body)
+	}
+}
+
+func TestAPIWebhookRules(t *testing.T) {
+	env := newAPITestEnv(t)
+	path := fmt.Sprintf("/mailboxes/%d/webhooks", env.aliceMailbox.ID)
+
+	invalid := []string{
+		`[{"field": "subject", "operator": "regex", "value": "(unclosed"}]`,
+		`[{"field": "size", "operator": "contains", "value": "100"}]`,
+		`[{"field": "size", "operator": "gt", "value": "big"}]`,
+		`[{"field": "header", "operator": "equals", "value": "yes"}]`,
+		`[{"field": "has_attachments", "operator": "equals", "value": "maybe"}]`,
+		`[{"field": "cc", "operator": "contains", "value": "x"}]`,
+		`[{"field": "from", "operator": "gt", "value": "x"}]`,
+	}
+	for _, rules := range invalid {
+		status, body := env.do(t, "alice", http.MethodPost, path, `{"name": "hook", "url": "https://example.com/hook", "rules": `+rules+`}`)
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("creating a webhook with rules %s = %d %s, want 422", rules, status, body)
+		}
+	}
+	hooks, err := env.webhooks.ListByMailbox(context.Background(), env.aliceMailbox.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 0 {
+		t.Fatalf("invalid rules left %d webhooks behind", len(hooks))
+	}
+
+	status, body := env.do(t, "alice", http.MethodPost, path, `{"name": "hook", "url": "https://example.com/hook", "rules": [
+		{"rule_group": 0, "field": "subject", "operator": "regex", "value": "^Invoice"},
+		{"rule_group": 1, "field": "header", "header_name": "X-Priority", "operator": "equals", "value": "1"},
+		{"rule_group": 1, "field": "size", "operator": "gt", "value": "1024"}
+	]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("creating a webhook with valid rules = %d %s", status, body)
+	}
+	var created struct {
+		Data domain.Webhook `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(body), &created); err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Data.Rules) != 3 {
+		t.Fatalf("created webhook has %d rules, want 3", len(created.Data.Rules))
+	}
+
+	// A rejected update keeps both the settings and the rules
+	update := fmt.Sprintf("/webhooks/%d", created.Data.ID)
+	status, body = env.do(t, "alice", http.MethodPut, update, `{"name": "renamed", "url": "https://example.com/hook", "rules": [{"field": "size", "operator": "lt", "value": "-"}]}`)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("updating with an invalid rule = %d %s, want 422", status, body)
+	}
+	wh, err := env.webhooks.GetByID(context.Background(), created.Data.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wh.Name != "hook" || len(wh.Rules) != 3 {
+		t.Errorf("after a rejected update the webhook is %q with %d rules", wh.Name, len(wh.Rules))
+	}
+
+	// Without a rules array the rules are kept, an empty one clears them
+	if status, body = env.do(t, "alice", http.MethodPut, update, `{"name": "renamed", "url": "https://example.com/hook"}`); status != http.StatusOK {
+		t.Fatalf("updating without rules = %d %s", status, body)
+	}
+	if wh, _ = env.webhooks.GetByID(context.Background(), created.Data.ID); len(wh.Rules) != 3 {
+		t.Errorf("update without rules left %d rules, want 3", len(wh.Rules))
+	}
+	if status, body = env.do(t, "alice", http.MethodPut, update, `{"name": "renamed", "url": "https://example.com/hook", "rules": []}`); status != http.StatusOK {
+		t.Fatalf("updating with no rules = %d %s", status, body)
+	}
+	if wh, _ = env.webhooks.GetByID(context.Background(), created.Data.ID); len(wh.Rules) != 0 {
+		t.Errorf("update with an empty rules array left %d rules", len(wh.Rules))
+	}
+}
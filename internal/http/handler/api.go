@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jr-k/mailgress/internal/domain"
+	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/storage"
+	"github.com/jr-k/mailgress/internal/webhook"
+)
+
+const (
+	apiDefaultPerPage = 50
+	apiMaxPerPage     = 200
+)
+
+// APIHandler serves the versioned JSON API under /api/v1. Authentication and
+// scopes are enforced by middleware; handlers apply the same mailbox ownership
+// rules as the web UI.
+type APIHandler struct {
+	mailboxService  *service.MailboxService
+	emailService    *service.EmailService
+	webhookService  *service.WebhookService
+	deliveryService *service.DeliveryService
+	domainService   *service.DomainService
+	tagService      *service.TagService
+	storage         storage.Storage
+	dispatcher      *webhook.Dispatcher
+}
+
+func NewAPIHandler(
+	mailboxService *service.MailboxService,
+	emailService *service.EmailService,
+	webhookService *service.WebhookService,
+	deliveryService *service.DeliveryService,
+	domainService *service.DomainService,
+	tagService *service.TagService,
+	storage storage.Storage,
+	dispatcher *webhook.Dispatcher,
+) *APIHandler {
+	return &APIHandler{
+		mailboxService:  mailboxService,
+		emailService:    emailService,
+		webhookService:  webhookService,
+		deliveryService: deliveryService,
+		domainService:   domainService,
+		tagService:      tagService,
+		storage:         storage,
+		dispatcher:      dispatcher,
+	}
+}
+
+type apiPagination struct {
+	Page    int64 `json:"page"`
+	PerPage int64 `json:"per_page"`
+	Total   int64 `json:"total"`
+}
+
+func (h *APIHandler) Me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  mw.GetUser(r),
+		"token": mw.GetAPIToken(r),
+	})
+}
+
+func canAccessMailbox(user *domain.User, mailbox *domain.Mailbox) bool {
+	return user.IsAdmin || (mailbox.OwnerID != nil && *mailbox.OwnerID == user.ID)
+}
+
+// searchScope limits a search across mailboxes to those canAccessMailbox
+// allows.
+func searchScope(user *domain.User) service.SearchScope {
+	if user.IsAdmin {
+		return service.SearchScope{}
+	}
+	return service.SearchScope{OwnerID: &user.ID}
+}
+
+// mailboxFromParam loads the mailbox named by a URL parameter and checks that
+// the current user may access it, writing the error response otherwise.
+func (h *APIHandler) mailboxFromParam(w http.ResponseWriter, r *http.Request, param string) (*domain.Mailbox, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Mailbox not found")
+		return nil, false
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Mailbox not found")
+		return nil, false
+	}
+
+	if !canAccessMailbox(mw.GetUser(r), mailbox) {
+		writeError(w, http.StatusForbidden, "Forbidden")
+		return nil, false
+	}
+
+	return mailbox, true
+}
+
+func pageParams(r *http.Request) (page, perPage int64) {
+	page, perPage = 1, apiDefaultPerPage
+	if p, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64); err == nil && p > 0 {
+		page = p
+	}
+	if pp, err := strconv.ParseInt(r.URL.Query().Get("per_page"), 10, 64); err == nil && pp > 0 {
+		perPage = min(pp, apiMaxPerPage)
+	}
+	return page, perPage
+}
+
+// paginate slices an in-memory list for endpoints whose queries are not paginated.
+func paginate[T any](items []T, page, perPage int64) []T {
+	start := (page - 1) * perPage
+	if start >= int64(len(items)) {
+		return []T{}
+	}
+	end := min(start+perPage, int64(len(items)))
+	return items[start:end]
+}
+
+func parseIDParam(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+}
+
+func parseBoolQuery(r *http.Request, name string) *bool {
+	val := r.URL.Query().Get(name)
+	if val == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return nil
+	}
+	return &b
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeList(w http.ResponseWriter, data interface{}, page, perPage, total int64) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"pagination": apiPagination{
+			Page:    page,
+			PerPage: perPage,
+			Total:   total,
+		},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeServiceError maps well-known service errors to HTTP statuses.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrEmailNotFound),
+		errors.Is(err, service.ErrAttachmentNotFound),
+		errors.Is(err, service.ErrMailboxNotFound),
+		errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrDeliveryNotFound),
+		errors.Is(err, service.ErrDomainNotFound),
+		errors.Is(err, service.ErrTagNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrDomainAlreadyExists),
+		errors.Is(err, service.ErrAddressTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	}
+}
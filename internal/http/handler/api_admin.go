@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// Domains and tags are administrator resources, as in the web UI.
+
+func (h *APIHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	domains, err := h.domainService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list domains")
+		return
+	}
+
+	isActive := parseBoolQuery(r, "is_active")
+	isVerified := parseBoolQuery(r, "is_verified")
+	filtered := make([]*domain.Domain, 0, len(domains))
+	for _, d := range domains {
+		if isActive != nil && d.IsActive != *isActive {
+			continue
+		}
+		if isVerified != nil && d.IsVerified != *isVerified {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+
+	page, perPage := pageParams(r)
+	writeList(w, paginate(filtered, page, perPage), page, perPage, int64(len(filtered)))
+}
+
+func (h *APIHandler) GetDomain(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.domainFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": d})
+}
+
+func (h *APIHandler) CreateDomain(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	d, err := h.domainService.Create(r.Context(), req.Name)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": d})
+}
+
+func (h *APIHandler) UpdateDomain(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.domainFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name       *string `json:"name"`
+		IsVerified *bool   `json:"is_verified"`
+		IsActive   *bool   `json:"is_active"`
+		RequireTLS *bool   `json:"require_tls"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	name, isVerified, isActive, requireTLS := d.Name, d.IsVerified, d.IsActive, d.RequireTLS
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.IsVerified != nil {
+		isVerified = *req.IsVerified
+	}
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	if req.RequireTLS != nil {
+		requireTLS = *req.RequireTLS
+	}
+
+	updated, err := h.domainService.Update(r.Context(), d.ID, name, isVerified, isActive, requireTLS)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": updated})
+}
+
+func (h *APIHandler) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.domainFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.Delete(r.Context(), d.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete domain")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tagService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list tags")
+		return
+	}
+
+	page, perPage := pageParams(r)
+	writeList(w, paginate(tags, page, perPage), page, perPage, int64(len(tags)))
+}
+
+func (h *APIHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if req.Color == "" {
+		req.Color = "#6366f1"
+	}
+
+	tag, err := h.tagService.Create(r.Context(), req.Name, req.Color)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": tag})
+}
+
+func (h *APIHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	tag, err := h.tagService.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	var req struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	name, color := tag.Name, tag.Color
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Color != nil {
+		color = *req.Color
+	}
+
+	updated, err := h.tagService.Update(r.Context(), id, name, color)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": updated})
+}
+
+func (h *APIHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	if _, err := h.tagService.GetByID(r.Context(), id); err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	if err := h.tagService.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) domainFromParam(w http.ResponseWriter, r *http.Request) (*domain.Domain, bool) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Domain not found")
+		return nil, false
+	}
+
+	d, err := h.domainService.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Domain not found")
+		return nil, false
+	}
+
+	return d, true
+}
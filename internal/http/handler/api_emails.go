@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jr-k/mailgress/internal/domain"
+	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/search"
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+// ListEmails returns a mailbox's emails, newest first. The q parameter
+// searches them with the same syntax as the inbox search box; subaddress
+// restricts the list to one plus-address tag and takes precedence.
+func (h *APIHandler) ListEmails(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	page, perPage := pageParams(r)
+	offset := (page - 1) * perPage
+	query := r.URL.Query().Get("q")
+	subaddress := r.URL.Query().Get("subaddress")
+
+	var emails []*domain.Email
+	var total int64
+	var err error
+	if subaddress != "" {
+		emails, err = h.emailService.ListBySubaddress(r.Context(), mailbox.ID, subaddress, perPage, offset)
+		if err == nil {
+			total, err = h.emailService.CountBySubaddress(r.Context(), mailbox.ID, subaddress)
+		}
+	} else if query != "" {
+		scope := service.SearchScope{MailboxID: mailbox.ID}
+		emails, err = h.emailService.Search(r.Context(), scope, query, perPage, offset)
+		if err == nil {
+			total, err = h.emailService.SearchCount(r.Context(), scope, query)
+		}
+	} else {
+		emails, err = h.emailService.ListByMailbox(r.Context(), mailbox.ID, perPage, offset)
+		if err == nil {
+			total, err = h.emailService.CountByMailbox(r.Context(), mailbox.ID)
+		}
+	}
+	if errors.Is(err, search.ErrInvalidQuery) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list emails")
+		return
+	}
+	if emails == nil {
+		emails = []*domain.Email{}
+	}
+
+	writeList(w, emails, page, perPage, total)
+}
+
+// SearchEmails searches every mailbox the token's user can read. Mailboxes
+// can be narrowed down in the query with mailbox:slug.
+func (h *APIHandler) SearchEmails(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pageParams(r)
+	offset := (page - 1) * perPage
+	query := r.URL.Query().Get("q")
+	scope := searchScope(mw.GetUser(r))
+
+	emails, err := h.emailService.Search(r.Context(), scope, query, perPage, offset)
+	var total int64
+	if err == nil {
+		total, err = h.emailService.SearchCount(r.Context(), scope, query)
+	}
+	if errors.Is(err, search.ErrInvalidQuery) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to search emails")
+		return
+	}
+
+	writeList(w, emails, page, perPage, total)
+}
+
+func (h *APIHandler) GetEmail(w http.ResponseWriter, r *http.Request) {
+	email, ok := h.emailFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	for i := range email.Attachments {
+		email.Attachments[i].DownloadURL = "/api/v1/attachments/" + strconv.FormatInt(email.Attachments[i].ID, 10) + "/download"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": email})
+}
+
+func (h *APIHandler) DeleteEmail(w http.ResponseWriter, r *http.Request) {
+	email, ok := h.emailFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.emailService.Delete(r.Context(), email.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) MarkEmailAsRead(w http.ResponseWriter, r *http.Request) {
+	h.setEmailRead(w, r, true)
+}
+
+func (h *APIHandler) MarkEmailAsUnread(w http.ResponseWriter, r *http.Request) {
+	h.setEmailRead(w, r, false)
+}
+
+func (h *APIHandler) setEmailRead(w http.ResponseWriter, r *http.Request, read bool) {
+	email, ok := h.emailFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if read {
+		err = h.emailService.MarkAsRead(r.Context(), email.ID)
+	} else {
+		err = h.emailService.MarkAsUnread(r.Context(), email.ID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update email")
+		return
+	}
+
+	email.IsRead = read
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": email})
+}
+
+func (h *APIHandler) DownloadEmailRaw(w http.ResponseWriter, r *http.Request) {
+	email, ok := h.emailFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	serveRawMessage(w, r, h.storage, email)
+}
+
+func (h *APIHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.attachmentFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	attachment.DownloadURL = "/api/v1/attachments/" + strconv.FormatInt(attachment.ID, 10) + "/download"
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": attachment})
+}
+
+func (h *APIHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.attachmentFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	serveAttachment(w, r, h.storage, attachment)
+}
+
+// emailFromParams resolves {id}/{emailId} and makes sure the email belongs
+// to a mailbox the current user can access.
+func (h *APIHandler) emailFromParams(w http.ResponseWriter, r *http.Request) (*domain.Email, bool) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+
+	emailID, err := parseIDParam(r, "emailId")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Email not found")
+		return nil, false
+	}
+
+	email, err := h.emailService.GetByID(r.Context(), emailID)
+	if err != nil || email.MailboxID != mailbox.ID {
+		writeError(w, http.StatusNotFound, "Email not found")
+		return nil, false
+	}
+
+	return email, true
+}
+
+func (h *APIHandler) attachmentFromParam(w http.ResponseWriter, r *http.Request) (*domain.Attachment, bool) {
+	attachmentID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Attachment not found")
+		return nil, false
+	}
+
+	attachment, err := h.emailService.GetAttachmentByID(r.Context(), attachmentID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Attachment not found")
+		return nil, false
+	}
+
+	email, err := h.emailService.GetByID(r.Context(), attachment.EmailID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Attachment not found")
+		return nil, false
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), email.MailboxID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Attachment not found")
+		return nil, false
+	}
+
+	if !canAccessMailbox(mw.GetUser(r), mailbox) {
+		writeError(w, http.StatusForbidden, "Forbidden")
+		return nil, false
+	}
+
+	return attachment, true
+}
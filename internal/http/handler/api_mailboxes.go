@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+type apiMailboxRequest struct {
+	Slug                string `json:"slug"`
+	Description         string `json:"description"`
+	OwnerID             *int64 `json:"owner_id"`
+	DomainID            *int64 `json:"domain_id"`
+	IsActive            *bool  `json:"is_active"`
+	MaxEmailSizeMB      *int   `json:"max_email_size_mb"`
+	MaxAttachmentSizeMB *int   `json:"max_attachment_size_mb"`
+	RetentionDays       *int   `json:"retention_days"`
+}
+
+// ListMailboxes supports the domain_id, is_active and q (slug substring) filters.
+func (h *APIHandler) ListMailboxes(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	var mailboxes []*domain.Mailbox
+	var err error
+	if user.IsAdmin {
+		mailboxes, err = h.mailboxService.List(r.Context())
+	} else {
+		mailboxes, err = h.mailboxService.ListByOwner(r.Context(), user.ID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list mailboxes")
+		return
+	}
+
+	domainID, _ := strconv.ParseInt(r.URL.Query().Get("domain_id"), 10, 64)
+	isActive := parseBoolQuery(r, "is_active")
+	query := strings.ToLower(r.URL.Query().Get("q"))
+
+	filtered := make([]*domain.Mailbox, 0, len(mailboxes))
+	for _, mb := range mailboxes {
+		if domainID != 0 && (mb.DomainID == nil || *mb.DomainID != domainID) {
+			continue
+		}
+		if isActive != nil && mb.IsActive != *isActive {
+			continue
+		}
+		if query != "" && !strings.Contains(mb.Slug, query) {
+			continue
+		}
+		filtered = append(filtered, mb)
+	}
+
+	page, perPage := pageParams(r)
+	items := paginate(filtered, page, perPage)
+	for _, mb := range items {
+		h.loadMailboxDomain(r, mb)
+	}
+
+	writeList(w, items, page, perPage, int64(len(filtered)))
+}
+
+func (h *APIHandler) GetMailbox(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	h.loadMailboxDomain(r, mailbox)
+	mailbox.Stats, _ = h.mailboxService.GetStats(r.Context(), mailbox.ID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": mailbox})
+}
+
+func (h *APIHandler) CreateMailbox(w http.ResponseWriter, r *http.Request) {
+	var req apiMailboxRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	mailbox, err := h.mailboxService.Create(r.Context(), req.Slug, req.OwnerID, req.DomainID, req.Description)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.loadMailboxDomain(r, mailbox)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": mailbox})
+}
+
+// UpdateMailbox applies a partial update: omitted fields keep their value.
+func (h *APIHandler) UpdateMailbox(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req apiMailboxRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	params := service.UpdateMailboxParams{
+		Slug:                mailbox.Slug,
+		OwnerID:             mailbox.OwnerID,
+		DomainID:            mailbox.DomainID,
+		Description:         mailbox.Description,
+		IsActive:            mailbox.IsActive,
+		MaxEmailSizeMB:      mailbox.MaxEmailSizeMB,
+		MaxAttachmentSizeMB: mailbox.MaxAttachmentSizeMB,
+		RetentionDays:       mailbox.RetentionDays,
+	}
+	if req.Slug != "" {
+		params.Slug = req.Slug
+	}
+	if req.Description != "" {
+		params.Description = req.Description
+	}
+	if req.OwnerID != nil {
+		params.OwnerID = req.OwnerID
+	}
+	if req.DomainID != nil {
+		params.DomainID = req.DomainID
+	}
+	if req.IsActive != nil {
+		params.IsActive = *req.IsActive
+	}
+	if req.MaxEmailSizeMB != nil {
+		params.MaxEmailSizeMB = *req.MaxEmailSizeMB
+	}
+	if req.MaxAttachmentSizeMB != nil {
+		params.MaxAttachmentSizeMB = *req.MaxAttachmentSizeMB
+	}
+	if req.RetentionDays != nil {
+		params.RetentionDays = *req.RetentionDays
+	}
+
+	updated, err := h.mailboxService.Update(r.Context(), mailbox.ID, params)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.loadMailboxDomain(r, updated)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": updated})
+}
+
+func (h *APIHandler) DeleteMailbox(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.mailboxService.Delete(r.Context(), mailbox.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete mailbox")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) loadMailboxDomain(r *http.Request, mailbox *domain.Mailbox) {
+	if mailbox.DomainID != nil {
+		mailbox.Domain, _ = h.domainService.GetByID(r.Context(), *mailbox.DomainID)
+	}
+	mailbox.Aliases, _ = h.mailboxService.ListAliases(r.Context(), mailbox.ID)
+}
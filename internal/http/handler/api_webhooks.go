@@ -0,0 +1,499 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/webhook"
+)
+
+type apiWebhookRequest struct {
+	Name                     string                     `json:"name"`
+	URL                      string                     `json:"url"`
+	Method                   string                     `json:"method"`
+	Headers                  map[string]string          `json:"headers"`
+	PayloadType              string                     `json:"payload_type"`
+	CustomPayload            string                     `json:"custom_payload"`
+	HMACSecret               string                     `json:"hmac_secret"`
+	ClearHMACSecret          bool                       `json:"clear_hmac_secret"`
+	TimeoutSec               int                        `json:"timeout_sec"`
+	MaxRetries               int                        `json:"max_retries"`
+	IncludeBody              bool                       `json:"include_body"`
+	IncludeAttachments       bool                       `json:"include_attachments"`
+	RawMode                  string                     `json:"raw_mode"`
+	AttachmentMode           string                     `json:"attachment_mode"`
+	AttachmentMaxInlineBytes int64                      `json:"attachment_max_inline_bytes"`
+	RetryBaseDelaySec        int                        `json:"retry_base_delay_sec"`
+	RetryMaxDelaySec         int                        `json:"retry_max_delay_sec"`
+	RetryJitterPercent       *int                       `json:"retry_jitter_percent"`
+	Events                   []string                   `json:"events"`
+	Encoding                 string                     `json:"encoding"`
+	QueryFields              []string                   `json:"query_fields"`
+	SignEd25519              bool                       `json:"sign_ed25519"`
+	TLSClientCert            string                     `json:"tls_client_cert"`
+	TLSClientKey             string                     `json:"tls_client_key"`
+	ClearTLSClientKey        bool                       `json:"clear_tls_client_key"`
+	TLSCABundle              string                     `json:"tls_ca_bundle"`
+	TLSSkipVerify            bool                       `json:"tls_skip_verify"`
+	ProxyURL                 string                     `json:"proxy_url"`
+	RedirectPolicy           string                     `json:"redirect_policy"`
+	Target                   string                     `json:"target"`
+	TargetConfig             domain.WebhookTargetConfig `json:"target_config"`
+	IsActive                 *bool                      `json:"is_active"`
+	Rules                    *[]struct {
+		RuleGroup  int    `json:"rule_group"`
+		Field      string `json:"field"`
+		Operator   string `json:"operator"`
+		Value      string `json:"value"`
+		HeaderName string `json:"header_name"`
+	} `json:"rules"`
+}
+
+// ListWebhooks supports the is_active filter.
+func (h *APIHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListByMailbox(r.Context(), mailbox.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list webhooks")
+		return
+	}
+
+	isActive := parseBoolQuery(r, "is_active")
+	filtered := make([]*domain.Webhook, 0, len(webhooks))
+	for _, wh := range webhooks {
+		if isActive != nil && wh.IsActive != *isActive {
+			continue
+		}
+		filtered = append(filtered, wh)
+	}
+
+	page, perPage := pageParams(r)
+	writeList(w, paginate(filtered, page, perPage), page, perPage, int64(len(filtered)))
+}
+
+func (h *APIHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req apiWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if !validEvents(w, req.Events) {
+		return
+	}
+	if err := webhook.ValidateBody(req.PayloadType, req.CustomPayload, req.Encoding, req.Events); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := h.dispatcher.ValidateTarget(&domain.Webhook{URL: req.URL, Target: req.Target, TargetConfig: req.TargetConfig}); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := webhook.ValidateTransport(req.TLSClientCert, req.TLSClientKey, req.TLSCABundle, req.ProxyURL); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := service.ValidateRules(req.webhookRules()); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if req.TimeoutSec == 0 {
+		req.TimeoutSec = 30
+	}
+	if req.MaxRetries == 0 {
+		req.MaxRetries = 3
+	}
+	jitter := service.DefaultRetryJitterPercent
+	if req.RetryJitterPercent != nil {
+		jitter = *req.RetryJitterPercent
+	}
+
+	wh, err := h.webhookService.Create(r.Context(), service.CreateWebhookParams{
+		MailboxID:                mailbox.ID,
+		Name:                     req.Name,
+		URL:                      req.URL,
+		Method:                   req.Method,
+		Headers:                  req.Headers,
+		PayloadType:              req.PayloadType,
+		CustomPayload:            req.CustomPayload,
+		HMACSecret:               req.HMACSecret,
+		TimeoutSec:               req.TimeoutSec,
+		MaxRetries:               req.MaxRetries,
+		IncludeBody:              req.IncludeBody,
+		IncludeAttachments:       req.IncludeAttachments,
+		RawMode:                  req.RawMode,
+		AttachmentMode:           req.AttachmentMode,
+		AttachmentMaxInlineBytes: req.AttachmentMaxInlineBytes,
+		RetryBaseDelaySec:        req.RetryBaseDelaySec,
+		RetryMaxDelaySec:         req.RetryMaxDelaySec,
+		RetryJitterPercent:       jitter,
+		Events:                   req.Events,
+		Encoding:                 req.Encoding,
+		QueryFields:              req.QueryFields,
+		SignEd25519:              req.SignEd25519,
+		TLSClientCert:            req.TLSClientCert,
+		TLSClientKey:             req.TLSClientKey,
+		TLSCABundle:              req.TLSCABundle,
+		TLSSkipVerify:            req.TLSSkipVerify,
+		ProxyURL:                 req.ProxyURL,
+		RedirectPolicy:           req.RedirectPolicy,
+		Target:                   req.Target,
+		TargetConfig:             req.TargetConfig,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if !h.replaceWebhookRules(w, r, wh.ID, &req) {
+		h.webhookService.Delete(r.Context(), wh.ID)
+		return
+	}
+
+	wh, _ = h.webhookService.GetByID(r.Context(), wh.ID)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": wh})
+}
+
+func (h *APIHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.webhookFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	wh.DeliveryStats, _ = h.webhookService.GetDeliveryStats(r.Context(), wh.ID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": wh})
+}
+
+// UpdateWebhook replaces the webhook settings. Rules are only replaced when
+// the request carries a rules array.
+func (h *APIHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.webhookFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validEvents(w, req.Events) {
+		return
+	}
+	if req.Events == nil {
+		req.Events = wh.Events
+	}
+	keepSecrets(wh, &req.HMACSecret, req.ClearHMACSecret, &req.TLSClientKey, req.ClearTLSClientKey)
+	req.ProxyURL = domain.RestoreURLCredentials(req.ProxyURL, wh.ProxyURL)
+	req.TargetConfig.BrokerURL = domain.RestoreURLCredentials(req.TargetConfig.BrokerURL, wh.TargetConfig.BrokerURL)
+	if err := webhook.ValidateBody(req.PayloadType, req.CustomPayload, req.Encoding, req.Events); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := h.dispatcher.ValidateTarget(&domain.Webhook{URL: req.URL, Target: req.Target, TargetConfig: req.TargetConfig}); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := webhook.ValidateTransport(req.TLSClientCert, req.TLSClientKey, req.TLSCABundle, req.ProxyURL); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := service.ValidateRules(req.webhookRules()); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	isActive := wh.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	if req.TimeoutSec == 0 {
+		req.TimeoutSec = wh.TimeoutSec
+	}
+	if req.MaxRetries == 0 {
+		req.MaxRetries = wh.MaxRetries
+	}
+	if req.RetryBaseDelaySec == 0 {
+		req.RetryBaseDelaySec = wh.RetryBaseDelaySec
+	}
+	if req.RetryMaxDelaySec == 0 {
+		req.RetryMaxDelaySec = wh.RetryMaxDelaySec
+	}
+	jitter := wh.RetryJitterPercent
+	if req.RetryJitterPercent != nil {
+		jitter = *req.RetryJitterPercent
+	}
+
+	_, err := h.webhookService.Update(r.Context(), service.UpdateWebhookParams{
+		ID:                       wh.ID,
+		Name:                     req.Name,
+		URL:                      req.URL,
+		Method:                   req.Method,
+		Headers:                  req.Headers,
+		PayloadType:              req.PayloadType,
+		CustomPayload:            req.CustomPayload,
+		HMACSecret:               req.HMACSecret,
+		TimeoutSec:               req.TimeoutSec,
+		MaxRetries:               req.MaxRetries,
+		IncludeBody:              req.IncludeBody,
+		IncludeAttachments:       req.IncludeAttachments,
+		RawMode:                  req.RawMode,
+		AttachmentMode:           req.AttachmentMode,
+		AttachmentMaxInlineBytes: req.AttachmentMaxInlineBytes,
+		RetryBaseDelaySec:        req.RetryBaseDelaySec,
+		RetryMaxDelaySec:         req.RetryMaxDelaySec,
+		RetryJitterPercent:       jitter,
+		Events:                   req.Events,
+		Encoding:                 req.Encoding,
+		QueryFields:              req.QueryFields,
+		SignEd25519:              req.SignEd25519,
+		TLSClientCert:            req.TLSClientCert,
+		TLSClientKey:             req.TLSClientKey,
+		TLSCABundle:              req.TLSCABundle,
+		TLSSkipVerify:            req.TLSSkipVerify,
+		ProxyURL:                 req.ProxyURL,
+		RedirectPolicy:           req.RedirectPolicy,
+		Target:                   req.Target,
+		TargetConfig:             req.TargetConfig,
+		IsActive:                 isActive,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if !h.replaceWebhookRules(w, r, wh.ID, &req) {
+		return
+	}
+
+	wh, _ = h.webhookService.GetByID(r.Context(), wh.ID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": wh})
+}
+
+// keepSecrets fills the HMAC secret and client key left empty in an update
+// with the stored ones: they are never sent to clients, so an empty field
+// means unchanged. The clear flags remove them instead.
+func keepSecrets(current *domain.Webhook, secret *string, clearSecret bool, clientKey *string, clearClientKey bool) {
+	if *secret == "" && !clearSecret {
+		*secret = current.HMACSecret
+	}
+	if *clientKey == "" && !clearClientKey {
+		*clientKey = current.TLSClientKey
+	}
+}
+
+// validEvents rejects event types webhooks cannot subscribe to.
+func validEvents(w http.ResponseWriter, events []string) bool {
+	for _, event := range events {
+		if !domain.IsValidEventType(event) {
+			writeError(w, http.StatusUnprocessableEntity, "unknown event: "+event)
+			return false
+		}
+	}
+	return true
+}
+
+// PreviewWebhookTemplate renders a body template against a stored email
+// without sending it.
+func (h *APIHandler) PreviewWebhookTemplate(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := h.mailboxFromParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req templatePreviewRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	preview, err := previewTemplate(r.Context(), h.emailService, h.domainService, mailbox, &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": preview})
+}
+
+func (h *APIHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.webhookFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(r.Context(), wh.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateWebhookSecret replaces the signing secret and returns the webhook
+// with the new secret. grace_hours overrides how long the previous secret
+// stays valid.
+func (h *APIHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.webhookFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		GraceHours *int `json:"grace_hours"`
+	}
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	grace := h.dispatcher.SecretGrace()
+	if req.GraceHours != nil {
+		if *req.GraceHours < 0 {
+			writeError(w, http.StatusUnprocessableEntity, "grace_hours must not be negative")
+			return
+		}
+		grace = time.Duration(*req.GraceHours) * time.Hour
+	}
+
+	updated, err := h.webhookService.RotateSecret(r.Context(), wh.ID, grace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to rotate secret")
+		return
+	}
+
+	// The secret is not part of the webhook JSON, so the new one is returned
+	// next to it, once
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": updated, "hmac_secret": updated.HMACSecret})
+}
+
+func (h *APIHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.webhookFromParam(w, r)
+	if !ok {
+		return
+	}
+
+	page, perPage := pageParams(r)
+	deliveries, err := h.deliveryService.ListByWebhook(r.Context(), wh.ID, perPage, (page-1)*perPage)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list deliveries")
+		return
+	}
+	total, _ := h.deliveryService.CountByWebhook(r.Context(), wh.ID)
+
+	writeList(w, deliveries, page, perPage, total)
+}
+
+func (h *APIHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	delivery, err := h.deliveryService.GetByID(r.Context(), deliveryID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	wh, err := h.webhookService.GetByID(r.Context(), delivery.WebhookID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), wh.MailboxID)
+	if err != nil || !canAccessMailbox(mw.GetUser(r), mailbox) {
+		writeError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	if err := h.dispatcher.ManualRetry(delivery); err != nil {
+		if errors.Is(err, webhook.ErrQueueFull) {
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to queue retry")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "queued"})
+}
+
+func (h *APIHandler) webhookFromParam(w http.ResponseWriter, r *http.Request) (*domain.Webhook, bool) {
+	webhookID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+
+	wh, err := h.webhookService.GetByID(r.Context(), webhookID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), wh.MailboxID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+
+	if !canAccessMailbox(mw.GetUser(r), mailbox) {
+		writeError(w, http.StatusForbidden, "Forbidden")
+		return nil, false
+	}
+
+	return wh, true
+}
+
+// replaceWebhookRules swaps the webhook's rules for the ones in the request,
+// if it carries any, and writes the error response when that fails.
+func (h *APIHandler) replaceWebhookRules(w http.ResponseWriter, r *http.Request, webhookID int64, req *apiWebhookRequest) bool {
+	if req.Rules == nil {
+		return true
+	}
+
+	err := h.webhookService.ReplaceRules(r.Context(), webhookID, req.webhookRules())
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhookRule):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "Failed to save webhook rules")
+		return false
+	}
+	return true
+}
+
+// webhookRules returns the rules of the request, nil when it has none.
+func (req *apiWebhookRequest) webhookRules() []domain.WebhookRule {
+	if req.Rules == nil {
+		return nil
+	}
+	rules := make([]domain.WebhookRule, len(*req.Rules))
+	for i, rule := range *req.Rules {
+		rules[i] = domain.WebhookRule{
+			RuleGroup:  rule.RuleGroup,
+			Field:      rule.Field,
+			Operator:   rule.Operator,
+			Value:      rule.Value,
+			HeaderName: rule.HeaderName,
+		}
+	}
+	return rules
+}
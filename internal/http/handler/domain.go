@@ -270,13 +270,14 @@ func (h *DomainHandler) Update(w http.ResponseWriter, r *http.Request) {
 		Name       string `json:"name"`
 		IsVerified bool   `json:"is_verified"`
 		IsActive   bool   `json:"is_active"`
+		RequireTLS bool   `json:"require_tls"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.inertia.Render(w, r, "Errors/ServerError", nil)
 		return
 	}
 
-	_, err = h.domainService.Update(r.Context(), id, req.Name, req.IsVerified, req.IsActive)
+	_, err = h.domainService.Update(r.Context(), id, req.Name, req.IsVerified, req.IsActive, req.RequireTLS)
 	if err != nil {
 		domain, _ := h.domainService.GetByID(r.Context(), id)
 		h.inertia.Render(w, r, "Domains/Edit", gonertia.Props{
@@ -342,7 +343,7 @@ func (h *DomainHandler) Verify(w http.ResponseWriter, r *http.Request) {
 
 	// If all DNS checks pass, automatically mark domain as verified
 	if result.MX.Valid && result.TXT.Valid && !domain.IsVerified {
-		h.domainService.Update(r.Context(), id, domain.Name, true, domain.IsActive)
+		h.domainService.Update(r.Context(), id, domain.Name, true, domain.IsActive, domain.RequireTLS)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -393,3 +394,177 @@ func (h *DomainHandler) SetTags(w http.ResponseWriter, r *http.Request) {
 		"tags":    tags,
 	})
 }
+
+func (h *DomainHandler) Routing(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	domain, err := h.domainService.GetByID(r.Context(), id)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	// Load mailbox count for sidebar
+	domainMailboxes, _ := h.mailboxService.ListByDomain(r.Context(), id)
+	domain.MailboxCount = int64(len(domainMailboxes))
+
+	allDomains, _ := h.domainService.List(r.Context())
+	routes, _ := h.domainService.ListRoutes(r.Context(), id)
+
+	// Routes and the catch-all may target a mailbox on any domain
+	domainNames := make(map[int64]string, len(allDomains))
+	for _, d := range allDomains {
+		domainNames[d.ID] = d.Name
+	}
+	type mailboxOption struct {
+		ID      int64  `json:"id"`
+		Address string `json:"address"`
+	}
+	mailboxes, _ := h.mailboxService.List(r.Context())
+	options := make([]mailboxOption, 0, len(mailboxes))
+	for _, mb := range mailboxes {
+		address := mb.Slug
+		if mb.DomainID != nil {
+			address += "@" + domainNames[*mb.DomainID]
+		}
+		options = append(options, mailboxOption{ID: mb.ID, Address: address})
+	}
+
+	props := gonertia.Props{
+		"domain":     domain,
+		"allDomains": allDomains,
+		"routes":     routes,
+		"mailboxes":  options,
+	}
+
+	if flash := mw.GetFlash(r); flash != nil {
+		props["flash"] = flash
+	}
+
+	h.inertia.Render(w, r, "Domains/Routing", props)
+}
+
+func (h *DomainHandler) SetCatchAll(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	var req struct {
+		MailboxID *int64 `json:"mailbox_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.inertia.Render(w, r, "Errors/ServerError", nil)
+		return
+	}
+
+	if _, err := h.domainService.SetCatchAll(r.Context(), id, req.MailboxID); err != nil {
+		h.flash.SetError(r, err.Error())
+	} else {
+		h.flash.SetSuccess(r, "Catch-all updated")
+	}
+	h.inertia.Back(w, r)
+}
+
+func (h *DomainHandler) StoreRoute(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	var req struct {
+		PatternType string `json:"pattern_type"`
+		Pattern     string `json:"pattern"`
+		MailboxID   int64  `json:"mailbox_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.inertia.Render(w, r, "Errors/ServerError", nil)
+		return
+	}
+
+	if _, err := h.domainService.CreateRoute(r.Context(), id, req.MailboxID, req.PatternType, req.Pattern); err != nil {
+		h.flash.SetError(r, err.Error())
+	} else {
+		h.flash.SetSuccess(r, "Route added")
+	}
+	h.inertia.Back(w, r)
+}
+
+func (h *DomainHandler) ReorderRoutes(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	var req struct {
+		RouteIDs []int64 `json:"route_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.inertia.Render(w, r, "Errors/ServerError", nil)
+		return
+	}
+
+	if err := h.domainService.ReorderRoutes(r.Context(), id, req.RouteIDs); err != nil {
+		h.inertia.Render(w, r, "Errors/ServerError", nil)
+		return
+	}
+	h.inertia.Back(w, r)
+}
+
+func (h *DomainHandler) DeleteRoute(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+	routeID, err := strconv.ParseInt(chi.URLParam(r, "routeId"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	if err := h.domainService.DeleteRoute(r.Context(), id, routeID); err != nil {
+		h.inertia.Render(w, r, "Errors/ServerError", nil)
+		return
+	}
+
+	h.flash.SetSuccess(r, "Route deleted")
+	h.inertia.Back(w, r)
+}
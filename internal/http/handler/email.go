@@ -1,13 +1,21 @@
 package handler
 
 import (
+	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jr-k/mailgress/internal/domain"
 	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/search"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/jr-k/mailgress/internal/storage"
 	"github.com/romsar/gonertia"
@@ -17,20 +25,23 @@ type EmailHandler struct {
 	inertia        *gonertia.Inertia
 	emailService   *service.EmailService
 	mailboxService *service.MailboxService
-	storage        *storage.Storage
+	storage        storage.Storage
+	urlSigner      *service.URLSigner
 }
 
 func NewEmailHandler(
 	inertia *gonertia.Inertia,
 	emailService *service.EmailService,
 	mailboxService *service.MailboxService,
-	storage *storage.Storage,
+	storage storage.Storage,
+	urlSigner *service.URLSigner,
 ) *EmailHandler {
 	return &EmailHandler{
 		inertia:        inertia,
 		emailService:   emailService,
 		mailboxService: mailboxService,
 		storage:        storage,
+		urlSigner:      urlSigner,
 	}
 }
 
@@ -81,6 +92,143 @@ func (h *EmailHandler) Show(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Search runs a search across every mailbox the user can read.
+func (h *EmailHandler) Search(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	query := r.URL.Query().Get("q")
+	perPage := int64(50)
+	offset := int64(page-1) * perPage
+
+	var mailboxes []*domain.Mailbox
+	if user.IsAdmin {
+		mailboxes, _ = h.mailboxService.List(r.Context())
+	} else {
+		mailboxes, _ = h.mailboxService.ListByOwner(r.Context(), user.ID)
+	}
+
+	emails := []*domain.Email{}
+	var total int64
+	var searchError string
+	if query != "" {
+		scope := searchScope(user)
+		result, err := h.emailService.Search(r.Context(), scope, query, perPage, offset)
+		if errors.Is(err, search.ErrInvalidQuery) {
+			searchError = err.Error()
+		} else if err != nil {
+			log.Printf("Error searching emails: %v", err)
+		} else {
+			emails = result
+			total, _ = h.emailService.SearchCount(r.Context(), scope, query)
+		}
+	}
+
+	h.inertia.Render(w, r, "Emails/Search", gonertia.Props{
+		"query":       query,
+		"searchError": searchError,
+		"emails":      emails,
+		"mailboxes":   mailboxes,
+		"pagination": map[string]interface{}{
+			"current_page": page,
+			"total":        total,
+			"per_page":     perPage,
+		},
+	})
+}
+
+func (h *EmailHandler) DownloadRaw(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	mailboxID, err := strconv.ParseInt(chi.URLParam(r, "mailboxId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	emailID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), mailboxID)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if !user.IsAdmin && (mailbox.OwnerID == nil || *mailbox.OwnerID != user.ID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	email, err := h.emailService.GetByID(r.Context(), emailID)
+	if err != nil || email.MailboxID != mailboxID {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	serveRawMessage(w, r, h.storage, email)
+}
+
+// DownloadRawSigned serves the original message to webhook receivers holding
+// a link produced by URLSigner; it is mounted outside the auth middleware.
+func (h *EmailHandler) DownloadRawSigned(w http.ResponseWriter, r *http.Request) {
+	if err := h.urlSigner.Verify(r.URL.Path, r.URL.Query()); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	emailID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	email, err := h.emailService.GetByID(r.Context(), emailID)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	serveRawMessage(w, r, h.storage, email)
+}
+
+func serveRawMessage(w http.ResponseWriter, r *http.Request, store storage.Storage, email *domain.Email) {
+	if email.RawPath == "" {
+		http.Error(w, "Original message not available", http.StatusNotFound)
+		return
+	}
+
+	disposition := contentDisposition("attachment", fmt.Sprintf("email-%d.eml", email.ID))
+	if redirectToStorage(w, r, store, email.RawPath, storage.PresignOptions{ContentType: "message/rfc822", ContentDisposition: disposition}) {
+		return
+	}
+
+	file, err := store.Get(email.RawPath)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "message/rfc822")
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", email.ReceivedAt, rs)
+		return
+	}
+	io.Copy(w, file)
+}
+
 func (h *EmailHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
 	user := mw.GetUser(r)
 
@@ -90,7 +238,13 @@ func (h *EmailHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	email, err := h.findEmailByAttachment(r, attachmentID)
+	attachment, err := h.emailService.GetAttachmentByID(r.Context(), attachmentID)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	email, err := h.emailService.GetByID(r.Context(), attachment.EmailID)
 	if err != nil {
 		http.Error(w, "Not found", http.StatusNotFound)
 		return
@@ -107,53 +261,232 @@ func (h *EmailHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var attachment *struct {
-		filename    string
-		contentType string
-		storagePath string
+	serveAttachment(w, r, h.storage, attachment)
+}
+
+// DownloadAttachmentSigned serves an attachment to webhook receivers holding
+// a link produced by URLSigner; it is mounted outside the auth middleware.
+func (h *EmailHandler) DownloadAttachmentSigned(w http.ResponseWriter, r *http.Request) {
+	if err := h.urlSigner.Verify(r.URL.Path, r.URL.Query()); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	attachmentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	attachment, err := h.emailService.GetAttachmentByID(r.Context(), attachmentID)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	serveAttachment(w, r, h.storage, attachment)
+}
+
+// zipEntryWriteTimeout is how long writing one attachment into a zip archive
+// may take.
+const zipEntryWriteTimeout = 5 * time.Minute
+
+// DownloadAttachments bundles every attachment of an email into a zip archive.
+func (h *EmailHandler) DownloadAttachments(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	mailboxID, err := strconv.ParseInt(chi.URLParam(r, "mailboxId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	emailID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), mailboxID)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if !user.IsAdmin && (mailbox.OwnerID == nil || *mailbox.OwnerID != user.ID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
 	}
 
+	email, err := h.emailService.GetByID(r.Context(), emailID)
+	if err != nil || email.MailboxID != mailboxID || len(email.Attachments) == 0 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", fmt.Sprintf("email-%d-attachments.zip", email.ID)))
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	rc := http.NewResponseController(w)
+	used := make(map[string]int)
 	for _, att := range email.Attachments {
-		if att.ID == attachmentID {
-			attachment = &struct {
-				filename    string
-				contentType string
-				storagePath string
-			}{
-				filename:    att.Filename,
-				contentType: att.ContentType,
-				storagePath: att.StoragePath,
-			}
-			break
+		// Large archives outlast the server's WriteTimeout, so every
+		// attachment gets its own deadline
+		if err := rc.SetWriteDeadline(time.Now().Add(zipEntryWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("Failed to extend the write deadline for email %d attachments: %v", email.ID, err)
+		}
+
+		file, err := h.storage.Get(att.StoragePath)
+		if err != nil {
+			log.Printf("Failed to open attachment %d for zip: %v", att.ID, err)
+			continue
+		}
+
+		entry, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     uniqueZipName(used, att.Filename),
+			Method:   zip.Deflate,
+			Modified: att.CreatedAt,
+		})
+		if err == nil {
+			_, err = io.Copy(entry, file)
+		}
+		file.Close()
+		if err != nil {
+			log.Printf("Failed to write attachment %d to zip: %v", att.ID, err)
+			return
 		}
 	}
+}
 
-	if attachment == nil {
-		http.Error(w, "Not found", http.StatusNotFound)
+func serveAttachment(w http.ResponseWriter, r *http.Request, store storage.Storage, attachment *domain.Attachment) {
+	disposition := "attachment"
+	if r.URL.Query().Get("disposition") == "inline" && inlineSafe(attachment.ContentType) {
+		disposition = "inline"
+	}
+	disposition = contentDisposition(disposition, attachment.Filename)
+
+	if redirectToStorage(w, r, store, attachment.StoragePath, storage.PresignOptions{ContentType: attachment.ContentType, ContentDisposition: disposition}) {
 		return
 	}
 
-	file, err := h.storage.Get(attachment.storagePath)
+	file, err := store.Get(attachment.StoragePath)
 	if err != nil {
 		http.Error(w, "File not found", http.StatusNotFound)
 		return
 	}
 	defer file.Close()
 
-	w.Header().Set("Content-Type", attachment.contentType)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", attachment.filename))
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
 
+	// ServeContent handles Range and conditional requests when the
+	// storage backend gives us a seekable file
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", attachment.CreatedAt, rs)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
 	io.Copy(w, file)
 }
 
-func (h *EmailHandler) findEmailByAttachment(r *http.Request, attachmentID int64) (*struct {
-	MailboxID   int64
-	Attachments []struct {
-		ID          int64
-		Filename    string
-		ContentType string
-		StoragePath string
+// presignedDownloadTTL is how long the storage URLs that downloads redirect
+// to stay valid.
+const presignedDownloadTTL = 5 * time.Minute
+
+// redirectToStorage redirects a download to a presigned URL of the storage
+// backend, when the storage driver hands them out, so the file does not go
+// through Mailgress. It reports whether it redirected.
+func redirectToStorage(w http.ResponseWriter, r *http.Request, store storage.Storage, path string, opts storage.PresignOptions) bool {
+	url, err := store.PresignGet(path, presignedDownloadTTL, opts)
+	if err != nil {
+		if !errors.Is(err, storage.ErrPresignUnsupported) {
+			log.Printf("Failed to presign download of %s: %v", path, err)
+		}
+		return false
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+	return true
+}
+
+// inlineSafe reports whether a content type can be rendered by the browser
+// without running scripts in our origin.
+func inlineSafe(contentType string) bool {
+	switch {
+	case contentType == "application/pdf", contentType == "text/plain":
+		return true
+	case contentType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "video/"):
+		return true
+	default:
+		return false
+	}
+}
+
+// contentDisposition builds a Content-Disposition header with an ASCII
+// fallback filename and an RFC 5987 filename* for non-ASCII names.
+func contentDisposition(disposition, filename string) string {
+	var fallback strings.Builder
+	for _, c := range filename {
+		switch {
+		case c < 0x20 || c == 0x7f:
+		case c == '"' || c == '\\' || c > 0x7e:
+			fallback.WriteRune('_')
+		default:
+			fallback.WriteRune(c)
+		}
+	}
+
+	header := fmt.Sprintf("%s; filename=\"%s\"", disposition, fallback.String())
+	if fallback.String() != filename {
+		header += "; filename*=UTF-8''" + rfc5987Escape(filename)
+	}
+	return header
+}
+
+func rfc5987Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// uniqueZipName returns the base name of filename, numbered "name (n).ext"
+// when an entry of the archive already has that name. Generated names are
+// recorded in used too, so they cannot collide with a later attachment.
+func uniqueZipName(used map[string]int, filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+
+	if used[name] == 0 {
+		used[name] = 1
+		return name
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, used[name], ext)
+		used[name]++
+		if used[candidate] == 0 {
+			used[candidate] = 1
+			return candidate
+		}
 	}
-}, error) {
-	return nil, fmt.Errorf("not implemented - need to add query")
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/storage"
+)
+
+// TestDownloadHeaders checks that downloads keep browsers from sniffing a
+// content type other than the one sent, which could render an attacker's
+// HTML in our origin.
+func TestDownloadHeaders(t *testing.T) {
+	store := storage.NewMemory()
+	rawPath, _, err := store.Store(1, "message.eml", strings.NewReader("Subject: hi\r\n\r\n<script>alert(1)</script>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attPath, size, err := store.Store(1, "page.html", strings.NewReader("<script>alert(1)</script>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := &domain.Email{ID: 1, RawPath: rawPath, ReceivedAt: time.Now()}
+	attachment := &domain.Attachment{ID: 2, EmailID: 1, Filename: "page.html", ContentType: "text/html", Size: size, StoragePath: attPath}
+
+	tests := []struct {
+		name        string
+		serve       func(w http.ResponseWriter, r *http.Request)
+		url         string
+		contentType string
+		disposition string
+	}{
+		{"raw message", func(w http.ResponseWriter, r *http.Request) { serveRawMessage(w, r, store, email) }, "/raw", "message/rfc822", `attachment; filename="email-1.eml"`},
+		{"attachment", func(w http.ResponseWriter, r *http.Request) { serveAttachment(w, r, store, attachment) }, "/download", "text/html", `attachment; filename="page.html"`},
+		{"inline attachment", func(w http.ResponseWriter, r *http.Request) { serveAttachment(w, r, store, attachment) }, "/download?disposition=inline", "text/html", `attachment; filename="page.html"`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.serve(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status %d", tt.name, rec.Code)
+			continue
+		}
+		h := rec.Header()
+		if h.Get("X-Content-Type-Options") != "nosniff" || h.Get("Content-Type") != tt.contentType || h.Get("Content-Disposition") != tt.disposition {
+			t.Errorf("%s: X-Content-Type-Options %q, Content-Type %q, Content-Disposition %q", tt.name,
+				h.Get("X-Content-Type-Options"), h.Get("Content-Type"), h.Get("Content-Disposition"))
+		}
+	}
+}
+
+func TestUniqueZipName(t *testing.T) {
+	tests := []struct {
+		filenames []string
+		want      []string
+	}{
+		{[]string{"a.pdf", "b.pdf"}, []string{"a.pdf", "b.pdf"}},
+		{[]string{"a.pdf", "a.pdf", "a.pdf"}, []string{"a.pdf", "a (1).pdf", "a (2).pdf"}},
+		{[]string{"a.pdf", "a.pdf", "a (1).pdf"}, []string{"a.pdf", "a (1).pdf", "a (1) (1).pdf"}},
+		{[]string{"a.pdf", "a (1).pdf", "a.pdf"}, []string{"a.pdf", "a (1).pdf", "a (2).pdf"}},
+		{[]string{"notes", "notes"}, []string{"notes", "notes (1)"}},
+		{[]string{"../../etc/passwd", `C:\tmp\passwd`}, []string{"passwd", "passwd (1)"}},
+		{[]string{"", "/", "attachment"}, []string{"attachment", "attachment (1)", "attachment (2)"}},
+	}
+	for _, tt := range tests {
+		used := make(map[string]int)
+		for i, filename := range tt.filenames {
+			if got := uniqueZipName(used, filename); got != tt.want[i] {
+				t.Errorf("%q: uniqueZipName(%q) = %q, want %q", tt.filenames, filename, got, tt.want[i])
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jr-k/mailgress/internal/domain"
 	mw "github.com/jr-k/mailgress/internal/http/middleware"
+	"github.com/jr-k/mailgress/internal/search"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/jr-k/mailgress/internal/webhook"
 	"github.com/romsar/gonertia"
@@ -234,22 +236,35 @@ func (h *MailboxHandler) Show(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	search := r.URL.Query().Get("search")
+	query := r.URL.Query().Get("search")
+	subaddress := r.URL.Query().Get("subaddress")
 	emailIDParam := r.URL.Query().Get("email_id")
 	perPage := int64(50)
 	offset := int64((page - 1)) * perPage
 
 	var emails interface{} = []*domain.Email{}
 	var total int64
+	var searchError string
 
-	if search != "" {
-		result, err := h.emailService.Search(r.Context(), mailbox.ID, search, perPage, offset)
+	if subaddress != "" {
+		result, err := h.emailService.ListBySubaddress(r.Context(), mailbox.ID, subaddress, perPage, offset)
 		if err != nil {
+			log.Printf("Error listing emails for mailbox %d: %v", mailbox.ID, err)
+		} else if result != nil {
+			emails = result
+		}
+		total, _ = h.emailService.CountBySubaddress(r.Context(), mailbox.ID, subaddress)
+	} else if query != "" {
+		scope := service.SearchScope{MailboxID: mailbox.ID}
+		result, err := h.emailService.Search(r.Context(), scope, query, perPage, offset)
+		if errors.Is(err, search.ErrInvalidQuery) {
+			searchError = err.Error()
+		} else if err != nil {
 			log.Printf("Error searching emails for mailbox %d: %v", mailbox.ID, err)
 		} else if result != nil {
 			emails = result
 		}
-		total, _ = h.emailService.SearchCount(r.Context(), mailbox.ID, search)
+		total, _ = h.emailService.SearchCount(r.Context(), scope, query)
 	} else {
 		result, err := h.emailService.ListByMailbox(r.Context(), mailbox.ID, perPage, offset)
 		if err != nil {
@@ -261,6 +276,8 @@ func (h *MailboxHandler) Show(w http.ResponseWriter, r *http.Request) {
 		log.Printf("DEBUG: Mailbox ID=%d, perPage=%d, offset=%d, emails found=%d, total=%d", mailbox.ID, perPage, offset, len(result), total)
 	}
 
+	subaddresses, _ := h.emailService.ListSubaddresses(r.Context(), mailbox.ID)
+
 	h.inertia.Render(w, r, "Mailboxes/Show", gonertia.Props{
 		"mailbox":      mailbox,
 		"allMailboxes": allMailboxes,
@@ -270,8 +287,11 @@ func (h *MailboxHandler) Show(w http.ResponseWriter, r *http.Request) {
 			"total":        total,
 			"per_page":     perPage,
 		},
-		"search":   search,
-		"emailId":  emailIDParam,
+		"search":       query,
+		"searchError":  searchError,
+		"subaddress":   subaddress,
+		"subaddresses": subaddresses,
+		"emailId":      emailIDParam,
 	})
 }
 
@@ -303,6 +323,8 @@ func (h *MailboxHandler) Edit(w http.ResponseWriter, r *http.Request) {
 	stats, _ := h.mailboxService.GetStats(r.Context(), id)
 	mailbox.Stats = stats
 
+	mailbox.Aliases, _ = h.mailboxService.ListAliases(r.Context(), id)
+
 	users, _ := h.userService.List(r.Context())
 	domains, _ := h.domainService.ListActive(r.Context())
 	allTags, _ := h.tagService.List(r.Context())
@@ -446,6 +468,75 @@ func (h *MailboxHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	h.inertia.Location(w, r, "/mailboxes")
 }
 
+func (h *MailboxHandler) StoreAlias(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	var req struct {
+		Slug     string `json:"slug"`
+		DomainID string `json:"domain_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.flash.SetError(r, "Invalid request")
+		h.inertia.Back(w, r)
+		return
+	}
+
+	domainID, err := strconv.ParseInt(req.DomainID, 10, 64)
+	if err != nil {
+		h.flash.SetError(r, "Please select a domain")
+		h.inertia.Back(w, r)
+		return
+	}
+
+	if err := h.mailboxService.CreateAlias(r.Context(), id, domainID, req.Slug); err != nil {
+		h.flash.SetError(r, err.Error())
+		h.inertia.Back(w, r)
+		return
+	}
+
+	h.flash.SetSuccess(r, "Alias added")
+	h.inertia.Back(w, r)
+}
+
+func (h *MailboxHandler) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+	if !user.IsAdmin {
+		h.inertia.Render(w, r, "Errors/Forbidden", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	aliasID, err := strconv.ParseInt(chi.URLParam(r, "aliasId"), 10, 64)
+	if err != nil {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
+	if err := h.mailboxService.DeleteAlias(r.Context(), id, aliasID); err != nil {
+		h.flash.SetError(r, "Failed to delete alias")
+		h.inertia.Back(w, r)
+		return
+	}
+
+	h.flash.SetSuccess(r, "Alias removed")
+	h.inertia.Back(w, r)
+}
+
 func (h *MailboxHandler) SetTags(w http.ResponseWriter, r *http.Request) {
 	user := mw.GetUser(r)
 	if !user.IsAdmin {
@@ -618,7 +709,18 @@ func (h *MailboxHandler) RetriggerWebhooks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	h.dispatcher.Dispatch(mailboxID, email)
+	if h.dispatcher.Saturated(r.Context()) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": webhook.ErrQueueFull.Error()})
+		return
+	}
+
+	if err := h.dispatcher.Dispatch(mailboxID, email); err != nil {
+		log.Printf("Failed to retrigger webhooks for email %d: %v", email.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to queue webhooks"})
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jr-k/mailgress/internal/domain"
 	mw "github.com/jr-k/mailgress/internal/http/middleware"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/romsar/gonertia"
@@ -17,16 +19,18 @@ type UserHandler struct {
 	avatarService *service.AvatarService
 	totpService   *service.TOTPService
 	authService   *service.AuthService
+	tokenService  *service.APITokenService
 	flash         *mw.FlashMiddleware
 }
 
-func NewUserHandler(inertia *gonertia.Inertia, userService *service.UserService, avatarService *service.AvatarService, totpService *service.TOTPService, authService *service.AuthService, flash *mw.FlashMiddleware) *UserHandler {
+func NewUserHandler(inertia *gonertia.Inertia, userService *service.UserService, avatarService *service.AvatarService, totpService *service.TOTPService, authService *service.AuthService, tokenService *service.APITokenService, flash *mw.FlashMiddleware) *UserHandler {
 	return &UserHandler{
 		inertia:       inertia,
 		userService:   userService,
 		avatarService: avatarService,
 		totpService:   totpService,
 		authService:   authService,
+		tokenService:  tokenService,
 		flash:         flash,
 	}
 }
@@ -296,8 +300,15 @@ func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	apiTokens, _ := h.tokenService.ListByUser(r.Context(), user.ID)
+	if apiTokens == nil {
+		apiTokens = []*domain.APIToken{}
+	}
+
 	props := gonertia.Props{
-		"user": user,
+		"user":      user,
+		"apiTokens": apiTokens,
+		"apiScopes": domain.APIScopes,
 	}
 
 	// Include flash from context if present
@@ -308,6 +319,68 @@ func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
 	h.inertia.Render(w, r, "Profile/Index", props)
 }
 
+// CreateAPIToken returns the plaintext token in the response body; it is
+// not stored and cannot be shown again.
+func (h *UserHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	if req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Name is required"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	token, plaintext, err := h.tokenService.Create(r.Context(), user.ID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":     plaintext,
+		"api_token": token,
+	})
+}
+
+func (h *UserHandler) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := mw.GetUser(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Token not found"})
+		return
+	}
+
+	if err := h.tokenService.Delete(r.Context(), user.ID, id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to revoke token"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
 func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	user := mw.GetUser(r)
 	if user == nil {
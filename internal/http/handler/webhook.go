@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jr-k/mailgress/internal/domain"
 	mw "github.com/jr-k/mailgress/internal/http/middleware"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/jr-k/mailgress/internal/webhook"
@@ -17,6 +22,7 @@ type WebhookHandler struct {
 	webhookService  *service.WebhookService
 	deliveryService *service.DeliveryService
 	mailboxService  *service.MailboxService
+	emailService    *service.EmailService
 	domainService   *service.DomainService
 	dispatcher      *webhook.Dispatcher
 	flash           *mw.FlashMiddleware
@@ -27,6 +33,7 @@ func NewWebhookHandler(
 	webhookService *service.WebhookService,
 	deliveryService *service.DeliveryService,
 	mailboxService *service.MailboxService,
+	emailService *service.EmailService,
 	domainService *service.DomainService,
 	dispatcher *webhook.Dispatcher,
 	flash *mw.FlashMiddleware,
@@ -36,6 +43,7 @@ func NewWebhookHandler(
 		webhookService:  webhookService,
 		deliveryService: deliveryService,
 		mailboxService:  mailboxService,
+		emailService:    emailService,
 		domainService:   domainService,
 		dispatcher:      dispatcher,
 		flash:           flash,
@@ -134,8 +142,9 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	allMailboxes := h.getAllMailboxesWithDomain(r)
 
 	h.inertia.Render(w, r, "Webhooks/Create", gonertia.Props{
-		"mailbox":      mailbox,
-		"allMailboxes": allMailboxes,
+		"mailbox":       mailbox,
+		"allMailboxes":  allMailboxes,
+		"brokerDrivers": h.dispatcher.BrokerDrivers(),
 	})
 }
 
@@ -146,16 +155,36 @@ func (h *WebhookHandler) Store(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name               string            `json:"name"`
-		URL                string            `json:"url"`
-		Method             string            `json:"method"`
-		HMACSecret         string            `json:"hmac_secret"`
-		Headers            map[string]string `json:"headers"`
-		TimeoutSec         int               `json:"timeout_sec"`
-		MaxRetries         int               `json:"max_retries"`
-		IncludeBody        bool              `json:"include_body"`
-		IncludeAttachments bool              `json:"include_attachments"`
-		Rules              []struct {
+		Name                     string                     `json:"name"`
+		URL                      string                     `json:"url"`
+		Method                   string                     `json:"method"`
+		HMACSecret               string                     `json:"hmac_secret"`
+		Headers                  map[string]string          `json:"headers"`
+		PayloadType              string                     `json:"payload_type"`
+		CustomPayload            string                     `json:"custom_payload"`
+		TimeoutSec               int                        `json:"timeout_sec"`
+		MaxRetries               int                        `json:"max_retries"`
+		IncludeBody              bool                       `json:"include_body"`
+		IncludeAttachments       bool                       `json:"include_attachments"`
+		RawMode                  string                     `json:"raw_mode"`
+		AttachmentMode           string                     `json:"attachment_mode"`
+		AttachmentMaxInlineBytes int64                      `json:"attachment_max_inline_bytes"`
+		RetryBaseDelaySec        int                        `json:"retry_base_delay_sec"`
+		RetryMaxDelaySec         int                        `json:"retry_max_delay_sec"`
+		RetryJitterPercent       int                        `json:"retry_jitter_percent"`
+		Events                   []string                   `json:"events"`
+		Encoding                 string                     `json:"encoding"`
+		QueryFields              []string                   `json:"query_fields"`
+		SignEd25519              bool                       `json:"sign_ed25519"`
+		TLSClientCert            string                     `json:"tls_client_cert"`
+		TLSClientKey             string                     `json:"tls_client_key"`
+		TLSCABundle              string                     `json:"tls_ca_bundle"`
+		TLSSkipVerify            bool                       `json:"tls_skip_verify"`
+		ProxyURL                 string                     `json:"proxy_url"`
+		RedirectPolicy           string                     `json:"redirect_policy"`
+		Target                   string                     `json:"target"`
+		TargetConfig             domain.WebhookTargetConfig `json:"target_config"`
+		Rules                    []struct {
 			RuleGroup  int    `json:"rule_group"`
 			Field      string `json:"field"`
 			Operator   string `json:"operator"`
@@ -172,6 +201,33 @@ func (h *WebhookHandler) Store(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := webhook.ValidateBody(req.PayloadType, req.CustomPayload, req.Encoding, req.Events); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		h.inertia.Render(w, r, "Webhooks/Create", gonertia.Props{
+			"mailbox": mailbox,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.dispatcher.ValidateTarget(&domain.Webhook{URL: req.URL, Target: req.Target, TargetConfig: req.TargetConfig}); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		h.inertia.Render(w, r, "Webhooks/Create", gonertia.Props{
+			"mailbox": mailbox,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := webhook.ValidateTransport(req.TLSClientCert, req.TLSClientKey, req.TLSCABundle, req.ProxyURL); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		h.inertia.Render(w, r, "Webhooks/Create", gonertia.Props{
+			"mailbox": mailbox,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	if req.TimeoutSec == 0 {
 		req.TimeoutSec = 30
 	}
@@ -180,16 +236,36 @@ func (h *WebhookHandler) Store(w http.ResponseWriter, r *http.Request) {
 	}
 
 	wh, err := h.webhookService.Create(r.Context(), service.CreateWebhookParams{
-		MailboxID:          mailboxID,
-		Name:               req.Name,
-		URL:                req.URL,
-		Method:             req.Method,
-		Headers:            req.Headers,
-		HMACSecret:         req.HMACSecret,
-		TimeoutSec:         req.TimeoutSec,
-		MaxRetries:         req.MaxRetries,
-		IncludeBody:        req.IncludeBody,
-		IncludeAttachments: req.IncludeAttachments,
+		MailboxID:                mailboxID,
+		Name:                     req.Name,
+		URL:                      req.URL,
+		Method:                   req.Method,
+		Headers:                  req.Headers,
+		PayloadType:              req.PayloadType,
+		CustomPayload:            req.CustomPayload,
+		HMACSecret:               req.HMACSecret,
+		TimeoutSec:               req.TimeoutSec,
+		MaxRetries:               req.MaxRetries,
+		IncludeBody:              req.IncludeBody,
+		IncludeAttachments:       req.IncludeAttachments,
+		RawMode:                  req.RawMode,
+		AttachmentMode:           req.AttachmentMode,
+		AttachmentMaxInlineBytes: req.AttachmentMaxInlineBytes,
+		RetryBaseDelaySec:        req.RetryBaseDelaySec,
+		RetryMaxDelaySec:         req.RetryMaxDelaySec,
+		RetryJitterPercent:       req.RetryJitterPercent,
+		Events:                   req.Events,
+		Encoding:                 req.Encoding,
+		QueryFields:              req.QueryFields,
+		SignEd25519:              req.SignEd25519,
+		TLSClientCert:            req.TLSClientCert,
+		TLSClientKey:             req.TLSClientKey,
+		TLSCABundle:              req.TLSCABundle,
+		TLSSkipVerify:            req.TLSSkipVerify,
+		ProxyURL:                 req.ProxyURL,
+		RedirectPolicy:           req.RedirectPolicy,
+		Target:                   req.Target,
+		TargetConfig:             req.TargetConfig,
 	})
 	if err != nil {
 		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
@@ -238,11 +314,21 @@ func (h *WebhookHandler) Show(w http.ResponseWriter, r *http.Request) {
 
 	allMailboxes := h.getAllMailboxesWithDomain(r)
 
-	h.inertia.Render(w, r, "Webhooks/Show", gonertia.Props{
+	props := gonertia.Props{
 		"mailbox":      mailbox,
 		"allMailboxes": allMailboxes,
 		"webhook":      wh,
-	})
+		"nextProbeAt":  h.dispatcher.NextProbeAt(wh),
+	}
+	if wh.PayloadType == webhook.PayloadTypeSendGrid {
+		props["verificationKey"] = h.dispatcher.Keys().ECDSAPublicKey()
+	}
+	if wh.SignEd25519 {
+		props["ed25519PublicKey"] = base64.StdEncoding.EncodeToString(h.dispatcher.Keys().Ed25519PublicKey())
+	}
+	props["secretGraceHours"] = int(h.dispatcher.SecretGrace().Hours())
+
+	h.inertia.Render(w, r, "Webhooks/Show", props)
 }
 
 func (h *WebhookHandler) Edit(w http.ResponseWriter, r *http.Request) {
@@ -274,9 +360,10 @@ func (h *WebhookHandler) Edit(w http.ResponseWriter, r *http.Request) {
 	allMailboxes := h.getAllMailboxesWithDomain(r)
 
 	props := gonertia.Props{
-		"mailbox":      mailbox,
-		"allMailboxes": allMailboxes,
-		"webhook":      wh,
+		"mailbox":       mailbox,
+		"allMailboxes":  allMailboxes,
+		"webhook":       wh,
+		"brokerDrivers": h.dispatcher.BrokerDrivers(),
 	}
 
 	// Include flash from context if present
@@ -299,20 +386,46 @@ func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	current, err := h.webhookService.GetByID(r.Context(), webhookID)
+	if err != nil || current.MailboxID != mailboxID {
+		h.inertia.Render(w, r, "Errors/NotFound", nil)
+		return
+	}
+
 	var req struct {
-		Name               string            `json:"name"`
-		URL                string            `json:"url"`
-		Method             string            `json:"method"`
-		HMACSecret         string            `json:"hmac_secret"`
-		Headers            map[string]string `json:"headers"`
-		PayloadType        string            `json:"payload_type"`
-		CustomPayload      string            `json:"custom_payload"`
-		TimeoutSec         int               `json:"timeout_sec"`
-		MaxRetries         int               `json:"max_retries"`
-		IncludeBody        bool              `json:"include_body"`
-		IncludeAttachments bool              `json:"include_attachments"`
-		IsActive           bool              `json:"is_active"`
-		Rules              []struct {
+		Name                     string                     `json:"name"`
+		URL                      string                     `json:"url"`
+		Method                   string                     `json:"method"`
+		HMACSecret               string                     `json:"hmac_secret"`
+		ClearHMACSecret          bool                       `json:"clear_hmac_secret"`
+		Headers                  map[string]string          `json:"headers"`
+		PayloadType              string                     `json:"payload_type"`
+		CustomPayload            string                     `json:"custom_payload"`
+		TimeoutSec               int                        `json:"timeout_sec"`
+		MaxRetries               int                        `json:"max_retries"`
+		IncludeBody              bool                       `json:"include_body"`
+		IncludeAttachments       bool                       `json:"include_attachments"`
+		RawMode                  string                     `json:"raw_mode"`
+		AttachmentMode           string                     `json:"attachment_mode"`
+		AttachmentMaxInlineBytes int64                      `json:"attachment_max_inline_bytes"`
+		RetryBaseDelaySec        int                        `json:"retry_base_delay_sec"`
+		RetryMaxDelaySec         int                        `json:"retry_max_delay_sec"`
+		RetryJitterPercent       int                        `json:"retry_jitter_percent"`
+		Events                   []string                   `json:"events"`
+		Encoding                 string                     `json:"encoding"`
+		QueryFields              []string                   `json:"query_fields"`
+		SignEd25519              bool                       `json:"sign_ed25519"`
+		TLSClientCert            string                     `json:"tls_client_cert"`
+		TLSClientKey             string                     `json:"tls_client_key"`
+		ClearTLSClientKey        bool                       `json:"clear_tls_client_key"`
+		TLSCABundle              string                     `json:"tls_ca_bundle"`
+		TLSSkipVerify            bool                       `json:"tls_skip_verify"`
+		ProxyURL                 string                     `json:"proxy_url"`
+		RedirectPolicy           string                     `json:"redirect_policy"`
+		Target                   string                     `json:"target"`
+		TargetConfig             domain.WebhookTargetConfig `json:"target_config"`
+		IsActive                 bool                       `json:"is_active"`
+		Rules                    []struct {
 			RuleGroup  int    `json:"rule_group"`
 			Field      string `json:"field"`
 			Operator   string `json:"operator"`
@@ -324,21 +437,75 @@ func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
 		h.inertia.Render(w, r, "Errors/ServerError", nil)
 		return
 	}
+	keepSecrets(current, &req.HMACSecret, req.ClearHMACSecret, &req.TLSClientKey, req.ClearTLSClientKey)
+	req.ProxyURL = domain.RestoreURLCredentials(req.ProxyURL, current.ProxyURL)
+	req.TargetConfig.BrokerURL = domain.RestoreURLCredentials(req.TargetConfig.BrokerURL, current.TargetConfig.BrokerURL)
+
+	if err := webhook.ValidateBody(req.PayloadType, req.CustomPayload, req.Encoding, req.Events); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		wh, _ := h.webhookService.GetByID(r.Context(), webhookID)
+		h.inertia.Render(w, r, "Webhooks/Edit", gonertia.Props{
+			"mailbox": mailbox,
+			"webhook": wh,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.dispatcher.ValidateTarget(&domain.Webhook{URL: req.URL, Target: req.Target, TargetConfig: req.TargetConfig}); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		wh, _ := h.webhookService.GetByID(r.Context(), webhookID)
+		h.inertia.Render(w, r, "Webhooks/Edit", gonertia.Props{
+			"mailbox": mailbox,
+			"webhook": wh,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := webhook.ValidateTransport(req.TLSClientCert, req.TLSClientKey, req.TLSCABundle, req.ProxyURL); err != nil {
+		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+		wh, _ := h.webhookService.GetByID(r.Context(), webhookID)
+		h.inertia.Render(w, r, "Webhooks/Edit", gonertia.Props{
+			"mailbox": mailbox,
+			"webhook": wh,
+			"error":   err.Error(),
+		})
+		return
+	}
 
 	_, err = h.webhookService.Update(r.Context(), service.UpdateWebhookParams{
-		ID:                 webhookID,
-		Name:               req.Name,
-		URL:                req.URL,
-		Method:             req.Method,
-		Headers:            req.Headers,
-		PayloadType:        req.PayloadType,
-		CustomPayload:      req.CustomPayload,
-		HMACSecret:         req.HMACSecret,
-		TimeoutSec:         req.TimeoutSec,
-		MaxRetries:         req.MaxRetries,
-		IncludeBody:        req.IncludeBody,
-		IncludeAttachments: req.IncludeAttachments,
-		IsActive:           req.IsActive,
+		ID:                       webhookID,
+		Name:                     req.Name,
+		URL:                      req.URL,
+		Method:                   req.Method,
+		Headers:                  req.Headers,
+		PayloadType:              req.PayloadType,
+		CustomPayload:            req.CustomPayload,
+		HMACSecret:               req.HMACSecret,
+		TimeoutSec:               req.TimeoutSec,
+		MaxRetries:               req.MaxRetries,
+		IncludeBody:              req.IncludeBody,
+		IncludeAttachments:       req.IncludeAttachments,
+		RawMode:                  req.RawMode,
+		AttachmentMode:           req.AttachmentMode,
+		AttachmentMaxInlineBytes: req.AttachmentMaxInlineBytes,
+		RetryBaseDelaySec:        req.RetryBaseDelaySec,
+		RetryMaxDelaySec:         req.RetryMaxDelaySec,
+		RetryJitterPercent:       req.RetryJitterPercent,
+		Events:                   req.Events,
+		Encoding:                 req.Encoding,
+		QueryFields:              req.QueryFields,
+		SignEd25519:              req.SignEd25519,
+		TLSClientCert:            req.TLSClientCert,
+		TLSClientKey:             req.TLSClientKey,
+		TLSCABundle:              req.TLSCABundle,
+		TLSSkipVerify:            req.TLSSkipVerify,
+		ProxyURL:                 req.ProxyURL,
+		RedirectPolicy:           req.RedirectPolicy,
+		Target:                   req.Target,
+		TargetConfig:             req.TargetConfig,
+		IsActive:                 req.IsActive,
 	})
 	if err != nil {
 		mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
@@ -388,6 +555,84 @@ func (h *WebhookHandler) ToggleActive(w http.ResponseWriter, r *http.Request) {
 	h.inertia.Back(w, r)
 }
 
+// RotateSecret gives the webhook a new signing secret. The previous secret
+// keeps signing deliveries for grace_hours, the configured grace period by
+// default.
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	mailboxID, ok := h.checkMailboxAccess(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	wh, err := h.webhookService.GetByID(r.Context(), webhookID)
+	if err != nil || wh.MailboxID != mailboxID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		GraceHours *int `json:"grace_hours"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	grace := h.dispatcher.SecretGrace()
+	if req.GraceHours != nil {
+		grace = time.Duration(*req.GraceHours) * time.Hour
+	}
+
+	updated, err := h.webhookService.RotateSecret(r.Context(), webhookID, grace)
+	if err != nil {
+		h.flash.SetError(r, "Failed to rotate secret")
+		h.inertia.Back(w, r)
+		return
+	}
+
+	// Secrets are not sent to the browser with the webhook, so the new one
+	// is shown once here
+	message := "Secret rotated, the new secret is " + updated.HMACSecret
+	if wh.HMACSecret != "" && grace > 0 {
+		message += ". The previous secret stays valid until " + time.Now().Add(grace).UTC().Format("2006-01-02 15:04 UTC")
+	}
+	h.flash.SetSuccess(r, message)
+	h.inertia.Back(w, r)
+}
+
+// RevokeSecrets ends the grace period of rotated secrets, leaving only the
+// current secret.
+func (h *WebhookHandler) RevokeSecrets(w http.ResponseWriter, r *http.Request) {
+	mailboxID, ok := h.checkMailboxAccess(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	wh, err := h.webhookService.GetByID(r.Context(), webhookID)
+	if err != nil || wh.MailboxID != mailboxID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := h.webhookService.RevokePreviousSecrets(r.Context(), webhookID); err != nil {
+		h.flash.SetError(r, "Failed to revoke previous secrets")
+		h.inertia.Back(w, r)
+		return
+	}
+
+	h.flash.SetSuccess(r, "Previous secrets revoked")
+	h.inertia.Back(w, r)
+}
+
 func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	mailboxID, ok := h.checkMailboxAccess(w, r)
 	if !ok {
@@ -426,7 +671,12 @@ func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statusCode, response, err := webhook.TestWebhook(r.Context(), wh)
+	mailbox, _ := h.mailboxService.GetByID(r.Context(), mailboxID)
+	if mailbox != nil && mailbox.DomainID != nil {
+		mailbox.Domain, _ = h.domainService.GetByID(r.Context(), *mailbox.DomainID)
+	}
+
+	statusCode, response, err := h.dispatcher.TestWebhook(r.Context(), wh, mailbox)
 
 	result := map[string]interface{}{
 		"status_code": statusCode,
@@ -440,6 +690,101 @@ func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// Preview renders a body template against a stored email of the mailbox
+// without sending anything.
+func (h *WebhookHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	mailboxID, ok := h.checkMailboxAccess(w, r)
+	if !ok {
+		return
+	}
+
+	var req templatePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	mailbox, err := h.mailboxService.GetByID(r.Context(), mailboxID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	preview, err := previewTemplate(r.Context(), h.emailService, h.domainService, mailbox, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+type templatePreviewRequest struct {
+	Template string `json:"template"`
+	EmailID  *int64 `json:"email_id"`
+}
+
+type templatePreview struct {
+	Body    string `json:"body"`
+	EmailID *int64 `json:"email_id"`
+	Error   string `json:"error,omitempty"`
+}
+
+// previewTemplate renders a body template as an email.received delivery of
+// the requested email, or of the latest email of the mailbox when none is
+// given. Empty mailboxes fall back to the sample email used by tests.
+// Template errors are reported in the preview rather than returned.
+func previewTemplate(ctx context.Context, emailService *service.EmailService, domainService *service.DomainService, mailbox *domain.Mailbox, req *templatePreviewRequest) (*templatePreview, error) {
+	now := time.Now()
+
+	var email *domain.Email
+	if req.EmailID != nil {
+		found, err := emailService.GetByID(ctx, *req.EmailID)
+		if err != nil {
+			return nil, err
+		}
+		if found.MailboxID != mailbox.ID {
+			return nil, service.ErrEmailNotFound
+		}
+		email = found
+	} else {
+		latest, err := emailService.ListByMailbox(ctx, mailbox.ID, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 {
+			if email, err = emailService.GetByID(ctx, latest[0].ID); err != nil {
+				return nil, err
+			}
+		} else {
+			email = webhook.SampleEmail(now)
+		}
+	}
+
+	if mailbox.Domain == nil && mailbox.DomainID != nil {
+		mailbox.Domain, _ = domainService.GetByID(ctx, *mailbox.DomainID)
+	}
+
+	preview := &templatePreview{}
+	if email.ID != 0 {
+		preview.EmailID = &email.ID
+	}
+
+	body, err := webhook.RenderTemplate(req.Template, webhook.NewTemplateData(domain.Event{
+		Type:       domain.EventEmailReceived,
+		OccurredAt: now,
+		Email:      email,
+		Mailbox:    mailbox,
+	}))
+	if err != nil {
+		preview.Error = err.Error()
+		return preview, nil
+	}
+	preview.Body = string(body)
+	return preview, nil
+}
+
 func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
 	mailboxID, ok := h.checkMailboxAccess(w, r)
 	if !ok {
@@ -549,7 +894,16 @@ func (h *WebhookHandler) Retry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.dispatcher.ManualRetry(delivery.WebhookID, delivery.EmailID)
+	if err := h.dispatcher.ManualRetry(delivery); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, webhook.ErrQueueFull) {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "queued"})
@@ -573,7 +927,7 @@ func (h *WebhookHandler) CancelRetrying(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.deliveryService.CancelRetryingByWebhook(r.Context(), webhookID); err != nil {
+	if err := h.dispatcher.CancelRetries(r.Context(), webhookID); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jr-k/mailgress/internal/webhook"
+)
+
+// WellKnownHandler serves public documents receivers fetch without an
+// account.
+type WellKnownHandler struct {
+	dispatcher *webhook.Dispatcher
+}
+
+func NewWellKnownHandler(dispatcher *webhook.Dispatcher) *WellKnownHandler {
+	return &WellKnownHandler{
+		dispatcher: dispatcher,
+	}
+}
+
+// WebhookKeys publishes the public webhook signing keys as a JWK set, so
+// receivers can verify ed25519= signatures and pin the key.
+func (h *WellKnownHandler) WebhookKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": h.dispatcher.Keys().PublicJWKs(),
+	})
+}
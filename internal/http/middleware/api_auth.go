@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+const apiTokenContextKey contextKey = "api_token"
+
+// APIAuthMiddleware authenticates /api requests with a personal access token
+// sent as "Authorization: Bearer <token>". Session cookies are not accepted.
+type APIAuthMiddleware struct {
+	apiTokenService *service.APITokenService
+	userService     *service.UserService
+}
+
+func NewAPIAuthMiddleware(apiTokenService *service.APITokenService, userService *service.UserService) *APIAuthMiddleware {
+	return &APIAuthMiddleware{
+		apiTokenService: apiTokenService,
+		userService:     userService,
+	}
+}
+
+func (m *APIAuthMiddleware) RequireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		plaintext, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || plaintext == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mailgress"`)
+			writeAPIError(w, http.StatusUnauthorized, "Missing bearer token")
+			return
+		}
+
+		token, err := m.apiTokenService.Authenticate(r.Context(), strings.TrimSpace(plaintext))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mailgress", error="invalid_token"`)
+			writeAPIError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		user, err := m.userService.GetByID(r.Context(), token.UserID)
+		if err != nil {
+			writeAPIError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, apiTokenContextKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireScope rejects requests whose token was not granted scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := GetAPIToken(r)
+			if token == nil || !token.HasScope(scope) {
+				writeAPIError(w, http.StatusForbidden, "Token is missing the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAPIAdmin mirrors RequireAdmin for JSON endpoints.
+func RequireAPIAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUser(r)
+		if user == nil || !user.IsAdmin {
+			writeAPIError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func GetAPIToken(r *http.Request) *domain.APIToken {
+	token, ok := r.Context().Value(apiTokenContextKey).(*domain.APIToken)
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
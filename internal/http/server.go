@@ -10,6 +10,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/domain"
 	"github.com/jr-k/mailgress/internal/http/handler"
 	mw "github.com/jr-k/mailgress/internal/http/middleware"
 	"github.com/jr-k/mailgress/internal/service"
@@ -37,7 +38,9 @@ func NewServer(
 	deliveryService *service.DeliveryService,
 	domainService *service.DomainService,
 	tagService *service.TagService,
-	storage *storage.Storage,
+	apiTokenService *service.APITokenService,
+	storage storage.Storage,
+	urlSigner *service.URLSigner,
 	dispatcher *webhook.Dispatcher,
 ) (*Server, error) {
 	viteHelper := vite.New(vite.Config{
@@ -60,7 +63,7 @@ func NewServer(
 	onboardingMiddleware := mw.NewOnboardingMiddleware(settingsService)
 	authMiddleware := mw.NewAuthMiddleware(authService, userService, inertia, cfg.SafeMode)
 	flashMiddleware := mw.NewFlashMiddleware()
-
+	apiAuthMiddleware := mw.NewAPIAuthMiddleware(apiTokenService, userService)
 
 	dnsService := service.NewDNSService()
 	avatarService := service.NewAvatarService(cfg.StoragePath + "/avatars")
@@ -69,13 +72,15 @@ func NewServer(
 	onboardingHandler := handler.NewOnboardingHandler(inertia, settingsService, userService, domainService)
 	authHandler := handler.NewAuthHandler(inertia, authService, userService, totpService)
 	dashboardHandler := handler.NewDashboardHandler(inertia, mailboxService, emailService, domainService)
-	userHandler := handler.NewUserHandler(inertia, userService, avatarService, totpService, authService, flashMiddleware)
+	userHandler := handler.NewUserHandler(inertia, userService, avatarService, totpService, authService, apiTokenService, flashMiddleware)
 	mailboxHandler := handler.NewMailboxHandler(inertia, mailboxService, emailService, userService, domainService, tagService, flashMiddleware, dispatcher)
-	emailHandler := handler.NewEmailHandler(inertia, emailService, mailboxService, storage)
-	webhookHandler := handler.NewWebhookHandler(inertia, webhookService, deliveryService, mailboxService, domainService, dispatcher, flashMiddleware)
+	emailHandler := handler.NewEmailHandler(inertia, emailService, mailboxService, storage, urlSigner)
+	webhookHandler := handler.NewWebhookHandler(inertia, webhookService, deliveryService, mailboxService, emailService, domainService, dispatcher, flashMiddleware)
 	domainHandler := handler.NewDomainHandler(inertia, domainService, dnsService, tagService, mailboxService, flashMiddleware)
 	tagHandler := handler.NewTagHandler(inertia, tagService, flashMiddleware)
 	aboutHandler := handler.NewAboutHandler(inertia)
+	wellKnownHandler := handler.NewWellKnownHandler(dispatcher)
+	apiHandler := handler.NewAPIHandler(mailboxService, emailService, webhookService, deliveryService, domainService, tagService, storage, dispatcher)
 
 	r := chi.NewRouter()
 
@@ -102,6 +107,17 @@ func NewServer(
 	r.Get("/login/2fa", authHandler.Show2FA)
 	r.Post("/login/2fa", authHandler.Verify2FA)
 
+	// Signed links handed out in webhook payloads
+	r.Get("/signed/emails/{id}/raw", emailHandler.DownloadRawSigned)
+	r.Get("/signed/attachments/{id}/download", emailHandler.DownloadAttachmentSigned)
+
+	// Public keys for verifying webhook signatures
+	r.Get("/.well-known/mailgress-webhook-keys", wellKnownHandler.WebhookKeys)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		apiRoutes(r, apiAuthMiddleware, apiHandler)
+	})
+
 	r.Group(func(r chi.Router) {
 		r.Use(authMiddleware.RequireAuth)
 
@@ -122,6 +138,9 @@ func NewServer(
 		r.Get("/profile/2fa/backup-codes", userHandler.ShowBackupCodes)
 		r.Post("/profile/2fa/backup-codes/regenerate", userHandler.RegenerateBackupCodes)
 
+		r.Post("/profile/tokens", userHandler.CreateAPIToken)
+		r.Delete("/profile/tokens/{id}", userHandler.DeleteAPIToken)
+
 		r.Get("/settings/about", aboutHandler.Show)
 
 		r.Get("/mailboxes", mailboxHandler.Index)
@@ -133,16 +152,22 @@ func NewServer(
 		r.Post("/mailboxes/{id}/toggle", mailboxHandler.ToggleActive)
 		r.Delete("/mailboxes/{id}", mailboxHandler.Delete)
 		r.Put("/mailboxes/{id}/tags", mailboxHandler.SetTags)
+		r.Post("/mailboxes/{id}/aliases", mailboxHandler.StoreAlias)
+		r.Delete("/mailboxes/{id}/aliases/{aliasId}", mailboxHandler.DeleteAlias)
 		r.Post("/mailboxes/{id}/emails/{emailId}/read", mailboxHandler.MarkEmailAsRead)
 		r.Post("/mailboxes/{id}/emails/{emailId}/unread", mailboxHandler.MarkEmailAsUnread)
 		r.Post("/mailboxes/{id}/emails/{emailId}/retrigger-webhooks", mailboxHandler.RetriggerWebhooks)
 		r.Delete("/mailboxes/{id}/emails/{emailId}", mailboxHandler.DeleteEmail)
 
+		r.Get("/search", emailHandler.Search)
 		r.Get("/mailboxes/{mailboxId}/emails/{id}", emailHandler.Show)
+		r.Get("/mailboxes/{mailboxId}/emails/{id}/raw", emailHandler.DownloadRaw)
+		r.Get("/mailboxes/{mailboxId}/emails/{id}/attachments.zip", emailHandler.DownloadAttachments)
 		r.Get("/attachments/{id}/download", emailHandler.DownloadAttachment)
 
 		r.Get("/mailboxes/{mailboxId}/webhooks", webhookHandler.Index)
 		r.Get("/mailboxes/{mailboxId}/webhooks/create", webhookHandler.Create)
+		r.Post("/mailboxes/{mailboxId}/webhooks/preview", webhookHandler.Preview)
 		r.Post("/mailboxes/{mailboxId}/webhooks", webhookHandler.Store)
 		r.Get("/mailboxes/{mailboxId}/webhooks/{id}", webhookHandler.Show)
 		r.Get("/mailboxes/{mailboxId}/webhooks/{id}/edit", webhookHandler.Edit)
@@ -150,6 +175,8 @@ func NewServer(
 		r.Delete("/mailboxes/{mailboxId}/webhooks/{id}", webhookHandler.Delete)
 		r.Post("/mailboxes/{mailboxId}/webhooks/{id}/toggle", webhookHandler.ToggleActive)
 		r.Post("/mailboxes/{mailboxId}/webhooks/{id}/test", webhookHandler.Test)
+		r.Post("/mailboxes/{mailboxId}/webhooks/{id}/rotate-secret", webhookHandler.RotateSecret)
+		r.Delete("/mailboxes/{mailboxId}/webhooks/{id}/previous-secrets", webhookHandler.RevokeSecrets)
 		r.Get("/mailboxes/{mailboxId}/webhooks/{id}/deliveries", webhookHandler.Deliveries)
 		r.Post("/deliveries/{id}/retry", webhookHandler.Retry)
 		r.Post("/mailboxes/{mailboxId}/webhooks/{id}/deliveries/cancel-retrying", webhookHandler.CancelRetrying)
@@ -174,6 +201,11 @@ func NewServer(
 			r.Post("/domains", domainHandler.Store)
 			r.Get("/domains/{id}", domainHandler.Show)
 			r.Get("/domains/{id}/mailboxes", domainHandler.Mailboxes)
+			r.Get("/domains/{id}/routing", domainHandler.Routing)
+			r.Put("/domains/{id}/catch-all", domainHandler.SetCatchAll)
+			r.Post("/domains/{id}/routes", domainHandler.StoreRoute)
+			r.Put("/domains/{id}/routes/order", domainHandler.ReorderRoutes)
+			r.Delete("/domains/{id}/routes/{routeId}", domainHandler.DeleteRoute)
 			r.Get("/domains/{id}/edit", domainHandler.Edit)
 			r.Put("/domains/{id}", domainHandler.Update)
 			r.Delete("/domains/{id}", domainHandler.Delete)
@@ -205,6 +237,70 @@ func NewServer(
 	}, nil
 }
 
+// apiRoutes mounts the JSON API. Every route needs a token, and each group
+// the scope its token must carry.
+func apiRoutes(r chi.Router, auth *mw.APIAuthMiddleware, api *handler.APIHandler) {
+	r.Use(auth.RequireToken)
+
+	r.Get("/me", api.Me)
+
+	r.With(mw.RequireScope(domain.ScopeMailboxesRead)).Get("/mailboxes", api.ListMailboxes)
+	r.With(mw.RequireScope(domain.ScopeMailboxesRead)).Get("/mailboxes/{id}", api.GetMailbox)
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireScope(domain.ScopeMailboxesWrite), mw.RequireAPIAdmin)
+		r.Post("/mailboxes", api.CreateMailbox)
+		r.Patch("/mailboxes/{id}", api.UpdateMailbox)
+		r.Delete("/mailboxes/{id}", api.DeleteMailbox)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireScope(domain.ScopeEmailsRead))
+		r.Get("/emails/search", api.SearchEmails)
+		r.Get("/mailboxes/{id}/emails", api.ListEmails)
+		r.Get("/mailboxes/{id}/emails/{emailId}", api.GetEmail)
+		r.Get("/mailboxes/{id}/emails/{emailId}/raw", api.DownloadEmailRaw)
+		r.Get("/attachments/{id}", api.GetAttachment)
+		r.Get("/attachments/{id}/download", api.DownloadAttachment)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireScope(domain.ScopeEmailsWrite))
+		r.Delete("/mailboxes/{id}/emails/{emailId}", api.DeleteEmail)
+		r.Post("/mailboxes/{id}/emails/{emailId}/read", api.MarkEmailAsRead)
+		r.Post("/mailboxes/{id}/emails/{emailId}/unread", api.MarkEmailAsUnread)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireScope(domain.ScopeWebhooksRead))
+		r.Get("/mailboxes/{id}/webhooks", api.ListWebhooks)
+		r.Get("/webhooks/{id}", api.GetWebhook)
+		r.Get("/webhooks/{id}/deliveries", api.ListDeliveries)
+		r.Post("/mailboxes/{id}/webhooks/preview", api.PreviewWebhookTemplate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireScope(domain.ScopeWebhooksWrite))
+		r.Post("/mailboxes/{id}/webhooks", api.CreateWebhook)
+		r.Put("/webhooks/{id}", api.UpdateWebhook)
+		r.Delete("/webhooks/{id}", api.DeleteWebhook)
+		r.Post("/webhooks/{id}/rotate-secret", api.RotateWebhookSecret)
+		r.Post("/deliveries/{id}/retry", api.RetryDelivery)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(mw.RequireAPIAdmin)
+
+		r.With(mw.RequireScope(domain.ScopeDomainsRead)).Get("/domains", api.ListDomains)
+		r.With(mw.RequireScope(domain.ScopeDomainsRead)).Get("/domains/{id}", api.GetDomain)
+		r.With(mw.RequireScope(domain.ScopeDomainsWrite)).Post("/domains", api.CreateDomain)
+		r.With(mw.RequireScope(domain.ScopeDomainsWrite)).Patch("/domains/{id}", api.UpdateDomain)
+		r.With(mw.RequireScope(domain.ScopeDomainsWrite)).Delete("/domains/{id}", api.DeleteDomain)
+
+		r.With(mw.RequireScope(domain.ScopeTagsRead)).Get("/tags", api.ListTags)
+		r.With(mw.RequireScope(domain.ScopeTagsWrite)).Post("/tags", api.CreateTag)
+		r.With(mw.RequireScope(domain.ScopeTagsWrite)).Patch("/tags/{id}", api.UpdateTag)
+		r.With(mw.RequireScope(domain.ScopeTagsWrite)).Delete("/tags/{id}", api.DeleteTag)
+	})
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	log.Printf("Starting HTTP server on %s", s.config.HTTPListenAddr)
 
@@ -0,0 +1,494 @@
+package mailauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// dkimMaxSignatures bounds the work done for messages carrying many
+// DKIM-Signature fields.
+const dkimMaxSignatures = 5
+
+// DKIMResult is the outcome of verifying one DKIM-Signature field.
+type DKIMResult struct {
+	Result   string
+	Domain   string
+	Selector string
+	Reason   string
+}
+
+type dkimError struct {
+	result string
+	reason string
+}
+
+func (e *dkimError) Error() string {
+	return e.reason
+}
+
+func dkimPermError(reason string) error {
+	return &dkimError{result: domain.AuthResultPermError, reason: reason}
+}
+
+func dkimFail(reason string) error {
+	return &dkimError{result: domain.AuthResultFail, reason: reason}
+}
+
+type headerField struct {
+	name string
+	raw  string // the complete field including folding and the final CRLF
+}
+
+type dkimSignature struct {
+	algorithm   string
+	hash        crypto.Hash
+	keyType     string
+	signature   []byte
+	bodyHash    []byte
+	domain      string
+	selector    string
+	headers     []string
+	headerCanon string
+	bodyCanon   string
+	length      int64
+	expires     int64
+	raw         string
+}
+
+// VerifyDKIM checks every DKIM-Signature field of a message. It returns
+// one result per signature, in header order.
+func VerifyDKIM(ctx context.Context, resolver Resolver, raw []byte) []DKIMResult {
+	header, body := splitMessage(raw)
+	fields := parseHeaderFields(header)
+
+	var results []DKIMResult
+	for _, field := range fields {
+		if !strings.EqualFold(field.name, "DKIM-Signature") {
+			continue
+		}
+		if len(results) == dkimMaxSignatures {
+			break
+		}
+		results = append(results, verifySignature(ctx, resolver, field, fields, body))
+	}
+	return results
+}
+
+func verifySignature(ctx context.Context, resolver Resolver, field headerField, fields []headerField, body []byte) DKIMResult {
+	sig, err := parseDKIMSignature(field.raw)
+	if err != nil {
+		return dkimErrorResult(sig, err)
+	}
+	result := DKIMResult{Domain: sig.domain, Selector: sig.selector}
+
+	if sig.expires > 0 && time.Now().Unix() > sig.expires {
+		result.Result = domain.AuthResultFail
+		result.Reason = "signature expired"
+		return result
+	}
+
+	bodyHash := canonicalBodyHash(body, sig)
+	if !bytes.Equal(bodyHash, sig.bodyHash) {
+		result.Result = domain.AuthResultFail
+		result.Reason = "body hash did not verify"
+		return result
+	}
+
+	pub, err := lookupDKIMKey(ctx, resolver, sig)
+	if err != nil {
+		return dkimErrorResult(sig, err)
+	}
+
+	h := sig.hash.New()
+	h.Write(canonicalHeaders(fields, sig))
+	digest := h.Sum(nil)
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, sig.hash, digest, sig.signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest, sig.signature) {
+			err = errors.New("bad signature")
+		}
+	default:
+		err = errors.New("unsupported key type")
+	}
+	if err != nil {
+		result.Result = domain.AuthResultFail
+		result.Reason = "signature did not verify"
+		return result
+	}
+
+	result.Result = domain.AuthResultPass
+	return result
+}
+
+func dkimErrorResult(sig *dkimSignature, err error) DKIMResult {
+	result := DKIMResult{Result: domain.AuthResultPermError, Reason: err.Error()}
+	if sig != nil {
+		result.Domain = sig.domain
+		result.Selector = sig.selector
+	}
+	var dErr *dkimError
+	if errors.As(err, &dErr) {
+		result.Result = dErr.result
+	}
+	return result
+}
+
+func parseDKIMSignature(raw string) (*dkimSignature, error) {
+	value := raw[strings.IndexByte(raw, ':')+1:]
+	tags, err := parseTagList(value)
+	if err != nil {
+		return nil, dkimPermError("malformed signature")
+	}
+
+	sig := &dkimSignature{
+		domain:      strings.ToLower(tags["d"]),
+		selector:    tags["s"],
+		headerCanon: "simple",
+		bodyCanon:   "simple",
+		length:      -1,
+		raw:         raw,
+	}
+
+	if tags["v"] != "1" {
+		return sig, dkimPermError("unsupported version")
+	}
+	for _, required := range []string{"a", "b", "bh", "d", "h", "s"} {
+		if tags[required] == "" {
+			return sig, dkimPermError("missing " + required + "= tag")
+		}
+	}
+
+	sig.algorithm = strings.ToLower(tags["a"])
+	switch sig.algorithm {
+	case "rsa-sha256":
+		sig.keyType, sig.hash = "rsa", crypto.SHA256
+	case "rsa-sha1":
+		sig.keyType, sig.hash = "rsa", crypto.SHA1
+	case "ed25519-sha256":
+		sig.keyType, sig.hash = "ed25519", crypto.SHA256
+	default:
+		return sig, dkimPermError("unsupported algorithm")
+	}
+
+	if sig.signature, err = base64.StdEncoding.DecodeString(stripWhitespace(tags["b"])); err != nil {
+		return sig, dkimPermError("malformed b= tag")
+	}
+	if sig.bodyHash, err = base64.StdEncoding.DecodeString(stripWhitespace(tags["bh"])); err != nil {
+		return sig, dkimPermError("malformed bh= tag")
+	}
+
+	if c := strings.ToLower(tags["c"]); c != "" {
+		parts := strings.SplitN(c, "/", 2)
+		sig.headerCanon = parts[0]
+		if len(parts) == 2 {
+			sig.bodyCanon = parts[1]
+		}
+		for _, canon := range []string{sig.headerCanon, sig.bodyCanon} {
+			if canon != "simple" && canon != "relaxed" {
+				return sig, dkimPermError("unsupported canonicalization")
+			}
+		}
+	}
+
+	hasFrom := false
+	for _, name := range strings.Split(tags["h"], ":") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.EqualFold(name, "From") {
+			hasFrom = true
+		}
+		sig.headers = append(sig.headers, name)
+	}
+	if !hasFrom {
+		return sig, dkimPermError("From field not signed")
+	}
+
+	if l := tags["l"]; l != "" {
+		if sig.length, err = strconv.ParseInt(l, 10, 64); err != nil || sig.length < 0 {
+			return sig, dkimPermError("malformed l= tag")
+		}
+	}
+	if x := tags["x"]; x != "" {
+		if sig.expires, err = strconv.ParseInt(x, 10, 64); err != nil {
+			return sig, dkimPermError("malformed x= tag")
+		}
+	}
+
+	if i := tags["i"]; i != "" {
+		at := strings.LastIndexByte(i, '@')
+		identity := strings.ToLower(i[at+1:])
+		if identity != sig.domain && !strings.HasSuffix(identity, "."+sig.domain) {
+			return sig, dkimPermError("i= not within d=")
+		}
+	}
+
+	return sig, nil
+}
+
+func lookupDKIMKey(ctx context.Context, resolver Resolver, sig *dkimSignature) (crypto.PublicKey, error) {
+	txts, err := resolver.LookupTXT(ctx, sig.selector+"._domainkey."+sig.domain)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, dkimPermError("no key for signature")
+		}
+		return nil, &dkimError{result: domain.AuthResultTempError, reason: "key unavailable"}
+	}
+	if len(txts) == 0 {
+		return nil, dkimPermError("no key for signature")
+	}
+
+	tags, err := parseTagList(txts[0])
+	if err != nil {
+		return nil, dkimPermError("malformed key record")
+	}
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		return nil, dkimPermError("unsupported key version")
+	}
+	if h := tags["h"]; h != "" {
+		hashName := "sha256"
+		if sig.hash == crypto.SHA1 {
+			hashName = "sha1"
+		}
+		acceptable := false
+		for _, name := range strings.Split(h, ":") {
+			if strings.TrimSpace(name) == hashName {
+				acceptable = true
+			}
+		}
+		if !acceptable {
+			return nil, dkimFail("hash algorithm not allowed by key")
+		}
+	}
+
+	keyType := tags["k"]
+	if keyType == "" {
+		keyType = "rsa"
+	}
+	if keyType != sig.keyType {
+		return nil, dkimPermError("key type mismatch")
+	}
+
+	p := stripWhitespace(tags["p"])
+	if p == "" {
+		return nil, dkimFail("key revoked")
+	}
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, dkimPermError("malformed public key")
+	}
+
+	if keyType == "ed25519" {
+		if len(der) != ed25519.PublicKeySize {
+			return nil, dkimPermError("malformed public key")
+		}
+		return ed25519.PublicKey(der), nil
+	}
+
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := pub.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, dkimPermError("key type mismatch")
+	}
+	if rsaKey, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return rsaKey, nil
+	}
+	return nil, dkimPermError("malformed public key")
+}
+
+func canonicalBodyHash(body []byte, sig *dkimSignature) []byte {
+	var canonical []byte
+	if sig.bodyCanon == "relaxed" {
+		canonical = relaxedBody(body)
+	} else {
+		canonical = simpleBody(body)
+	}
+	if sig.length >= 0 && sig.length < int64(len(canonical)) {
+		canonical = canonical[:sig.length]
+	}
+
+	h := sig.hash.New()
+	h.Write(canonical)
+	return h.Sum(nil)
+}
+
+func simpleBody(body []byte) []byte {
+	for bytes.HasSuffix(body, []byte("\r\n\r\n")) {
+		body = body[:len(body)-2]
+	}
+	if len(body) == 0 || !bytes.HasSuffix(body, []byte("\r\n")) {
+		body = append(append([]byte{}, body...), '\r', '\n')
+	}
+	return body
+}
+
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	var b strings.Builder
+	for _, line := range lines {
+		line = collapseWhitespace(line)
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteString("\r\n")
+	}
+
+	out := []byte(b.String())
+	for bytes.HasSuffix(out, []byte("\r\n\r\n")) {
+		out = out[:len(out)-2]
+	}
+	if bytes.Equal(out, []byte("\r\n")) {
+		return nil
+	}
+	return out
+}
+
+// canonicalHeaders builds the data covered by the header hash: the signed
+// fields, bottom-up per name, followed by the signature field with an
+// empty b= value and no trailing CRLF.
+func canonicalHeaders(fields []headerField, sig *dkimSignature) []byte {
+	used := make(map[int]bool)
+	var b strings.Builder
+
+	for _, name := range sig.headers {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if used[i] || !strings.EqualFold(fields[i].name, name) {
+				continue
+			}
+			used[i] = true
+			b.WriteString(canonicalHeader(fields[i].raw, sig.headerCanon))
+			break
+		}
+	}
+
+	self := canonicalHeader(stripSignatureValue(sig.raw), sig.headerCanon)
+	b.WriteString(strings.TrimSuffix(self, "\r\n"))
+	return []byte(b.String())
+}
+
+func canonicalHeader(raw, canon string) string {
+	if canon != "relaxed" {
+		return raw
+	}
+	colon := strings.IndexByte(raw, ':')
+	name := strings.ToLower(strings.TrimRight(raw[:colon], " \t"))
+	value := strings.ReplaceAll(raw[colon+1:], "\r\n", "")
+	value = strings.Trim(collapseWhitespace(value), " ")
+	return name + ":" + value + "\r\n"
+}
+
+// stripSignatureValue empties the b= tag of a DKIM-Signature field while
+// keeping every other tag byte for byte.
+func stripSignatureValue(raw string) string {
+	colon := strings.IndexByte(raw, ':')
+
+	offset := colon + 1
+	for _, tag := range strings.Split(raw[colon+1:], ";") {
+		eq := strings.IndexByte(tag, '=')
+		if eq >= 0 && strings.TrimSpace(tag[:eq]) == "b" {
+			return raw[:offset+eq+1] + raw[offset+len(tag):]
+		}
+		offset += len(tag) + 1
+	}
+	return raw
+}
+
+func parseTagList(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		eq := strings.IndexByte(part, '=')
+		if eq <= 0 {
+			return nil, errors.New("malformed tag list")
+		}
+		name := strings.TrimSpace(part[:eq])
+		if _, dup := tags[name]; dup {
+			return nil, errors.New("duplicate tag")
+		}
+		tags[name] = strings.TrimSpace(part[eq+1:])
+	}
+	return tags, nil
+}
+
+// splitMessage separates the header block (with its final CRLF) from the
+// body. Bare LF line endings are normalised to CRLF first.
+func splitMessage(raw []byte) ([]byte, []byte) {
+	if !bytes.Contains(raw, []byte("\r\n")) {
+		raw = bytes.ReplaceAll(raw, []byte("\n"), []byte("\r\n"))
+	}
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		return raw[:i+2], raw[i+4:]
+	}
+	return raw, nil
+}
+
+func parseHeaderFields(header []byte) []headerField {
+	var fields []headerField
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].raw += line
+			continue
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon <= 0 {
+			continue
+		}
+		fields = append(fields, headerField{
+			name: strings.TrimSpace(line[:colon]),
+			raw:  line,
+		})
+	}
+	return fields
+}
+
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func stripWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,128 @@
+package mailauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+type dmarcRecord struct {
+	policy          string
+	subdomainPolicy string
+	strictDKIM      bool
+	strictSPF       bool
+}
+
+// CheckDMARC evaluates the DMARC policy of fromDomain given the SPF result
+// for spfDomain and the DKIM signature results. It returns the result and
+// the policy the domain owner published.
+func CheckDMARC(ctx context.Context, resolver Resolver, fromDomain, spfResult, spfDomain string, dkim []DKIMResult) (string, string) {
+	if fromDomain == "" {
+		return domain.AuthResultNone, ""
+	}
+	orgDomain := OrganizationalDomain(fromDomain)
+
+	record, err := lookupDMARC(ctx, resolver, "_dmarc."+fromDomain)
+	if err == nil && record == nil && orgDomain != fromDomain {
+		record, err = lookupDMARC(ctx, resolver, "_dmarc."+orgDomain)
+	}
+	if err != nil {
+		return domain.AuthResultTempError, ""
+	}
+	if record == nil {
+		return domain.AuthResultNone, ""
+	}
+
+	policy := record.policy
+	if fromDomain != orgDomain && record.subdomainPolicy != "" {
+		policy = record.subdomainPolicy
+	}
+
+	if spfResult == domain.AuthResultPass && aligned(fromDomain, spfDomain, record.strictSPF) {
+		return domain.AuthResultPass, policy
+	}
+	for _, sig := range dkim {
+		if sig.Result == domain.AuthResultPass && aligned(fromDomain, sig.Domain, record.strictDKIM) {
+			return domain.AuthResultPass, policy
+		}
+	}
+
+	return domain.AuthResultFail, policy
+}
+
+// lookupDMARC returns nil without error when no usable record exists.
+func lookupDMARC(ctx context.Context, resolver Resolver, name string) (*dmarcRecord, error) {
+	txts, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, txt := range txts {
+		if !strings.HasPrefix(strings.TrimSpace(txt), "v=DMARC1") {
+			continue
+		}
+		tags, err := parseTagList(txt)
+		if err != nil {
+			continue
+		}
+
+		record := &dmarcRecord{
+			policy:          strings.ToLower(tags["p"]),
+			subdomainPolicy: strings.ToLower(tags["sp"]),
+			strictDKIM:      strings.ToLower(tags["adkim"]) == "s",
+			strictSPF:       strings.ToLower(tags["aspf"]) == "s",
+		}
+		switch record.policy {
+		case "none", "quarantine", "reject":
+		default:
+			// RFC 7489 6.6.3: an invalid p= with a valid rua= is treated as
+			// p=none; without it the record is ignored.
+			if tags["rua"] == "" {
+				continue
+			}
+			record.policy = "none"
+		}
+		return record, nil
+	}
+	return nil, nil
+}
+
+func aligned(fromDomain, authDomain string, strict bool) bool {
+	authDomain = strings.ToLower(strings.TrimSuffix(authDomain, "."))
+	if authDomain == "" {
+		return false
+	}
+	if strict {
+		return authDomain == fromDomain
+	}
+	return OrganizationalDomain(authDomain) == OrganizationalDomain(fromDomain)
+}
+
+// secondLevelLabels are common registry second-level labels under
+// two-letter country TLDs (co.uk, com.au, ...).
+var secondLevelLabels = map[string]bool{
+	"ac": true, "co": true, "com": true, "edu": true, "gov": true,
+	"net": true, "org": true, "ne": true, "or": true, "go": true,
+}
+
+// OrganizationalDomain approximates the registrable domain of name. Without
+// a full public suffix list it keeps the last two labels, or three when the
+// name sits under a country-code second-level registry such as co.uk.
+func OrganizationalDomain(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	labels := strings.Split(name, ".")
+	if len(labels) <= 2 {
+		return name
+	}
+
+	keep := 2
+	tld := labels[len(labels)-1]
+	if len(tld) == 2 && secondLevelLabels[labels[len(labels)-2]] {
+		keep = 3
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
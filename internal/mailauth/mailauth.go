@@ -0,0 +1,167 @@
+// Package mailauth verifies the sender of inbound mail with SPF (RFC 7208),
+// DKIM (RFC 6376, RFC 8463) and DMARC (RFC 7489), and renders the outcome
+// as an Authentication-Results header (RFC 8601).
+package mailauth
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/mail"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+type Verifier struct {
+	resolver   Resolver
+	authServID string
+}
+
+// NewVerifier returns a Verifier that performs its DNS lookups through
+// resolver and identifies itself as authServID in Authentication-Results.
+func NewVerifier(resolver Resolver, authServID string) *Verifier {
+	return &Verifier{
+		resolver:   resolver,
+		authServID: authServID,
+	}
+}
+
+type Result struct {
+	domain.EmailAuth
+	FromDomain  string
+	DKIMResults []DKIMResult
+}
+
+// Verify runs all three checks for a message received from ip. helo is the
+// EHLO/HELO name and mailFrom the SMTP envelope sender.
+func (v *Verifier) Verify(ctx context.Context, ip net.IP, helo, mailFrom string, raw []byte) *Result {
+	res := &Result{}
+
+	res.SPF, res.SPFDomain = CheckSPF(ctx, v.resolver, ip, helo, mailFrom)
+
+	res.DKIMResults = VerifyDKIM(ctx, v.resolver, raw)
+	res.DKIM = domain.AuthResultNone
+	res.FromDomain = headerFromDomain(raw)
+	for i, sig := range res.DKIMResults {
+		// Report the first passing signature, preferring one that is
+		// aligned with the From domain; otherwise the first signature.
+		better := i == 0 ||
+			(sig.Result == domain.AuthResultPass && res.DKIM != domain.AuthResultPass) ||
+			(sig.Result == domain.AuthResultPass && sig.Domain == res.FromDomain && res.DKIMDomain != res.FromDomain)
+		if better {
+			res.DKIM = sig.Result
+			res.DKIMDomain = sig.Domain
+		}
+	}
+
+	res.DMARC, res.DMARCPolicy = CheckDMARC(ctx, v.resolver, res.FromDomain, res.SPF, res.SPFDomain, res.DKIMResults)
+
+	return res
+}
+
+// Header renders the value of an Authentication-Results header field.
+func (v *Verifier) Header(res *Result) string {
+	var b strings.Builder
+	b.WriteString(v.authServID)
+
+	b.WriteString("; spf=")
+	b.WriteString(res.SPF)
+	if res.SPFDomain != "" {
+		b.WriteString(" smtp.mailfrom=")
+		b.WriteString(res.SPFDomain)
+	}
+
+	if len(res.DKIMResults) == 0 {
+		b.WriteString("; dkim=none")
+	}
+	for _, sig := range res.DKIMResults {
+		b.WriteString("; dkim=")
+		b.WriteString(sig.Result)
+		if sig.Reason != "" {
+			b.WriteString(" (")
+			b.WriteString(sig.Reason)
+			b.WriteString(")")
+		}
+		if sig.Domain != "" {
+			b.WriteString(" header.d=")
+			b.WriteString(sig.Domain)
+		}
+		if sig.Selector != "" {
+			b.WriteString(" header.s=")
+			b.WriteString(sig.Selector)
+		}
+	}
+
+	b.WriteString("; dmarc=")
+	b.WriteString(res.DMARC)
+	if res.DMARCPolicy != "" {
+		b.WriteString(" (p=")
+		b.WriteString(res.DMARCPolicy)
+		b.WriteString(")")
+	}
+	if res.FromDomain != "" {
+		b.WriteString(" header.from=")
+		b.WriteString(res.FromDomain)
+	}
+
+	return b.String()
+}
+
+// StripForged removes the Authentication-Results fields that claim to come
+// from our authserv-id from the header of raw. Upstream hops can add them to
+// pass off unverified mail as checked (RFC 8601 5). Fields of other hosts
+// are kept.
+func (v *Verifier) StripForged(raw []byte) []byte {
+	end := bytes.Index(raw, []byte("\r\n\r\n"))
+	if lf := bytes.Index(raw, []byte("\n\n")); lf >= 0 && (end < 0 || lf < end) {
+		end = lf
+	}
+	if end < 0 {
+		end = len(raw)
+	}
+
+	out := make([]byte, 0, len(raw))
+	dropping := false
+	for _, line := range bytes.SplitAfter(raw[:end], []byte("\n")) {
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			if !dropping {
+				out = append(out, line...)
+			}
+			continue
+		}
+		dropping = false
+		name, value, ok := strings.Cut(string(line), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Authentication-Results") && v.claimsOurID(value) {
+			dropping = true
+			continue
+		}
+		out = append(out, line...)
+	}
+	return append(out, raw[end:]...)
+}
+
+// claimsOurID reports whether an Authentication-Results value starts with
+// our authserv-id, optionally followed by a version.
+func (v *Verifier) claimsOurID(value string) bool {
+	id, _, _ := strings.Cut(value, ";")
+	fields := strings.Fields(id)
+	return len(fields) > 0 && strings.EqualFold(fields[0], v.authServID)
+}
+
+// headerFromDomain returns the domain of the RFC 5322 From address.
+func headerFromDomain(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	addrs, err := msg.Header.AddressList("From")
+	if err != nil || len(addrs) == 0 {
+		return ""
+	}
+	at := strings.LastIndexByte(addrs[0].Address, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(addrs[0].Address[at+1:])
+}
@@ -0,0 +1,332 @@
+package mailauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// fakeResolver answers from static maps. Names listed in failing return a
+// temporary DNS error, unknown names NXDOMAIN.
+type fakeResolver struct {
+	txt     map[string][]string
+	ip      map[string][]net.IPAddr
+	mx      map[string][]*net.MX
+	failing map[string]bool
+}
+
+func (r *fakeResolver) lookup(name string) error {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if r.failing[name] {
+		return &net.DNSError{Err: "timeout", Name: name, IsTimeout: true, IsTemporary: true}
+	}
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txts, ok := r.txt[strings.TrimSuffix(strings.ToLower(name), ".")]; ok {
+		return txts, nil
+	}
+	return nil, r.lookup(name)
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if addrs, ok := r.ip[strings.TrimSuffix(strings.ToLower(host), ".")]; ok {
+		return addrs, nil
+	}
+	return nil, r.lookup(host)
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if mxs, ok := r.mx[strings.TrimSuffix(strings.ToLower(name), ".")]; ok {
+		return mxs, nil
+	}
+	return nil, r.lookup(name)
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return nil, r.lookup(addr)
+}
+
+func TestCheckSPF(t *testing.T) {
+	resolver := &fakeResolver{
+		txt: map[string][]string{
+			"pass.example":      {"v=spf1 ip4:192.0.2.0/24 -all"},
+			"fail.example":      {"v=spf1 ip4:198.51.100.1 -all"},
+			"softfail.example":  {"v=spf1 ip4:198.51.100.1 ~all"},
+			"neutral.example":   {"v=spf1 ?all"},
+			"include.example":   {"v=spf1 include:pass.example -all"},
+			"redirect.example":  {"v=spf1 redirect=pass.example"},
+			"a.example":         {"v=spf1 a -all"},
+			"mx.example":        {"v=spf1 mx -all"},
+			"twice.example":     {"v=spf1 -all", "v=spf1 +all"},
+			"unrelated.example": {"google-site-verification=abc"},
+			"broken.example":    {"v=spf1 include:down.example -all"},
+		},
+		ip: map[string][]net.IPAddr{
+			"a.example":       {{IP: net.ParseIP("192.0.2.10")}},
+			"mail.mx.example": {{IP: net.ParseIP("192.0.2.10")}},
+		},
+		mx: map[string][]*net.MX{
+			"mx.example": {{Host: "mail.mx.example.", Pref: 10}},
+		},
+		failing: map[string]bool{
+			"down.example":   true,
+			"outage.example": true,
+		},
+	}
+	ip := net.ParseIP("192.0.2.10")
+
+	tests := []struct {
+		name     string
+		mailFrom string
+		want     string
+	}{
+		{"pass", "alice@pass.example", domain.AuthResultPass},
+		{"fail", "alice@fail.example", domain.AuthResultFail},
+		{"softfail", "alice@softfail.example", domain.AuthResultSoftFail},
+		{"neutral", "alice@neutral.example", domain.AuthResultNeutral},
+		{"none without record", "alice@nowhere.example", domain.AuthResultNone},
+		{"none without spf record", "alice@unrelated.example", domain.AuthResultNone},
+		{"temperror", "alice@outage.example", domain.AuthResultTempError},
+		{"temperror in include", "alice@broken.example", domain.AuthResultTempError},
+		{"permerror for two records", "alice@twice.example", domain.AuthResultPermError},
+		{"include", "alice@include.example", domain.AuthResultPass},
+		{"redirect", "alice@redirect.example", domain.AuthResultPass},
+		{"a mechanism", "alice@a.example", domain.AuthResultPass},
+		{"mx mechanism", "alice@mx.example", domain.AuthResultPass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := CheckSPF(context.Background(), resolver, ip, "mx.sender.example", tt.mailFrom)
+			if got != tt.want {
+				t.Errorf("CheckSPF(%s) = %s, want %s", tt.mailFrom, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("null sender checks helo", func(t *testing.T) {
+		got, checked := CheckSPF(context.Background(), resolver, ip, "pass.example", "<>")
+		if got != domain.AuthResultPass || checked != "pass.example" {
+			t.Errorf("CheckSPF(<>) = %s for %s, want pass for pass.example", got, checked)
+		}
+	})
+}
+
+func TestCheckDMARC(t *testing.T) {
+	resolver := &fakeResolver{
+		txt: map[string][]string{
+			"_dmarc.example.com":   {"v=DMARC1; p=reject; sp=quarantine"},
+			"_dmarc.strict.com":    {"v=DMARC1; p=reject; adkim=s; aspf=s"},
+			"_dmarc.example.co.uk": {"v=DMARC1; p=none"},
+		},
+		failing: map[string]bool{"_dmarc.outage.com": true},
+	}
+	pass := func(d string) []DKIMResult {
+		return []DKIMResult{{Result: domain.AuthResultPass, Domain: d}}
+	}
+
+	tests := []struct {
+		name       string
+		from       string
+		spf        string
+		spfDomain  string
+		dkim       []DKIMResult
+		want       string
+		wantPolicy string
+	}{
+		{"aligned spf", "example.com", domain.AuthResultPass, "example.com", nil, domain.AuthResultPass, "reject"},
+		{"relaxed spf on subdomain", "example.com", domain.AuthResultPass, "bounce.example.com", nil, domain.AuthResultPass, "reject"},
+		{"unaligned spf", "example.com", domain.AuthResultPass, "other.com", nil, domain.AuthResultFail, "reject"},
+		{"failed spf", "example.com", domain.AuthResultFail, "example.com", nil, domain.AuthResultFail, "reject"},
+		{"aligned dkim", "example.com", domain.AuthResultFail, "other.com", pass("example.com"), domain.AuthResultPass, "reject"},
+		{"relaxed dkim on subdomain", "example.com", domain.AuthResultNone, "", pass("mail.example.com"), domain.AuthResultPass, "reject"},
+		{"unaligned dkim", "example.com", domain.AuthResultNone, "", pass("other.com"), domain.AuthResultFail, "reject"},
+		{"failed dkim", "example.com", domain.AuthResultNone, "", []DKIMResult{{Result: domain.AuthResultFail, Domain: "example.com"}}, domain.AuthResultFail, "reject"},
+		{"strict spf on subdomain", "strict.com", domain.AuthResultPass, "bounce.strict.com", nil, domain.AuthResultFail, "reject"},
+		{"strict dkim on subdomain", "strict.com", domain.AuthResultNone, "", pass("mail.strict.com"), domain.AuthResultFail, "reject"},
+		{"strict exact match", "strict.com", domain.AuthResultPass, "strict.com", nil, domain.AuthResultPass, "reject"},
+		{"subdomain policy from organizational domain", "news.example.com", domain.AuthResultPass, "news.example.com", nil, domain.AuthResultPass, "quarantine"},
+		{"public suffix", "shop.example.co.uk", domain.AuthResultPass, "example.co.uk", nil, domain.AuthResultPass, "none"},
+		{"no record", "nodmarc.com", domain.AuthResultPass, "nodmarc.com", nil, domain.AuthResultNone, ""},
+		{"temperror", "outage.com", domain.AuthResultPass, "outage.com", nil, domain.AuthResultTempError, ""},
+		{"no from domain", "", domain.AuthResultPass, "example.com", nil, domain.AuthResultNone, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, policy := CheckDMARC(context.Background(), resolver, tt.from, tt.spf, tt.spfDomain, tt.dkim)
+			if got != tt.want || policy != tt.wantPolicy {
+				t.Errorf("CheckDMARC(%s) = %s (p=%s), want %s (p=%s)", tt.from, got, policy, tt.want, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestOrganizationalDomain(t *testing.T) {
+	tests := map[string]string{
+		"example.com":        "example.com",
+		"mail.example.com":   "example.com",
+		"a.b.example.com":    "example.com",
+		"example.co.uk":      "example.co.uk",
+		"mail.example.co.uk": "example.co.uk",
+		"Mail.Example.COM.":  "example.com",
+	}
+	for name, want := range tests {
+		if got := OrganizationalDomain(name); got != want {
+			t.Errorf("OrganizationalDomain(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+// signEd25519 signs header and body with c=simple/simple and returns the
+// message with the DKIM-Signature field prepended.
+func signEd25519(key ed25519.PrivateKey, d, selector, header, body string) string {
+	bodyHash := sha256.Sum256([]byte(body))
+	field := "DKIM-Signature: v=1; a=ed25519-sha256; c=simple/simple; d=" + d +
+		"; s=" + selector + "; h=From:Subject; bh=" + base64.StdEncoding.EncodeToString(bodyHash[:]) + "; b="
+
+	var signed strings.Builder
+	for _, line := range strings.SplitAfter(header, "\r\n") {
+		if line != "" {
+			signed.WriteString(line)
+		}
+	}
+	signed.WriteString(field)
+	digest := sha256.Sum256([]byte(signed.String()))
+	sig := ed25519.Sign(key, digest[:])
+
+	return field + base64.StdEncoding.EncodeToString(sig) + "\r\n" + header + "\r\n" + body
+}
+
+func TestVerifyDKIM(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub)
+	resolver := &fakeResolver{
+		txt: map[string][]string{
+			"sel._domainkey.example.com":     {record},
+			"revoked._domainkey.example.com": {"v=DKIM1; k=ed25519; p="},
+		},
+		failing: map[string]bool{"down._domainkey.example.com": true},
+	}
+	header := "From: Alice <alice@example.com>\r\nSubject: Hello\r\n"
+	body := "Hi Bob,\r\nsee you tomorrow.\r\n"
+
+	tests := []struct {
+		name     string
+		message  string
+		want     string
+		selector string
+	}{
+		{"pass", signEd25519(key, "example.com", "sel", header, body), domain.AuthResultPass, "sel"},
+		{"tampered body", strings.Replace(signEd25519(key, "example.com", "sel", header, body), "tomorrow", "today", 1), domain.AuthResultFail, "sel"},
+		{"tampered header", strings.Replace(signEd25519(key, "example.com", "sel", header, body), "Subject: Hello", "Subject: Urgent", 1), domain.AuthResultFail, "sel"},
+		{"missing key", signEd25519(key, "example.com", "gone", header, body), domain.AuthResultPermError, "gone"},
+		{"revoked key", signEd25519(key, "example.com", "revoked", header, body), domain.AuthResultFail, "revoked"},
+		{"key lookup failure", signEd25519(key, "example.com", "down", header, body), domain.AuthResultTempError, "down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := VerifyDKIM(context.Background(), resolver, []byte(tt.message))
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			got := results[0]
+			if got.Result != tt.want {
+				t.Errorf("result = %s (%s), want %s", got.Result, got.Reason, tt.want)
+			}
+			if got.Domain != "example.com" || got.Selector != tt.selector {
+				t.Errorf("signature = %s/%s, want example.com/%s", got.Domain, got.Selector, tt.selector)
+			}
+		})
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		if results := VerifyDKIM(context.Background(), resolver, []byte(header+"\r\n"+body)); len(results) != 0 {
+			t.Errorf("got %d results for an unsigned message", len(results))
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := &fakeResolver{
+		txt: map[string][]string{
+			"bounce.example.com":         {"v=spf1 -all"},
+			"sel._domainkey.example.com": {"v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub)},
+			"_dmarc.example.com":         {"v=DMARC1; p=reject"},
+		},
+	}
+	raw := signEd25519(key, "example.com", "sel", "From: alice@example.com\r\nSubject: Hello\r\n", "Hi\r\n")
+
+	v := NewVerifier(resolver, "mx.mailgress.test")
+	res := v.Verify(context.Background(), net.ParseIP("192.0.2.10"), "mx.example.com", "bounce@bounce.example.com", []byte(raw))
+	if res.SPF != domain.AuthResultFail || res.DKIM != domain.AuthResultPass || res.DMARC != domain.AuthResultPass {
+		t.Fatalf("got spf=%s dkim=%s dmarc=%s, want fail/pass/pass", res.SPF, res.DKIM, res.DMARC)
+	}
+
+	want := "mx.mailgress.test; spf=fail smtp.mailfrom=bounce.example.com; dkim=pass header.d=example.com header.s=sel; dmarc=pass (p=reject) header.from=example.com"
+	if got := v.Header(res); got != want {
+		t.Errorf("Header() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStripForged(t *testing.T) {
+	v := NewVerifier(&fakeResolver{}, "mx.mailgress.test")
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			"forged",
+			"Authentication-Results: mx.mailgress.test; spf=pass\r\nFrom: a@example.com\r\n\r\nbody\r\n",
+			"From: a@example.com\r\n\r\nbody\r\n",
+		},
+		{
+			"forged with version and folding",
+			"From: a@example.com\r\nauthentication-results: MX.Mailgress.Test 1;\r\n\tdkim=pass\r\nSubject: hi\r\n\r\nbody\r\n",
+			"From: a@example.com\r\nSubject: hi\r\n\r\nbody\r\n",
+		},
+		{
+			"other hosts kept",
+			"Authentication-Results: mx.upstream.example; spf=pass\r\nFrom: a@example.com\r\n\r\nbody\r\n",
+			"Authentication-Results: mx.upstream.example; spf=pass\r\nFrom: a@example.com\r\n\r\nbody\r\n",
+		},
+		{
+			"prefix of our id kept",
+			"Authentication-Results: mx.mailgress.test.evil; spf=pass\r\n\r\nbody\r\n",
+			"Authentication-Results: mx.mailgress.test.evil; spf=pass\r\n\r\nbody\r\n",
+		},
+		{
+			"body untouched",
+			"From: a@example.com\r\n\r\nAuthentication-Results: mx.mailgress.test; spf=pass\r\n",
+			"From: a@example.com\r\n\r\nAuthentication-Results: mx.mailgress.test; spf=pass\r\n",
+		},
+		{
+			"bare line feeds",
+			"Authentication-Results: mx.mailgress.test; spf=pass\nFrom: a@example.com\n\nbody\n",
+			"From: a@example.com\n\nbody\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(v.StripForged([]byte(tt.raw))); got != tt.want {
+				t.Errorf("StripForged() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package mailauth
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Resolver is the subset of *net.Resolver used by the SPF, DKIM and DMARC
+// checks. net.DefaultResolver satisfies it; tests can plug in a static one.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// isNotFound reports whether a lookup failed because the name or record
+// does not exist, as opposed to a transient DNS failure.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+	return false
+}
@@ -0,0 +1,514 @@
+package mailauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// spfLookupLimit caps the DNS-querying terms evaluated per check (RFC 7208 4.6.4).
+const spfLookupLimit = 10
+
+var (
+	errSPFPermError = errors.New("spf permerror")
+	errSPFTempError = errors.New("spf temperror")
+)
+
+type spfChecker struct {
+	resolver Resolver
+	ip       net.IP
+	sender   string
+	helo     string
+	lookups  int
+}
+
+// CheckSPF evaluates the SPF policy of the MAIL FROM domain (or the HELO
+// name for the null sender) against the connecting IP. It returns the
+// result and the domain that was checked.
+func CheckSPF(ctx context.Context, resolver Resolver, ip net.IP, helo, mailFrom string) (string, string) {
+	sender := strings.Trim(strings.TrimSpace(mailFrom), "<>")
+	if sender == "" {
+		sender = "postmaster@" + helo
+	}
+	at := strings.LastIndex(sender, "@")
+	if at < 0 {
+		sender = "postmaster@" + sender
+		at = strings.LastIndex(sender, "@")
+	}
+	senderDomain := strings.ToLower(strings.TrimSuffix(sender[at+1:], "."))
+	if senderDomain == "" || ip == nil {
+		return domain.AuthResultNone, senderDomain
+	}
+
+	c := &spfChecker{
+		resolver: resolver,
+		ip:       ip,
+		sender:   sender,
+		helo:     helo,
+	}
+	return c.checkHost(ctx, senderDomain), senderDomain
+}
+
+func (c *spfChecker) checkHost(ctx context.Context, name string) string {
+	record, err := c.lookupRecord(ctx, name)
+	if err != nil {
+		if errors.Is(err, errSPFTempError) {
+			return domain.AuthResultTempError
+		}
+		return domain.AuthResultPermError
+	}
+	if record == "" {
+		return domain.AuthResultNone
+	}
+
+	var redirect string
+	for _, term := range strings.Fields(record)[1:] {
+		if name, value, ok := spfModifier(term); ok {
+			if name == "redirect" {
+				redirect = value
+			}
+			continue
+		}
+
+		qualifier := domain.AuthResultPass
+		switch term[0] {
+		case '+':
+			term = term[1:]
+		case '-':
+			qualifier = domain.AuthResultFail
+			term = term[1:]
+		case '~':
+			qualifier = domain.AuthResultSoftFail
+			term = term[1:]
+		case '?':
+			qualifier = domain.AuthResultNeutral
+			term = term[1:]
+		}
+
+		matched, err := c.matchMechanism(ctx, name, term)
+		if err != nil {
+			if errors.Is(err, errSPFTempError) {
+				return domain.AuthResultTempError
+			}
+			return domain.AuthResultPermError
+		}
+		if matched {
+			return qualifier
+		}
+	}
+
+	if redirect != "" {
+		if err := c.countLookup(); err != nil {
+			return domain.AuthResultPermError
+		}
+		target, err := c.expand(redirect, name)
+		if err != nil {
+			return domain.AuthResultPermError
+		}
+		result := c.checkHost(ctx, target)
+		if result == domain.AuthResultNone {
+			return domain.AuthResultPermError
+		}
+		return result
+	}
+
+	return domain.AuthResultNeutral
+}
+
+// lookupRecord returns the single v=spf1 record published for name, or ""
+// if there is none.
+func (c *spfChecker) lookupRecord(ctx context.Context, name string) (string, error) {
+	txts, err := c.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", errSPFTempError
+	}
+
+	var record string
+	for _, txt := range txts {
+		lower := strings.ToLower(txt)
+		if lower != "v=spf1" && !strings.HasPrefix(lower, "v=spf1 ") {
+			continue
+		}
+		if record != "" {
+			return "", errSPFPermError
+		}
+		record = txt
+	}
+	return record, nil
+}
+
+func (c *spfChecker) matchMechanism(ctx context.Context, current, term string) (bool, error) {
+	mechanism, arg := term, ""
+	if i := strings.IndexAny(term, ":/"); i >= 0 {
+		mechanism, arg = term[:i], term[i:]
+	}
+	mechanism = strings.ToLower(mechanism)
+
+	switch mechanism {
+	case "all":
+		return true, nil
+
+	case "ip4", "ip6":
+		if !strings.HasPrefix(arg, ":") {
+			return false, errSPFPermError
+		}
+		network := arg[1:]
+		if !strings.Contains(network, "/") {
+			if mechanism == "ip4" {
+				network += "/32"
+			} else {
+				network += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return false, errSPFPermError
+		}
+		return ipNet.Contains(c.ip), nil
+
+	case "include":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.domainSpec(arg, current, false)
+		if err != nil {
+			return false, err
+		}
+		switch c.checkHost(ctx, target) {
+		case domain.AuthResultPass:
+			return true, nil
+		case domain.AuthResultTempError:
+			return false, errSPFTempError
+		case domain.AuthResultPermError, domain.AuthResultNone:
+			return false, errSPFPermError
+		default:
+			return false, nil
+		}
+
+	case "a":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		spec, cidr4, cidr6, err := splitDualCIDR(arg)
+		if err != nil {
+			return false, err
+		}
+		target, err := c.domainSpec(spec, current, true)
+		if err != nil {
+			return false, err
+		}
+		return c.matchHost(ctx, target, cidr4, cidr6)
+
+	case "mx":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		spec, cidr4, cidr6, err := splitDualCIDR(arg)
+		if err != nil {
+			return false, err
+		}
+		target, err := c.domainSpec(spec, current, true)
+		if err != nil {
+			return false, err
+		}
+		mxs, err := c.resolver.LookupMX(ctx, target)
+		if err != nil {
+			if isNotFound(err) {
+				return false, nil
+			}
+			return false, errSPFTempError
+		}
+		if len(mxs) > spfLookupLimit {
+			return false, errSPFPermError
+		}
+		for _, mx := range mxs {
+			matched, err := c.matchHost(ctx, mx.Host, cidr4, cidr6)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+
+	case "ptr":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.domainSpec(arg, current, true)
+		if err != nil {
+			return false, err
+		}
+		return c.matchPTR(ctx, target), nil
+
+	case "exists":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.domainSpec(arg, current, false)
+		if err != nil {
+			return false, err
+		}
+		addrs, err := c.resolver.LookupIPAddr(ctx, target)
+		if err != nil {
+			if isNotFound(err) {
+				return false, nil
+			}
+			return false, errSPFTempError
+		}
+		for _, addr := range addrs {
+			if addr.IP.To4() != nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, errSPFPermError
+}
+
+func (c *spfChecker) matchHost(ctx context.Context, host string, cidr4, cidr6 int) (bool, error) {
+	addrs, err := c.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, errSPFTempError
+	}
+
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			if c.ip.To4() != nil && ip4.Mask(net.CIDRMask(cidr4, 32)).Equal(c.ip.To4().Mask(net.CIDRMask(cidr4, 32))) {
+				return true, nil
+			}
+			continue
+		}
+		if c.ip.To4() == nil && addr.IP.Mask(net.CIDRMask(cidr6, 128)).Equal(c.ip.Mask(net.CIDRMask(cidr6, 128))) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchPTR implements the (discouraged) ptr mechanism: a validated reverse
+// name of the client must equal or be a subdomain of target.
+func (c *spfChecker) matchPTR(ctx context.Context, target string) bool {
+	names, err := c.resolver.LookupAddr(ctx, c.ip.String())
+	if err != nil {
+		return false
+	}
+	target = strings.ToLower(strings.TrimSuffix(target, "."))
+
+	for i, name := range names {
+		if i >= spfLookupLimit {
+			break
+		}
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name != target && !strings.HasSuffix(name, "."+target) {
+			continue
+		}
+		addrs, err := c.resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(c.ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *spfChecker) countLookup() error {
+	c.lookups++
+	if c.lookups > spfLookupLimit {
+		return errSPFPermError
+	}
+	return nil
+}
+
+// domainSpec expands the ":domain" argument of a mechanism, falling back to
+// the current domain when it is optional and absent.
+func (c *spfChecker) domainSpec(arg, current string, optional bool) (string, error) {
+	if arg == "" {
+		if optional {
+			return current, nil
+		}
+		return "", errSPFPermError
+	}
+	if !strings.HasPrefix(arg, ":") || len(arg) == 1 {
+		return "", errSPFPermError
+	}
+	return c.expand(arg[1:], current)
+}
+
+// expand performs SPF macro expansion (RFC 7208 section 7).
+func (c *spfChecker) expand(spec, current string) (string, error) {
+	if !strings.Contains(spec, "%") {
+		return spec, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			b.WriteByte(spec[i])
+			continue
+		}
+		i++
+		if i >= len(spec) {
+			return "", errSPFPermError
+		}
+		switch spec[i] {
+		case '%':
+			b.WriteByte('%')
+			continue
+		case '_':
+			b.WriteByte(' ')
+			continue
+		case '-':
+			b.WriteString("%20")
+			continue
+		case '{':
+		default:
+			return "", errSPFPermError
+		}
+
+		end := strings.IndexByte(spec[i:], '}')
+		if end < 2 {
+			return "", errSPFPermError
+		}
+		macro := spec[i+1 : i+end]
+		i += end
+
+		value, err := c.macroValue(macro, current)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+func (c *spfChecker) macroValue(macro, current string) (string, error) {
+	letter := macro[0]
+	upper := letter >= 'A' && letter <= 'Z'
+	if upper {
+		letter += 'a' - 'A'
+	}
+
+	at := strings.LastIndex(c.sender, "@")
+	var value string
+	switch letter {
+	case 's':
+		value = c.sender
+	case 'l':
+		value = c.sender[:at]
+	case 'o':
+		value = c.sender[at+1:]
+	case 'd':
+		value = current
+	case 'h':
+		value = c.helo
+	case 'p':
+		value = "unknown"
+	case 'v':
+		value = "in-addr"
+		if c.ip.To4() == nil {
+			value = "ip6"
+		}
+	case 'i':
+		if ip4 := c.ip.To4(); ip4 != nil {
+			value = ip4.String()
+		} else {
+			nibbles := make([]string, 0, 32)
+			for _, octet := range c.ip.To16() {
+				nibbles = append(nibbles, fmt.Sprintf("%x", octet>>4), fmt.Sprintf("%x", octet&0x0f))
+			}
+			value = strings.Join(nibbles, ".")
+		}
+	default:
+		return "", errSPFPermError
+	}
+
+	transformers := macro[1:]
+	digits := 0
+	for len(transformers) > 0 && transformers[0] >= '0' && transformers[0] <= '9' {
+		digits = digits*10 + int(transformers[0]-'0')
+		transformers = transformers[1:]
+	}
+	reverse := false
+	if len(transformers) > 0 && (transformers[0] == 'r' || transformers[0] == 'R') {
+		reverse = true
+		transformers = transformers[1:]
+	}
+	delimiters := "."
+	if transformers != "" {
+		if strings.Trim(transformers, ".-+,/_=") != "" {
+			return "", errSPFPermError
+		}
+		delimiters = transformers
+	}
+
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return strings.ContainsRune(delimiters, r)
+	})
+	if reverse {
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+	}
+	if digits > 0 && digits < len(parts) {
+		parts = parts[len(parts)-digits:]
+	}
+	value = strings.Join(parts, ".")
+
+	if upper {
+		value = url.QueryEscape(value)
+	}
+	return value, nil
+}
+
+// spfModifier recognises name=value terms. Mechanisms never contain '='
+// before their first ':' or '/'.
+func spfModifier(term string) (string, string, bool) {
+	eq := strings.IndexByte(term, '=')
+	if eq <= 0 {
+		return "", "", false
+	}
+	if sep := strings.IndexAny(term, ":/"); sep >= 0 && sep < eq {
+		return "", "", false
+	}
+	return strings.ToLower(term[:eq]), term[eq+1:], true
+}
+
+// splitDualCIDR splits "[:domain][/cidr4][//cidr6]" into its parts.
+func splitDualCIDR(arg string) (string, int, int, error) {
+	cidr4, cidr6 := 32, 128
+
+	if i := strings.Index(arg, "//"); i >= 0 {
+		n, err := strconv.Atoi(arg[i+2:])
+		if err != nil || n < 0 || n > 128 {
+			return "", 0, 0, errSPFPermError
+		}
+		cidr6 = n
+		arg = arg[:i]
+	}
+	if i := strings.LastIndexByte(arg, '/'); i >= 0 {
+		n, err := strconv.Atoi(arg[i+1:])
+		if err != nil || n < 0 || n > 32 {
+			return "", 0, 0, errSPFPermError
+		}
+		cidr4 = n
+		arg = arg[:i]
+	}
+
+	return arg, cidr4, cidr6, nil
+}
@@ -0,0 +1,72 @@
+// Package mailer sends the few messages Mailgress originates itself, such as
+// notifications to mailbox owners, through an outgoing SMTP relay.
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// ErrNotConfigured is returned by Send when no relay is set.
+var ErrNotConfigured = errors.New("no SMTP relay configured")
+
+type Mailer struct {
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+// NewMailer returns a Mailer relaying through addr (host:port). Credentials
+// are optional; when set they are sent with PLAIN auth, which net/smtp only
+// allows over TLS or to localhost.
+func NewMailer(addr, username, password, from string) *Mailer {
+	return &Mailer{
+		addr:     addr,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *Mailer) Enabled() bool {
+	return m.addr != ""
+}
+
+// Send delivers a plain text message to every recipient.
+func (m *Mailer) Send(to []string, subject, body string) error {
+	if !m.Enabled() {
+		return ErrNotConfigured
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return fmt.Errorf("invalid relay address: %w", err)
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(m.addr, auth, m.from, to, msg.Bytes())
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fts5Columns are the emails_fts columns a field restricts a term to.
+var fts5Columns = map[string]string{
+	FieldFrom:     "from_address",
+	FieldTo:       "to_address",
+	FieldSubject:  "subject",
+	FieldBody:     "text_body",
+	FieldFilename: "attachment_names",
+}
+
+// FTS5 renders the terms as SQLite FTS5 match expressions: match for the
+// terms an email must contain, exclude for the negated terms it must not.
+// Either is empty when there are no such terms, since FTS5 cannot express
+// a query made only of NOT.
+func (q *Query) FTS5() (match, exclude string) {
+	var include, except []string
+	for _, term := range q.Terms {
+		if !hasToken(term.Text) {
+			continue
+		}
+		expr := `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+		if !term.Phrase {
+			expr += "*"
+		}
+		if column, ok := fts5Columns[term.Field]; ok {
+			expr = "{" + column + "} : " + expr
+		}
+		if term.Negated {
+			except = append(except, expr)
+		} else {
+			include = append(include, expr)
+		}
+	}
+	return strings.Join(include, " AND "), strings.Join(except, " OR ")
+}
+
+// tsqueryWeights are the weights search_vector gives each field in
+// Postgres. Body and filenames share D.
+var tsqueryWeights = map[string]string{
+	FieldSubject:  "A",
+	FieldFrom:     "B",
+	FieldTo:       "C",
+	FieldBody:     "D",
+	FieldFilename: "D",
+}
+
+// TSQuery renders the terms as Postgres tsqueries for the search_vector
+// column, split into lexemes the way the 'simple' configuration does. Like
+// FTS5, match holds the terms an email must contain and exclude the negated
+// terms it must not.
+func (q *Query) TSQuery() (match, exclude string) {
+	var include, except []string
+	for _, term := range q.Terms {
+		lexemes := strings.FieldsFunc(strings.ToLower(term.Text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(lexemes) == 0 {
+			continue
+		}
+
+		weight := tsqueryWeights[term.Field]
+		for i, lexeme := range lexemes {
+			suffix := weight
+			if !term.Phrase && i == len(lexemes)-1 {
+				suffix = "*" + weight
+			}
+			if suffix != "" {
+				lexeme += ":" + suffix
+			}
+			lexemes[i] = lexeme
+		}
+
+		expr := strings.Join(lexemes, " <-> ")
+		if len(lexemes) > 1 {
+			expr = "(" + expr + ")"
+		}
+		if term.Negated {
+			except = append(except, expr)
+		} else {
+			include = append(include, expr)
+		}
+	}
+	return strings.Join(include, " & "), strings.Join(except, " | ")
+}
+
+// hasToken reports whether text has anything the tokenizer would index.
+func hasToken(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
@@ -0,0 +1,49 @@
+package search
+
+import "testing"
+
+func TestFullText(t *testing.T) {
+	tests := []struct {
+		input                  string
+		fts5Match, fts5Exclude string
+		tsMatch, tsExclude     string
+	}{
+		{"", "", "", "", ""},
+		{"has:attachment is:read", "", "", "", ""},
+		{"invoice", `"invoice"*`, "", "invoice:*", ""},
+		{"Invoice 2026", `"Invoice"* AND "2026"*`, "", "invoice:* & 2026:*", ""},
+		{`"quarterly report"`, `"quarterly report"`, "", "(quarterly <-> report)", ""},
+		{"billing@example.org", `"billing@example.org"*`, "", "(billing <-> example <-> org:*)", ""},
+		{"from:acme", `{from_address} : "acme"*`, "", "acme:*B", ""},
+		{"to:billing", `{to_address} : "billing"*`, "", "billing:*C", ""},
+		{`subject:"weekly news"`, `{subject} : "weekly news"`, "", "(weekly:A <-> news:A)", ""},
+		{"body:total", `{text_body} : "total"*`, "", "total:*D", ""},
+		{"filename:report.pdf", `{attachment_names} : "report.pdf"*`, "", "(report:D <-> pdf:*D)", ""},
+		{"invoice -spam -from:noreply", `"invoice"*`, `"spam"* OR {from_address} : "noreply"*`, "invoice:*", "spam:* | noreply:*B"},
+		{"-spam", "", `"spam"*`, "", "spam:*"},
+
+		// Quotes and operators of either syntax stay inside the string
+		{`it"s`, `"it""s"*`, "", "(it <-> s:*)", ""},
+		{`(invoice OR receipt)`, `"(invoice"* AND "OR"* AND "receipt)"*`, "", "invoice:* & or:* & receipt:*", ""},
+		{`a&b|c!`, `"a&b|c!"*`, "", "(a <-> b <-> c:*)", ""},
+		{`NEAR(x) AND`, `"NEAR(x)"* AND "AND"*`, "", "(near <-> x:*) & and:*", ""},
+		{`re:hello`, `"re:hello"*`, "", "(re <-> hello:*)", ""},
+
+		// Terms without letters or digits are dropped
+		{`- ... "!!"`, "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match, exclude := q.FTS5(); match != tt.fts5Match || exclude != tt.fts5Exclude {
+				t.Errorf("FTS5() = %q, %q, want %q, %q", match, exclude, tt.fts5Match, tt.fts5Exclude)
+			}
+			if match, exclude := q.TSQuery(); match != tt.tsMatch || exclude != tt.tsExclude {
+				t.Errorf("TSQuery() = %q, %q, want %q, %q", match, exclude, tt.tsMatch, tt.tsExclude)
+			}
+		})
+	}
+}
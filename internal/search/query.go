@@ -0,0 +1,194 @@
+// Package search parses the email search syntax into full-text terms and
+// filters, and renders the terms for the database's full-text engine.
+//
+//	from:acme subject:"invoice" has:attachment before:2026-01-01 -is:read
+//
+// Bare words and "quoted phrases" match subject, sender, recipients, text
+// body and attachment filenames. Words match as prefixes, phrases exactly.
+// A leading - negates a term or filter.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidQuery wraps errors about malformed queries, which are the
+// user's to fix.
+var ErrInvalidQuery = errors.New("invalid search")
+
+// Fields a term can be restricted to with field:value.
+const (
+	FieldAny      = ""
+	FieldFrom     = "from"
+	FieldTo       = "to"
+	FieldSubject  = "subject"
+	FieldBody     = "body"
+	FieldFilename = "filename"
+)
+
+// Term is a word or phrase to look up in the full-text index.
+type Term struct {
+	Field   string
+	Text    string
+	Phrase  bool
+	Negated bool
+}
+
+// Query is a parsed search. Nil filters are not applied.
+type Query struct {
+	Terms         []Term
+	HasAttachment *bool
+	IsRead        *bool
+	Before        *time.Time
+	After         *time.Time
+	// Mailbox restricts a search across mailboxes to the one with this slug
+	Mailbox string
+}
+
+// Parse parses a search. Operators it does not know, such as "re:", are
+// searched as text.
+func Parse(input string) (*Query, error) {
+	q := &Query{}
+	p := &parser{input: []rune(input)}
+
+	for {
+		p.skipSpace()
+		if p.done() {
+			return q, nil
+		}
+
+		negated := false
+		if p.peek() == '-' && p.pos+1 < len(p.input) && !unicode.IsSpace(p.input[p.pos+1]) {
+			negated = true
+			p.pos++
+		}
+
+		key := p.key()
+		text, phrase, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "from", "to", "subject", "body", "filename":
+			if text == "" {
+				return nil, invalid("%s: needs a value", key)
+			}
+			q.Terms = append(q.Terms, Term{Field: key, Text: text, Phrase: phrase, Negated: negated})
+		case "has":
+			if !strings.EqualFold(text, "attachment") {
+				return nil, invalid("has:%s is not supported, use has:attachment", text)
+			}
+			q.HasAttachment = boolPtr(!negated)
+		case "is":
+			switch strings.ToLower(text) {
+			case "read":
+				q.IsRead = boolPtr(!negated)
+			case "unread":
+				q.IsRead = boolPtr(negated)
+			default:
+				return nil, invalid("is:%s is not supported, use is:read or is:unread", text)
+			}
+		case "before", "after":
+			date, err := parseDate(text)
+			if err != nil {
+				return nil, invalid("%s:%s is not a date, use YYYY-MM-DD", key, text)
+			}
+			// -before:X is on or after X, -after:X is before X
+			if (key == "before") != negated {
+				q.Before = &date
+			} else {
+				q.After = &date
+			}
+		case "mailbox":
+			if negated {
+				return nil, invalid("mailbox: cannot be negated")
+			}
+			q.Mailbox = text
+		default:
+			if key != "" {
+				text = key + ":" + text
+			}
+			if text != "" {
+				q.Terms = append(q.Terms, Term{Field: FieldAny, Text: text, Phrase: phrase, Negated: negated})
+			}
+		}
+	}
+}
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.input) }
+
+func (p *parser) peek() rune { return p.input[p.pos] }
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// key consumes "name:" when the input continues with letters and a colon,
+// and returns the lower-cased name.
+func (p *parser) key() string {
+	end := p.pos
+	for end < len(p.input) && unicode.IsLetter(p.input[end]) {
+		end++
+	}
+	if end == p.pos || end >= len(p.input) || p.input[end] != ':' {
+		return ""
+	}
+	key := strings.ToLower(string(p.input[p.pos:end]))
+	p.pos = end + 1
+	return key
+}
+
+// value consumes a word, or a phrase in double quotes.
+func (p *parser) value() (string, bool, error) {
+	if p.done() || unicode.IsSpace(p.peek()) {
+		return "", false, nil
+	}
+	if p.peek() != '"' {
+		start := p.pos
+		for !p.done() && !unicode.IsSpace(p.peek()) {
+			p.pos++
+		}
+		return string(p.input[start:p.pos]), false, nil
+	}
+
+	p.pos++
+	start := p.pos
+	for !p.done() && p.peek() != '"' {
+		p.pos++
+	}
+	if p.done() {
+		return "", false, invalid("missing closing quote")
+	}
+	text := string(p.input[start:p.pos])
+	p.pos++
+	return strings.TrimSpace(text), true, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006/01/02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unable to parse date")
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
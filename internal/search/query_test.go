@@ -0,0 +1,105 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Query
+	}{
+		{"", Query{}},
+		{"   ", Query{}},
+		{"invoice", Query{Terms: []Term{{Text: "invoice"}}}},
+		{"quarterly  invoice", Query{Terms: []Term{{Text: "quarterly"}, {Text: "invoice"}}}},
+		{"-spam", Query{Terms: []Term{{Text: "spam", Negated: true}}}},
+		{"- spam", Query{Terms: []Term{{Text: "-"}, {Text: "spam"}}}},
+		{"from:acme", Query{Terms: []Term{{Field: FieldFrom, Text: "acme"}}}},
+		{"FROM:acme", Query{Terms: []Term{{Field: FieldFrom, Text: "acme"}}}},
+		{"to:billing@example.org", Query{Terms: []Term{{Field: FieldTo, Text: "billing@example.org"}}}},
+		{"subject:report", Query{Terms: []Term{{Field: FieldSubject, Text: "report"}}}},
+		{"body:total", Query{Terms: []Term{{Field: FieldBody, Text: "total"}}}},
+		{"filename:pdf", Query{Terms: []Term{{Field: FieldFilename, Text: "pdf"}}}},
+		{"-from:acme", Query{Terms: []Term{{Field: FieldFrom, Text: "acme", Negated: true}}}},
+		{"has:attachment", Query{HasAttachment: boolPtr(true)}},
+		{"-has:Attachment", Query{HasAttachment: boolPtr(false)}},
+		{"is:read", Query{IsRead: boolPtr(true)}},
+		{"is:unread", Query{IsRead: boolPtr(false)}},
+		{"-is:read", Query{IsRead: boolPtr(false)}},
+		{"-is:unread", Query{IsRead: boolPtr(true)}},
+		{"before:2026-01-01", Query{Before: date("2026-01-01")}},
+		{"after:2026/01/31", Query{After: date("2026-01-31")}},
+		{"-before:2026-01-01", Query{After: date("2026-01-01")}},
+		{"-after:2026-01-01", Query{Before: date("2026-01-01")}},
+		{"after:2025-12-01 before:2026-01-01", Query{After: date("2025-12-01"), Before: date("2026-01-01")}},
+		{"mailbox:support", Query{Mailbox: "support"}},
+		{"re:hello", Query{Terms: []Term{{Text: "re:hello"}}}},
+		{"https://example.com", Query{Terms: []Term{{Text: "https://example.com"}}}},
+		{"12:30", Query{Terms: []Term{{Text: "12:30"}}}},
+
+		// Quoted phrases
+		{`"quarterly report"`, Query{Terms: []Term{{Text: "quarterly report", Phrase: true}}}},
+		{`subject:"  quarterly report "`, Query{Terms: []Term{{Field: FieldSubject, Text: "quarterly report", Phrase: true}}}},
+		{`-"out of office"`, Query{Terms: []Term{{Text: "out of office", Phrase: true, Negated: true}}}},
+		{`""`, Query{}},
+		{`"a"b`, Query{Terms: []Term{{Text: "a", Phrase: true}, {Text: "b"}}}},
+
+		// Stray quotes and parentheses are searched as text
+		{`it"s`, Query{Terms: []Term{{Text: `it"s`}}}},
+		{`(invoice OR receipt)`, Query{Terms: []Term{{Text: "(invoice"}, {Text: "OR"}, {Text: "receipt)"}}}},
+		{`from:(acme)`, Query{Terms: []Term{{Field: FieldFrom, Text: "(acme)"}}}},
+
+		{
+			`from:acme subject:"invoice" has:attachment before:2026-01-01 -is:read`,
+			Query{
+				Terms:         []Term{{Field: FieldFrom, Text: "acme"}, {Field: FieldSubject, Text: "invoice", Phrase: true}},
+				HasAttachment: boolPtr(true),
+				IsRead:        boolPtr(false),
+				Before:        date("2026-01-01"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{
+		`"unterminated`,
+		`subject:"unterminated`,
+		`invoice "`,
+		`from:`,
+		`subject:""`,
+		`has:link`,
+		`is:starred`,
+		`before:yesterday`,
+		`after:2026-13-01`,
+		`before:01/02/2026`,
+		`-mailbox:support`,
+	} {
+		if q, err := Parse(input); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("Parse(%q) = %+v, %v, want ErrInvalidQuery", input, q, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// apiTokenPrefix makes tokens recognisable in logs and secret scanners.
+const apiTokenPrefix = "mgr_"
+
+var (
+	ErrAPITokenNotFound = errors.New("api token not found")
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrNoScopes         = errors.New("at least one scope is required")
+)
+
+type APITokenService struct {
+	queries *db.Queries
+}
+
+func NewAPITokenService(queries *db.Queries) *APITokenService {
+	return &APITokenService{queries: queries}
+}
+
+// Create issues a new token and returns it together with the plaintext value,
+// which is only available at creation time; the database keeps a SHA-256 hash.
+func (s *APITokenService) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*domain.APIToken, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	plaintext := apiTokenPrefix + hex.EncodeToString(buf)
+
+	var expires sql.NullTime
+	if expiresAt != nil {
+		expires = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+
+	dbToken, err := s.queries.CreateApiToken(ctx, db.CreateApiTokenParams{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashAPIToken(plaintext),
+		TokenPrefix: plaintext[:len(apiTokenPrefix)+8],
+		Scopes:      strings.Join(scopes, ","),
+		ExpiresAt:   expires,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.toDomain(dbToken), plaintext, nil
+}
+
+func (s *APITokenService) ListByUser(ctx context.Context, userID int64) ([]*domain.APIToken, error) {
+	dbTokens, err := s.queries.ListApiTokensByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*domain.APIToken, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		tokens[i] = s.toDomain(dbToken)
+	}
+	return tokens, nil
+}
+
+func (s *APITokenService) Delete(ctx context.Context, userID, id int64) error {
+	return s.queries.DeleteApiToken(ctx, db.DeleteApiTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+}
+
+// Authenticate resolves a plaintext bearer token. Expired and unknown tokens
+// both return ErrAPITokenNotFound.
+func (s *APITokenService) Authenticate(ctx context.Context, plaintext string) (*domain.APIToken, error) {
+	if !strings.HasPrefix(plaintext, apiTokenPrefix) {
+		return nil, ErrAPITokenNotFound
+	}
+
+	dbToken, err := s.queries.GetApiTokenByHash(ctx, hashAPIToken(plaintext))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPITokenNotFound
+		}
+		return nil, err
+	}
+
+	token := s.toDomain(dbToken)
+	if token.IsExpired() {
+		return nil, ErrAPITokenNotFound
+	}
+
+	s.queries.TouchApiToken(ctx, dbToken.ID)
+
+	return token, nil
+}
+
+func hashAPIToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func validScope(scope string) bool {
+	for _, s := range domain.APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *APITokenService) toDomain(dbToken db.ApiToken) *domain.APIToken {
+	token := &domain.APIToken{
+		ID:        dbToken.ID,
+		UserID:    dbToken.UserID,
+		Name:      dbToken.Name,
+		Prefix:    dbToken.TokenPrefix,
+		CreatedAt: dbToken.CreatedAt,
+	}
+	if dbToken.Scopes != "" {
+		token.Scopes = strings.Split(dbToken.Scopes, ",")
+	}
+	if dbToken.ExpiresAt.Valid {
+		token.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return token
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jr-k/mailgress/internal/database/db"
 	"github.com/jr-k/mailgress/internal/domain"
@@ -48,7 +49,7 @@ func (s *DeliveryService) ListByWebhook(ctx context.Context, webhookID int64, li
 }
 
 func (s *DeliveryService) ListByEmail(ctx context.Context, emailID int64) ([]*domain.WebhookDelivery, error) {
-	dbDeliveries, err := s.queries.ListDeliveriesByEmail(ctx, emailID)
+	dbDeliveries, err := s.queries.ListDeliveriesByEmail(ctx, sql.NullInt64{Int64: emailID, Valid: true})
 	if err != nil {
 		return nil, err
 	}
@@ -60,26 +61,20 @@ func (s *DeliveryService) ListByEmail(ctx context.Context, emailID int64) ([]*do
 	return deliveries, nil
 }
 
-func (s *DeliveryService) ListPending(ctx context.Context, limit int64) ([]*domain.WebhookDelivery, error) {
-	dbDeliveries, err := s.queries.ListPendingDeliveries(ctx, limit)
-	if err != nil {
-		return nil, err
+func (s *DeliveryService) Create(ctx context.Context, webhookID int64, event string, emailID *int64, attempt int, requestBody, idempotencyKey string) (*domain.WebhookDelivery, error) {
+	var dbEmailID sql.NullInt64
+	if emailID != nil {
+		dbEmailID = sql.NullInt64{Int64: *emailID, Valid: true}
 	}
 
-	deliveries := make([]*domain.WebhookDelivery, len(dbDeliveries))
-	for i, dbDelivery := range dbDeliveries {
-		deliveries[i] = s.toDomain(dbDelivery)
-	}
-	return deliveries, nil
-}
-
-func (s *DeliveryService) Create(ctx context.Context, webhookID, emailID int64, attempt int, requestBody string) (*domain.WebhookDelivery, error) {
 	dbDelivery, err := s.queries.CreateDelivery(ctx, db.CreateDeliveryParams{
-		WebhookID:   webhookID,
-		EmailID:     emailID,
-		Attempt:     int64(attempt),
-		Status:      domain.DeliveryStatusPending,
-		RequestBody: sql.NullString{String: requestBody, Valid: requestBody != ""},
+		WebhookID:      webhookID,
+		EmailID:        dbEmailID,
+		Event:          event,
+		Attempt:        int64(attempt),
+		Status:         domain.DeliveryStatusPending,
+		RequestBody:    sql.NullString{String: requestBody, Valid: requestBody != ""},
+		IdempotencyKey: idempotencyKey,
 	})
 	if err != nil {
 		return nil, err
@@ -87,7 +82,9 @@ func (s *DeliveryService) Create(ctx context.Context, webhookID, emailID int64,
 	return s.toDomain(dbDelivery), nil
 }
 
-func (s *DeliveryService) UpdateStatus(ctx context.Context, id int64, status string, statusCode *int, responseBody, errorMessage string, durationMs *int) (*domain.WebhookDelivery, error) {
+// UpdateStatus records the outcome of an attempt. nextAttemptAt is set for
+// retrying deliveries and nil otherwise.
+func (s *DeliveryService) UpdateStatus(ctx context.Context, id int64, status string, statusCode *int, responseBody, errorMessage string, durationMs *int, nextAttemptAt *time.Time) (*domain.WebhookDelivery, error) {
 	var dbStatusCode sql.NullInt64
 	if statusCode != nil {
 		dbStatusCode = sql.NullInt64{Int64: int64(*statusCode), Valid: true}
@@ -98,13 +95,19 @@ func (s *DeliveryService) UpdateStatus(ctx context.Context, id int64, status str
 		dbDurationMs = sql.NullInt64{Int64: int64(*durationMs), Valid: true}
 	}
 
+	var dbNextAttemptAt sql.NullTime
+	if nextAttemptAt != nil {
+		dbNextAttemptAt = sql.NullTime{Time: nextAttemptAt.UTC(), Valid: true}
+	}
+
 	dbDelivery, err := s.queries.UpdateDelivery(ctx, db.UpdateDeliveryParams{
-		ID:           id,
-		Status:       status,
-		StatusCode:   dbStatusCode,
-		ResponseBody: sql.NullString{String: responseBody, Valid: responseBody != ""},
-		ErrorMessage: sql.NullString{String: errorMessage, Valid: errorMessage != ""},
-		DurationMs:   dbDurationMs,
+		ID:            id,
+		Status:        status,
+		StatusCode:    dbStatusCode,
+		ResponseBody:  sql.NullString{String: responseBody, Valid: responseBody != ""},
+		ErrorMessage:  sql.NullString{String: errorMessage, Valid: errorMessage != ""},
+		DurationMs:    dbDurationMs,
+		NextAttemptAt: dbNextAttemptAt,
 	})
 	if err != nil {
 		return nil, err
@@ -112,6 +115,12 @@ func (s *DeliveryService) UpdateStatus(ctx context.Context, id int64, status str
 	return s.toDomain(dbDelivery), nil
 }
 
+// FailUnfinished marks a delivery that was left pending or retrying as
+// failed, once a later attempt has superseded it.
+func (s *DeliveryService) FailUnfinished(ctx context.Context, id int64) error {
+	return s.queries.FailUnfinishedDelivery(ctx, id)
+}
+
 func (s *DeliveryService) CountByWebhook(ctx context.Context, webhookID int64) (int64, error) {
 	return s.queries.CountDeliveriesByWebhook(ctx, webhookID)
 }
@@ -126,12 +135,16 @@ func (s *DeliveryService) DeleteAllByWebhook(ctx context.Context, webhookID int6
 
 func (s *DeliveryService) toDomain(dbDelivery db.WebhookDelivery) *domain.WebhookDelivery {
 	delivery := &domain.WebhookDelivery{
-		ID:        dbDelivery.ID,
-		WebhookID: dbDelivery.WebhookID,
-		EmailID:   dbDelivery.EmailID,
-		Attempt:   int(dbDelivery.Attempt),
-		Status:    dbDelivery.Status,
-		CreatedAt: dbDelivery.CreatedAt,
+		ID:             dbDelivery.ID,
+		WebhookID:      dbDelivery.WebhookID,
+		Event:          dbDelivery.Event,
+		IdempotencyKey: dbDelivery.IdempotencyKey,
+		Attempt:        int(dbDelivery.Attempt),
+		Status:         dbDelivery.Status,
+		CreatedAt:      dbDelivery.CreatedAt,
+	}
+	if dbDelivery.EmailID.Valid {
+		delivery.EmailID = &dbDelivery.EmailID.Int64
 	}
 	if dbDelivery.StatusCode.Valid {
 		v := int(dbDelivery.StatusCode.Int64)
@@ -150,5 +163,9 @@ func (s *DeliveryService) toDomain(dbDelivery db.WebhookDelivery) *domain.Webhoo
 		v := int(dbDelivery.DurationMs.Int64)
 		delivery.DurationMs = &v
 	}
+	if dbDelivery.NextAttemptAt.Valid {
+		v := dbDelivery.NextAttemptAt.Time
+		delivery.NextAttemptAt = &v
+	}
 	return delivery
 }
@@ -6,24 +6,34 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/jr-k/mailgress/internal/database"
 	"github.com/jr-k/mailgress/internal/database/db"
 	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
 )
 
 var (
 	ErrDomainNotFound      = errors.New("domain not found")
 	ErrDomainAlreadyExists = errors.New("domain already exists")
 	ErrInvalidDomainName   = errors.New("invalid domain name")
+	ErrDomainRouteNotFound = errors.New("route not found")
+	ErrInvalidRoutePattern = errors.New("invalid route pattern")
+	ErrMailboxNotInDomain  = errors.New("mailbox does not belong to the domain")
 	domainPattern          = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
 )
 
 type DomainService struct {
 	queries *db.Queries
+	tx      *database.Transactor
+	bus     *events.Bus
+	// patterns caches compiled route patterns by type and pattern
+	patterns sync.Map
 }
 
-func NewDomainService(queries *db.Queries) *DomainService {
-	return &DomainService{queries: queries}
+func NewDomainService(queries *db.Queries, tx *database.Transactor, bus *events.Bus) *DomainService {
+	return &DomainService{queries: queries, tx: tx, bus: bus}
 }
 
 func (s *DomainService) GetByID(ctx context.Context, id int64) (*domain.Domain, error) {
@@ -101,31 +111,44 @@ func (s *DomainService) Create(ctx context.Context, name string) (*domain.Domain
 	return s.toDomain(dbDomain), nil
 }
 
-func (s *DomainService) Update(ctx context.Context, id int64, name string, isVerified, isActive bool) (*domain.Domain, error) {
+func (s *DomainService) Update(ctx context.Context, id int64, name string, isVerified, isActive, requireTLS bool) (*domain.Domain, error) {
 	name = strings.ToLower(strings.TrimSpace(name))
 	if !domainPattern.MatchString(name) {
 		return nil, ErrInvalidDomainName
 	}
 
-	var verifiedFlag, activeFlag int64
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifiedFlag, activeFlag, requireTLSFlag int64
 	if isVerified {
 		verifiedFlag = 1
 	}
 	if isActive {
 		activeFlag = 1
 	}
+	if requireTLS {
+		requireTLSFlag = 1
+	}
 
 	dbDomain, err := s.queries.UpdateDomain(ctx, db.UpdateDomainParams{
 		ID:         id,
 		Name:       name,
 		IsVerified: verifiedFlag,
 		IsActive:   activeFlag,
+		RequireTls: requireTLSFlag,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return s.toDomain(dbDomain), nil
+	updated := s.toDomain(dbDomain)
+	if updated.IsVerified && !current.IsVerified {
+		s.bus.Publish(ctx, domain.Event{Type: domain.EventDomainVerified, Domain: updated})
+	}
+	return updated, nil
 }
 
 func (s *DomainService) Delete(ctx context.Context, id int64) error {
@@ -140,13 +163,181 @@ func (s *DomainService) GetDNSRecords(d *domain.Domain) []domain.DNSRecord {
 	return d.GetDNSRecords()
 }
 
+// SetCatchAll points unknown recipients of the domain at mailboxID; nil
+// turns the catch-all off.
+func (s *DomainService) SetCatchAll(ctx context.Context, id int64, mailboxID *int64) (*domain.Domain, error) {
+	var catchAll sql.NullInt64
+	if mailboxID != nil {
+		if err := s.checkMailboxDomain(ctx, id, *mailboxID); err != nil {
+			return nil, err
+		}
+		catchAll = sql.NullInt64{Int64: *mailboxID, Valid: true}
+	}
+
+	dbDomain, err := s.queries.SetDomainCatchAll(ctx, db.SetDomainCatchAllParams{
+		CatchAllMailboxID: catchAll,
+		ID:                id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, err
+	}
+	return s.toDomain(dbDomain), nil
+}
+
+func (s *DomainService) ListRoutes(ctx context.Context, domainID int64) ([]*domain.DomainRoute, error) {
+	dbRoutes, err := s.queries.ListDomainRoutes(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]*domain.DomainRoute, len(dbRoutes))
+	for i, dbRoute := range dbRoutes {
+		routes[i] = s.routeToDomain(dbRoute)
+	}
+	return routes, nil
+}
+
+// CreateRoute appends a route after the existing ones of the domain. The
+// pattern is compiled once here and kept for ResolveMailbox.
+func (s *DomainService) CreateRoute(ctx context.Context, domainID, mailboxID int64, patternType, pattern string) (*domain.DomainRoute, error) {
+	pattern = strings.TrimSpace(pattern)
+	if patternType != domain.RoutePatternRegex {
+		patternType = domain.RoutePatternGlob
+	}
+
+	route := &domain.DomainRoute{PatternType: patternType, Pattern: pattern}
+	re, err := route.Compile()
+	if err != nil || pattern == "" {
+		return nil, ErrInvalidRoutePattern
+	}
+
+	if err := s.checkMailboxDomain(ctx, domainID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	dbRoute, err := s.queries.CreateDomainRoute(ctx, db.CreateDomainRouteParams{
+		DomainID:    domainID,
+		MailboxID:   mailboxID,
+		PatternType: patternType,
+		Pattern:     pattern,
+		DomainID_2:  domainID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.patterns.Store(patternKey(route), re)
+	return s.routeToDomain(dbRoute), nil
+}
+
+func (s *DomainService) DeleteRoute(ctx context.Context, domainID, routeID int64) error {
+	return s.queries.DeleteDomainRoute(ctx, db.DeleteDomainRouteParams{
+		ID:       routeID,
+		DomainID: domainID,
+	})
+}
+
+// ReorderRoutes assigns positions following the order of routeIDs, all of
+// them or none. IDs that do not belong to the domain are ignored.
+func (s *DomainService) ReorderRoutes(ctx context.Context, domainID int64, routeIDs []int64) error {
+	return s.tx.InTx(ctx, func(q *db.Queries) error {
+		for i, routeID := range routeIDs {
+			err := q.UpdateDomainRoutePosition(ctx, db.UpdateDomainRoutePositionParams{
+				Position: int64(i + 1),
+				ID:       routeID,
+				DomainID: domainID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResolveMailbox returns the mailbox a local part without an exact slug
+// match is delivered to: the first matching route, else the catch-all.
+func (s *DomainService) ResolveMailbox(ctx context.Context, d *domain.Domain, localPart string) (int64, error) {
+	routes, err := s.ListRoutes(ctx, d.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, route := range routes {
+		if re := s.compiledRoute(route); re != nil && re.MatchString(localPart) {
+			return route.MailboxID, nil
+		}
+	}
+
+	if d.CatchAllMailboxID != nil {
+		return *d.CatchAllMailboxID, nil
+	}
+	return 0, ErrMailboxNotFound
+}
+
+// compiledRoute returns the compiled pattern of a route, compiling it on
+// first use for routes created before the process started. Routes whose
+// pattern does not compile never match.
+func (s *DomainService) compiledRoute(route *domain.DomainRoute) *regexp.Regexp {
+	key := patternKey(route)
+	if re, ok := s.patterns.Load(key); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := route.Compile()
+	if err != nil {
+		re = nil
+	}
+	s.patterns.Store(key, re)
+	return re
+}
+
+func patternKey(route *domain.DomainRoute) string {
+	return route.PatternType + ":" + route.Pattern
+}
+
+// checkMailboxDomain makes sure mail of the domain can be routed to the
+// mailbox: it must exist and belong to the domain.
+func (s *DomainService) checkMailboxDomain(ctx context.Context, domainID, mailboxID int64) error {
+	mailbox, err := s.queries.GetMailboxByID(ctx, mailboxID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMailboxNotFound
+		}
+		return err
+	}
+	if !mailbox.DomainID.Valid || mailbox.DomainID.Int64 != domainID {
+		return ErrMailboxNotInDomain
+	}
+	return nil
+}
+
 func (s *DomainService) toDomain(dbDomain db.Domain) *domain.Domain {
-	return &domain.Domain{
+	d := &domain.Domain{
 		ID:         dbDomain.ID,
 		Name:       dbDomain.Name,
 		IsVerified: dbDomain.IsVerified != 0,
 		IsActive:   dbDomain.IsActive != 0,
+		RequireTLS: dbDomain.RequireTls != 0,
 		CreatedAt:  dbDomain.CreatedAt,
 		UpdatedAt:  dbDomain.UpdatedAt,
 	}
+	if dbDomain.CatchAllMailboxID.Valid {
+		v := dbDomain.CatchAllMailboxID.Int64
+		d.CatchAllMailboxID = &v
+	}
+	return d
+}
+
+func (s *DomainService) routeToDomain(dbRoute db.DomainRoute) *domain.DomainRoute {
+	return &domain.DomainRoute{
+		ID:          dbRoute.ID,
+		DomainID:    dbRoute.DomainID,
+		MailboxID:   dbRoute.MailboxID,
+		PatternType: dbRoute.PatternType,
+		Pattern:     dbRoute.Pattern,
+		Position:    int(dbRoute.Position),
+		CreatedAt:   dbRoute.CreatedAt,
+	}
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
+)
+
+func TestDomainRouting(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, queries := openTestDB(t)
+	bus := events.NewBus()
+	domains := NewDomainService(queries, database.NewTransactor(sqlDB, "sqlite"), bus)
+	mailboxes := NewMailboxService(queries, bus)
+
+	example, err := domains.Create(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := domains.Create(ctx, "other.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailbox := func(slug string, d *domain.Domain) *domain.Mailbox {
+		t.Helper()
+		m, err := mailboxes.Create(ctx, slug, nil, &d.ID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	sales := mailbox("sales", example)
+	support := mailbox("support", example)
+	catchAll := mailbox("catchall", example)
+	foreign := mailbox("foreign", other)
+
+	resolve := func(localPart string) int64 {
+		t.Helper()
+		d, err := domains.GetByID(ctx, example.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := domains.ResolveMailbox(ctx, d, localPart)
+		if err != nil && !errors.Is(err, ErrMailboxNotFound) {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// Routes and the catch-all only lead to mailboxes of the domain
+	if _, err := domains.SetCatchAll(ctx, example.ID, &foreign.ID); !errors.Is(err, ErrMailboxNotInDomain) {
+		t.Errorf("catch-all to another domain's mailbox = %v, want ErrMailboxNotInDomain", err)
+	}
+	missing := int64(9999)
+	if _, err := domains.SetCatchAll(ctx, example.ID, &missing); !errors.Is(err, ErrMailboxNotFound) {
+		t.Errorf("catch-all to a missing mailbox = %v, want ErrMailboxNotFound", err)
+	}
+	if _, err := domains.CreateRoute(ctx, example.ID, foreign.ID, domain.RoutePatternGlob, "*"); !errors.Is(err, ErrMailboxNotInDomain) {
+		t.Errorf("route to another domain's mailbox = %v, want ErrMailboxNotInDomain", err)
+	}
+	for _, pattern := range []string{"", "  ", "(unclosed"} {
+		if _, err := domains.CreateRoute(ctx, example.ID, sales.ID, domain.RoutePatternRegex, pattern); !errors.Is(err, ErrInvalidRoutePattern) {
+			t.Errorf("route with pattern %q = %v, want ErrInvalidRoutePattern", pattern, err)
+		}
+	}
+
+	if got := resolve("anyone"); got != 0 {
+		t.Errorf("without routes or catch-all anyone goes to %d", got)
+	}
+
+	first, err := domains.CreateRoute(ctx, example.ID, sales.ID, domain.RoutePatternGlob, "sales-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := domains.CreateRoute(ctx, example.ID, support.ID, domain.RoutePatternRegex, "^s.*-(eu|us)$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := domains.SetCatchAll(ctx, example.ID, &catchAll.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		localPart string
+		want      int64
+	}{
+		{"sales-eu", sales.ID},
+		{"SALES-EU", sales.ID},
+		{"sales-", sales.ID},
+		{"support-us", support.ID},
+		{"sales", catchAll.ID},
+		{"support-fr", catchAll.ID},
+		{"x-sales-eu", catchAll.ID},
+	}
+	for _, tt := range tests {
+		if got := resolve(tt.localPart); got != tt.want {
+			t.Errorf("%s goes to mailbox %d, want %d", tt.localPart, got, tt.want)
+		}
+	}
+
+	// The first matching route wins
+	if err := domains.ReorderRoutes(ctx, example.ID, []int64{second.ID, first.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve("sales-eu"); got != support.ID {
+		t.Errorf("sales-eu goes to %d after reordering, want the support route first", got)
+	}
+	if got := resolve("sales-fr"); got != sales.ID {
+		t.Errorf("sales-fr goes to %d after reordering, want the sales route", got)
+	}
+	routes, err := domains.ListRoutes(ctx, example.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].ID != second.ID || routes[0].Position != 1 || routes[1].Position != 2 {
+		t.Errorf("routes after reordering = %+v, %+v", routes[0], routes[1])
+	}
+
+	// Deleting a route and clearing the catch-all
+	if err := domains.DeleteRoute(ctx, example.ID, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve("support-us"); got != catchAll.ID {
+		t.Errorf("support-us goes to %d after deleting its route, want the catch-all", got)
+	}
+	if _, err := domains.SetCatchAll(ctx, example.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve("support-us"); got != 0 {
+		t.Errorf("support-us goes to %d without a catch-all", got)
+	}
+}
@@ -9,16 +9,24 @@ import (
 
 	"github.com/jr-k/mailgress/internal/database/db"
 	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
+	"github.com/jr-k/mailgress/internal/search"
 )
 
-var ErrEmailNotFound = errors.New("email not found")
+var (
+	ErrEmailNotFound      = errors.New("email not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
 
 type EmailService struct {
 	queries *db.Queries
+	bus     *events.Bus
+	// driver picks the full-text syntax searches are rendered in
+	driver string
 }
 
-func NewEmailService(queries *db.Queries) *EmailService {
-	return &EmailService{queries: queries}
+func NewEmailService(queries *db.Queries, bus *events.Bus, driver string) *EmailService {
+	return &EmailService{queries: queries, bus: bus, driver: driver}
 }
 
 func (s *EmailService) GetByID(ctx context.Context, id int64) (*domain.Email, error) {
@@ -38,15 +46,7 @@ func (s *EmailService) GetByID(ctx context.Context, id int64) (*domain.Email, er
 	}
 	email.Attachments = make([]domain.Attachment, len(dbAttachments))
 	for i, dbAtt := range dbAttachments {
-		email.Attachments[i] = domain.Attachment{
-			ID:          dbAtt.ID,
-			EmailID:     dbAtt.EmailID,
-			Filename:    dbAtt.Filename,
-			ContentType: dbAtt.ContentType,
-			Size:        dbAtt.Size,
-			StoragePath: dbAtt.StoragePath,
-			CreatedAt:   dbAtt.CreatedAt,
-		}
+		email.Attachments[i] = *s.attachmentToDomain(dbAtt)
 	}
 	email.HasAttachments = len(email.Attachments) > 0
 
@@ -70,14 +70,76 @@ func (s *EmailService) ListByMailbox(ctx context.Context, mailboxID int64, limit
 	return emails, nil
 }
 
-func (s *EmailService) Search(ctx context.Context, mailboxID int64, query string, limit, offset int64) ([]*domain.Email, error) {
-	searchPattern := "%" + query + "%"
+func (s *EmailService) ListBySubaddress(ctx context.Context, mailboxID int64, subaddress string, limit, offset int64) ([]*domain.Email, error) {
+	dbEmails, err := s.queries.ListEmailsByMailboxAndSubaddress(ctx, db.ListEmailsByMailboxAndSubaddressParams{
+		MailboxID:  mailboxID,
+		Subaddress: subaddress,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*domain.Email, len(dbEmails))
+	for i, dbEmail := range dbEmails {
+		emails[i] = s.toDomain(dbEmail)
+	}
+	return emails, nil
+}
+
+func (s *EmailService) CountBySubaddress(ctx context.Context, mailboxID int64, subaddress string) (int64, error) {
+	return s.queries.CountEmailsByMailboxAndSubaddress(ctx, db.CountEmailsByMailboxAndSubaddressParams{
+		MailboxID:  mailboxID,
+		Subaddress: subaddress,
+	})
+}
+
+// ListSubaddresses returns the plus-address tags received by a mailbox.
+func (s *EmailService) ListSubaddresses(ctx context.Context, mailboxID int64) ([]domain.SubaddressCount, error) {
+	rows, err := s.queries.ListMailboxSubaddresses(ctx, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	subaddresses := make([]domain.SubaddressCount, len(rows))
+	for i, row := range rows {
+		subaddresses[i] = domain.SubaddressCount{
+			Subaddress: row.Subaddress,
+			EmailCount: row.EmailCount,
+		}
+	}
+	return subaddresses, nil
+}
+
+// SearchScope limits a search to the mailboxes a user can read.
+type SearchScope struct {
+	// MailboxID searches a single mailbox, 0 searches every mailbox
+	MailboxID int64
+	// OwnerID restricts the search to mailboxes owned by a user, nil for
+	// admins
+	OwnerID *int64
+}
+
+// Search runs a query in the search syntax of the search package. Malformed
+// queries return an error wrapping search.ErrInvalidQuery.
+func (s *EmailService) Search(ctx context.Context, scope SearchScope, query string, limit, offset int64) ([]*domain.Email, error) {
+	params, err := s.searchParams(scope, query)
+	if err != nil {
+		return nil, err
+	}
 	dbEmails, err := s.queries.SearchEmails(ctx, db.SearchEmailsParams{
-		MailboxID:   mailboxID,
-		Subject:     sql.NullString{String: searchPattern, Valid: true},
-		FromAddress: searchPattern,
-		Limit:       limit,
-		Offset:      offset,
+		MailboxID:      params.MailboxID,
+		OwnerID:        params.OwnerID,
+		MailboxSlug:    params.MailboxSlug,
+		Match:          params.Match,
+		Exclude:        params.Exclude,
+		HasAttachment:  params.HasAttachment,
+		IsRead:         params.IsRead,
+		ReceivedBefore: params.ReceivedBefore,
+		ReceivedAfter:  params.ReceivedAfter,
+		Limit:          limit,
+		Offset:         offset,
 	})
 	if err != nil {
 		return nil, err
@@ -98,13 +160,46 @@ func (s *EmailService) CountAll(ctx context.Context) (int64, error) {
 	return s.queries.CountAllEmails(ctx)
 }
 
-func (s *EmailService) SearchCount(ctx context.Context, mailboxID int64, query string) (int64, error) {
-	searchPattern := "%" + query + "%"
-	return s.queries.CountSearchEmails(ctx, db.CountSearchEmailsParams{
-		MailboxID:   mailboxID,
-		Subject:     sql.NullString{String: searchPattern, Valid: true},
-		FromAddress: searchPattern,
-	})
+func (s *EmailService) SearchCount(ctx context.Context, scope SearchScope, query string) (int64, error) {
+	params, err := s.searchParams(scope, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.queries.CountSearchEmails(ctx, params)
+}
+
+func (s *EmailService) searchParams(scope SearchScope, query string) (db.CountSearchEmailsParams, error) {
+	parsed, err := search.Parse(query)
+	if err != nil {
+		return db.CountSearchEmailsParams{}, err
+	}
+
+	match, exclude := parsed.FTS5()
+	if s.driver == "postgres" {
+		match, exclude = parsed.TSQuery()
+	}
+	params := db.CountSearchEmailsParams{
+		MailboxID:   sql.NullInt64{Int64: scope.MailboxID, Valid: scope.MailboxID != 0},
+		MailboxSlug: sql.NullString{String: parsed.Mailbox, Valid: parsed.Mailbox != ""},
+		Match:       sql.NullString{String: match, Valid: match != ""},
+		Exclude:     sql.NullString{String: exclude, Valid: exclude != ""},
+	}
+	if scope.OwnerID != nil {
+		params.OwnerID = sql.NullInt64{Int64: *scope.OwnerID, Valid: true}
+	}
+	if parsed.HasAttachment != nil {
+		params.HasAttachment = sql.NullInt64{Int64: boolToInt(*parsed.HasAttachment), Valid: true}
+	}
+	if parsed.IsRead != nil {
+		params.IsRead = sql.NullInt64{Int64: boolToInt(*parsed.IsRead), Valid: true}
+	}
+	if parsed.Before != nil {
+		params.ReceivedBefore = sql.NullTime{Time: *parsed.Before, Valid: true}
+	}
+	if parsed.After != nil {
+		params.ReceivedAfter = sql.NullTime{Time: *parsed.After, Valid: true}
+	}
+	return params, nil
 }
 
 type CreateEmailParams struct {
@@ -118,6 +213,9 @@ type CreateEmailParams struct {
 	TextBody    string
 	HTMLBody    string
 	RawSize     int64
+	Auth        domain.EmailAuth
+	Alias       string
+	Subaddress  string
 }
 
 func (s *EmailService) Create(ctx context.Context, params CreateEmailParams) (*domain.Email, error) {
@@ -133,16 +231,24 @@ func (s *EmailService) Create(ctx context.Context, params CreateEmailParams) (*d
 	}
 
 	dbEmail, err := s.queries.CreateEmail(ctx, db.CreateEmailParams{
-		MailboxID:   params.MailboxID,
-		MessageID:   sql.NullString{String: params.MessageID, Valid: params.MessageID != ""},
-		FromAddress: params.FromAddress,
-		ToAddress:   params.ToAddress,
-		Subject:     sql.NullString{String: params.Subject, Valid: params.Subject != ""},
-		Date:        dateVal,
-		Headers:     sql.NullString{String: headersJSON, Valid: true},
-		TextBody:    sql.NullString{String: params.TextBody, Valid: params.TextBody != ""},
-		HtmlBody:    sql.NullString{String: params.HTMLBody, Valid: params.HTMLBody != ""},
-		RawSize:     params.RawSize,
+		MailboxID:    params.MailboxID,
+		MessageID:    sql.NullString{String: params.MessageID, Valid: params.MessageID != ""},
+		FromAddress:  params.FromAddress,
+		ToAddress:    params.ToAddress,
+		Subject:      sql.NullString{String: params.Subject, Valid: params.Subject != ""},
+		Date:         dateVal,
+		Headers:      sql.NullString{String: headersJSON, Valid: true},
+		TextBody:     sql.NullString{String: params.TextBody, Valid: params.TextBody != ""},
+		HtmlBody:     sql.NullString{String: params.HTMLBody, Valid: params.HTMLBody != ""},
+		RawSize:      params.RawSize,
+		SpfResult:    params.Auth.SPF,
+		SpfDomain:    params.Auth.SPFDomain,
+		DkimResult:   params.Auth.DKIM,
+		DkimDomain:   params.Auth.DKIMDomain,
+		DmarcResult:  params.Auth.DMARC,
+		DmarcPolicy:  params.Auth.DMARCPolicy,
+		AliasAddress: params.Alias,
+		Subaddress:   params.Subaddress,
 	})
 	if err != nil {
 		return nil, err
@@ -162,19 +268,51 @@ func (s *EmailService) CreateAttachment(ctx context.Context, emailID int64, file
 	if err != nil {
 		return nil, err
 	}
+	if err := s.queries.AddEmailAttachmentName(ctx, db.AddEmailAttachmentNameParams{
+		Filename: filename,
+		ID:       emailID,
+	}); err != nil {
+		return nil, err
+	}
 
-	return &domain.Attachment{
-		ID:          dbAtt.ID,
-		EmailID:     dbAtt.EmailID,
-		Filename:    dbAtt.Filename,
-		ContentType: dbAtt.ContentType,
-		Size:        dbAtt.Size,
-		StoragePath: dbAtt.StoragePath,
-		CreatedAt:   dbAtt.CreatedAt,
-	}, nil
+	return s.attachmentToDomain(dbAtt), nil
+}
+
+func (s *EmailService) GetAttachmentByID(ctx context.Context, id int64) (*domain.Attachment, error) {
+	dbAtt, err := s.queries.GetAttachmentByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return s.attachmentToDomain(dbAtt), nil
+}
+
+func (s *EmailService) SetRawPath(ctx context.Context, id int64, rawPath string) error {
+	return s.queries.UpdateEmailRawPath(ctx, db.UpdateEmailRawPathParams{
+		RawPath: sql.NullString{String: rawPath, Valid: rawPath != ""},
+		ID:      id,
+	})
 }
 
 func (s *EmailService) Delete(ctx context.Context, id int64) error {
+	// Load the email first so subscribers get a snapshot of what was deleted
+	email, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.queries.DeleteEmail(ctx, id); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, domain.Event{Type: domain.EventEmailDeleted, Email: email})
+	return nil
+}
+
+// Discard removes an email whose delivery was not accepted. Unlike Delete
+// no event is published, as the email was never announced.
+func (s *EmailService) Discard(ctx context.Context, id int64) error {
 	return s.queries.DeleteEmail(ctx, id)
 }
 
@@ -182,15 +320,46 @@ func (s *EmailService) DeleteOldEmails(ctx context.Context, before time.Time) er
 	return s.queries.DeleteOldEmails(ctx, before)
 }
 
-func (s *EmailService) DeleteOldEmailsByMailbox(ctx context.Context, mailboxID int64, before time.Time) error {
-	return s.queries.DeleteOldEmailsByMailbox(ctx, db.DeleteOldEmailsByMailboxParams{
+// DeleteOldEmailsByMailbox applies retention to a mailbox and returns the
+// number of emails removed.
+func (s *EmailService) DeleteOldEmailsByMailbox(ctx context.Context, mailboxID int64, before time.Time) (int64, error) {
+	deleted, err := s.queries.DeleteOldEmailsByMailbox(ctx, db.DeleteOldEmailsByMailboxParams{
 		MailboxID:  mailboxID,
 		ReceivedAt: before,
 	})
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		s.bus.Publish(ctx, domain.Event{
+			Type: domain.EventRetentionPurged,
+			Retention: &domain.RetentionPurge{
+				MailboxID:    mailboxID,
+				Before:       before.UTC(),
+				DeletedCount: deleted,
+			},
+		})
+	}
+	return deleted, nil
 }
 
+// MarkAsRead publishes email.read only when the email was unread.
 func (s *EmailService) MarkAsRead(ctx context.Context, id int64) error {
-	return s.queries.MarkEmailAsRead(ctx, id)
+	email, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if email.IsRead {
+		return nil
+	}
+	if err := s.queries.MarkEmailAsRead(ctx, id); err != nil {
+		return err
+	}
+
+	email.IsRead = true
+	s.bus.Publish(ctx, domain.Event{Type: domain.EventEmailRead, Email: email})
+	return nil
 }
 
 func (s *EmailService) MarkAsUnread(ctx context.Context, id int64) error {
@@ -201,6 +370,13 @@ func (s *EmailService) CountUnreadByMailbox(ctx context.Context, mailboxID int64
 	return s.queries.CountUnreadByMailbox(ctx, mailboxID)
 }
 
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func parseDateTime(s string) (time.Time, error) {
 	formats := []string{
 		time.RFC3339,
@@ -225,6 +401,17 @@ func (s *EmailService) toDomain(dbEmail db.Email) *domain.Email {
 		RawSize:     dbEmail.RawSize,
 		ReceivedAt:  dbEmail.ReceivedAt,
 		IsRead:      dbEmail.IsRead == 1,
+		Auth: domain.EmailAuth{
+			SPF:         dbEmail.SpfResult,
+			SPFDomain:   dbEmail.SpfDomain,
+			DKIM:        dbEmail.DkimResult,
+			DKIMDomain:  dbEmail.DkimDomain,
+			DMARC:       dbEmail.DmarcResult,
+			DMARCPolicy: dbEmail.DmarcPolicy,
+		},
+		Alias:          dbEmail.AliasAddress,
+		Subaddress:     dbEmail.Subaddress,
+		HasAttachments: dbEmail.AttachmentNames != "",
 	}
 	if dbEmail.MessageID.Valid {
 		email.MessageID = dbEmail.MessageID.String
@@ -246,5 +433,21 @@ func (s *EmailService) toDomain(dbEmail db.Email) *domain.Email {
 	if dbEmail.HtmlBody.Valid {
 		email.HTMLBody = dbEmail.HtmlBody.String
 	}
+	if dbEmail.RawPath.Valid {
+		email.RawPath = dbEmail.RawPath.String
+		email.HasRaw = true
+	}
 	return email
 }
+
+func (s *EmailService) attachmentToDomain(dbAtt db.Attachment) *domain.Attachment {
+	return &domain.Attachment{
+		ID:          dbAtt.ID,
+		EmailID:     dbAtt.EmailID,
+		Filename:    dbAtt.Filename,
+		ContentType: dbAtt.ContentType,
+		Size:        dbAtt.Size,
+		StoragePath: dbAtt.StoragePath,
+		CreatedAt:   dbAtt.CreatedAt,
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/events"
+	"github.com/jr-k/mailgress/internal/search"
+)
+
+func TestSearchParams(t *testing.T) {
+	query := `from:acme "weekly report" -spam has:attachment is:unread after:2026-01-01 before:2026/02/01 mailbox:support`
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	owner := int64(7)
+	common := db.CountSearchEmailsParams{
+		MailboxID:      sql.NullInt64{Int64: 3, Valid: true},
+		OwnerID:        sql.NullInt64{Int64: owner, Valid: true},
+		MailboxSlug:    sql.NullString{String: "support", Valid: true},
+		HasAttachment:  sql.NullInt64{Int64: 1, Valid: true},
+		IsRead:         sql.NullInt64{Int64: 0, Valid: true},
+		ReceivedBefore: sql.NullTime{Time: before, Valid: true},
+		ReceivedAfter:  sql.NullTime{Time: after, Valid: true},
+	}
+
+	tests := []struct {
+		driver, match, exclude string
+	}{
+		{"sqlite", `{from_address} : "acme"* AND "weekly report"`, `"spam"*`},
+		{"postgres", `acme:*B & (weekly <-> report)`, `spam:*`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			emails := NewEmailService(nil, events.NewBus(), tt.driver)
+			got, err := emails.searchParams(SearchScope{MailboxID: 3, OwnerID: &owner}, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := common
+			want.Match = sql.NullString{String: tt.match, Valid: true}
+			want.Exclude = sql.NullString{String: tt.exclude, Valid: true}
+			if got != want {
+				t.Errorf("searchParams() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+
+	emails := NewEmailService(nil, events.NewBus(), "sqlite")
+	got, err := emails.searchParams(SearchScope{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (db.CountSearchEmailsParams{}) {
+		t.Errorf("an empty search sets %+v, want no filter", got)
+	}
+	if _, err := emails.searchParams(SearchScope{}, `subject:"open`); !errors.Is(err, search.ErrInvalidQuery) {
+		t.Errorf("malformed search = %v, want ErrInvalidQuery", err)
+	}
+}
+
+// TestSearchSQLite runs searches against the SQLite full-text index, so the
+// rendered expressions must be valid FTS5.
+func TestSearchSQLite(t *testing.T) {
+	ctx := context.Background()
+	_, queries := openTestDB(t)
+	emails := NewEmailService(queries, events.NewBus(), "sqlite")
+
+	mailbox, err := queries.CreateMailbox(ctx, db.CreateMailboxParams{Slug: "inbox", IsActive: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice, err := emails.Create(ctx, CreateEmailParams{
+		MailboxID:   mailbox.ID,
+		FromAddress: "billing@acme.com",
+		ToAddress:   "inbox@mailgress.test",
+		Subject:     "Your invoice (March)",
+		TextBody:    "The invoice is attached. It's due in 30 days.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emails.CreateAttachment(ctx, invoice.ID, "invoice-march.pdf", "application/pdf", 3, "attachments/invoice.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emails.Create(ctx, CreateEmailParams{
+		MailboxID:   mailbox.ID,
+		FromAddress: "news@example.com",
+		ToAddress:   "inbox@mailgress.test",
+		Subject:     "Weekly news",
+		TextBody:    "Nothing about invoices this week",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"", 2},
+		{"invoice", 2},
+		{"invoice -news", 1},
+		{"-invoice", 0},
+		{"from:acme", 1},
+		{"-from:acme", 1},
+		{"to:mailgress", 2},
+		{`subject:"weekly news"`, 1},
+		{`"news weekly"`, 0},
+		{"body:attached", 1},
+		{"filename:march", 1},
+		{"has:attachment", 1},
+		{"-has:attachment", 1},
+		{"is:unread", 2},
+		{"is:read", 0},
+		{"before:2000-01-01", 0},
+		{"after:2000-01-01", 2},
+		{"mailbox:inbox", 2},
+		{"mailbox:other", 0},
+		{`it"s`, 1},
+		{`(march)`, 1},
+		{`invoice OR news`, 0},
+		{`NEAR(invoice news)`, 0},
+		{`"`, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			found, err := emails.Search(ctx, SearchScope{}, tt.query, 10, 0)
+			if tt.want < 0 {
+				if !errors.Is(err, search.ErrInvalidQuery) {
+					t.Errorf("Search() = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := emails.SearchCount(ctx, SearchScope{}, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(found)) != tt.want || count != tt.want {
+				t.Errorf("found %d emails, count %d, want %d", len(found), count, tt.want)
+			}
+		})
+	}
+}
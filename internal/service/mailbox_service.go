@@ -10,20 +10,23 @@ import (
 
 	"github.com/jr-k/mailgress/internal/database/db"
 	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
 )
 
 var (
 	ErrMailboxNotFound = errors.New("mailbox not found")
 	ErrInvalidSlug     = errors.New("invalid slug format")
+	ErrAddressTaken    = errors.New("address is already used by another mailbox or alias")
 	slugPattern        = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$|^[a-z0-9]$`)
 )
 
 type MailboxService struct {
 	queries *db.Queries
+	bus     *events.Bus
 }
 
-func NewMailboxService(queries *db.Queries) *MailboxService {
-	return &MailboxService{queries: queries}
+func NewMailboxService(queries *db.Queries, bus *events.Bus) *MailboxService {
+	return &MailboxService{queries: queries, bus: bus}
 }
 
 func (s *MailboxService) GetByID(ctx context.Context, id int64) (*domain.Mailbox, error) {
@@ -96,6 +99,9 @@ func (s *MailboxService) Create(ctx context.Context, slug string, ownerID *int64
 	var domainIDVal sql.NullInt64
 	if domainID != nil {
 		domainIDVal = sql.NullInt64{Int64: *domainID, Valid: true}
+		if err := s.checkAliasFree(ctx, slug, *domainID); err != nil {
+			return nil, err
+		}
 	}
 
 	dbMailbox, err := s.queries.CreateMailbox(ctx, db.CreateMailboxParams{
@@ -112,7 +118,9 @@ func (s *MailboxService) Create(ctx context.Context, slug string, ownerID *int64
 		return nil, err
 	}
 
-	return s.toDomain(dbMailbox), nil
+	mailbox := s.toDomain(dbMailbox)
+	s.publish(ctx, domain.EventMailboxCreated, mailbox)
+	return mailbox, nil
 }
 
 type UpdateMailboxParams struct {
@@ -145,6 +153,9 @@ func (s *MailboxService) Update(ctx context.Context, id int64, params UpdateMail
 	var domainIDVal sql.NullInt64
 	if params.DomainID != nil {
 		domainIDVal = sql.NullInt64{Int64: *params.DomainID, Valid: true}
+		if err := s.checkAliasFree(ctx, slug, *params.DomainID); err != nil {
+			return nil, err
+		}
 	}
 
 	dbMailbox, err := s.queries.UpdateMailbox(ctx, db.UpdateMailboxParams{
@@ -162,7 +173,9 @@ func (s *MailboxService) Update(ctx context.Context, id int64, params UpdateMail
 		return nil, err
 	}
 
-	return s.toDomain(dbMailbox), nil
+	mailbox := s.toDomain(dbMailbox)
+	s.publish(ctx, domain.EventMailboxUpdated, mailbox)
+	return mailbox, nil
 }
 
 func (s *MailboxService) ToggleActive(ctx context.Context, id int64) (*domain.Mailbox, error) {
@@ -170,11 +183,34 @@ func (s *MailboxService) ToggleActive(ctx context.Context, id int64) (*domain.Ma
 	if err != nil {
 		return nil, err
 	}
-	return s.toDomain(dbMailbox), nil
+
+	mailbox := s.toDomain(dbMailbox)
+	s.publish(ctx, domain.EventMailboxUpdated, mailbox)
+	return mailbox, nil
 }
 
 func (s *MailboxService) Delete(ctx context.Context, id int64) error {
-	return s.queries.DeleteMailbox(ctx, id)
+	mailbox, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.queries.DeleteMailbox(ctx, id); err != nil {
+		return err
+	}
+
+	s.publish(ctx, domain.EventMailboxDeleted, mailbox)
+	return nil
+}
+
+// publish sends a mailbox event with the mailbox's domain loaded, so
+// subscribers can render its address.
+func (s *MailboxService) publish(ctx context.Context, eventType string, mailbox *domain.Mailbox) {
+	if mailbox.DomainID != nil && mailbox.Domain == nil {
+		if dbDomain, err := s.queries.GetDomainByID(ctx, *mailbox.DomainID); err == nil {
+			mailbox.Domain = &domain.Domain{ID: dbDomain.ID, Name: dbDomain.Name}
+		}
+	}
+	s.bus.Publish(ctx, domain.Event{Type: eventType, Mailbox: mailbox})
 }
 
 func (s *MailboxService) Count(ctx context.Context) (int64, error) {
@@ -249,13 +285,110 @@ func (s *MailboxService) GetByEmail(ctx context.Context, email string) (*domain.
 		Slug: slug,
 		Name: domainName,
 	})
+	if err == nil {
+		return s.toDomain(dbMailbox), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	alias, err := s.queries.GetMailboxAliasBySlugAndDomainName(ctx, db.GetMailboxAliasBySlugAndDomainNameParams{
+		Slug: slug,
+		Name: domainName,
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrMailboxNotFound
 		}
 		return nil, err
 	}
-	return s.toDomain(dbMailbox), nil
+	return s.GetByID(ctx, alias.MailboxID)
+}
+
+// GetByAlias returns the mailbox an alias slug on domainID delivers to.
+func (s *MailboxService) GetByAlias(ctx context.Context, slug string, domainID int64) (*domain.Mailbox, error) {
+	alias, err := s.queries.GetMailboxAliasBySlugAndDomain(ctx, db.GetMailboxAliasBySlugAndDomainParams{
+		Slug:     slug,
+		DomainID: domainID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMailboxNotFound
+		}
+		return nil, err
+	}
+	return s.GetByID(ctx, alias.MailboxID)
+}
+
+func (s *MailboxService) ListAliases(ctx context.Context, mailboxID int64) ([]domain.MailboxAlias, error) {
+	rows, err := s.queries.ListMailboxAliases(ctx, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]domain.MailboxAlias, len(rows))
+	for i, row := range rows {
+		aliases[i] = domain.MailboxAlias{
+			ID:         row.ID,
+			MailboxID:  row.MailboxID,
+			DomainID:   row.DomainID,
+			Slug:       row.Slug,
+			DomainName: row.DomainName,
+			CreatedAt:  row.CreatedAt,
+		}
+	}
+	return aliases, nil
+}
+
+// CreateAlias adds slug@domain as an extra address of a mailbox. The address
+// must not already belong to a mailbox or to another alias.
+func (s *MailboxService) CreateAlias(ctx context.Context, mailboxID, domainID int64, slug string) error {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return ErrInvalidSlug
+	}
+
+	_, err := s.queries.GetMailboxBySlugAndDomain(ctx, db.GetMailboxBySlugAndDomainParams{
+		Slug:     slug,
+		DomainID: sql.NullInt64{Int64: domainID, Valid: true},
+	})
+	if err == nil {
+		return ErrAddressTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := s.checkAliasFree(ctx, slug, domainID); err != nil {
+		return err
+	}
+
+	_, err = s.queries.CreateMailboxAlias(ctx, db.CreateMailboxAliasParams{
+		MailboxID: mailboxID,
+		DomainID:  domainID,
+		Slug:      slug,
+	})
+	return err
+}
+
+func (s *MailboxService) DeleteAlias(ctx context.Context, mailboxID, aliasID int64) error {
+	return s.queries.DeleteMailboxAlias(ctx, db.DeleteMailboxAliasParams{
+		ID:        aliasID,
+		MailboxID: mailboxID,
+	})
+}
+
+func (s *MailboxService) checkAliasFree(ctx context.Context, slug string, domainID int64) error {
+	exists, err := s.queries.MailboxAliasExistsBySlugAndDomain(ctx, db.MailboxAliasExistsBySlugAndDomainParams{
+		Slug:     slug,
+		DomainID: domainID,
+	})
+	if err != nil {
+		return err
+	}
+	if exists != 0 {
+		return ErrAddressTaken
+	}
+	return nil
 }
 
 func (s *MailboxService) toDomain(dbMailbox db.Mailbox) *domain.Mailbox {
@@ -288,3 +421,12 @@ func ExtractSlug(localPart string) string {
 	}
 	return strings.ToLower(localPart)
 }
+
+// ExtractSubaddress returns the tag of a plus-addressed local part
+// (orders in inbox+orders), or an empty string.
+func ExtractSubaddress(localPart string) string {
+	if idx := strings.Index(localPart, "+"); idx != -1 {
+		return localPart[idx+1:]
+	}
+	return ""
+}
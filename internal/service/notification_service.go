@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/mailer"
+)
+
+// NotificationService tells users about events that need their attention.
+// Messages go out through the SMTP relay; without one they are logged.
+type NotificationService struct {
+	queries *db.Queries
+	mailer  *mailer.Mailer
+	appURL  string
+}
+
+func NewNotificationService(queries *db.Queries, m *mailer.Mailer, appURL string) *NotificationService {
+	return &NotificationService{
+		queries: queries,
+		mailer:  m,
+		appURL:  strings.TrimRight(appURL, "/"),
+	}
+}
+
+// WebhookDisabled notifies the owner of the webhook's mailbox, or every
+// admin when the mailbox has no owner, that the webhook was disabled after
+// failing since failingSince.
+func (s *NotificationService) WebhookDisabled(ctx context.Context, webhook *domain.Webhook, failingSince time.Time, lastError string) error {
+	mailbox, err := s.queries.GetMailboxByID(ctx, webhook.MailboxID)
+	if err != nil {
+		return err
+	}
+
+	address := mailbox.Slug
+	if mailbox.DomainID.Valid {
+		if d, err := s.queries.GetDomainByID(ctx, mailbox.DomainID.Int64); err == nil {
+			address = mailbox.Slug + "@" + d.Name
+		}
+	}
+
+	recipients, err := s.recipients(ctx, mailbox)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Webhook %q on %s was disabled", webhook.Name, address)
+	body := fmt.Sprintf(`The webhook %q on mailbox %s has been failing since %s and was disabled.
+
+URL: %s
+Last error: %s
+
+New email will not be sent to this webhook until it is enabled again:
+%s/mailboxes/%d/webhooks/%d
+`,
+		webhook.Name, address, failingSince.UTC().Format(time.RFC1123),
+		webhook.URL, lastError,
+		s.appURL, webhook.MailboxID, webhook.ID)
+
+	if err := s.mailer.Send(recipients, subject, body); err != nil {
+		if errors.Is(err, mailer.ErrNotConfigured) {
+			log.Printf("Notification for %s: %s", strings.Join(recipients, ", "), subject)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *NotificationService) recipients(ctx context.Context, mailbox db.Mailbox) ([]string, error) {
+	if mailbox.OwnerID.Valid {
+		owner, err := s.queries.GetUserByID(ctx, mailbox.OwnerID.Int64)
+		if err == nil {
+			return []string{owner.Email}, nil
+		}
+	}
+
+	users, err := s.queries.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var admins []string
+	for _, u := range users {
+		if u.IsAdmin != 0 {
+			admins = append(admins, u.Email)
+		}
+	}
+	return admins, nil
+}
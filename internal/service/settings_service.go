@@ -11,6 +11,12 @@ import (
 const (
 	SettingOnboardingCompleted = "onboarding_completed"
 	SettingAppName             = "app_name"
+	// SettingWebhookECDSAKey holds the PEM private key that signs webhooks
+	// using the SendGrid preset.
+	SettingWebhookECDSAKey = "webhook_ecdsa_key"
+	// SettingWebhookEd25519Key holds the PEM private key of the optional
+	// Ed25519 webhook signature.
+	SettingWebhookEd25519Key = "webhook_ed25519_key"
 )
 
 var ErrSettingNotFound = errors.New("setting not found")
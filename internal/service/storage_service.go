@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/storage"
+)
+
+// orphanGracePeriod keeps recent files out of the orphan report, since
+// attachments are written before their rows are inserted.
+const orphanGracePeriod = time.Hour
+
+// tombstoneBatchSize is how many queued deletions are read at once.
+const tombstoneBatchSize = 500
+
+// StorageService removes the stored files of deleted emails and
+// attachments, and reconciles storage against the database. Deleting a row
+// queues its file as a tombstone in the same transaction, so files are
+// removed even when the row went through ON DELETE CASCADE.
+type StorageService struct {
+	queries *db.Queries
+	storage storage.Storage
+}
+
+func NewStorageService(queries *db.Queries, store storage.Storage) *StorageService {
+	return &StorageService{queries: queries, storage: store}
+}
+
+// StorageReport is the result of a reconciliation.
+type StorageReport struct {
+	// Files is the number of stored files checked
+	Files int
+	// OrphanFiles are stored files no row references
+	OrphanFiles []string
+	// DeletedOrphans is how many orphan files were removed
+	DeletedOrphans int
+	// MissingFiles are rows whose stored file is gone
+	MissingFiles []MissingFile
+	// PendingTombstones is the number of files still queued for deletion
+	PendingTombstones int64
+}
+
+// MissingFile is a row referencing a file that is not in storage.
+type MissingFile struct {
+	Kind    string `json:"kind"`
+	ID      int64  `json:"id"`
+	EmailID int64  `json:"email_id"`
+	Path    string `json:"path"`
+}
+
+// PurgeTombstones removes the files of deleted rows and returns how many
+// it removed. Files already gone count as removed. A file that cannot be
+// removed stays queued for the next run.
+func (s *StorageService) PurgeTombstones(ctx context.Context) (int, error) {
+	purged := 0
+	var lastFailed int64
+	for {
+		tombstones, err := s.queries.ListStorageTombstones(ctx, tombstoneBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		progressed := false
+		for _, tombstone := range tombstones {
+			if tombstone.ID <= lastFailed {
+				continue
+			}
+			if err := s.storage.Delete(tombstone.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Failed to delete stored file %s: %v", tombstone.Path, err)
+				lastFailed = tombstone.ID
+				continue
+			}
+			if err := s.queries.DeleteStorageTombstone(ctx, tombstone.ID); err != nil {
+				return purged, err
+			}
+			purged++
+			progressed = true
+		}
+		if !progressed || len(tombstones) < tombstoneBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Reconcile compares stored files against the attachments and raw messages
+// of emails. Files no row references and older than an hour are reported,
+// and removed when deleteOrphans is set. Rows whose file is missing are
+// reported only.
+func (s *StorageService) Reconcile(ctx context.Context, deleteOrphans bool) (*StorageReport, error) {
+	// Read the files first, so a file stored during the walk and its row
+	// inserted after the queries are not reported as orphaned
+	files := make(map[string]storage.ObjectInfo)
+	err := s.storage.Walk(func(info storage.ObjectInfo) error {
+		files[info.Path] = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+
+	attachments, err := s.queries.ListAttachmentPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawMessages, err := s.queries.ListEmailRawPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.queries.CountStorageTombstones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StorageReport{
+		Files:             len(files),
+		OrphanFiles:       []string{},
+		MissingFiles:      []MissingFile{},
+		PendingTombstones: pending,
+	}
+
+	referenced := make(map[string]bool, len(attachments)+len(rawMessages))
+	for _, att := range attachments {
+		referenced[att.StoragePath] = true
+		if _, ok := files[att.StoragePath]; !ok && !s.exists(att.StoragePath) {
+			report.MissingFiles = append(report.MissingFiles, MissingFile{Kind: "attachment", ID: att.ID, EmailID: att.EmailID, Path: att.StoragePath})
+		}
+	}
+	for _, email := range rawMessages {
+		referenced[email.RawPath.String] = true
+		if _, ok := files[email.RawPath.String]; !ok && !s.exists(email.RawPath.String) {
+			report.MissingFiles = append(report.MissingFiles, MissingFile{Kind: "raw_message", ID: email.ID, EmailID: email.ID, Path: email.RawPath.String})
+		}
+	}
+
+	cutoff := time.Now().Add(-orphanGracePeriod)
+	for path, info := range files {
+		if referenced[path] || info.ModTime.After(cutoff) {
+			continue
+		}
+		report.OrphanFiles = append(report.OrphanFiles, path)
+		if deleteOrphans {
+			if err := s.storage.Delete(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Failed to delete orphaned file %s: %v", path, err)
+				continue
+			}
+			report.DeletedOrphans++
+		}
+	}
+	sort.Strings(report.OrphanFiles)
+
+	return report, nil
+}
+
+// exists checks a file stored after the walk. Files that cannot be
+// checked are not reported as missing.
+func (s *StorageService) exists(path string) bool {
+	_, err := s.storage.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/storage"
+)
+
+// storageEnv is a storage service over a migrated SQLite database and local
+// storage in temporary directories.
+type storageEnv struct {
+	service *StorageService
+	queries *db.Queries
+	store   storage.Storage
+	root    string
+	mailbox db.Mailbox
+}
+
+func newStorageEnv(t *testing.T) *storageEnv {
+	t.Helper()
+	_, queries := openTestDB(t)
+	root := filepath.Join(t.TempDir(), "storage")
+	store, err := storage.NewLocal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailbox, err := queries.CreateMailbox(context.Background(), db.CreateMailboxParams{Slug: "inbox", IsActive: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &storageEnv{
+		service: NewStorageService(queries, store),
+		queries: queries,
+		store:   store,
+		root:    root,
+		mailbox: mailbox,
+	}
+}
+
+// storedEmail is an email with a stored raw message and one attachment.
+type storedEmail struct {
+	id         int64
+	rawPath    string
+	attachment string
+}
+
+func (e *storageEnv) addEmail(t *testing.T) storedEmail {
+	t.Helper()
+	ctx := context.Background()
+	email, err := e.queries.CreateEmail(ctx, db.CreateEmailParams{
+		MailboxID:   e.mailbox.ID,
+		FromAddress: "alice@example.com",
+		ToAddress:   "inbox@mailgress.test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPath, _, err := e.store.Store(email.ID, "message.eml", strings.NewReader("Subject: hi\r\n\r\nhi\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.queries.UpdateEmailRawPath(ctx, db.UpdateEmailRawPathParams{RawPath: sql.NullString{String: rawPath, Valid: true}, ID: email.ID}); err != nil {
+		t.Fatal(err)
+	}
+	attachment, size, err := e.store.Store(email.ID, "report.pdf", strings.NewReader("%PDF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		EmailID:     email.ID,
+		Filename:    "report.pdf",
+		ContentType: "application/pdf",
+		Size:        size,
+		StoragePath: attachment,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return storedEmail{id: email.ID, rawPath: rawPath, attachment: attachment}
+}
+
+// age moves the modification time of every stored file past the orphan
+// grace period.
+func (e *storageEnv) age(t *testing.T) {
+	t.Helper()
+	old := time.Now().Add(-2 * orphanGracePeriod)
+	err := filepath.WalkDir(e.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (e *storageEnv) exists(t *testing.T, path string) bool {
+	t.Helper()
+	_, err := e.store.Stat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestPurgeTombstones(t *testing.T) {
+	ctx := context.Background()
+	env := newStorageEnv(t)
+	deleted := env.addEmail(t)
+	kept := env.addEmail(t)
+
+	if err := env.queries.DeleteEmail(ctx, deleted.id); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := env.service.PurgeTombstones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d files, want the raw message and the attachment", purged)
+	}
+	for _, path := range []string{deleted.rawPath, deleted.attachment} {
+		if env.exists(t, path) {
+			t.Errorf("%s of the deleted email is still stored", path)
+		}
+	}
+	for _, path := range []string{kept.rawPath, kept.attachment} {
+		if !env.exists(t, path) {
+			t.Errorf("%s of a kept email was removed", path)
+		}
+	}
+
+	// Deleting the mailbox cascades to its emails and their attachments
+	if err := os.Remove(filepath.Join(env.root, filepath.FromSlash(kept.attachment))); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.queries.DeleteMailbox(ctx, env.mailbox.ID); err != nil {
+		t.Fatal(err)
+	}
+	purged, err = env.service.PurgeTombstones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d files after deleting the mailbox, want 2 including the missing one", purged)
+	}
+	if env.exists(t, kept.rawPath) {
+		t.Errorf("%s is still stored after deleting the mailbox", kept.rawPath)
+	}
+	pending, err := env.queries.CountStorageTombstones(ctx)
+	if err != nil || pending != 0 {
+		t.Errorf("%d tombstones left, %v, want none", pending, err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	env := newStorageEnv(t)
+	first := env.addEmail(t)
+	second := env.addEmail(t)
+
+	orphan := "2020/01/01/99/abcdefgh_orphan.txt"
+	if err := env.store.Put(orphan, strings.NewReader("orphan"), 6); err != nil {
+		t.Fatal(err)
+	}
+	env.age(t)
+	recent := "2020/01/01/99/abcdefgh_recent.txt"
+	if err := env.store.Put(recent, strings.NewReader("recent"), 6); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.store.Delete(second.attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := env.service.Reconcile(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 5 {
+		t.Errorf("checked %d files, want 5", report.Files)
+	}
+	if !reflect.DeepEqual(report.OrphanFiles, []string{orphan}) {
+		t.Errorf("orphans = %v, want only %s", report.OrphanFiles, orphan)
+	}
+	if report.DeletedOrphans != 0 || !env.exists(t, orphan) {
+		t.Errorf("an orphan was deleted without deleteOrphans")
+	}
+	if len(report.MissingFiles) != 1 || report.MissingFiles[0].Path != second.attachment || report.MissingFiles[0].Kind != "attachment" {
+		t.Errorf("missing files = %+v, want the removed attachment", report.MissingFiles)
+	}
+
+	report, err = env.service.Reconcile(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.DeletedOrphans != 1 || env.exists(t, orphan) {
+		t.Errorf("deleted %d orphans, want %s deleted", report.DeletedOrphans, orphan)
+	}
+	for _, path := range []string{first.rawPath, first.attachment, second.rawPath, recent} {
+		if !env.exists(t, path) {
+			t.Errorf("%s was deleted as an orphan", path)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/database/db"
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// WebhookJobService stores the webhook delivery queue. Jobs are claimed
+// with a time-limited lease, so a job held by a worker that died is picked
+// up again once its lease expires.
+type WebhookJobService struct {
+	queries *db.Queries
+	tx      *database.Transactor
+}
+
+func NewWebhookJobService(queries *db.Queries, tx *database.Transactor) *WebhookJobService {
+	return &WebhookJobService{queries: queries, tx: tx}
+}
+
+// Enqueue queues an email.received delivery. The payload is built from the
+// stored email when the job runs. An empty idempotencyKey gets a new one;
+// manual retries pass the key of the delivery they repeat.
+func (s *WebhookJobService) Enqueue(ctx context.Context, webhookID, emailID int64, idempotencyKey string) (*domain.WebhookJob, error) {
+	return s.enqueue(ctx, s.queries, webhookID, sql.NullInt64{Int64: emailID, Valid: true}, domain.EventEmailReceived, sql.NullString{}, idempotencyKey)
+}
+
+// EnqueueAll queues an email.received delivery for each of webhookIDs in a
+// single transaction: either every webhook gets the email or none does.
+func (s *WebhookJobService) EnqueueAll(ctx context.Context, webhookIDs []int64, emailID int64) error {
+	return s.tx.InTx(ctx, func(q *db.Queries) error {
+		for _, webhookID := range webhookIDs {
+			if _, err := s.enqueue(ctx, q, webhookID, sql.NullInt64{Int64: emailID, Valid: true}, domain.EventEmailReceived, sql.NullString{}, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EnqueueEvent queues any other event with its payload already rendered.
+// The job does not reference an email, so it outlives the email's deletion.
+func (s *WebhookJobService) EnqueueEvent(ctx context.Context, webhookID int64, event string, payload string, idempotencyKey string) (*domain.WebhookJob, error) {
+	return s.enqueue(ctx, s.queries, webhookID, sql.NullInt64{}, event, sql.NullString{String: payload, Valid: true}, idempotencyKey)
+}
+
+func (s *WebhookJobService) enqueue(ctx context.Context, queries *db.Queries, webhookID int64, emailID sql.NullInt64, event string, payload sql.NullString, idempotencyKey string) (*domain.WebhookJob, error) {
+	if idempotencyKey == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		idempotencyKey = hex.EncodeToString(buf)
+	}
+
+	dbJob, err := queries.EnqueueWebhookJob(ctx, db.EnqueueWebhookJobParams{
+		WebhookID:      webhookID,
+		EmailID:        emailID,
+		Event:          event,
+		Payload:        payload,
+		Attempt:        1,
+		AvailableAt:    time.Now().UTC(),
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toDomain(dbJob), nil
+}
+
+// Claim leases the next due job to owner for the given duration. It returns
+// nil without error when no job is due.
+func (s *WebhookJobService) Claim(ctx context.Context, owner string, lease time.Duration) (*domain.WebhookJob, error) {
+	now := time.Now().UTC()
+	dbJob, err := s.queries.ClaimWebhookJob(ctx, db.ClaimWebhookJobParams{
+		LeaseOwner:       owner,
+		LeaseExpiresAt:   sql.NullTime{Time: now.Add(lease), Valid: true},
+		AvailableAt:      now,
+		LeaseExpiresAt_2: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.toDomain(dbJob), nil
+}
+
+// Reschedule releases a job for another attempt once at has passed.
+func (s *WebhookJobService) Reschedule(ctx context.Context, id int64, attempt int, lastDeliveryID int64, at time.Time) error {
+	return s.queries.RescheduleWebhookJob(ctx, db.RescheduleWebhookJobParams{
+		Attempt:        int64(attempt),
+		LastDeliveryID: sql.NullInt64{Int64: lastDeliveryID, Valid: true},
+		AvailableAt:    at.UTC(),
+		ID:             id,
+	})
+}
+
+// Defer puts a job back in the queue until at without counting an attempt.
+func (s *WebhookJobService) Defer(ctx context.Context, job *domain.WebhookJob, at time.Time) error {
+	var lastDeliveryID sql.NullInt64
+	if job.LastDeliveryID != nil {
+		lastDeliveryID = sql.NullInt64{Int64: *job.LastDeliveryID, Valid: true}
+	}
+	return s.queries.RescheduleWebhookJob(ctx, db.RescheduleWebhookJobParams{
+		Attempt:        int64(job.Attempt),
+		LastDeliveryID: lastDeliveryID,
+		AvailableAt:    at.UTC(),
+		ID:             job.ID,
+	})
+}
+
+func (s *WebhookJobService) Complete(ctx context.Context, id int64) error {
+	return s.queries.DeleteWebhookJob(ctx, id)
+}
+
+// CancelRetries drops the pending retries of a webhook, leaving events that
+// have not been attempted yet in the queue.
+func (s *WebhookJobService) CancelRetries(ctx context.Context, webhookID int64) error {
+	return s.queries.DeleteRetryWebhookJobsByWebhook(ctx, webhookID)
+}
+
+func (s *WebhookJobService) Count(ctx context.Context) (int64, error) {
+	return s.queries.CountWebhookJobs(ctx)
+}
+
+func (s *WebhookJobService) toDomain(dbJob db.WebhookJob) *domain.WebhookJob {
+	job := &domain.WebhookJob{
+		ID:             dbJob.ID,
+		WebhookID:      dbJob.WebhookID,
+		Event:          dbJob.Event,
+		IdempotencyKey: dbJob.IdempotencyKey,
+		Attempt:        int(dbJob.Attempt),
+		AvailableAt:    dbJob.AvailableAt,
+		LeaseOwner:     dbJob.LeaseOwner,
+		CreatedAt:      dbJob.CreatedAt,
+	}
+	if dbJob.EmailID.Valid {
+		job.EmailID = &dbJob.EmailID.Int64
+	}
+	if dbJob.Payload.Valid {
+		job.Payload = dbJob.Payload.String
+	}
+	if dbJob.LastDeliveryID.Valid {
+		job.LastDeliveryID = &dbJob.LastDeliveryID.Int64
+	}
+	if dbJob.LeaseExpiresAt.Valid {
+		job.LeaseExpiresAt = &dbJob.LeaseExpiresAt.Time
+	}
+	return job
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/database/db"
+)
+
+// openTestDB returns a migrated SQLite database in a temporary directory.
+func openTestDB(t *testing.T) (*sql.DB, *db.Queries) {
+	t.Helper()
+	sqlDB, queries, err := database.NewConnection("sqlite", filepath.Join(t.TempDir(), "mailgress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.RunMigrations(sqlDB, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB, queries
+}
+
+func TestEnqueueAll(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, queries := openTestDB(t)
+	jobs := NewWebhookJobService(queries, database.NewTransactor(sqlDB, "sqlite"))
+
+	mailbox, err := queries.CreateMailbox(ctx, db.CreateMailboxParams{Slug: "inbox", IsActive: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	email, err := queries.CreateEmail(ctx, db.CreateEmailParams{MailboxID: mailbox.ID, FromAddress: "alice@example.com", ToAddress: "inbox@mailgress.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks := NewWebhookService(queries, database.NewTransactor(sqlDB, "sqlite"))
+	var ids []int64
+	for _, name := range []string{"first", "second"} {
+		wh, err := webhooks.Create(ctx, CreateWebhookParams{MailboxID: mailbox.ID, Name: name, URL: "https://example.com/" + name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, wh.ID)
+	}
+
+	// The last webhook does not exist, so nothing is queued
+	if err := jobs.EnqueueAll(ctx, append(ids, 9999), email.ID); err == nil {
+		t.Fatal("queued a job for a missing webhook")
+	}
+	if count, err := jobs.Count(ctx); err != nil || count != 0 {
+		t.Fatalf("%d jobs left by a failed EnqueueAll, %v, want none", count, err)
+	}
+
+	if err := jobs.EnqueueAll(ctx, ids, email.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := jobs.Count(ctx); err != nil || count != 2 {
+		t.Fatalf("%d jobs queued, %v, want 2", count, err)
+	}
+}
+
+func TestNormalizeTimeout(t *testing.T) {
+	for timeout, want := range map[int]int{
+		-1:                       DefaultWebhookTimeoutSec,
+		0:                        DefaultWebhookTimeoutSec,
+		5:                        5,
+		MaxWebhookTimeoutSec:     MaxWebhookTimeoutSec,
+		MaxWebhookTimeoutSec + 1: MaxWebhookTimeoutSec,
+		86400:                    MaxWebhookTimeoutSec,
+	} {
+		if got := normalizeTimeout(timeout); got != want {
+			t.Errorf("normalizeTimeout(%d) = %d, want %d", timeout, got, want)
+		}
+	}
+}
@@ -2,22 +2,37 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jr-k/mailgress/internal/database"
 	"github.com/jr-k/mailgress/internal/database/db"
 	"github.com/jr-k/mailgress/internal/domain"
 )
 
-var ErrWebhookNotFound = errors.New("webhook not found")
+var (
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrInvalidWebhookRule = errors.New("invalid webhook rule")
+)
+
+// webhookSecretPrefix marks secrets generated by a rotation.
+const webhookSecretPrefix = "whsec_"
 
 type WebhookService struct {
 	queries *db.Queries
+	tx      *database.Transactor
 }
 
-func NewWebhookService(queries *db.Queries) *WebhookService {
-	return &WebhookService{queries: queries}
+func NewWebhookService(queries *db.Queries, tx *database.Transactor) *WebhookService {
+	return &WebhookService{queries: queries, tx: tx}
 }
 
 func (s *WebhookService) GetByID(ctx context.Context, id int64) (*domain.Webhook, error) {
@@ -40,6 +55,10 @@ func (s *WebhookService) GetByID(ctx context.Context, id int64) (*domain.Webhook
 		webhook.Rules[i] = s.ruleToDomain(dbRule)
 	}
 
+	if webhook.PreviousSecrets, err = s.previousSecrets(ctx, id); err != nil {
+		return nil, err
+	}
+
 	return webhook, nil
 }
 
@@ -61,7 +80,34 @@ func (s *WebhookService) ListActiveByMailbox(ctx context.Context, mailboxID int6
 	if err != nil {
 		return nil, err
 	}
+	return s.withRules(ctx, dbWebhooks)
+}
+
+// ListActiveByDomainOwner returns the active webhooks of the mailboxes in
+// the domain owned by ownerID, with their rules.
+func (s *WebhookService) ListActiveByDomainOwner(ctx context.Context, domainID, ownerID int64) ([]*domain.Webhook, error) {
+	dbWebhooks, err := s.queries.ListActiveWebhooksByDomainOwner(ctx, db.ListActiveWebhooksByDomainOwnerParams{
+		DomainID: sql.NullInt64{Int64: domainID, Valid: true},
+		OwnerID:  sql.NullInt64{Int64: ownerID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.withRules(ctx, dbWebhooks)
+}
+
+// ListActiveAdminByDomain returns the active webhooks of the mailboxes in
+// the domain that admins manage, owned by an admin or by nobody, with their
+// rules.
+func (s *WebhookService) ListActiveAdminByDomain(ctx context.Context, domainID int64) ([]*domain.Webhook, error) {
+	dbWebhooks, err := s.queries.ListActiveAdminWebhooksByDomain(ctx, sql.NullInt64{Int64: domainID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return s.withRules(ctx, dbWebhooks)
+}
 
+func (s *WebhookService) withRules(ctx context.Context, dbWebhooks []db.Webhook) ([]*domain.Webhook, error) {
 	webhooks := make([]*domain.Webhook, len(dbWebhooks))
 	for i, dbWebhook := range dbWebhooks {
 		wh := s.toDomain(dbWebhook)
@@ -75,24 +121,63 @@ func (s *WebhookService) ListActiveByMailbox(ctx context.Context, mailboxID int6
 			wh.Rules[j] = s.ruleToDomain(dbRule)
 		}
 
+		if wh.PreviousSecrets, err = s.previousSecrets(ctx, wh.ID); err != nil {
+			return nil, err
+		}
+
 		webhooks[i] = wh
 	}
 	return webhooks, nil
 }
 
+// DefaultAttachmentMaxInlineBytes matches the column default of 5MB.
+const DefaultAttachmentMaxInlineBytes = 5 * 1024 * 1024
+
+// Webhook timeouts in seconds. MaxWebhookTimeoutSec keeps every attempt
+// well inside the lease the dispatcher holds on a job.
+const (
+	DefaultWebhookTimeoutSec = 30
+	MaxWebhookTimeoutSec     = 60
+)
+
+// Retry schedule defaults, matching the column defaults.
+const (
+	DefaultRetryBaseDelaySec  = 30
+	DefaultRetryMaxDelaySec   = 3600
+	DefaultRetryJitterPercent = 20
+)
+
 type CreateWebhookParams struct {
-	MailboxID          int64
-	Name               string
-	URL                string
-	Method             string
-	Headers            map[string]string
-	PayloadType        string
-	CustomPayload      string
-	HMACSecret         string
-	TimeoutSec         int
-	MaxRetries         int
-	IncludeBody        bool
-	IncludeAttachments bool
+	MailboxID                int64
+	Name                     string
+	URL                      string
+	Method                   string
+	Headers                  map[string]string
+	PayloadType              string
+	CustomPayload            string
+	HMACSecret               string
+	TimeoutSec               int
+	MaxRetries               int
+	IncludeBody              bool
+	IncludeAttachments       bool
+	RawMode                  string
+	AttachmentMode           string
+	AttachmentMaxInlineBytes int64
+	RetryBaseDelaySec        int
+	RetryMaxDelaySec         int
+	RetryJitterPercent       int
+	Events                   []string
+	Encoding                 string
+	QueryFields              []string
+	SignEd25519              bool
+	TLSClientCert            string
+	TLSClientKey             string
+	TLSCABundle              string
+	TLSSkipVerify            bool
+	ProxyURL                 string
+	RedirectPolicy           string
+	Target                   string
+	TargetConfig             domain.WebhookTargetConfig
 }
 
 func (s *WebhookService) Create(ctx context.Context, params CreateWebhookParams) (*domain.Webhook, error) {
@@ -102,13 +187,19 @@ func (s *WebhookService) Create(ctx context.Context, params CreateWebhookParams)
 		headersJSON = string(data)
 	}
 
-	var includeBody, includeAttachments int64
+	var includeBody, includeAttachments, signEd25519, skipVerify int64
 	if params.IncludeBody {
 		includeBody = 1
 	}
 	if params.IncludeAttachments {
 		includeAttachments = 1
 	}
+	if params.SignEd25519 {
+		signEd25519 = 1
+	}
+	if params.TLSSkipVerify {
+		skipVerify = 1
+	}
 
 	method := params.Method
 	if method == "" {
@@ -120,20 +211,48 @@ func (s *WebhookService) Create(ctx context.Context, params CreateWebhookParams)
 		payloadType = "default"
 	}
 
+	rawMode := normalizeRawMode(params.RawMode)
+	attachmentMode := normalizeAttachmentMode(params.AttachmentMode)
+
+	maxInline := params.AttachmentMaxInlineBytes
+	if maxInline <= 0 {
+		maxInline = DefaultAttachmentMaxInlineBytes
+	}
+
+	baseDelay, maxDelay, jitter := normalizeRetrySchedule(params.RetryBaseDelaySec, params.RetryMaxDelaySec, params.RetryJitterPercent)
+
 	dbWebhook, err := s.queries.CreateWebhook(ctx, db.CreateWebhookParams{
-		MailboxID:          params.MailboxID,
-		Name:               params.Name,
-		Url:                params.URL,
-		Method:             method,
-		Headers:            sql.NullString{String: headersJSON, Valid: true},
-		PayloadType:        payloadType,
-		CustomPayload:      sql.NullString{String: params.CustomPayload, Valid: params.CustomPayload != ""},
-		HmacSecret:         sql.NullString{String: params.HMACSecret, Valid: params.HMACSecret != ""},
-		TimeoutSec:         int64(params.TimeoutSec),
-		MaxRetries:         int64(params.MaxRetries),
-		IncludeBody:        includeBody,
-		IncludeAttachments: includeAttachments,
-		IsActive:           1,
+		MailboxID:                params.MailboxID,
+		Name:                     params.Name,
+		Url:                      params.URL,
+		Method:                   method,
+		Headers:                  sql.NullString{String: headersJSON, Valid: true},
+		PayloadType:              payloadType,
+		CustomPayload:            sql.NullString{String: params.CustomPayload, Valid: params.CustomPayload != ""},
+		HmacSecret:               sql.NullString{String: params.HMACSecret, Valid: params.HMACSecret != ""},
+		TimeoutSec:               int64(normalizeTimeout(params.TimeoutSec)),
+		MaxRetries:               int64(params.MaxRetries),
+		IncludeBody:              includeBody,
+		IncludeAttachments:       includeAttachments,
+		RawMode:                  rawMode,
+		AttachmentMode:           attachmentMode,
+		AttachmentMaxInlineBytes: maxInline,
+		RetryBaseDelaySec:        int64(baseDelay),
+		RetryMaxDelaySec:         int64(maxDelay),
+		RetryJitterPercent:       int64(jitter),
+		Events:                   normalizeEvents(params.Events),
+		Encoding:                 normalizeEncoding(params.Encoding),
+		QueryFields:              joinQueryFields(params.QueryFields),
+		SignEd25519:              signEd25519,
+		TlsClientCert:            strings.TrimSpace(params.TLSClientCert),
+		TlsClientKey:             strings.TrimSpace(params.TLSClientKey),
+		TlsCaBundle:              strings.TrimSpace(params.TLSCABundle),
+		TlsSkipVerify:            skipVerify,
+		ProxyUrl:                 strings.TrimSpace(params.ProxyURL),
+		RedirectPolicy:           normalizeRedirectPolicy(params.RedirectPolicy),
+		Target:                   normalizeTarget(params.Target),
+		TargetConfig:             targetConfigJSON(params.TargetConfig),
+		IsActive:                 1,
 	})
 	if err != nil {
 		return nil, err
@@ -143,19 +262,37 @@ func (s *WebhookService) Create(ctx context.Context, params CreateWebhookParams)
 }
 
 type UpdateWebhookParams struct {
-	ID                 int64
-	Name               string
-	URL                string
-	Method             string
-	Headers            map[string]string
-	PayloadType        string
-	CustomPayload      string
-	HMACSecret         string
-	TimeoutSec         int
-	MaxRetries         int
-	IncludeBody        bool
-	IncludeAttachments bool
-	IsActive           bool
+	ID                       int64
+	Name                     string
+	URL                      string
+	Method                   string
+	Headers                  map[string]string
+	PayloadType              string
+	CustomPayload            string
+	HMACSecret               string
+	TimeoutSec               int
+	MaxRetries               int
+	IncludeBody              bool
+	IncludeAttachments       bool
+	RawMode                  string
+	AttachmentMode           string
+	AttachmentMaxInlineBytes int64
+	RetryBaseDelaySec        int
+	RetryMaxDelaySec         int
+	RetryJitterPercent       int
+	Events                   []string
+	Encoding                 string
+	QueryFields              []string
+	SignEd25519              bool
+	TLSClientCert            string
+	TLSClientKey             string
+	TLSCABundle              string
+	TLSSkipVerify            bool
+	ProxyURL                 string
+	RedirectPolicy           string
+	Target                   string
+	TargetConfig             domain.WebhookTargetConfig
+	IsActive                 bool
 }
 
 func (s *WebhookService) Update(ctx context.Context, params UpdateWebhookParams) (*domain.Webhook, error) {
@@ -165,13 +302,19 @@ func (s *WebhookService) Update(ctx context.Context, params UpdateWebhookParams)
 		headersJSON = string(data)
 	}
 
-	var includeBody, includeAttachments, isActive int64
+	var includeBody, includeAttachments, signEd25519, skipVerify, isActive int64
 	if params.IncludeBody {
 		includeBody = 1
 	}
 	if params.IncludeAttachments {
 		includeAttachments = 1
 	}
+	if params.SignEd25519 {
+		signEd25519 = 1
+	}
+	if params.TLSSkipVerify {
+		skipVerify = 1
+	}
 	if params.IsActive {
 		isActive = 1
 	}
@@ -186,20 +329,48 @@ func (s *WebhookService) Update(ctx context.Context, params UpdateWebhookParams)
 		payloadType = "default"
 	}
 
+	rawMode := normalizeRawMode(params.RawMode)
+	attachmentMode := normalizeAttachmentMode(params.AttachmentMode)
+
+	maxInline := params.AttachmentMaxInlineBytes
+	if maxInline <= 0 {
+		maxInline = DefaultAttachmentMaxInlineBytes
+	}
+
+	baseDelay, maxDelay, jitter := normalizeRetrySchedule(params.RetryBaseDelaySec, params.RetryMaxDelaySec, params.RetryJitterPercent)
+
 	dbWebhook, err := s.queries.UpdateWebhook(ctx, db.UpdateWebhookParams{
-		ID:                 params.ID,
-		Name:               params.Name,
-		Url:                params.URL,
-		Method:             method,
-		Headers:            sql.NullString{String: headersJSON, Valid: true},
-		PayloadType:        payloadType,
-		CustomPayload:      sql.NullString{String: params.CustomPayload, Valid: params.CustomPayload != ""},
-		HmacSecret:         sql.NullString{String: params.HMACSecret, Valid: params.HMACSecret != ""},
-		TimeoutSec:         int64(params.TimeoutSec),
-		MaxRetries:         int64(params.MaxRetries),
-		IncludeBody:        includeBody,
-		IncludeAttachments: includeAttachments,
-		IsActive:           isActive,
+		ID:                       params.ID,
+		Name:                     params.Name,
+		Url:                      params.URL,
+		Method:                   method,
+		Headers:                  sql.NullString{String: headersJSON, Valid: true},
+		PayloadType:              payloadType,
+		CustomPayload:            sql.NullString{String: params.CustomPayload, Valid: params.CustomPayload != ""},
+		HmacSecret:               sql.NullString{String: params.HMACSecret, Valid: params.HMACSecret != ""},
+		TimeoutSec:               int64(normalizeTimeout(params.TimeoutSec)),
+		MaxRetries:               int64(params.MaxRetries),
+		IncludeBody:              includeBody,
+		IncludeAttachments:       includeAttachments,
+		RawMode:                  rawMode,
+		AttachmentMode:           attachmentMode,
+		AttachmentMaxInlineBytes: maxInline,
+		RetryBaseDelaySec:        int64(baseDelay),
+		RetryMaxDelaySec:         int64(maxDelay),
+		RetryJitterPercent:       int64(jitter),
+		Events:                   normalizeEvents(params.Events),
+		Encoding:                 normalizeEncoding(params.Encoding),
+		QueryFields:              joinQueryFields(params.QueryFields),
+		SignEd25519:              signEd25519,
+		TlsClientCert:            strings.TrimSpace(params.TLSClientCert),
+		TlsClientKey:             strings.TrimSpace(params.TLSClientKey),
+		TlsCaBundle:              strings.TrimSpace(params.TLSCABundle),
+		TlsSkipVerify:            skipVerify,
+		ProxyUrl:                 strings.TrimSpace(params.ProxyURL),
+		RedirectPolicy:           normalizeRedirectPolicy(params.RedirectPolicy),
+		Target:                   normalizeTarget(params.Target),
+		TargetConfig:             targetConfigJSON(params.TargetConfig),
+		IsActive:                 isActive,
 	})
 	if err != nil {
 		return nil, err
@@ -245,31 +416,217 @@ func (s *WebhookService) DeleteRulesByWebhook(ctx context.Context, webhookID int
 	return s.queries.DeleteRulesByWebhook(ctx, webhookID)
 }
 
+// ReplaceRules swaps all the rules of a webhook at once: invalid rules are
+// rejected before anything changes, and a failed insert keeps the old rules.
+func (s *WebhookService) ReplaceRules(ctx context.Context, webhookID int64, rules []domain.WebhookRule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(q *db.Queries) error {
+		if err := q.DeleteRulesByWebhook(ctx, webhookID); err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if _, err := q.CreateWebhookRule(ctx, db.CreateWebhookRuleParams{
+				WebhookID:  webhookID,
+				RuleGroup:  int64(rule.RuleGroup),
+				Field:      rule.Field,
+				Operator:   rule.Operator,
+				Value:      rule.Value,
+				HeaderName: sql.NullString{String: rule.HeaderName, Valid: rule.HeaderName != ""},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ValidateRules rejects rules the evaluator could never match: unknown fields
+// or operators, size thresholds that are not numbers, regexes that do not
+// compile and header rules without a header name.
+func ValidateRules(rules []domain.WebhookRule) error {
+	for i, rule := range rules {
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("%w: rule %d: %s", ErrInvalidWebhookRule, i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateRule(rule domain.WebhookRule) error {
+	switch rule.Field {
+	case domain.RuleFieldSize:
+		if rule.Operator != domain.RuleOperatorGt && rule.Operator != domain.RuleOperatorLt {
+			return errors.New("size rules compare with gt or lt")
+		}
+		if _, err := strconv.ParseInt(rule.Value, 10, 64); err != nil {
+			return errors.New("size must be a number of bytes")
+		}
+		return nil
+	case domain.RuleFieldHasAttachments:
+		if !strings.EqualFold(rule.Value, "true") && !strings.EqualFold(rule.Value, "false") {
+			return errors.New("has_attachments must be true or false")
+		}
+		return nil
+	case domain.RuleFieldHeader:
+		if strings.TrimSpace(rule.HeaderName) == "" {
+			return errors.New("header rules need a header name")
+		}
+	case domain.RuleFieldSubject, domain.RuleFieldFrom, domain.RuleFieldTo, domain.RuleFieldSubaddress,
+		domain.RuleFieldBody, domain.RuleFieldSPF, domain.RuleFieldDKIM, domain.RuleFieldDMARC:
+	default:
+		return fmt.Errorf("unknown field %q", rule.Field)
+	}
+
+	switch rule.Operator {
+	case domain.RuleOperatorContains, domain.RuleOperatorNotContains, domain.RuleOperatorEquals:
+	case domain.RuleOperatorRegex:
+		if _, err := regexp.Compile(rule.Value); err != nil {
+			return fmt.Errorf("invalid regex: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q for %s", rule.Operator, rule.Field)
+	}
+	return nil
+}
+
 func (s *WebhookService) GetDeliveryStats(ctx context.Context, webhookID int64) (*domain.WebhookDeliveryStats, error) {
 	stats, err := s.queries.GetWebhookDeliveryStats(ctx, webhookID)
 	if err != nil {
 		return nil, err
 	}
 
-	var successCount, failedCount, pendingCount int64
-	if stats.SuccessCount.Valid {
-		successCount = int64(stats.SuccessCount.Float64)
-	}
-	if stats.FailedCount.Valid {
-		failedCount = int64(stats.FailedCount.Float64)
-	}
-	if stats.PendingCount.Valid {
-		pendingCount = int64(stats.PendingCount.Float64)
+	// Queued events have no delivery record until their first attempt
+	queued, err := s.queries.CountQueuedWebhookJobsByWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
 	}
-
 	return &domain.WebhookDeliveryStats{
 		Total:        stats.Total,
-		SuccessCount: successCount,
-		FailedCount:  failedCount,
-		PendingCount: pendingCount,
+		SuccessCount: stats.SuccessCount,
+		FailedCount:  stats.FailedCount,
+		PendingCount: stats.PendingCount + queued,
 	}, nil
 }
 
+// RecordSuccess closes the webhook's circuit and resets its failure streak.
+func (s *WebhookService) RecordSuccess(ctx context.Context, id int64) error {
+	return s.queries.RecordWebhookSuccess(ctx, db.RecordWebhookSuccessParams{
+		Now: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:  id,
+	})
+}
+
+// RecordFailure extends the webhook's failure streak and opens the circuit
+// once it reaches threshold, or again after a failed probe. A threshold of
+// zero leaves the circuit closed.
+func (s *WebhookService) RecordFailure(ctx context.Context, id int64, threshold int) (*domain.Webhook, error) {
+	dbWebhook, err := s.queries.RecordWebhookFailure(ctx, db.RecordWebhookFailureParams{
+		Now:       sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		Threshold: int64(threshold),
+		ID:        id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toDomain(dbWebhook), nil
+}
+
+// ClaimProbe moves an open circuit to half open when it has been open since
+// openBefore, or takes over a probe that started before probeBefore and
+// never reported back. It reports whether the caller may send the probe.
+func (s *WebhookService) ClaimProbe(ctx context.Context, id int64, openBefore, probeBefore time.Time) (bool, error) {
+	_, err := s.queries.ClaimWebhookProbe(ctx, db.ClaimWebhookProbeParams{
+		Now:         sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:          id,
+		OpenBefore:  sql.NullTime{Time: openBefore.UTC(), Valid: true},
+		ProbeBefore: sql.NullTime{Time: probeBefore.UTC(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Disable deactivates a webhook that kept failing and resets its circuit.
+func (s *WebhookService) Disable(ctx context.Context, id int64) error {
+	return s.queries.DisableWebhook(ctx, db.DisableWebhookParams{
+		CircuitChangedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:               id,
+	})
+}
+
+// RotateSecret replaces the signing secret of a webhook with a new random
+// one. The old secret keeps signing deliveries next to the new one until
+// grace has passed, so receivers can switch over without dropping requests.
+// A webhook without a secret simply gets one.
+func (s *WebhookService) RotateSecret(ctx context.Context, id int64, grace time.Duration) (*domain.Webhook, error) {
+	webhook, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(buf)
+
+	if webhook.HMACSecret != "" && grace > 0 {
+		if _, err := s.queries.CreateWebhookSecret(ctx, db.CreateWebhookSecretParams{
+			WebhookID: id,
+			Secret:    webhook.HMACSecret,
+			ExpiresAt: time.Now().UTC().Add(grace),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.queries.UpdateWebhookSecret(ctx, db.UpdateWebhookSecretParams{
+		HmacSecret: sql.NullString{String: secret, Valid: true},
+		ID:         id,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// RevokePreviousSecrets ends the grace period of rotated secrets right away.
+func (s *WebhookService) RevokePreviousSecrets(ctx context.Context, id int64) error {
+	return s.queries.DeleteWebhookSecretsByWebhook(ctx, id)
+}
+
+// CleanupExpiredSecrets deletes rotated secrets whose grace period is over.
+func (s *WebhookService) CleanupExpiredSecrets(ctx context.Context) error {
+	return s.queries.DeleteExpiredWebhookSecrets(ctx, time.Now().UTC())
+}
+
+func (s *WebhookService) previousSecrets(ctx context.Context, webhookID int64) ([]domain.WebhookSecret, error) {
+	dbSecrets, err := s.queries.ListActiveWebhookSecrets(ctx, db.ListActiveWebhookSecretsParams{
+		WebhookID: webhookID,
+		ExpiresAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]domain.WebhookSecret, len(dbSecrets))
+	for i, dbSecret := range dbSecrets {
+		secrets[i] = domain.WebhookSecret{
+			ID:        dbSecret.ID,
+			WebhookID: dbSecret.WebhookID,
+			Secret:    dbSecret.Secret,
+			ExpiresAt: dbSecret.ExpiresAt,
+			CreatedAt: dbSecret.CreatedAt,
+		}
+	}
+	return secrets, nil
+}
+
 func (s *WebhookService) toDomain(dbWebhook db.Webhook) *domain.Webhook {
 	method := dbWebhook.Method
 	if method == "" {
@@ -280,29 +637,63 @@ func (s *WebhookService) toDomain(dbWebhook db.Webhook) *domain.Webhook {
 		payloadType = "default"
 	}
 	webhook := &domain.Webhook{
-		ID:                 dbWebhook.ID,
-		MailboxID:          dbWebhook.MailboxID,
-		Name:               dbWebhook.Name,
-		URL:                dbWebhook.Url,
-		Method:             method,
-		PayloadType:        payloadType,
-		TimeoutSec:         int(dbWebhook.TimeoutSec),
-		MaxRetries:         int(dbWebhook.MaxRetries),
-		IncludeBody:        dbWebhook.IncludeBody != 0,
-		IncludeAttachments: dbWebhook.IncludeAttachments != 0,
-		IsActive:           dbWebhook.IsActive != 0,
-		CreatedAt:          dbWebhook.CreatedAt,
-		UpdatedAt:          dbWebhook.UpdatedAt,
+		ID:                       dbWebhook.ID,
+		MailboxID:                dbWebhook.MailboxID,
+		Name:                     dbWebhook.Name,
+		URL:                      dbWebhook.Url,
+		Method:                   method,
+		PayloadType:              payloadType,
+		TimeoutSec:               int(dbWebhook.TimeoutSec),
+		MaxRetries:               int(dbWebhook.MaxRetries),
+		IncludeBody:              dbWebhook.IncludeBody != 0,
+		IncludeAttachments:       dbWebhook.IncludeAttachments != 0,
+		RawMode:                  normalizeRawMode(dbWebhook.RawMode),
+		AttachmentMode:           normalizeAttachmentMode(dbWebhook.AttachmentMode),
+		AttachmentMaxInlineBytes: dbWebhook.AttachmentMaxInlineBytes,
+		RetryBaseDelaySec:        int(dbWebhook.RetryBaseDelaySec),
+		RetryMaxDelaySec:         int(dbWebhook.RetryMaxDelaySec),
+		RetryJitterPercent:       int(dbWebhook.RetryJitterPercent),
+		CircuitState:             dbWebhook.CircuitState,
+		ConsecutiveFailures:      int(dbWebhook.ConsecutiveFailures),
+		Events:                   strings.Split(dbWebhook.Events, ","),
+		Encoding:                 normalizeEncoding(dbWebhook.Encoding),
+		SignEd25519:              dbWebhook.SignEd25519 != 0,
+		TLSClientCert:            dbWebhook.TlsClientCert,
+		TLSClientKey:             dbWebhook.TlsClientKey,
+		TLSCABundle:              dbWebhook.TlsCaBundle,
+		TLSSkipVerify:            dbWebhook.TlsSkipVerify != 0,
+		ProxyURL:                 dbWebhook.ProxyUrl,
+		RedirectPolicy:           normalizeRedirectPolicy(dbWebhook.RedirectPolicy),
+		Target:                   normalizeTarget(dbWebhook.Target),
+		IsActive:                 dbWebhook.IsActive != 0,
+		CreatedAt:                dbWebhook.CreatedAt,
+		UpdatedAt:                dbWebhook.UpdatedAt,
 	}
 	if dbWebhook.Headers.Valid {
 		json.Unmarshal([]byte(dbWebhook.Headers.String), &webhook.Headers)
 	}
+	json.Unmarshal([]byte(dbWebhook.TargetConfig), &webhook.TargetConfig)
+	webhook.HasBrokerCredentials = domain.RedactURL(webhook.TargetConfig.BrokerURL) != webhook.TargetConfig.BrokerURL
 	if dbWebhook.CustomPayload.Valid {
 		webhook.CustomPayload = dbWebhook.CustomPayload.String
 	}
 	if dbWebhook.HmacSecret.Valid {
 		webhook.HMACSecret = dbWebhook.HmacSecret.String
 	}
+	webhook.HasHMACSecret = webhook.HMACSecret != ""
+	webhook.HasClientKey = webhook.TLSClientKey != ""
+	webhook.HasProxyCredentials = domain.RedactURL(webhook.ProxyURL) != webhook.ProxyURL
+	if dbWebhook.QueryFields != "" {
+		webhook.QueryFields = strings.Split(dbWebhook.QueryFields, ",")
+	}
+	if dbWebhook.FailingSince.Valid {
+		v := dbWebhook.FailingSince.Time
+		webhook.FailingSince = &v
+	}
+	if dbWebhook.CircuitChangedAt.Valid {
+		v := dbWebhook.CircuitChangedAt.Time
+		webhook.CircuitChangedAt = &v
+	}
 	return webhook
 }
 
@@ -321,3 +712,132 @@ func (s *WebhookService) ruleToDomain(dbRule db.WebhookRule) domain.WebhookRule
 	}
 	return rule
 }
+
+func normalizeRawMode(mode string) string {
+	switch mode {
+	case domain.WebhookRawModeInline, domain.WebhookRawModeURL:
+		return mode
+	default:
+		return domain.WebhookRawModeNone
+	}
+}
+
+func normalizeAttachmentMode(mode string) string {
+	switch mode {
+	case domain.WebhookAttachmentModeInline, domain.WebhookAttachmentModeURL:
+		return mode
+	default:
+		return domain.WebhookAttachmentModeMetadata
+	}
+}
+
+func normalizeEncoding(encoding string) string {
+	switch encoding {
+	case domain.WebhookEncodingForm, domain.WebhookEncodingMultipart, domain.WebhookEncodingRFC822:
+		return encoding
+	default:
+		return domain.WebhookEncodingJSON
+	}
+}
+
+func normalizeRedirectPolicy(policy string) string {
+	switch policy {
+	case domain.WebhookRedirectSameHost, domain.WebhookRedirectNone:
+		return policy
+	default:
+		return domain.WebhookRedirectFollow
+	}
+}
+
+func normalizeTarget(target string) string {
+	switch target {
+	case domain.WebhookTargetSMTP, domain.WebhookTargetBroker, domain.WebhookTargetDirectory:
+		return target
+	default:
+		return domain.WebhookTargetHTTP
+	}
+}
+
+// targetConfigJSON trims the target settings and encodes them for storage.
+func targetConfigJSON(config domain.WebhookTargetConfig) string {
+	var forwardTo []string
+	for _, addr := range config.ForwardTo {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			forwardTo = append(forwardTo, addr)
+		}
+	}
+	config.ForwardTo = forwardTo
+	config.SMTPServer = strings.TrimSpace(config.SMTPServer)
+	config.BrokerDriver = strings.TrimSpace(config.BrokerDriver)
+	config.BrokerURL = strings.TrimSpace(config.BrokerURL)
+	config.BrokerTopic = strings.TrimSpace(config.BrokerTopic)
+	config.Directory = strings.TrimSpace(config.Directory)
+
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// joinQueryFields trims the query field entries and drops empty ones.
+func joinQueryFields(fields []string) string {
+	var kept []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			kept = append(kept, f)
+		}
+	}
+	return strings.Join(kept, ",")
+}
+
+// normalizeEvents drops unknown and duplicate event types and returns the
+// rest comma-separated in their canonical order. A webhook without any valid
+// event is subscribed to email.received.
+func normalizeEvents(events []string) string {
+	wanted := make(map[string]bool, len(events))
+	for _, e := range events {
+		wanted[strings.TrimSpace(e)] = true
+	}
+
+	var kept []string
+	for _, e := range domain.EventTypes {
+		if wanted[e] {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		return domain.EventEmailReceived
+	}
+	return strings.Join(kept, ",")
+}
+
+// normalizeTimeout falls back to the default for an unset timeout and caps
+// it at MaxWebhookTimeoutSec.
+func normalizeTimeout(timeoutSec int) int {
+	if timeoutSec <= 0 {
+		return DefaultWebhookTimeoutSec
+	}
+	if timeoutSec > MaxWebhookTimeoutSec {
+		return MaxWebhookTimeoutSec
+	}
+	return timeoutSec
+}
+
+// normalizeRetrySchedule falls back to the defaults for unset delays, keeps
+// the cap at or above the base delay and bounds the jitter to 0-100%.
+func normalizeRetrySchedule(baseDelay, maxDelay, jitter int) (int, int, int) {
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryBaseDelaySec
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelaySec
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 100 {
+		jitter = 100
+	}
+	return baseDelay, maxDelay, jitter
+}
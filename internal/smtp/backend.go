@@ -3,6 +3,7 @@ package smtp
 import (
 	"github.com/emersion/go-smtp"
 	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/mailauth"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/jr-k/mailgress/internal/storage"
 	"github.com/jr-k/mailgress/internal/webhook"
@@ -13,8 +14,9 @@ type Backend struct {
 	mailboxService *service.MailboxService
 	emailService   *service.EmailService
 	domainService  *service.DomainService
-	storage        *storage.Storage
+	storage        storage.Storage
 	dispatcher     *webhook.Dispatcher
+	verifier       *mailauth.Verifier
 	rateLimiter    *RateLimiter
 }
 
@@ -23,8 +25,9 @@ func NewBackend(
 	mailboxService *service.MailboxService,
 	emailService *service.EmailService,
 	domainService *service.DomainService,
-	storage *storage.Storage,
+	storage storage.Storage,
 	dispatcher *webhook.Dispatcher,
+	verifier *mailauth.Verifier,
 ) *Backend {
 	return &Backend{
 		config:         cfg,
@@ -33,6 +36,7 @@ func NewBackend(
 		domainService:  domainService,
 		storage:        storage,
 		dispatcher:     dispatcher,
+		verifier:       verifier,
 		rateLimiter:    NewRateLimiter(100, 60),
 	}
 }
@@ -47,8 +51,12 @@ func (b *Backend) NewSession(c *smtp.Conn) (smtp.Session, error) {
 		}
 	}
 
+	_, isTLS := c.TLSConnectionState()
+
 	return &Session{
 		backend: b,
 		ip:      ip,
+		tls:     isTLS,
+		helo:    c.Hostname(),
 	}, nil
 }
@@ -3,18 +3,22 @@ package smtp
 import (
 	"context"
 	"log"
+	"net"
 	"time"
 
 	"github.com/emersion/go-smtp"
 	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/mailauth"
 	"github.com/jr-k/mailgress/internal/service"
 	"github.com/jr-k/mailgress/internal/storage"
 	"github.com/jr-k/mailgress/internal/webhook"
 )
 
 type Server struct {
-	server *smtp.Server
-	config *config.Config
+	server    *smtp.Server
+	tlsServer *smtp.Server
+	certs     *CertReloader
+	config    *config.Config
 }
 
 func NewServer(
@@ -22,42 +26,90 @@ func NewServer(
 	mailboxService *service.MailboxService,
 	emailService *service.EmailService,
 	domainService *service.DomainService,
-	storage *storage.Storage,
+	storage storage.Storage,
 	dispatcher *webhook.Dispatcher,
-) *Server {
-	backend := NewBackend(cfg, mailboxService, emailService, domainService, storage, dispatcher)
+) (*Server, error) {
+	// A nil verifier skips sender authentication entirely
+	var verifier *mailauth.Verifier
+	if cfg.SMTPAuthChecks {
+		verifier = mailauth.NewVerifier(net.DefaultResolver, cfg.SMTPHostname)
+	}
+
+	backend := NewBackend(cfg, mailboxService, emailService, domainService, storage, dispatcher, verifier)
+
+	var certs *CertReloader
+	if cfg.SMTPTLSEnabled() {
+		var err error
+		certs, err = NewCertReloader(cfg.SMTPTLSCertFile, cfg.SMTPTLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server := newSMTPServer(backend, cfg.SMTPListenAddr, cfg.SMTPHostname, certs)
+
+	var tlsServer *smtp.Server
+	if certs != nil && cfg.SMTPTLSListenAddr != "" {
+		tlsServer = newSMTPServer(backend, cfg.SMTPTLSListenAddr, cfg.SMTPHostname, certs)
+	}
+
+	return &Server{
+		server:    server,
+		tlsServer: tlsServer,
+		certs:     certs,
+		config:    cfg,
+	}, nil
+}
 
+func newSMTPServer(backend *Backend, addr, hostname string, certs *CertReloader) *smtp.Server {
 	server := smtp.NewServer(backend)
-	server.Addr = cfg.SMTPListenAddr
-	server.Domain = "mailgress"
+	server.Addr = addr
+	server.Domain = hostname
 	server.ReadTimeout = 30 * time.Second
 	server.WriteTimeout = 30 * time.Second
 	server.MaxMessageBytes = 100 * 1024 * 1024 // 100MB absolute max, per-mailbox limits checked in session
 	server.MaxRecipients = 50
-	server.AllowInsecureAuth = true
+	server.AllowInsecureAuth = certs == nil
 
-	return &Server{
-		server: server,
-		config: cfg,
+	// STARTTLS is advertised as soon as a TLS config is present
+	if certs != nil {
+		server.TLSConfig = certs.TLSConfig()
 	}
+
+	return server
 }
 
 func (s *Server) Start(ctx context.Context) error {
 	log.Printf("Starting SMTP server on %s", s.config.SMTPListenAddr)
+	if s.certs != nil {
+		log.Println("SMTP STARTTLS enabled")
+		go s.certs.Watch(ctx, 30*time.Second)
+	}
 
-	errChan := make(chan error, 1)
+	errChan := make(chan error, 2)
 	go func() {
 		errChan <- s.server.ListenAndServe()
 	}()
 
+	if s.tlsServer != nil {
+		log.Printf("Starting SMTP implicit TLS server on %s", s.config.SMTPTLSListenAddr)
+		go func() {
+			errChan <- s.tlsServer.ListenAndServeTLS()
+		}()
+	}
+
 	select {
 	case <-ctx.Done():
-		return s.server.Close()
+		return s.Close()
 	case err := <-errChan:
+		s.Close()
 		return err
 	}
 }
 
 func (s *Server) Close() error {
+	if s.tlsServer != nil {
+		s.tlsServer.Close()
+	}
 	return s.server.Close()
 }
@@ -9,29 +9,35 @@ import (
 	"mime"
 	"mime/multipart"
 	"mime/quotedprintable"
+	"net"
 	"net/mail"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-smtp"
+	"github.com/jr-k/mailgress/internal/domain"
 	"github.com/jr-k/mailgress/internal/service"
 )
 
 type Session struct {
 	backend    *Backend
 	ip         string
+	tls        bool
+	helo       string
 	from       string
 	recipients []recipientInfo
 }
 
 type recipientInfo struct {
-	address             string
-	localPart           string
-	slug                string
-	mailboxID           int64
-	domainID            int64
-	maxEmailSizeBytes   int64
-	maxAttachSizeBytes  int64
+	address            string
+	localPart          string
+	slug               string
+	mailboxID          int64
+	domainID           int64
+	alias              string
+	subaddress         string
+	maxEmailSizeBytes  int64
+	maxAttachSizeBytes int64
 }
 
 func (s *Session) AuthPlain(username, password string) error {
@@ -75,9 +81,32 @@ func (s *Session) Rcpt(to string, opts *smtp.RcptOptions) error {
 		}
 	}
 
+	// The recipient domain is only known here, so a plaintext transaction
+	// for a TLS-only domain is refused before any data is accepted.
+	if domain.RequireTLS && !s.tls {
+		return &smtp.SMTPError{
+			Code:         530,
+			EnhancedCode: smtp.EnhancedCode{5, 7, 0},
+			Message:      "Must issue a STARTTLS command first",
+		}
+	}
+
 	slug := service.ExtractSlug(localPart)
 
+	var alias string
 	mailbox, err := s.backend.mailboxService.GetBySlugAndDomain(ctx, slug, domain.ID)
+	if err != nil {
+		// Aliases are exact addresses too, so they win over patterns
+		if mailbox, err = s.backend.mailboxService.GetByAlias(ctx, slug, domain.ID); err == nil {
+			alias = slug + "@" + domain.Name
+		}
+	}
+	if err != nil {
+		// No exact match: try the domain's pattern routes, then its catch-all
+		if mailboxID, routeErr := s.backend.domainService.ResolveMailbox(ctx, domain, localPart); routeErr == nil {
+			mailbox, err = s.backend.mailboxService.GetByID(ctx, mailboxID)
+		}
+	}
 	if err != nil {
 		return &smtp.SMTPError{
 			Code:         550,
@@ -100,6 +129,8 @@ func (s *Session) Rcpt(to string, opts *smtp.RcptOptions) error {
 		slug:               slug,
 		mailboxID:          mailbox.ID,
 		domainID:           domain.ID,
+		alias:              alias,
+		subaddress:         service.ExtractSubaddress(localPart),
 		maxEmailSizeBytes:  mailbox.MaxEmailSizeBytes(),
 		maxAttachSizeBytes: mailbox.MaxAttachmentSizeBytes(),
 	})
@@ -108,6 +139,16 @@ func (s *Session) Rcpt(to string, opts *smtp.RcptOptions) error {
 }
 
 func (s *Session) Data(r io.Reader) error {
+	// Ask the sending MTA to retry later rather than accept mail whose
+	// webhooks could not be queued.
+	if s.backend.dispatcher.Saturated(context.Background()) {
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 3, 2},
+			Message:      "Webhook queue is full, try again later",
+		}
+	}
+
 	// Use the minimum email size limit from all recipients
 	maxEmailSize := int64(100 * 1024 * 1024) // 100MB default
 	for _, rcpt := range s.recipients {
@@ -137,6 +178,25 @@ func (s *Session) Data(r io.Reader) error {
 		}
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Authentication-Results from upstream hops cannot be trusted: ours
+	// replaces them in the stored headers, and in the stored original the
+	// fields claiming our authserv-id are removed before ours is prepended
+	stored := raw
+	var auth domain.EmailAuth
+	if s.backend.verifier != nil {
+		authCtx, authCancel := context.WithTimeout(ctx, 10*time.Second)
+		result := s.backend.verifier.Verify(authCtx, s.remoteIP(), s.helo, s.from, raw)
+		authCancel()
+
+		auth = result.EmailAuth
+		authHeader := s.backend.verifier.Header(result)
+		headers["Authentication-Results"] = authHeader
+		stored = append([]byte("Authentication-Results: "+authHeader+"\r\n"), s.backend.verifier.StripForged(raw)...)
+	}
+
 	var date *time.Time
 	if d, err := msg.Header.Date(); err == nil {
 		date = &d
@@ -144,8 +204,11 @@ func (s *Session) Data(r io.Reader) error {
 
 	textBody, htmlBody := extractBodies(msg)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	type received struct {
+		mailboxID int64
+		email     *domain.Email
+	}
+	var receivedEmails []received
 
 	for _, rcpt := range s.recipients {
 		email, err := s.backend.emailService.Create(ctx, service.CreateEmailParams{
@@ -159,13 +222,25 @@ func (s *Session) Data(r io.Reader) error {
 			TextBody:    textBody,
 			HTMLBody:    htmlBody,
 			RawSize:     int64(len(raw)),
+			Auth:        auth,
+			Alias:       rcpt.alias,
+			Subaddress:  rcpt.subaddress,
 		})
 		if err != nil {
 			log.Printf("Failed to store email for %s: %v", rcpt.address, err)
 			continue
 		}
 
-		if err := s.processAttachments(ctx, email.ID, msg, rcpt.maxAttachSizeBytes); err != nil {
+		if rawPath, _, err := s.backend.storage.Store(email.ID, "message.eml", bytes.NewReader(stored)); err != nil {
+			log.Printf("Failed to store raw message for email %d: %v", email.ID, err)
+		} else if err := s.backend.emailService.SetRawPath(ctx, email.ID, rawPath); err != nil {
+			log.Printf("Failed to record raw message for email %d: %v", email.ID, err)
+		}
+
+		// msg.Body has already been drained by extractBodies, so every
+		// recipient walks its own copy of the message
+		attachMsg, _ := mail.ReadMessage(bytes.NewReader(raw))
+		if err := s.processAttachments(ctx, email.ID, attachMsg, rcpt.maxAttachSizeBytes); err != nil {
 			log.Printf("Failed to process attachments for email %d: %v", email.ID, err)
 		}
 
@@ -175,7 +250,26 @@ func (s *Session) Data(r io.Reader) error {
 			continue
 		}
 
-		s.backend.dispatcher.Dispatch(rcpt.mailboxID, fullEmail)
+		receivedEmails = append(receivedEmails, received{mailboxID: rcpt.mailboxID, email: fullEmail})
+	}
+
+	for _, r := range receivedEmails {
+		if err := s.backend.dispatcher.Dispatch(r.mailboxID, r.email); err != nil {
+			log.Printf("Failed to queue webhooks for email %d: %v", r.email.ID, err)
+			// The sender retries on a temporary failure, so the copies stored
+			// for this transaction are dropped to avoid duplicates. Jobs
+			// already queued go with them.
+			for _, r := range receivedEmails {
+				if err := s.backend.emailService.Discard(ctx, r.email.ID); err != nil {
+					log.Printf("Failed to discard email %d: %v", r.email.ID, err)
+				}
+			}
+			return &smtp.SMTPError{
+				Code:         451,
+				EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+				Message:      "Temporary failure, try again later",
+			}
+		}
 	}
 
 	return nil
@@ -190,6 +284,14 @@ func (s *Session) Logout() error {
 	return nil
 }
 
+func (s *Session) remoteIP() net.IP {
+	host, _, err := net.SplitHostPort(s.ip)
+	if err != nil {
+		host = s.ip
+	}
+	return net.ParseIP(host)
+}
+
 func parseAddress(addr string) (localPart, domain string, err error) {
 	addr = strings.TrimSpace(addr)
 	addr = strings.Trim(addr, "<>")
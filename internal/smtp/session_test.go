@@ -0,0 +1,99 @@
+package smtp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+func TestRcptPrecedence(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, queries, err := database.NewConnection("sqlite", filepath.Join(t.TempDir(), "mailgress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.RunMigrations(sqlDB, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	bus := events.NewBus()
+	domains := service.NewDomainService(queries, database.NewTransactor(sqlDB, "sqlite"), bus)
+	mailboxes := service.NewMailboxService(queries, bus)
+
+	example, err := domains.Create(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailbox := func(slug string) *domain.Mailbox {
+		t.Helper()
+		m, err := mailboxes.Create(ctx, slug, nil, &example.ID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	sales := mailbox("sales")
+	billing := mailbox("billing")
+	routed := mailbox("routed")
+	catchAll := mailbox("catchall")
+	if err := mailboxes.CreateAlias(ctx, billing.ID, example.ID, "invoices"); err != nil {
+		t.Fatal(err)
+	}
+	// The route matches every address, so only exact matches get past it
+	if _, err := domains.CreateRoute(ctx, example.ID, routed.ID, domain.RoutePatternGlob, "*"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := domains.SetCatchAll(ctx, example.ID, &catchAll.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &Backend{mailboxService: mailboxes, domainService: domains}
+	tests := []struct {
+		to        string
+		want      int64
+		wantAlias string
+	}{
+		{"sales@example.com", sales.ID, ""},
+		{"sales+eu@example.com", sales.ID, ""},
+		{"invoices@example.com", billing.ID, "invoices@example.com"},
+		{"anyone@example.com", routed.ID, ""},
+	}
+	for _, tt := range tests {
+		s := &Session{backend: backend}
+		if err := s.Rcpt(tt.to, nil); err != nil {
+			t.Errorf("Rcpt(%s) = %v", tt.to, err)
+			continue
+		}
+		if got := s.recipients[0]; got.mailboxID != tt.want || got.alias != tt.wantAlias {
+			t.Errorf("Rcpt(%s) delivers to mailbox %d alias %q, want %d alias %q", tt.to, got.mailboxID, got.alias, tt.want, tt.wantAlias)
+		}
+	}
+
+	// Without routes the catch-all takes the rest
+	routes, err := domains.ListRoutes(ctx, example.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := domains.DeleteRoute(ctx, example.ID, routes[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	s := &Session{backend: backend}
+	if err := s.Rcpt("anyone@example.com", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.recipients[0].mailboxID; got != catchAll.ID {
+		t.Errorf("anyone@example.com delivers to mailbox %d, want the catch-all", got)
+	}
+
+	if _, err := domains.SetCatchAll(ctx, example.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Session{backend: backend}).Rcpt("anyone@example.com", nil); err == nil {
+		t.Error("Rcpt to an unknown address without catch-all succeeded")
+	}
+}
@@ -0,0 +1,124 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// CertReloader serves the SMTP certificate from PEM files and swaps it in
+// place when the files change, so listeners never need to be restarted.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) Reload() error {
+	certTime, keyTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.certTime = certTime
+	r.keyTime = keyTime
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// Watch reloads the certificate on SIGHUP and whenever the modification time
+// of either file changes. A failed reload keeps the previous certificate.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			r.reloadAndLog("SIGHUP")
+		case <-ticker.C:
+			if r.changed() {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *CertReloader) reloadAndLog(reason string) {
+	if err := r.Reload(); err != nil {
+		log.Printf("Failed to reload SMTP TLS certificate (%s): %v", reason, err)
+		return
+	}
+	log.Printf("Reloaded SMTP TLS certificate (%s)", reason)
+}
+
+func (r *CertReloader) changed() bool {
+	certTime, keyTime, err := r.modTimes()
+	if err != nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !certTime.Equal(r.certTime) || !keyTime.Equal(r.keyTime)
+}
+
+func (r *CertReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat key: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// CopyStats counts the blobs Copy went through.
+type CopyStats struct {
+	Copied  int
+	Skipped int
+	Failed  int
+	Bytes   int64
+}
+
+// Copy copies every blob of src to dst under the same path, so the paths
+// recorded in the database stay valid. Blobs dst already holds with the
+// same size are skipped, which lets an interrupted copy resume. With
+// deleteSource, each blob is removed from src once dst holds it. progress
+// is called after each blob with the error it failed with, if any; failed
+// blobs are left in src and counted.
+func Copy(src, dst Storage, deleteSource bool, progress func(info ObjectInfo, err error)) (*CopyStats, error) {
+	// List first, so that deleting from src does not disturb the walk
+	var infos []ObjectInfo
+	err := src.Walk(func(info ObjectInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	stats := &CopyStats{}
+	for _, info := range infos {
+		copied, err := copyBlob(src, dst, info)
+		if err == nil && deleteSource {
+			err = src.Delete(info.Path)
+		}
+		switch {
+		case err != nil:
+			stats.Failed++
+		case copied:
+			stats.Copied++
+			stats.Bytes += info.Size
+		default:
+			stats.Skipped++
+		}
+		if progress != nil {
+			progress(info, err)
+		}
+	}
+	return stats, nil
+}
+
+// copyBlob copies one blob and checks its size in dst. It reports false
+// when dst already had it.
+func copyBlob(src, dst Storage, info ObjectInfo) (bool, error) {
+	if existing, err := dst.Stat(info.Path); err == nil && existing.Size == info.Size {
+		return false, nil
+	}
+
+	file, err := src.Get(info.Path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if err := dst.Put(info.Path, file, info.Size); err != nil {
+		return false, err
+	}
+
+	copied, err := dst.Stat(info.Path)
+	if err != nil {
+		return false, err
+	}
+	if copied.Size != info.Size {
+		return false, fmt.Errorf("copied %d of %d bytes", copied.Size, info.Size)
+	}
+	return true, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// failingPut fails to store one path.
+type failingPut struct {
+	Storage
+	path string
+}
+
+func (s failingPut) Put(path string, content io.Reader, size int64) error {
+	if path == s.path {
+		return errors.New("disk full")
+	}
+	return s.Storage.Put(path, content, size)
+}
+
+func TestCopy(t *testing.T) {
+	src, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := NewMemory()
+
+	done := put(t, src, "already copied")
+	partial := put(t, src, "cut off halfway")
+	fresh := put(t, src, "not copied yet")
+	if err := src.Put("avatars/1.png", strings.NewReader("png"), 3); err != nil {
+		t.Fatal(err)
+	}
+	// An earlier copy stopped while writing partial
+	if err := dst.Put(done, strings.NewReader("already copied"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Put(partial, strings.NewReader("cut off"), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	stats, err := Copy(src, dst, false, func(info ObjectInfo, err error) {
+		if err != nil {
+			t.Errorf("copying %s: %v", info.Path, err)
+		}
+		seen = append(seen, info.Path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CopyStats{Copied: 2, Skipped: 1, Bytes: int64(len("cut off halfway") + len("not copied yet"))}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+	if len(seen) != 3 {
+		t.Errorf("progress reported %v, want the three stored blobs", seen)
+	}
+	for path, content := range map[string]string{done: "already copied", partial: "cut off halfway", fresh: "not copied yet"} {
+		if got := readAll(t, dst, path); got != content {
+			t.Errorf("%s in the destination = %q, want %q", path, got, content)
+		}
+	}
+	if _, err := dst.Stat("avatars/1.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Copy copied a blob Walk skips: %v", err)
+	}
+
+	// Copying again resumes with nothing left to do
+	stats, err = Copy(src, dst, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (CopyStats{Skipped: 3}) {
+		t.Errorf("stats of a second copy = %+v, want every blob skipped", *stats)
+	}
+
+	// Moving removes each blob from the source once the destination holds
+	// it, and leaves the ones that failed
+	next := put(t, src, "added later")
+	stats, err = Copy(src, failingPut{Storage: NewMemory(), path: next}, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := int64(len("already copied") + len("cut off halfway") + len("not copied yet"))
+	if *stats != (CopyStats{Copied: 3, Failed: 1, Bytes: moved}) {
+		t.Errorf("stats of a move = %+v, want 3 copied and 1 failed", *stats)
+	}
+	for _, path := range []string{done, partial, fresh} {
+		if _, err := src.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s is still in the source after moving: %v", path, err)
+		}
+	}
+	if got := readAll(t, src, next); got != "added later" {
+		t.Errorf("the blob that failed to move = %q, want it kept in the source", got)
+	}
+	if got := readAll(t, src, "avatars/1.png"); got != "png" {
+		t.Errorf("moving touched a blob Walk skips: %q", got)
+	}
+}
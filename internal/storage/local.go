@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local keeps blobs as files under a directory.
+type Local struct {
+	basePath string
+}
+
+func NewLocal(basePath string) (*Local, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{basePath: filepath.Clean(basePath)}, nil
+}
+
+func (s *Local) Store(emailID int64, filename string, content io.Reader) (string, int64, error) {
+	relPath := newPath(emailID, filename)
+	size, err := s.write(relPath, content)
+	if err != nil {
+		return "", 0, err
+	}
+	return relPath, size, nil
+}
+
+func (s *Local) Put(path string, content io.Reader, size int64) error {
+	_, err := s.write(path, content)
+	return err
+}
+
+func (s *Local) write(path string, content io.Reader) (int64, error) {
+	fullPath, err := s.fullPath(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	size, err := io.Copy(file, content)
+	if err != nil {
+		os.Remove(fullPath)
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+	return size, nil
+}
+
+func (s *Local) Get(path string) (io.ReadCloser, error) {
+	fullPath, err := s.fullPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(fullPath)
+}
+
+func (s *Local) GetRange(path string, offset, length int64) (io.ReadCloser, error) {
+	fullPath, err := s.fullPath(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if offset < 0 || offset >= info.Size() {
+		file.Close()
+		return nil, ErrInvalidRange
+	}
+	if length < 0 || offset+length > info.Size() {
+		length = info.Size() - offset
+	}
+	return &sectionReader{SectionReader: io.NewSectionReader(file, offset, length), Closer: file}, nil
+}
+
+type sectionReader struct {
+	*io.SectionReader
+	io.Closer
+}
+
+func (s *Local) Stat(path string) (*ObjectInfo, error) {
+	fullPath, err := s.fullPath(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "stat", Path: fullPath, Err: fs.ErrNotExist}
+	}
+	return &ObjectInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Delete removes a stored file and the directories Store created for it
+// once they are empty.
+func (s *Local) Delete(path string) error {
+	fullPath, err := s.fullPath(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil {
+		return err
+	}
+	for dir := filepath.Dir(fullPath); strings.HasPrefix(dir, s.basePath+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Local) Walk(fn func(info ObjectInfo) error) error {
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !yearDir.MatchString(entry.Name()) {
+			continue
+		}
+		err := filepath.WalkDir(filepath.Join(s.basePath, entry.Name()), func(fullPath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			relPath, _ := filepath.Rel(s.basePath, fullPath)
+			return fn(ObjectInfo{Path: filepath.ToSlash(relPath), Size: info.Size(), ModTime: info.ModTime()})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Local) PresignGet(path string, ttl time.Duration, opts PresignOptions) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// fullPath returns where a blob is kept on disk, refusing paths that would
+// leave the storage directory.
+func (s *Local) fullPath(path string) (string, error) {
+	cleaned, err := cleanPath(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.basePath, filepath.FromSlash(cleaned)), nil
+}
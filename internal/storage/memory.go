@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory keeps blobs in memory, for tests: storage.NewMemory().
+type Memory struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+type memoryBlob struct {
+	data    []byte
+	modTime time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string]memoryBlob)}
+}
+
+func (s *Memory) Store(emailID int64, filename string, content io.Reader) (string, int64, error) {
+	path := newPath(emailID, filename)
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", 0, err
+	}
+	s.put(path, data)
+	return path, int64(len(data)), nil
+}
+
+func (s *Memory) Put(path string, content io.Reader, size int64) error {
+	cleaned, err := cleanPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.put(cleaned, data)
+	return nil
+}
+
+func (s *Memory) put(path string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[path] = memoryBlob{data: data, modTime: time.Now()}
+}
+
+func (s *Memory) blob(path string) (memoryBlob, error) {
+	cleaned, err := cleanPath(path)
+	if err != nil {
+		return memoryBlob{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.blobs[cleaned]
+	if !ok {
+		return memoryBlob{}, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return blob, nil
+}
+
+func (s *Memory) Get(path string) (io.ReadCloser, error) {
+	blob, err := s.blob(path)
+	if err != nil {
+		return nil, err
+	}
+	return memoryReader{bytes.NewReader(blob.data)}, nil
+}
+
+func (s *Memory) GetRange(path string, offset, length int64) (io.ReadCloser, error) {
+	blob, err := s.blob(path)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(blob.data))
+	if offset < 0 || offset >= size {
+		return nil, ErrInvalidRange
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+	return memoryReader{bytes.NewReader(blob.data[offset : offset+length])}, nil
+}
+
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (memoryReader) Close() error {
+	return nil
+}
+
+func (s *Memory) Stat(path string) (*ObjectInfo, error) {
+	blob, err := s.blob(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Path: path, Size: int64(len(blob.data)), ModTime: blob.modTime}, nil
+}
+
+func (s *Memory) Delete(path string) error {
+	cleaned, err := cleanPath(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[cleaned]; !ok {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	delete(s.blobs, cleaned)
+	return nil
+}
+
+func (s *Memory) Walk(fn func(info ObjectInfo) error) error {
+	s.mu.RLock()
+	infos := make([]ObjectInfo, 0, len(s.blobs))
+	for path, blob := range s.blobs {
+		if stored(path) {
+			infos = append(infos, ObjectInfo{Path: path, Size: int64(len(blob.data)), ModTime: blob.modTime})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Memory) PresignGet(path string, ttl time.Duration, opts PresignOptions) (string, error) {
+	return "", ErrPresignUnsupported
+}
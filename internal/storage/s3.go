@@ -0,0 +1,591 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the payload hash of requests without a body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// maxPresignTTL is the longest validity Signature Version 4 allows.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// S3Config configures the S3 driver.
+type S3Config struct {
+	// Endpoint is the URL of the S3 API, such as http://minio:9000. It
+	// defaults to AWS in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every key, so one bucket can hold several
+	// installations.
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as endpoint/bucket/key instead of
+	// bucket.endpoint/key. MinIO and most self-hosted stores need it.
+	PathStyle bool
+	// Presign lets PresignGet hand out download URLs, for buckets the
+	// clients of Mailgress can reach at Endpoint.
+	Presign bool
+}
+
+// S3 keeps blobs in an S3-compatible object store. Requests are signed with
+// AWS Signature Version 4.
+type S3 struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	prefix          string
+	accessKeyID     string
+	secretAccessKey string
+	pathStyle       bool
+	presign         bool
+	client          *http.Client
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 storage needs a bucket")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 storage needs an access key ID and secret access key")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Minute
+
+	return &S3{
+		endpoint:        u,
+		region:          region,
+		bucket:          cfg.Bucket,
+		prefix:          strings.Trim(cfg.Prefix, "/"),
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		pathStyle:       cfg.PathStyle,
+		presign:         cfg.Presign,
+		client:          &http.Client{Transport: transport},
+	}, nil
+}
+
+func (s *S3) Store(emailID int64, filename string, content io.Reader) (string, int64, error) {
+	path := newPath(emailID, filename)
+	size, err := s.put(path, content, -1)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, size, nil
+}
+
+func (s *S3) Put(path string, content io.Reader, size int64) error {
+	_, err := s.put(path, content, size)
+	return err
+}
+
+// put uploads a blob in a single request, signing its SHA-256. Content that
+// cannot be read twice is spooled to a temporary file first, since the
+// hash and length go in the request headers.
+func (s *S3) put(path string, content io.Reader, size int64) (int64, error) {
+	key, err := s.key(path)
+	if err != nil {
+		return 0, err
+	}
+
+	body, ok := content.(io.ReadSeeker)
+	if !ok || size < 0 {
+		spool, err := os.CreateTemp("", "mailgress-upload-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		if size, err = io.Copy(spool, content); err != nil {
+			return 0, fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		body = spool
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return 0, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key, nil), io.NopCloser(io.LimitReader(body, size)))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := s.do(req, hex.EncodeToString(hash.Sum(nil)), path)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return size, nil
+}
+
+// Get returns a reader that streams the whole blob and, when seeked,
+// continues from the new offset with a ranged request.
+func (s *S3) Get(path string) (io.ReadCloser, error) {
+	key, err := s.key(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptySHA256, path)
+	if err != nil {
+		return nil, err
+	}
+
+	size := resp.ContentLength
+	if size < 0 {
+		info, err := s.Stat(path)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		size = info.Size
+	}
+	return &s3Object{s3: s, path: path, size: size, body: resp.Body}, nil
+}
+
+func (s *S3) GetRange(path string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, ErrInvalidRange
+	}
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	key, err := s.key(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+
+	resp, err := s.do(req, emptySHA256, path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		return resp.Body, nil
+	}
+	// The whole blob came back, which stores do for ranges covering it
+	if offset > 0 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 endpoint ignored the range of %s", path)
+	}
+	if length < 0 {
+		return resp.Body, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, length), resp.Body}, nil
+}
+
+func (s *S3) Stat(path string) (*ObjectInfo, error) {
+	key, err := s.key(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodHead, s.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptySHA256, path)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &ObjectInfo{Path: path, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+func (s *S3) Delete(path string) error {
+	key, err := s.key(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(key, nil), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptySHA256, path)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3) Walk(fn func(info ObjectInfo) error) error {
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequest(http.MethodGet, s.objectURL("", query), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req, emptySHA256, "")
+		if err != nil {
+			return err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read S3 object list: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			path := strings.TrimPrefix(object.Key, prefix)
+			if !stored(path) || strings.HasSuffix(path, "/") {
+				continue
+			}
+			if err := fn(ObjectInfo{Path: path, Size: object.Size, ModTime: object.LastModified}); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) PresignGet(path string, ttl time.Duration, opts PresignOptions) (string, error) {
+	if !s.presign {
+		return "", ErrPresignUnsupported
+	}
+	key, err := s.key(path)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+	query := url.Values{}
+	if opts.ContentType != "" {
+		query.Set("response-content-type", opts.ContentType)
+	}
+	if opts.ContentDisposition != "" {
+		query.Set("response-content-disposition", opts.ContentDisposition)
+	}
+	return s.presignURL(http.MethodGet, key, query, ttl, time.Now()), nil
+}
+
+// key returns the object key of a path.
+func (s *S3) key(path string) (string, error) {
+	cleaned, err := cleanPath(path)
+	if err != nil {
+		return "", err
+	}
+	if s.prefix != "" {
+		return s.prefix + "/" + cleaned, nil
+	}
+	return cleaned, nil
+}
+
+// objectURL returns the URL of an object, or of the bucket when key is
+// empty.
+func (s *S3) objectURL(key string, query url.Values) string {
+	u := *s.endpoint
+	path := u.Path + "/"
+	if s.pathStyle {
+		path += s.bucket + "/"
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	path += key
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// do signs and sends a request, turning S3 error responses into errors.
+// Missing objects fail with fs.ErrNotExist.
+func (s *S3) do(req *http.Request, payloadHash, path string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && (s3Err.Code == "" || s3Err.Code == "NoSuchKey"):
+		return nil, &fs.PathError{Op: strings.ToLower(req.Method), Path: path, Err: fs.ErrNotExist}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return nil, ErrInvalidRange
+	case s3Err.Code != "":
+		return nil, fmt.Errorf("S3 %s %s: %s: %s", req.Method, path, s3Err.Code, s3Err.Message)
+	default:
+		return nil, fmt.Errorf("S3 %s %s: unexpected status %d", req.Method, path, resp.StatusCode)
+	}
+}
+
+// sign adds Signature Version 4 headers to a request. The host, range and
+// x-amz-* headers are signed.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope, signature := s.signature(canonicalRequest, now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+// presignURL returns a URL with the Signature Version 4 in its query, with
+// only the host header signed.
+func (s *S3) presignURL(method, key string, query url.Values, ttl time.Duration, now time.Time) string {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	u, _ := url.Parse(s.objectURL(key, query))
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	_, signature := s.signature(canonicalRequest, now)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.UTC().Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request and returns the credential scope and
+// the hex signature.
+func (s *S3) signature(canonicalRequest string, now time.Time) (string, string) {
+	scope := s.scope(now)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.UTC().Format("20060102T150405Z"),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), now.UTC().Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return scope, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes a query the way Signature Version 4 expects:
+// sorted by name, with everything but unreserved characters escaped.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes every byte but unreserved characters, and slashes
+// unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Object streams a blob. Reading after a seek continues with a ranged
+// request from the new offset, so http.ServeContent can serve ranges.
+type s3Object struct {
+	s3         *S3
+	path       string
+	size       int64
+	offset     int64
+	body       io.ReadCloser
+	bodyOffset int64
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil || o.bodyOffset != o.offset {
+		if o.body != nil {
+			o.body.Close()
+			o.body = nil
+		}
+		body, err := o.s3.GetRange(o.path, o.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		o.body, o.bodyOffset = body, o.offset
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	o.bodyOffset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
@@ -1,76 +1,129 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"path"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/jr-k/mailgress/internal/config"
 )
 
 var safeFilename = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
 
-type Storage struct {
-	basePath string
+// yearDir matches the top-level directories Store writes to.
+var yearDir = regexp.MustCompile(`^[0-9]{4}$`)
+
+// ErrPresignUnsupported is returned by PresignGet when a driver does not
+// hand out direct download URLs, and files are served through Mailgress.
+var ErrPresignUnsupported = errors.New("storage driver does not support presigned URLs")
+
+// ErrInvalidRange is returned by GetRange when the range starts past the
+// end of the object.
+var ErrInvalidRange = errors.New("invalid range")
+
+// Storage keeps attachments and raw messages. Paths are relative and use
+// forward slashes, so a blob keeps its path when moved between drivers.
+// Errors for missing blobs wrap fs.ErrNotExist.
+type Storage interface {
+	// Store saves content under a new path for the email and returns the
+	// path and the number of bytes written.
+	Store(emailID int64, filename string, content io.Reader) (string, int64, error)
+	// Put saves content under path, replacing any blob already there.
+	// size is the length of content, or -1 when unknown.
+	Put(path string, content io.Reader, size int64) error
+	// Get opens a blob for streaming. The reader also implements io.Seeker,
+	// so ranges can be served from it.
+	Get(path string) (io.ReadCloser, error)
+	// GetRange opens length bytes of a blob from offset, or the rest of the
+	// blob when length is negative.
+	GetRange(path string, offset, length int64) (io.ReadCloser, error)
+	// Stat returns the size and modification time of a blob.
+	Stat(path string) (*ObjectInfo, error)
+	// Delete removes a blob. Object stores report success for blobs that
+	// do not exist, other drivers fail with fs.ErrNotExist.
+	Delete(path string) error
+	// Walk calls fn for every blob stored by Store. Other data under the
+	// storage root, such as avatars, is skipped.
+	Walk(fn func(info ObjectInfo) error) error
+	// PresignGet returns a URL that downloads a blob without credentials
+	// until ttl passes, or ErrPresignUnsupported.
+	PresignGet(path string, ttl time.Duration, opts PresignOptions) (string, error)
 }
 
-func NewStorage(basePath string) (*Storage, error) {
-	if err := os.MkdirAll(basePath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create storage directory: %w", err)
-	}
-	return &Storage{basePath: basePath}, nil
+// ObjectInfo describes a stored blob.
+type ObjectInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
 }
 
-func (s *Storage) Store(emailID int64, filename string, content io.Reader) (string, int64, error) {
-	now := time.Now()
-	dir := filepath.Join(
-		s.basePath,
-		fmt.Sprintf("%d", now.Year()),
-		fmt.Sprintf("%02d", now.Month()),
-		fmt.Sprintf("%02d", now.Day()),
-		fmt.Sprintf("%d", emailID),
-	)
+// PresignOptions sets the response headers of a presigned download.
+type PresignOptions struct {
+	ContentType        string
+	ContentDisposition string
+}
+
+// Drivers lists the storage drivers New accepts.
+var Drivers = []string{"local", "s3"}
 
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", 0, fmt.Errorf("failed to create directory: %w", err)
+// New returns the storage driver named driver, configured from cfg.
+func New(driver string, cfg *config.Config) (Storage, error) {
+	switch driver {
+	case "local", "":
+		return NewLocal(cfg.StoragePath)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        cfg.StorageS3Endpoint,
+			Region:          cfg.StorageS3Region,
+			Bucket:          cfg.StorageS3Bucket,
+			Prefix:          cfg.StorageS3Prefix,
+			AccessKeyID:     cfg.StorageS3AccessKeyID,
+			SecretAccessKey: cfg.StorageS3SecretAccessKey,
+			PathStyle:       cfg.StorageS3PathStyle,
+			Presign:         cfg.StorageS3Presign,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q, available: %s", driver, strings.Join(Drivers, ", "))
 	}
+}
 
+// newPath returns the path Store saves a file of an email under:
+// year/month/day/email ID/random prefix_sanitized filename.
+func newPath(emailID int64, filename string) string {
+	now := time.Now()
 	safeName := safeFilename.ReplaceAllString(filename, "_")
 	if len(safeName) > 100 {
 		safeName = safeName[:100]
 	}
 	uniqueName := fmt.Sprintf("%s_%s", uuid.New().String()[:8], safeName)
-	fullPath := filepath.Join(dir, uniqueName)
-
-	file, err := os.Create(fullPath)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	size, err := io.Copy(file, content)
-	if err != nil {
-		os.Remove(fullPath)
-		return "", 0, fmt.Errorf("failed to write file: %w", err)
-	}
-
-	relPath, _ := filepath.Rel(s.basePath, fullPath)
-	return relPath, size, nil
-}
-
-func (s *Storage) Get(path string) (io.ReadCloser, error) {
-	fullPath := filepath.Join(s.basePath, path)
-	return os.Open(fullPath)
+	return path.Join(
+		fmt.Sprintf("%d", now.Year()),
+		fmt.Sprintf("%02d", now.Month()),
+		fmt.Sprintf("%02d", now.Day()),
+		fmt.Sprintf("%d", emailID),
+		uniqueName,
+	)
 }
 
-func (s *Storage) Delete(path string) error {
-	fullPath := filepath.Join(s.basePath, path)
-	return os.Remove(fullPath)
+// cleanPath checks a path handed to a driver and returns it in the form
+// drivers key blobs by.
+func cleanPath(p string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.HasPrefix(cleaned, "/") {
+		return "", fmt.Errorf("invalid storage path %q", p)
+	}
+	return cleaned, nil
 }
 
-func (s *Storage) FullPath(path string) string {
-	return filepath.Join(s.basePath, path)
+// stored reports whether a path is one Store writes, under a year
+// directory.
+func stored(p string) bool {
+	first, _, _ := strings.Cut(p, "/")
+	return yearDir.MatchString(first)
 }
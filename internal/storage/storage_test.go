@@ -0,0 +1,418 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testContent = "hello, world"
+
+// drivers returns a constructor of empty storage for every driver, and
+// whether the driver hands out presigned URLs.
+func drivers() []struct {
+	name    string
+	new     func(t *testing.T) Storage
+	presign bool
+} {
+	return []struct {
+		name    string
+		new     func(t *testing.T) Storage
+		presign bool
+	}{
+		{"local", func(t *testing.T) Storage {
+			store, err := NewLocal(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		}, false},
+		{"memory", func(t *testing.T) Storage { return NewMemory() }, false},
+		{"s3", func(t *testing.T) Storage { return newFakeS3(t, true) }, true},
+		{"s3 without presign", func(t *testing.T) Storage { return newFakeS3(t, false) }, false},
+	}
+}
+
+func TestStorage(t *testing.T) {
+	for _, driver := range drivers() {
+		t.Run(driver.name, func(t *testing.T) {
+			testStorage(t, driver.new, driver.presign)
+		})
+	}
+}
+
+// testStorage checks a driver against the contract of the Storage
+// interface.
+func testStorage(t *testing.T, newStore func(t *testing.T) Storage, presign bool) {
+	t.Run("Store", func(t *testing.T) {
+		store := newStore(t)
+		path, size, err := store.Store(7, "my report.pdf", strings.NewReader(testContent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(testContent)) {
+			t.Errorf("Store wrote %d bytes, want %d", size, len(testContent))
+		}
+		parts := strings.Split(path, "/")
+		if len(parts) != 5 || !stored(path) || parts[3] != "7" || !strings.HasSuffix(parts[4], "_my_report.pdf") {
+			t.Errorf("Store saved under %q, want year/month/day/7/prefix_my_report.pdf", path)
+		}
+		if got := readAll(t, store, path); got != testContent {
+			t.Errorf("Get = %q, want %q", got, testContent)
+		}
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		store := newStore(t)
+		path := "2024/01/02/1/abcdefgh_message.eml"
+		if err := store.Put(path, strings.NewReader("first"), 5); err != nil {
+			t.Fatal(err)
+		}
+		// A reader that cannot seek, of unknown size
+		if err := store.Put(path, io.MultiReader(strings.NewReader("sec"), strings.NewReader("ond")), -1); err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, store, path); got != "second" {
+			t.Errorf("Get after replacing = %q, want second", got)
+		}
+		if err := store.Put(path, strings.NewReader(""), 0); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := store.Stat(path); err != nil || info.Size != 0 {
+			t.Errorf("Stat of an empty blob = %+v, %v", info, err)
+		}
+	})
+
+	t.Run("Get seeks", func(t *testing.T) {
+		store := newStore(t)
+		path := put(t, store, testContent)
+		file, err := store.Get(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		seeker, ok := file.(io.Seeker)
+		if !ok {
+			t.Fatalf("Get returned a %T, which cannot seek", file)
+		}
+		head := make([]byte, 5)
+		if _, err := io.ReadFull(file, head); err != nil || string(head) != "hello" {
+			t.Fatalf("reading the head = %q, %v", head, err)
+		}
+		if _, err := seeker.Seek(7, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		rest, err := io.ReadAll(file)
+		if err != nil || string(rest) != "world" {
+			t.Errorf("reading after seeking to 7 = %q, %v, want world", rest, err)
+		}
+		if end, err := seeker.Seek(0, io.SeekEnd); err != nil || end != int64(len(testContent)) {
+			t.Errorf("seeking to the end = %d, %v", end, err)
+		}
+	})
+
+	t.Run("GetRange", func(t *testing.T) {
+		store := newStore(t)
+		path := put(t, store, testContent)
+		tests := []struct {
+			offset, length int64
+			want           string
+			err            error
+		}{
+			{0, 5, "hello", nil},
+			{7, -1, "world", nil},
+			{7, 100, "world", nil},
+			{11, 1, "d", nil},
+			{12, -1, "", ErrInvalidRange},
+			{100, 5, "", ErrInvalidRange},
+			{-1, 5, "", ErrInvalidRange},
+		}
+		for _, tt := range tests {
+			body, err := store.GetRange(path, tt.offset, tt.length)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("GetRange(%d, %d) error = %v, want %v", tt.offset, tt.length, err, tt.err)
+				}
+				if err == nil {
+					body.Close()
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("GetRange(%d, %d): %v", tt.offset, tt.length, err)
+				continue
+			}
+			got, err := io.ReadAll(body)
+			body.Close()
+			if err != nil || string(got) != tt.want {
+				t.Errorf("GetRange(%d, %d) = %q, %v, want %q", tt.offset, tt.length, got, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		store := newStore(t)
+		path := put(t, store, testContent)
+		info, err := store.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Path != path || info.Size != int64(len(testContent)) {
+			t.Errorf("Stat = %+v, want %s of %d bytes", info, path, len(testContent))
+		}
+		if age := time.Since(info.ModTime); age < -time.Minute || age > time.Minute {
+			t.Errorf("Stat modification time %v, want about now", info.ModTime)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		store := newStore(t)
+		path := "2024/01/02/1/abcdefgh_missing.eml"
+		if _, err := store.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat = %v, want fs.ErrNotExist", err)
+		}
+		if _, err := store.Get(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Get = %v, want fs.ErrNotExist", err)
+		}
+		if _, err := store.GetRange(path, 0, 5); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("GetRange = %v, want fs.ErrNotExist", err)
+		}
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		store := newStore(t)
+		for _, path := range []string{"", "../escape", "/absolute", "a/../../escape"} {
+			if err := store.Put(path, strings.NewReader(testContent), int64(len(testContent))); err == nil {
+				t.Errorf("Put accepted %q", path)
+			}
+			if _, err := store.Get(path); err == nil {
+				t.Errorf("Get accepted %q", path)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		path := put(t, store, testContent)
+		if err := store.Delete(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat after Delete = %v, want fs.ErrNotExist", err)
+		}
+		if err := store.Delete(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("deleting a missing blob = %v, want nil or fs.ErrNotExist", err)
+		}
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		store := newStore(t)
+		first := put(t, store, "first")
+		second := put(t, store, "second blob")
+		if err := store.Put("avatars/1.png", strings.NewReader("png"), 3); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []ObjectInfo
+		err := store.Walk(func(info ObjectInfo) error {
+			got = append(got, info)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].Size < got[j].Size })
+		if len(got) != 2 || got[0].Path != first || got[0].Size != 5 || got[1].Path != second || got[1].Size != 11 {
+			t.Errorf("Walk = %+v, want %s and %s only", got, first, second)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err = store.Walk(func(info ObjectInfo) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("Walk returned %v after %d calls, want the callback's error after 1", err, calls)
+		}
+	})
+
+	t.Run("PresignGet", func(t *testing.T) {
+		store := newStore(t)
+		path := put(t, store, testContent)
+		url, err := store.PresignGet(path, time.Minute, PresignOptions{ContentType: "text/plain"})
+		if !presign {
+			if !errors.Is(err, ErrPresignUnsupported) {
+				t.Errorf("PresignGet = %q, %v, want ErrPresignUnsupported", url, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != testContent || resp.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("presigned download = %d %q as %q", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+		}
+	})
+}
+
+// put stores content under a new path and returns the path.
+func put(t *testing.T, store Storage, content string) string {
+	t.Helper()
+	path, _, err := store.Store(1, "blob.txt", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readAll(t *testing.T, store Storage, path string) string {
+	t.Helper()
+	file, err := store.Get(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// fakeS3 is an in-memory S3 endpoint serving one bucket with path-style
+// addressing. It checks that requests are signed and that uploads match
+// their signed payload hash, and lists one object per page so listings
+// go through continuation tokens.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+const fakeBucket = "mail"
+
+func newFakeS3(t *testing.T, presign bool) *S3 {
+	t.Helper()
+	server := httptest.NewServer(&fakeS3{objects: make(map[string]fakeObject)})
+	t.Cleanup(server.Close)
+	store, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          fakeBucket,
+		Prefix:          "mailgress",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+		Presign:         presign,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") && query.Get("X-Amz-Signature") == "" {
+		s3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+fakeBucket+"/")
+	if !ok {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		f.list(w, query.Get("prefix"), query.Get("continuation-token"))
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		hash := sha256.Sum256(data)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+			s3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+		f.objects[key] = fakeObject{data: data, modTime: time.Now()}
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if contentType := query.Get("response-content-type"); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		http.ServeContent(w, r, key, object.modTime, bytes.NewReader(object.data))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	type content struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	var result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && key > token {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		object := f.objects[keys[0]]
+		result.Contents = []content{{Key: keys[0], Size: int64(len(object.data)), LastModified: object.modTime.UTC()}}
+		result.IsTruncated = len(keys) > 1
+		if result.IsTruncated {
+			result.NextContinuationToken = keys[0]
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
@@ -2,53 +2,133 @@ package webhook
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/jr-k/mailgress/internal/config"
 	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
 	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/storage"
 )
 
-type Job struct {
-	Webhook *domain.Webhook
-	Email   *domain.Email
-	Attempt int
-}
+// ErrQueueFull is returned when the webhook queue holds more jobs than
+// WEBHOOK_QUEUE_MAX. Callers push back on their source instead of losing
+// the event.
+var ErrQueueFull = errors.New("webhook queue is full")
+
+const (
+	// pollInterval bounds how long a due job waits when no Dispatch call
+	// wakes the workers, e.g. for retries or jobs left by another process.
+	pollInterval = time.Second
+	// leaseDuration covers one attempt: deliver gives up after at most
+	// service.MaxWebhookTimeoutSec, the rest is room for building the
+	// payload and recording the result.
+	leaseDuration = 2 * time.Minute
+)
 
 type Dispatcher struct {
-	jobs            chan *Job
-	workers         int
-	webhookService  *service.WebhookService
-	deliveryService *service.DeliveryService
-	emailService    *service.EmailService
-	config          *config.Config
-	wg              sync.WaitGroup
-	ctx             context.Context
-	cancel          context.CancelFunc
-	evaluator       *RuleEvaluator
+	workers             int
+	queueMax            int64
+	circuitThreshold    int
+	probeInterval       time.Duration
+	disableAfter        time.Duration
+	secretGrace         time.Duration
+	owner               string
+	wake                chan struct{}
+	webhookService      *service.WebhookService
+	deliveryService     *service.DeliveryService
+	jobService          *service.WebhookJobService
+	emailService        *service.EmailService
+	mailboxService      *service.MailboxService
+	domainService       *service.DomainService
+	notificationService *service.NotificationService
+	storage             storage.Storage
+	urlSigner           *service.URLSigner
+	keys                *SigningKeys
+	bus                 *events.Bus
+	config              *config.Config
+	wg                  sync.WaitGroup
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	evaluator           *RuleEvaluator
+	targets             map[string]Target
+	brokerDrivers       map[string]BrokerDriver
 }
 
 func NewDispatcher(
 	cfg *config.Config,
 	webhookService *service.WebhookService,
 	deliveryService *service.DeliveryService,
+	jobService *service.WebhookJobService,
 	emailService *service.EmailService,
+	mailboxService *service.MailboxService,
+	domainService *service.DomainService,
+	notificationService *service.NotificationService,
+	storage storage.Storage,
+	urlSigner *service.URLSigner,
+	keys *SigningKeys,
+	bus *events.Bus,
+	brokerDrivers map[string]BrokerDriver,
 ) *Dispatcher {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Dispatcher{
-		jobs:            make(chan *Job, 1000),
-		workers:         cfg.WebhookWorkers,
-		webhookService:  webhookService,
-		deliveryService: deliveryService,
-		emailService:    emailService,
-		config:          cfg,
-		ctx:             ctx,
-		cancel:          cancel,
-		evaluator:       NewRuleEvaluator(),
+	relayFrom := cfg.SMTPRelayFrom
+	if relayFrom == "" {
+		relayFrom = "mailgress@" + cfg.SMTPHostname
+	}
+
+	d := &Dispatcher{
+		workers:             cfg.WebhookWorkers,
+		queueMax:            int64(cfg.WebhookQueueMax),
+		circuitThreshold:    cfg.WebhookCircuitThreshold,
+		probeInterval:       time.Duration(cfg.WebhookCircuitProbeSeconds) * time.Second,
+		disableAfter:        time.Duration(cfg.WebhookDisableAfterHours) * time.Hour,
+		secretGrace:         time.Duration(cfg.WebhookSecretGraceHours) * time.Hour,
+		owner:               leaseOwner(),
+		wake:                make(chan struct{}, 1),
+		webhookService:      webhookService,
+		deliveryService:     deliveryService,
+		jobService:          jobService,
+		emailService:        emailService,
+		mailboxService:      mailboxService,
+		domainService:       domainService,
+		notificationService: notificationService,
+		storage:             storage,
+		urlSigner:           urlSigner,
+		keys:                keys,
+		bus:                 bus,
+		config:              cfg,
+		ctx:                 ctx,
+		cancel:              cancel,
+		evaluator:           NewRuleEvaluator(),
+		brokerDrivers:       brokerDrivers,
+		targets: map[string]Target{
+			domain.WebhookTargetHTTP:      &httpTarget{keys: keys},
+			domain.WebhookTargetSMTP:      &smtpTarget{hostname: cfg.SMTPHostname, from: relayFrom},
+			domain.WebhookTargetBroker:    &brokerTarget{drivers: brokerDrivers},
+			domain.WebhookTargetDirectory: &directoryTarget{root: cfg.WebhookDirectoryRoot},
+		},
 	}
+	bus.Subscribe(d.handleEvent)
+	return d
+}
+
+// leaseOwner identifies this process in job leases.
+func leaseOwner() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(suffix))
 }
 
 func (d *Dispatcher) Start() {
@@ -58,88 +138,308 @@ func (d *Dispatcher) Start() {
 		d.wg.Add(1)
 		go d.worker(i)
 	}
-
-	d.wg.Add(1)
-	go d.retryWorker()
 }
 
+// Stop waits for in-flight deliveries to finish. Queued jobs stay in the
+// database and are picked up on the next start.
 func (d *Dispatcher) Stop() {
 	log.Println("Stopping webhook dispatcher...")
 	d.cancel()
-	close(d.jobs)
 	d.wg.Wait()
 	log.Println("Webhook dispatcher stopped")
 }
 
-func (d *Dispatcher) Dispatch(mailboxID int64, email *domain.Email) {
+// Saturated reports whether the queue has reached WEBHOOK_QUEUE_MAX.
+func (d *Dispatcher) Saturated(ctx context.Context) bool {
+	if d.queueMax <= 0 {
+		return false
+	}
+	count, err := d.jobService.Count(ctx)
+	if err != nil {
+		log.Printf("Failed to count webhook jobs: %v", err)
+		return false
+	}
+	return count >= d.queueMax
+}
+
+// Dispatch queues the email for every active webhook of the mailbox that
+// subscribes to email.received and whose rules match. Jobs are stored before
+// Dispatch returns, all of them or none: a nil error means every matching
+// webhook will be attempted, an error that none will, so the sender can be
+// told to try again.
+func (d *Dispatcher) Dispatch(mailboxID int64, email *domain.Email) error {
 	webhooks, err := d.webhookService.ListActiveByMailbox(d.ctx, mailboxID)
 	if err != nil {
-		log.Printf("Failed to get webhooks for mailbox %d: %v", mailboxID, err)
+		return fmt.Errorf("failed to get webhooks for mailbox %d: %w", mailboxID, err)
+	}
+
+	var matched []int64
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(domain.EventEmailReceived) && d.evaluator.Evaluate(webhook.Rules, email) {
+			matched = append(matched, webhook.ID)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	if err := d.jobService.EnqueueAll(d.ctx, matched, email.ID); err != nil {
+		return fmt.Errorf("failed to queue webhooks for email %d: %w", email.ID, err)
+	}
+
+	d.notify()
+	return nil
+}
+
+// handleEvent queues an event published on the bus for the subscribed
+// webhooks in its scope. The payload is rendered now, as the subject of the
+// event may be gone by the time the job runs.
+func (d *Dispatcher) handleEvent(ctx context.Context, event domain.Event) {
+	// The publishing request may finish before the jobs are stored
+	ctx = context.WithoutCancel(ctx)
+
+	webhooks, err := d.eventWebhooks(ctx, event)
+	if err != nil {
+		log.Printf("Failed to get webhooks for %s event: %v", event.Type, err)
 		return
 	}
+	if event.Email != nil && event.Mailbox == nil {
+		// Body templates can refer to the mailbox of an email
+		event.Mailbox = d.loadMailbox(ctx, event.Email.MailboxID)
+	}
 
+	queued := false
 	for _, webhook := range webhooks {
-		if !d.evaluator.Evaluate(webhook.Rules, email) {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if event.Email != nil && !d.evaluator.Evaluate(webhook.Rules, event.Email) {
+			continue
+		}
+		// A webhook is not told about its own failures
+		if event.Delivery != nil && event.Delivery.WebhookID == webhook.ID {
 			continue
 		}
 
-		select {
-		case d.jobs <- &Job{Webhook: webhook, Email: email, Attempt: 1}:
-		default:
-			log.Printf("Webhook job queue full, dropping job for webhook %d", webhook.ID)
+		payload, err := BuildEventPayload(event, webhook)
+		if err != nil {
+			d.recordRenderFailure(ctx, webhook, event.Type, nil, err)
+			continue
+		}
+		if _, err := d.jobService.EnqueueEvent(ctx, webhook.ID, event.Type, string(payload), ""); err != nil {
+			log.Printf("Failed to queue %s event for webhook %d: %v", event.Type, webhook.ID, err)
+			continue
 		}
+		queued = true
+	}
+
+	if queued {
+		d.notify()
+	}
+}
+
+// eventWebhooks returns the webhooks that may receive an event: those of the
+// mailbox it concerns, or for mailbox events those of every mailbox in the
+// domain with the same owner. Domain events, and events of mailboxes without
+// an owner, go to the webhooks of the mailboxes admins manage, as only
+// admins manage domains and unowned mailboxes.
+func (d *Dispatcher) eventWebhooks(ctx context.Context, event domain.Event) ([]*domain.Webhook, error) {
+	switch {
+	case event.Email != nil:
+		return d.webhookService.ListActiveByMailbox(ctx, event.Email.MailboxID)
+	case event.Mailbox != nil:
+		switch {
+		case event.Mailbox.DomainID == nil:
+			return d.webhookService.ListActiveByMailbox(ctx, event.Mailbox.ID)
+		case event.Mailbox.OwnerID == nil:
+			return d.webhookService.ListActiveAdminByDomain(ctx, *event.Mailbox.DomainID)
+		}
+		return d.webhookService.ListActiveByDomainOwner(ctx, *event.Mailbox.DomainID, *event.Mailbox.OwnerID)
+	case event.Domain != nil:
+		return d.webhookService.ListActiveAdminByDomain(ctx, event.Domain.ID)
+	case event.Delivery != nil && event.Delivery.Webhook != nil:
+		return d.webhookService.ListActiveByMailbox(ctx, event.Delivery.Webhook.MailboxID)
+	case event.Retention != nil:
+		return d.webhookService.ListActiveByMailbox(ctx, event.Retention.MailboxID)
+	}
+	return nil, nil
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
 	}
 }
 
 func (d *Dispatcher) worker(id int) {
 	defer d.wg.Done()
 
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
 	for {
+		// Drain every due job before going back to sleep
+		for d.ctx.Err() == nil && d.runNext() {
+		}
+
 		select {
 		case <-d.ctx.Done():
 			return
-		case job, ok := <-d.jobs:
-			if !ok {
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and processes one due job. It reports whether a job was run.
+func (d *Dispatcher) runNext() bool {
+	job, err := d.jobService.Claim(d.ctx, d.owner, leaseDuration)
+	if err != nil {
+		if d.ctx.Err() == nil {
+			log.Printf("Failed to claim webhook job: %v", err)
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// In-flight deliveries are not tied to d.ctx, so Stop lets them finish
+	// instead of abandoning a request the receiver may already have handled.
+	d.processJob(context.Background(), job)
+	return true
+}
+
+func (d *Dispatcher) processJob(ctx context.Context, job *domain.WebhookJob) {
+	webhook, err := d.webhookService.GetByID(ctx, job.WebhookID)
+	if err != nil || !webhook.IsActive {
+		// Deleted webhooks cascade; disabled ones drop their backlog
+		d.finishJob(ctx, job)
+		return
+	}
+
+	if webhook.CircuitState != domain.CircuitClosed && !d.claimProbe(ctx, webhook, job) {
+		return
+	}
+
+	if job.LastDeliveryID != nil {
+		if err := d.deliveryService.FailUnfinished(ctx, *job.LastDeliveryID); err != nil {
+			log.Printf("Failed to close delivery %d: %v", *job.LastDeliveryID, err)
+		}
+	}
+
+	if job.Attempt > 1 && job.Attempt > webhook.MaxRetries {
+		d.finishJob(ctx, job)
+		return
+	}
+
+	payloadBytes := []byte(job.Payload)
+	var email *domain.Email
+	var files []File
+	var body *Body
+	if job.Event == domain.EventEmailReceived {
+		if job.EmailID == nil {
+			d.finishJob(ctx, job)
+			return
+		}
+		var err error
+		email, err = d.emailService.GetByID(ctx, *job.EmailID)
+		if err != nil {
+			log.Printf("Failed to get email %d for webhook %d: %v", *job.EmailID, webhook.ID, err)
+			d.finishJob(ctx, job)
+			return
+		}
+
+		switch {
+		case webhook.PayloadType == PayloadTypeTemplate:
+			event := domain.Event{
+				Type:       domain.EventEmailReceived,
+				OccurredAt: time.Now(),
+				Email:      email,
+				Mailbox:    d.loadMailbox(ctx, email.MailboxID),
+			}
+			payloadBytes, err = RenderTemplate(webhook.CustomPayload, NewTemplateData(event))
+			if err != nil {
+				d.recordRenderFailure(ctx, webhook, job.Event, job.EmailID, err)
+				d.finishJob(ctx, job)
+				return
+			}
+		case IsPreset(webhook.PayloadType):
+			payloadBytes, body, err = d.presetBody(webhook, email)
+			if err != nil {
+				d.recordRenderFailure(ctx, webhook, job.Event, job.EmailID, err)
+				d.finishJob(ctx, job)
 				return
 			}
-			d.processJob(job)
+		default:
+			payload := BuildPayload(email, webhook)
+			files = d.enrichPayload(payload, email, webhook)
+			payloadBytes, _ = payload.JSON()
 		}
 	}
-}
 
-func (d *Dispatcher) processJob(job *Job) {
-	payload := BuildPayload(job.Email, job.Webhook)
-	payloadBytes, _ := payload.JSON()
+	if body == nil && webhook.Target == domain.WebhookTargetHTTP {
+		var err error
+		if body, err = d.encodeBody(webhook, payloadBytes, email, files); err != nil {
+			d.recordRenderFailure(ctx, webhook, job.Event, job.EmailID, err)
+			d.finishJob(ctx, job)
+			return
+		}
+	}
+
+	msg := &Message{Event: job.Event, DeliveryID: job.IdempotencyKey, Payload: payloadBytes, Body: body}
+	if webhook.Target != domain.WebhookTargetHTTP && email != nil && email.RawPath != "" {
+		if msg.Raw, err = d.readFile(email.RawPath); err != nil {
+			log.Printf("Failed to read raw message for email %d: %v", email.ID, err)
+		}
+	}
 
-	delivery, err := d.deliveryService.Create(d.ctx, job.Webhook.ID, job.Email.ID, job.Attempt, string(payloadBytes))
+	delivery, err := d.deliveryService.Create(ctx, webhook.ID, job.Event, job.EmailID, job.Attempt, string(payloadBytes), job.IdempotencyKey)
 	if err != nil {
+		// Leave the job leased; it is retried once the lease expires
 		log.Printf("Failed to create delivery record: %v", err)
 		return
 	}
 
 	startTime := time.Now()
-	timeout := time.Duration(job.Webhook.TimeoutSec) * time.Second
-	statusCode, responseBody, err := SendWebhook(d.ctx, job.Webhook, payloadBytes, timeout)
+	resp, err := d.deliver(ctx, webhook, msg)
 	duration := int(time.Since(startTime).Milliseconds())
 
-	var status string
+	var statusCode int
+	var responseBody string
+	if resp != nil {
+		statusCode = resp.StatusCode
+		responseBody = resp.Body
+	}
+
 	var errorMsg string
+	retry := false
 
 	if err != nil {
 		errorMsg = err.Error()
-		if job.Attempt < job.Webhook.MaxRetries {
-			status = domain.DeliveryStatusRetrying
-		} else {
-			status = domain.DeliveryStatusFailed
-		}
-	} else if statusCode >= 200 && statusCode < 300 {
-		status = domain.DeliveryStatusSuccess
-	} else {
+		// A blocked address stays blocked until the allowlist changes
+		retry = !errors.Is(err, ErrBlockedAddress) && !errors.Is(err, ErrPermanent)
+	} else if statusCode != 0 && (statusCode < 200 || statusCode >= 300) {
 		errorMsg = "Non-2xx response"
-		if job.Attempt < job.Webhook.MaxRetries {
+		retry = retryable(statusCode)
+		if !retry {
+			errorMsg = fmt.Sprintf("HTTP %d is a permanent failure, not retrying", statusCode)
+		}
+	}
+
+	status := domain.DeliveryStatusSuccess
+	var nextAttemptAt *time.Time
+	if errorMsg != "" {
+		status = domain.DeliveryStatusFailed
+		if retry && job.Attempt < webhook.MaxRetries {
 			status = domain.DeliveryStatusRetrying
-		} else {
-			status = domain.DeliveryStatusFailed
+			delay, ok := retryAfter(resp, time.Now())
+			if !ok {
+				delay = backoff(webhook, job.Attempt)
+			}
+			at := time.Now().Add(delay)
+			nextAttemptAt = &at
 		}
 	}
 
@@ -148,80 +448,379 @@ func (d *Dispatcher) processJob(job *Job) {
 		truncatedResponse = truncatedResponse[:1000] + "..."
 	}
 
-	_, err = d.deliveryService.UpdateStatus(d.ctx, delivery.ID, status, &statusCode, truncatedResponse, errorMsg, &duration)
+	updated, err := d.deliveryService.UpdateStatus(ctx, delivery.ID, status, &statusCode, truncatedResponse, errorMsg, &duration, nextAttemptAt)
 	if err != nil {
 		log.Printf("Failed to update delivery status: %v", err)
 	}
-}
 
-func (d *Dispatcher) retryWorker() {
-	defer d.wg.Done()
+	// delivery.failed deliveries never raise another delivery.failed, so a
+	// mailbox whose webhooks all fail does not loop
+	if status == domain.DeliveryStatusFailed && updated != nil && job.Event != domain.EventDeliveryFailed {
+		updated.Webhook = webhook
+		d.bus.Publish(ctx, domain.Event{Type: domain.EventDeliveryFailed, Delivery: updated})
+	}
 
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	if errorMsg == "" {
+		if webhook.CircuitState != domain.CircuitClosed || webhook.ConsecutiveFailures > 0 {
+			if err := d.webhookService.RecordSuccess(ctx, webhook.ID); err != nil {
+				log.Printf("Failed to reset circuit of webhook %d: %v", webhook.ID, err)
+			} else if webhook.CircuitState != domain.CircuitClosed {
+				log.Printf("Circuit closed for webhook %d", webhook.ID)
+			}
+		}
+	} else {
+		d.recordFailure(ctx, webhook, errorMsg)
+	}
 
-	for {
-		select {
-		case <-d.ctx.Done():
-			return
-		case <-ticker.C:
-			d.processPendingRetries()
+	if nextAttemptAt != nil {
+		if err := d.jobService.Reschedule(ctx, job.ID, job.Attempt+1, delivery.ID, *nextAttemptAt); err != nil {
+			log.Printf("Failed to schedule retry of webhook job %d: %v", job.ID, err)
 		}
+		return
 	}
+
+	d.finishJob(ctx, job)
 }
 
-func (d *Dispatcher) processPendingRetries() {
-	deliveries, err := d.deliveryService.ListPending(d.ctx, 100)
+// deliver hands the message to the webhook's target, bounded by the
+// webhook's timeout. Timeouts saved before they were capped are capped here,
+// an attempt outliving the job's lease would be run twice.
+func (d *Dispatcher) deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error) {
+	target, ok := d.targets[webhook.Target]
+	if !ok {
+		return nil, permanent(fmt.Errorf("unknown target %q", webhook.Target))
+	}
+
+	timeoutSec := min(webhook.TimeoutSec, service.MaxWebhookTimeoutSec)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+	return target.Deliver(ctx, webhook, msg)
+}
+
+// SetTarget replaces the implementation of a target, such as with a fake
+// in tests. It must be called before Start.
+func (d *Dispatcher) SetTarget(name string, target Target) {
+	d.targets[name] = target
+}
+
+// ValidateTarget checks the target settings of a webhook before it is saved.
+// Webhooks without a target are HTTP webhooks.
+func (d *Dispatcher) ValidateTarget(webhook *domain.Webhook) error {
+	name := webhook.Target
+	if name == "" {
+		name = domain.WebhookTargetHTTP
+	}
+	target, ok := d.targets[name]
+	if !ok {
+		return fmt.Errorf("unknown target %q", name)
+	}
+	return target.Validate(webhook)
+}
+
+// recordRenderFailure stores a failed delivery for a payload that could not
+// be built, typically a body template that fails to execute. The receiver
+// is not contacted and the delivery is not retried.
+func (d *Dispatcher) recordRenderFailure(ctx context.Context, webhook *domain.Webhook, event string, emailID *int64, renderErr error) {
+	log.Printf("Failed to build %s payload for webhook %d: %v", event, webhook.ID, renderErr)
+
+	delivery, err := d.deliveryService.Create(ctx, webhook.ID, event, emailID, 1, "", "")
+	if err != nil {
+		log.Printf("Failed to create delivery record: %v", err)
+		return
+	}
+	updated, err := d.deliveryService.UpdateStatus(ctx, delivery.ID, domain.DeliveryStatusFailed, nil, "", "Failed to build payload: "+renderErr.Error(), nil, nil)
 	if err != nil {
-		log.Printf("Failed to list pending deliveries: %v", err)
+		log.Printf("Failed to update delivery status: %v", err)
 		return
 	}
 
-	for _, delivery := range deliveries {
-		if delivery.Status != domain.DeliveryStatusRetrying {
-			continue
+	if event != domain.EventDeliveryFailed {
+		updated.Webhook = webhook
+		d.bus.Publish(ctx, domain.Event{Type: domain.EventDeliveryFailed, Delivery: updated})
+	}
+}
+
+// loadMailbox returns the mailbox with its domain, or nil if it is gone.
+func (d *Dispatcher) loadMailbox(ctx context.Context, id int64) *domain.Mailbox {
+	mailbox, err := d.mailboxService.GetByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+	if mailbox.DomainID != nil {
+		mailbox.Domain, _ = d.domainService.GetByID(ctx, *mailbox.DomainID)
+	}
+	return mailbox
+}
+
+// claimProbe lets one job through an open circuit once the probe interval
+// has passed. Every other job is deferred until the next probe is due.
+func (d *Dispatcher) claimProbe(ctx context.Context, webhook *domain.Webhook, job *domain.WebhookJob) bool {
+	now := time.Now()
+	ok, err := d.webhookService.ClaimProbe(ctx, webhook.ID, now.Add(-d.probeInterval), now.Add(-leaseDuration))
+	if err != nil {
+		log.Printf("Failed to claim probe for webhook %d: %v", webhook.ID, err)
+	}
+	if ok {
+		log.Printf("Probing webhook %d with open circuit", webhook.ID)
+		return true
+	}
+
+	at := now.Add(d.probeInterval)
+	if next := d.NextProbeAt(webhook); next != nil && next.After(now) {
+		at = *next
+	}
+	if err := d.jobService.Defer(ctx, job, at); err != nil {
+		log.Printf("Failed to defer webhook job %d: %v", job.ID, err)
+	}
+	return false
+}
+
+// NextProbeAt returns when an open circuit lets the next probe through, or
+// nil when the circuit is closed or a probe is in flight.
+func (d *Dispatcher) NextProbeAt(webhook *domain.Webhook) *time.Time {
+	if webhook.CircuitState != domain.CircuitOpen || webhook.CircuitChangedAt == nil {
+		return nil
+	}
+	at := webhook.CircuitChangedAt.Add(d.probeInterval)
+	return &at
+}
+
+// recordFailure counts a failed attempt against the circuit breaker and
+// disables the webhook once it has been failing for longer than
+// WEBHOOK_DISABLE_AFTER_HOURS.
+func (d *Dispatcher) recordFailure(ctx context.Context, webhook *domain.Webhook, errorMsg string) {
+	updated, err := d.webhookService.RecordFailure(ctx, webhook.ID, d.circuitThreshold)
+	if err != nil {
+		log.Printf("Failed to record failure of webhook %d: %v", webhook.ID, err)
+		return
+	}
+	if webhook.CircuitState == domain.CircuitClosed && updated.CircuitState == domain.CircuitOpen {
+		log.Printf("Circuit opened for webhook %d after %d consecutive failures", webhook.ID, updated.ConsecutiveFailures)
+	}
+
+	if d.disableAfter <= 0 || updated.FailingSince == nil || time.Since(*updated.FailingSince) < d.disableAfter {
+		return
+	}
+
+	if err := d.webhookService.Disable(ctx, webhook.ID); err != nil {
+		log.Printf("Failed to disable webhook %d: %v", webhook.ID, err)
+		return
+	}
+	log.Printf("Disabled webhook %d after failing since %s", webhook.ID, updated.FailingSince.Format(time.RFC3339))
+
+	if err := d.notificationService.WebhookDisabled(ctx, updated, *updated.FailingSince, errorMsg); err != nil {
+		log.Printf("Failed to notify about disabled webhook %d: %v", webhook.ID, err)
+	}
+}
+
+func (d *Dispatcher) finishJob(ctx context.Context, job *domain.WebhookJob) {
+	if err := d.jobService.Complete(ctx, job.ID); err != nil {
+		log.Printf("Failed to remove webhook job %d: %v", job.ID, err)
+	}
+}
+
+// enrichPayload adds content that lives in storage rather than in the
+// database: the original message and attachment bodies or signed links.
+// With the multipart encoding, inline content is returned as file parts
+// instead of being embedded in the payload.
+func (d *Dispatcher) enrichPayload(payload *Payload, email *domain.Email, webhook *domain.Webhook) []File {
+	var files []File
+
+	raw, err := d.attachRaw(payload, email, webhook)
+	if err != nil {
+		log.Printf("Failed to attach raw message for email %d: %v", email.ID, err)
+	}
+	if raw != nil {
+		files = append(files, *raw)
+	}
+
+	if webhook.IncludeAttachments {
+		files = append(files, d.attachAttachments(payload, email, webhook)...)
+	}
+	return files
+}
+
+// encodeBody encodes the payload for the webhook. The rfc822 encoding sends
+// the stored original message of the email instead.
+func (d *Dispatcher) encodeBody(webhook *domain.Webhook, payload []byte, email *domain.Email, files []File) (*Body, error) {
+	var raw []byte
+	if webhook.Encoding == domain.WebhookEncodingRFC822 && webhook.Method != "GET" && email != nil && email.RawPath != "" {
+		var err error
+		if raw, err = d.readFile(email.RawPath); err != nil {
+			return nil, err
 		}
+	}
+	return EncodeBody(webhook, payload, files, raw)
+}
 
-		webhook, err := d.webhookService.GetByID(d.ctx, delivery.WebhookID)
+// presetBody renders an email as a provider preset. Presets always carry
+// the attachments as files; those over the webhook's inline cap are left
+// out.
+func (d *Dispatcher) presetBody(webhook *domain.Webhook, email *domain.Email) ([]byte, *Body, error) {
+	var raw []byte
+	if email.RawPath != "" {
+		var err error
+		if raw, err = d.readFile(email.RawPath); err != nil {
+			log.Printf("Failed to read raw message for email %d: %v", email.ID, err)
+		}
+	}
+
+	var files []File
+	for _, att := range email.Attachments {
+		if att.Size > webhook.AttachmentMaxInlineBytes {
+			log.Printf("Leaving attachment %d out of webhook %d: larger than the inline limit", att.ID, webhook.ID)
+			continue
+		}
+		content, err := d.readFile(att.StoragePath)
 		if err != nil {
+			log.Printf("Failed to read attachment %d for webhook %d: %v", att.ID, webhook.ID, err)
 			continue
 		}
+		files = append(files, File{Filename: att.Filename, ContentType: att.ContentType, Content: content})
+	}
+
+	return BuildPreset(webhook, email, raw, files, d.keys, time.Now())
+}
+
+// Keys returns the installation signing keys.
+func (d *Dispatcher) Keys() *SigningKeys {
+	return d.keys
+}
+
+// SecretGrace is how long a rotated secret keeps signing deliveries.
+func (d *Dispatcher) SecretGrace() time.Duration {
+	return d.secretGrace
+}
+
+// BrokerDrivers returns the names of the drivers broker targets can use.
+func (d *Dispatcher) BrokerDrivers() []string {
+	names := make([]string, 0, len(d.brokerDrivers))
+	for name := range d.brokerDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attachRaw adds the original message to the payload according to the
+// webhook's raw mode, either inline as base64 or as a signed download link.
+func (d *Dispatcher) attachRaw(payload *Payload, email *domain.Email, webhook *domain.Webhook) (*File, error) {
+	if email.RawPath == "" {
+		return nil, nil
+	}
+
+	switch webhook.RawMode {
+	case domain.WebhookRawModeInline:
+		raw, err := d.readFile(email.RawPath)
+		if err != nil {
+			return nil, err
+		}
+		if webhook.Encoding == domain.WebhookEncodingMultipart {
+			return &File{Field: "message", Filename: "message.eml", ContentType: "message/rfc822", Content: raw}, nil
+		}
+		payload.Email.Raw = base64.StdEncoding.EncodeToString(raw)
+	case domain.WebhookRawModeURL:
+		payload.Email.RawURL = d.urlSigner.Sign(fmt.Sprintf("/signed/emails/%d/raw", email.ID))
+	}
+
+	return nil, nil
+}
 
-		// Check if max retries exceeded - mark as failed and skip
-		if delivery.Attempt >= webhook.MaxRetries {
-			d.deliveryService.UpdateStatus(d.ctx, delivery.ID, domain.DeliveryStatusFailed, nil, "", "Max retries exceeded", nil)
+// attachAttachments fills in attachment content for inline mode and signed
+// links for url mode. Inline attachments over the webhook's cap, or that
+// cannot be read, fall back to a signed link so the receiver can still fetch them.
+// Multipart webhooks get inline attachments back as attachment-N file parts.
+func (d *Dispatcher) attachAttachments(payload *Payload, email *domain.Email, webhook *domain.Webhook) []File {
+	if webhook.AttachmentMode == domain.WebhookAttachmentModeMetadata {
+		return nil
+	}
+
+	var files []File
+
+	for i := range payload.Email.Attachments {
+		att := &payload.Email.Attachments[i]
+		signedURL := d.urlSigner.Sign(fmt.Sprintf("/signed/attachments/%d/download", att.ID))
+
+		if webhook.AttachmentMode == domain.WebhookAttachmentModeURL || att.Size > webhook.AttachmentMaxInlineBytes {
+			att.URL = signedURL
 			continue
 		}
 
-		email, err := d.emailService.GetByID(d.ctx, delivery.EmailID)
+		storagePath := attachmentStoragePath(email, att.ID)
+		content, err := d.readFile(storagePath)
 		if err != nil {
-			log.Printf("Failed to get email %d for retry: %v", delivery.EmailID, err)
-			d.deliveryService.UpdateStatus(d.ctx, delivery.ID, domain.DeliveryStatusFailed, nil, "", "Email not found", nil)
+			log.Printf("Failed to read attachment %d for webhook %d: %v", att.ID, webhook.ID, err)
+			att.URL = signedURL
+			continue
+		}
+		if webhook.Encoding == domain.WebhookEncodingMultipart {
+			files = append(files, File{
+				Field:       fmt.Sprintf("attachment-%d", len(files)+1),
+				Filename:    att.Filename,
+				ContentType: att.ContentType,
+				Content:     content,
+			})
 			continue
 		}
+		att.Content = base64.StdEncoding.EncodeToString(content)
+	}
+	return files
+}
 
-		// Mark the current delivery as failed before scheduling the retry
-		// This prevents the retryWorker from picking it up again
-		d.deliveryService.UpdateStatus(d.ctx, delivery.ID, domain.DeliveryStatusFailed, delivery.StatusCode, delivery.ResponseBody, delivery.ErrorMessage+" (retry scheduled)", delivery.DurationMs)
+func (d *Dispatcher) readFile(path string) ([]byte, error) {
+	file, err := d.storage.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
 
-		select {
-		case d.jobs <- &Job{Webhook: webhook, Email: email, Attempt: delivery.Attempt + 1}:
-		default:
-			log.Printf("Job queue full, could not schedule retry for webhook %d", webhook.ID)
+func attachmentStoragePath(email *domain.Email, attachmentID int64) string {
+	for _, att := range email.Attachments {
+		if att.ID == attachmentID {
+			return att.StoragePath
 		}
 	}
+	return ""
 }
 
-func (d *Dispatcher) ManualRetry(webhookID, emailID int64) error {
-	webhook, err := d.webhookService.GetByID(d.ctx, webhookID)
-	if err != nil {
+// ManualRetry queues a fresh delivery of the event behind an earlier
+// delivery. email.received is rebuilt from the stored email, other events
+// are sent again with the payload of the original delivery. The retry keeps
+// the idempotency key of the original, since the receiver may have handled
+// it already.
+func (d *Dispatcher) ManualRetry(delivery *domain.WebhookDelivery) error {
+	if d.Saturated(d.ctx) {
+		return ErrQueueFull
+	}
+
+	if _, err := d.webhookService.GetByID(d.ctx, delivery.WebhookID); err != nil {
 		return err
 	}
 
-	select {
-	case d.jobs <- &Job{Webhook: webhook, Email: &domain.Email{ID: emailID}, Attempt: 1}:
-		return nil
-	default:
-		return nil
+	if delivery.Event == domain.EventEmailReceived {
+		if delivery.EmailID == nil {
+			return service.ErrEmailNotFound
+		}
+		if _, err := d.emailService.GetByID(d.ctx, *delivery.EmailID); err != nil {
+			return err
+		}
+		if _, err := d.jobService.Enqueue(d.ctx, delivery.WebhookID, *delivery.EmailID, delivery.IdempotencyKey); err != nil {
+			return err
+		}
+	} else if _, err := d.jobService.EnqueueEvent(d.ctx, delivery.WebhookID, delivery.Event, delivery.RequestBody, delivery.IdempotencyKey); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
+}
+
+// CancelRetries drops the scheduled retries of a webhook and marks their
+// delivery records as failed.
+func (d *Dispatcher) CancelRetries(ctx context.Context, webhookID int64) error {
+	if err := d.jobService.CancelRetries(ctx, webhookID); err != nil {
+		return err
 	}
+	return d.deliveryService.CancelRetryingByWebhook(ctx, webhookID)
 }
@@ -0,0 +1,455 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-smtp"
+
+	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/database"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/events"
+	"github.com/jr-k/mailgress/internal/service"
+	"github.com/jr-k/mailgress/internal/storage"
+)
+
+const testRaw = "From: alice@example.com\r\nTo: inbox@mailgress.test\r\nSubject: Quarterly report\r\n\r\nSee attached.\r\n"
+
+// testEnv is a dispatcher over a migrated SQLite database in a temporary
+// directory, with one mailbox holding one received email.
+type testEnv struct {
+	dispatcher *Dispatcher
+	webhooks   *service.WebhookService
+	deliveries *service.DeliveryService
+	mailbox    *domain.Mailbox
+	email      *domain.Email
+	broker     *memoryBroker
+}
+
+func newTestEnv(t *testing.T, cfg *config.Config) *testEnv {
+	t.Helper()
+	ctx := context.Background()
+
+	sqlDB, queries, err := database.NewConnection("sqlite", filepath.Join(t.TempDir(), "mailgress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.RunMigrations(sqlDB, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.SMTPHostname == "" {
+		cfg.SMTPHostname = "mx.mailgress.test"
+	}
+	bus := events.NewBus()
+	tx := database.NewTransactor(sqlDB, "sqlite")
+	store := storage.NewMemory()
+	settingsService := service.NewSettingsService(queries)
+	mailboxService := service.NewMailboxService(queries, bus)
+	emailService := service.NewEmailService(queries, bus, "sqlite")
+	env := &testEnv{
+		webhooks:   service.NewWebhookService(queries, tx),
+		deliveries: service.NewDeliveryService(queries),
+		broker:     &memoryBroker{},
+	}
+
+	keys, err := LoadSigningKeys(ctx, settingsService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drivers := DefaultBrokerDrivers()
+	drivers["memory"] = env.broker
+	env.dispatcher = NewDispatcher(cfg, env.webhooks, env.deliveries, service.NewWebhookJobService(queries, tx), emailService,
+		mailboxService, service.NewDomainService(queries, tx, bus), service.NewNotificationService(queries, nil, ""),
+		store, service.NewURLSigner("http://localhost", "test-key", time.Hour), keys, bus, drivers)
+	t.Cleanup(env.dispatcher.Stop)
+
+	if env.mailbox, err = mailboxService.Create(ctx, "inbox", nil, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	email, err := emailService.Create(ctx, service.CreateEmailParams{
+		MailboxID:   env.mailbox.ID,
+		FromAddress: "alice@example.com",
+		ToAddress:   "inbox@mailgress.test",
+		Subject:     "Quarterly report",
+		TextBody:    "See attached.",
+		RawSize:     int64(len(testRaw)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPath, _, err := store.Store(email.ID, "message.eml", strings.NewReader(testRaw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := emailService.SetRawPath(ctx, email.ID, rawPath); err != nil {
+		t.Fatal(err)
+	}
+	if env.email, err = emailService.GetByID(ctx, email.ID); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// deliver queues the email for a webhook with the given target, runs the
+// job and returns the delivery it recorded.
+func (env *testEnv) deliver(t *testing.T, target string, targetConfig domain.WebhookTargetConfig) *domain.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+
+	wh, err := env.webhooks.Create(ctx, service.CreateWebhookParams{
+		MailboxID:    env.mailbox.ID,
+		Name:         target,
+		Events:       []string{domain.EventEmailReceived},
+		MaxRetries:   3,
+		TimeoutSec:   10,
+		Target:       target,
+		TargetConfig: targetConfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.dispatcher.ValidateTarget(wh); err != nil {
+		t.Fatalf("ValidateTarget: %v", err)
+	}
+
+	if err := env.dispatcher.Dispatch(env.mailbox.ID, env.email); err != nil {
+		t.Fatal(err)
+	}
+	if !env.dispatcher.runNext() {
+		t.Fatal("no job was run")
+	}
+
+	deliveries, err := env.deliveries.ListByWebhook(ctx, wh.ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func TestDispatchBrokerTarget(t *testing.T) {
+	env := newTestEnv(t, &config.Config{})
+	delivery := env.deliver(t, domain.WebhookTargetBroker, domain.WebhookTargetConfig{
+		BrokerDriver: "memory",
+		BrokerTopic:  "mail.received",
+	})
+	if delivery.Status != domain.DeliveryStatusSuccess {
+		t.Fatalf("delivery %s: %s", delivery.Status, delivery.ErrorMessage)
+	}
+
+	messages := env.broker.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d published messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.Topic != "mail.received" {
+		t.Errorf("topic = %q, want mail.received", msg.Topic)
+	}
+	if msg.Headers["X-Mailgress-Event"] != domain.EventEmailReceived || msg.Headers["X-Mailgress-Delivery-Id"] != delivery.IdempotencyKey {
+		t.Errorf("headers = %v", msg.Headers)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(msg.Body, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if !bytes.Contains(msg.Body, []byte("Quarterly report")) {
+		t.Errorf("payload does not carry the subject: %s", msg.Body)
+	}
+}
+
+func TestDispatchDirectoryTarget(t *testing.T) {
+	root := t.TempDir()
+	env := newTestEnv(t, &config.Config{WebhookDirectoryRoot: root})
+
+	delivery := env.deliver(t, domain.WebhookTargetDirectory, domain.WebhookTargetConfig{Directory: "inbox"})
+	if delivery.Status != domain.DeliveryStatusSuccess {
+		t.Fatalf("delivery %s: %s", delivery.Status, delivery.ErrorMessage)
+	}
+
+	dir := filepath.Join(root, "inbox")
+	raw, err := os.ReadFile(filepath.Join(dir, delivery.IdempotencyKey+".eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != testRaw {
+		t.Errorf("eml = %q, want the original message", raw)
+	}
+	payload, err := os.ReadFile(filepath.Join(dir, delivery.IdempotencyKey+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(payload) || !bytes.Contains(payload, []byte("Quarterly report")) {
+		t.Errorf("json = %s", payload)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("directory holds %d files, want the .eml and .json only", len(entries))
+	}
+}
+
+func TestDirectoryTargetStaysInRoot(t *testing.T) {
+	target := &directoryTarget{root: t.TempDir()}
+	for _, dir := range []string{"../outside", "/etc", "a/../../b"} {
+		if err := target.Validate(&domain.Webhook{TargetConfig: domain.WebhookTargetConfig{Directory: dir}}); err == nil {
+			t.Errorf("directory %q was accepted", dir)
+		}
+	}
+	if err := (&directoryTarget{}).Validate(&domain.Webhook{}); err == nil {
+		t.Error("directory target without a root was accepted")
+	}
+}
+
+// smtpBackend is a go-smtp backend that keeps the messages it accepts, and
+// rejects recipients at reject.example.
+type smtpBackend struct {
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+func (b *smtpBackend) NewSession(c *smtp.Conn) (smtp.Session, error) {
+	return &smtpSession{backend: b}, nil
+}
+
+func (b *smtpBackend) received() []smtpMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]smtpMessage(nil), b.messages...)
+}
+
+type smtpSession struct {
+	backend *smtpBackend
+	msg     smtpMessage
+}
+
+func (s *smtpSession) Mail(from string, opts *smtp.MailOptions) error {
+	s.msg.from = from
+	return nil
+}
+
+func (s *smtpSession) Rcpt(to string, opts *smtp.RcptOptions) error {
+	if strings.HasSuffix(to, "@reject.example") {
+		return &smtp.SMTPError{Code: 550, EnhancedCode: smtp.EnhancedCode{5, 1, 1}, Message: "No such user"}
+	}
+	s.msg.to = append(s.msg.to, to)
+	return nil
+}
+
+func (s *smtpSession) Data(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.msg.data = data
+	s.backend.mu.Lock()
+	s.backend.messages = append(s.backend.messages, s.msg)
+	s.backend.mu.Unlock()
+	return nil
+}
+
+func (s *smtpSession) Reset() {
+	s.msg = smtpMessage{}
+}
+
+func (s *smtpSession) Logout() error {
+	return nil
+}
+
+// startSMTPServer serves backend on a loopback port, which the network
+// guard is told to allow for the test.
+func startSMTPServer(t *testing.T, backend *smtpBackend) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := smtp.NewServer(backend)
+	server.Domain = "receiver.test"
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	if err := SetAllowlist("127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetAllowlist("") })
+	return listener.Addr().String()
+}
+
+func TestDispatchSMTPTarget(t *testing.T) {
+	backend := &smtpBackend{}
+	addr := startSMTPServer(t, backend)
+	env := newTestEnv(t, &config.Config{SMTPRelayFrom: "relay@mailgress.test"})
+
+	delivery := env.deliver(t, domain.WebhookTargetSMTP, domain.WebhookTargetConfig{
+		ForwardTo:  []string{"bob@example.org"},
+		SMTPServer: addr,
+	})
+	if delivery.Status != domain.DeliveryStatusSuccess {
+		t.Fatalf("delivery %s: %s", delivery.Status, delivery.ErrorMessage)
+	}
+
+	messages := backend.received()
+	if len(messages) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.from != "relay@mailgress.test" || len(msg.to) != 1 || msg.to[0] != "bob@example.org" {
+		t.Errorf("envelope = %s -> %v", msg.from, msg.to)
+	}
+	wantHeaders := "X-Mailgress-Event: email.received\r\nX-Mailgress-Delivery-Id: " + delivery.IdempotencyKey + "\r\n"
+	if !bytes.HasPrefix(msg.data, []byte(wantHeaders)) {
+		t.Errorf("message does not start with the delivery headers: %q", msg.data)
+	}
+	if !bytes.HasSuffix(msg.data, []byte(testRaw)) {
+		t.Errorf("message does not carry the original: %q", msg.data)
+	}
+}
+
+func TestDispatchSMTPTargetRejected(t *testing.T) {
+	backend := &smtpBackend{}
+	addr := startSMTPServer(t, backend)
+	env := newTestEnv(t, &config.Config{})
+
+	delivery := env.deliver(t, domain.WebhookTargetSMTP, domain.WebhookTargetConfig{
+		ForwardTo:  []string{"nobody@reject.example"},
+		SMTPServer: addr,
+	})
+	// A 5xx reply is permanent, so the delivery fails without a retry
+	if delivery.Status != domain.DeliveryStatusFailed || delivery.NextAttemptAt != nil {
+		t.Fatalf("delivery %s, next attempt %v, want failed without retry", delivery.Status, delivery.NextAttemptAt)
+	}
+	if !strings.Contains(delivery.ErrorMessage, "550") {
+		t.Errorf("error = %q, want the 550 reply", delivery.ErrorMessage)
+	}
+	if len(backend.received()) != 0 {
+		t.Error("server received a message")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, &config.Config{WebhookCircuitThreshold: 2})
+	allowlistForTest(t, "127.0.0.1")
+
+	var mu sync.Mutex
+	hits, status := 0, http.StatusServiceUnavailable
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits++
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(receiver.Close)
+	respond := func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = code
+	}
+	received := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+
+	wh, err := env.webhooks.Create(ctx, service.CreateWebhookParams{
+		MailboxID:  env.mailbox.ID,
+		Name:       "flaky",
+		URL:        receiver.URL,
+		Events:     []string{domain.EventEmailReceived},
+		MaxRetries: 1,
+		TimeoutSec: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// send queues the email and runs one job, returning the webhook after it
+	send := func() *domain.Webhook {
+		t.Helper()
+		if err := env.dispatcher.Dispatch(env.mailbox.ID, env.email); err != nil {
+			t.Fatal(err)
+		}
+		if !env.dispatcher.runNext() {
+			t.Fatal("no job was run")
+		}
+		got, err := env.webhooks.GetByID(ctx, wh.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	// Closed until the threshold is reached
+	if got := send(); got.CircuitState != domain.CircuitClosed || got.ConsecutiveFailures != 1 || got.FailingSince == nil {
+		t.Fatalf("after one failure the circuit is %s with %d failures", got.CircuitState, got.ConsecutiveFailures)
+	}
+	got := send()
+	if got.CircuitState != domain.CircuitOpen || got.ConsecutiveFailures != 2 {
+		t.Fatalf("after two failures the circuit is %s with %d failures, want open", got.CircuitState, got.ConsecutiveFailures)
+	}
+	if next := env.dispatcher.NextProbeAt(got); next == nil || !next.Equal(got.CircuitChangedAt.Add(env.dispatcher.probeInterval)) {
+		t.Errorf("NextProbeAt() = %v, want the probe interval after the circuit opened", next)
+	}
+
+	// Open: jobs wait for the probe interval without reaching the receiver
+	env.dispatcher.probeInterval = time.Hour
+	if got := send(); got.CircuitState != domain.CircuitOpen || received() != 2 {
+		t.Fatalf("an open circuit let a request through: circuit %s, %d requests", got.CircuitState, received())
+	}
+	if env.dispatcher.runNext() {
+		t.Error("the deferred job was run before the probe was due")
+	}
+
+	// Half open: once the interval has passed one probe goes out, and a
+	// failed probe opens the circuit again
+	env.dispatcher.probeInterval = 0
+	if got := send(); got.CircuitState != domain.CircuitOpen || got.ConsecutiveFailures != 3 || received() != 3 {
+		t.Fatalf("after a failed probe the circuit is %s with %d failures and %d requests", got.CircuitState, got.ConsecutiveFailures, received())
+	}
+	if ok, err := env.webhooks.ClaimProbe(ctx, wh.ID, time.Now(), time.Now().Add(-time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimProbe() = %v, %v, want the probe", ok, err)
+	}
+	if ok, _ := env.webhooks.ClaimProbe(ctx, wh.ID, time.Now(), time.Now().Add(-time.Minute)); ok {
+		t.Error("a second probe was claimed while one is in flight")
+	}
+	if got, _ := env.webhooks.GetByID(ctx, wh.ID); got.CircuitState != domain.CircuitHalfOpen || env.dispatcher.NextProbeAt(got) != nil {
+		t.Errorf("a claimed probe leaves the circuit %s", got.CircuitState)
+	}
+	// A probe that never reported back is taken over
+	if ok, _ := env.webhooks.ClaimProbe(ctx, wh.ID, time.Now(), time.Now().Add(time.Minute)); !ok {
+		t.Error("a stale probe was not taken over")
+	}
+	if got := send(); got.CircuitState != domain.CircuitHalfOpen || received() != 3 {
+		t.Fatalf("a request went out while a probe was in flight: circuit %s, %d requests", got.CircuitState, received())
+	}
+	if _, err := env.webhooks.RecordFailure(ctx, wh.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closed again after a successful probe
+	respond(http.StatusOK)
+	if got := send(); got.CircuitState != domain.CircuitClosed || got.ConsecutiveFailures != 0 || got.FailingSince != nil || received() != 4 {
+		t.Fatalf("after a successful probe the circuit is %s with %d failures, failing since %v", got.CircuitState, got.ConsecutiveFailures, got.FailingSince)
+	}
+}
@@ -0,0 +1,265 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+var errNoRawMessage = errors.New("the rfc822 encoding needs the original message, which is not stored for this delivery")
+
+// Body is an encoded request body. GET requests carry no data, only the
+// query parameters picked from the payload.
+type Body struct {
+	ContentType string
+	Data        []byte
+	Query       url.Values
+	// Header holds headers the encoding adds, such as provider signatures.
+	Header http.Header
+}
+
+// File is a file part of a multipart body.
+type File struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Field is a flattened payload value. Nested names are joined with dots,
+// array elements use their index: email.attachments.0.filename.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// EncodeBody turns a rendered payload into the request body of the webhook's
+// method and encoding. Form and multipart bodies carry the flattened
+// payload, which must then be a JSON object; multipart bodies add files as
+// file parts. The rfc822 encoding sends raw, the original message, as is.
+func EncodeBody(webhook *domain.Webhook, payload []byte, files []File, raw []byte) (*Body, error) {
+	if webhook.Method == "GET" {
+		query, err := queryParams(payload, webhook.QueryFields)
+		if err != nil {
+			return nil, err
+		}
+		return &Body{Query: query}, nil
+	}
+
+	switch webhook.Encoding {
+	case domain.WebhookEncodingForm:
+		fields, err := FlattenPayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		return &Body{ContentType: "application/x-www-form-urlencoded", Data: []byte(encodeForm(fields))}, nil
+	case domain.WebhookEncodingMultipart:
+		fields, err := FlattenPayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		return encodeMultipart(fields, files)
+	case domain.WebhookEncodingRFC822:
+		if raw == nil {
+			return nil, errNoRawMessage
+		}
+		return &Body{ContentType: "message/rfc822", Data: raw}, nil
+	default:
+		return &Body{ContentType: "application/json", Data: payload}, nil
+	}
+}
+
+// ValidateBody checks how a webhook builds its request body before it is
+// saved. The original message and provider presets only exist for incoming
+// mail, so such webhooks cannot subscribe to other events.
+func ValidateBody(payloadType, customPayload, encoding string, events []string) error {
+	if err := validateTemplate(payloadType, customPayload); err != nil {
+		return err
+	}
+
+	var mailOnly string
+	switch encoding {
+	case "", domain.WebhookEncodingJSON, domain.WebhookEncodingForm, domain.WebhookEncodingMultipart:
+	case domain.WebhookEncodingRFC822:
+		mailOnly = "the rfc822 encoding"
+	default:
+		return fmt.Errorf("unknown encoding: %s", encoding)
+	}
+	if IsPreset(payloadType) {
+		mailOnly = "the " + payloadType + " payload"
+	}
+
+	if mailOnly != "" {
+		for _, event := range events {
+			if event != domain.EventEmailReceived {
+				return fmt.Errorf("%s only applies to %s, not %s", mailOnly, domain.EventEmailReceived, event)
+			}
+		}
+	}
+	return nil
+}
+
+// FlattenPayload lists the values of a JSON object payload in document
+// order. Null values are left out.
+func FlattenPayload(payload []byte) ([]Field, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return nil, errors.New("payload is not a JSON object")
+	}
+
+	var fields []Field
+	if err := flattenObject(dec, "", &fields); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+func flattenObject(dec *json.Decoder, prefix string, fields *[]Field) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if err := flattenValue(dec, joinFieldName(prefix, key), fields); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func flattenArray(dec *json.Decoder, prefix string, fields *[]Field) error {
+	for i := 0; dec.More(); i++ {
+		if err := flattenValue(dec, joinFieldName(prefix, strconv.Itoa(i)), fields); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func flattenValue(dec *json.Decoder, name string, fields *[]Field) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return flattenObject(dec, name, fields)
+		}
+		return flattenArray(dec, name, fields)
+	case string:
+		*fields = append(*fields, Field{Name: name, Value: v})
+	case json.Number:
+		*fields = append(*fields, Field{Name: name, Value: v.String()})
+	case bool:
+		*fields = append(*fields, Field{Name: name, Value: strconv.FormatBool(v)})
+	}
+	return nil
+}
+
+func joinFieldName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// queryParams picks the configured fields out of the payload. Each entry is
+// either a field name, used as the parameter name too, or param=field.
+func queryParams(payload []byte, queryFields []string) (url.Values, error) {
+	if len(queryFields) == 0 {
+		return nil, nil
+	}
+
+	fields, err := FlattenPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.Name] = f.Value
+	}
+
+	query := url.Values{}
+	for _, entry := range queryFields {
+		param, field, found := strings.Cut(entry, "=")
+		if !found {
+			field = param
+		}
+		if v, ok := values[field]; ok {
+			query.Set(param, v)
+		}
+	}
+	return query, nil
+}
+
+// encodeForm encodes fields as application/x-www-form-urlencoded, keeping
+// their order, unlike url.Values.
+func encodeForm(fields []Field) string {
+	var buf strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(url.QueryEscape(f.Name))
+		buf.WriteByte('=')
+		buf.WriteString(url.QueryEscape(f.Value))
+	}
+	return buf.String()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func encodeMultipart(fields []Field, files []File) (*Body, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for _, f := range fields {
+		if err := w.WriteField(f.Name, f.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.Field), quoteEscaper.Replace(file.Filename)))
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &Body{ContentType: w.FormDataContentType(), Data: buf.Bytes()}, nil
+}
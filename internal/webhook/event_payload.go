@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// MailboxEventPayload is sent for mailbox.created, mailbox.updated and
+// mailbox.deleted.
+type MailboxEventPayload struct {
+	Event     string                 `json:"event"`
+	Timestamp string                 `json:"timestamp"`
+	Mailbox   MailboxPayload         `json:"mailbox"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type MailboxPayload struct {
+	ID            int64  `json:"id"`
+	Address       string `json:"address"`
+	Slug          string `json:"slug"`
+	DomainID      *int64 `json:"domain_id"`
+	Description   string `json:"description,omitempty"`
+	IsActive      bool   `json:"is_active"`
+	RetentionDays int    `json:"retention_days"`
+}
+
+// DomainEventPayload is sent for domain.verified.
+type DomainEventPayload struct {
+	Event     string                 `json:"event"`
+	Timestamp string                 `json:"timestamp"`
+	Domain    DomainPayload          `json:"domain"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type DomainPayload struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	IsVerified bool   `json:"is_verified"`
+	IsActive   bool   `json:"is_active"`
+}
+
+// DeliveryFailedPayload is sent for delivery.failed, once a delivery to
+// another webhook of the mailbox has failed for good.
+type DeliveryFailedPayload struct {
+	Event     string                 `json:"event"`
+	Timestamp string                 `json:"timestamp"`
+	Delivery  DeliveryPayload        `json:"delivery"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type DeliveryPayload struct {
+	ID          int64  `json:"id"`
+	WebhookID   int64  `json:"webhook_id"`
+	WebhookName string `json:"webhook_name"`
+	Event       string `json:"event"`
+	EmailID     *int64 `json:"email_id,omitempty"`
+	Attempt     int    `json:"attempt"`
+	StatusCode  *int   `json:"status_code,omitempty"`
+	Error       string `json:"error"`
+}
+
+// RetentionPurgedPayload is sent for retention.purged.
+type RetentionPurgedPayload struct {
+	Event     string                 `json:"event"`
+	Timestamp string                 `json:"timestamp"`
+	Retention domain.RetentionPurge  `json:"retention"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// BuildEventPayload renders the payload of any event other than
+// email.received. Email events carry the same email object as
+// email.received, without the raw message or attachment content. Webhooks
+// with a body template get the rendered template instead.
+func BuildEventPayload(event domain.Event, webhook *domain.Webhook) ([]byte, error) {
+	if webhook.PayloadType == PayloadTypeTemplate {
+		return RenderTemplate(webhook.CustomPayload, NewTemplateData(event))
+	}
+
+	timestamp := event.OccurredAt.UTC().Format(time.RFC3339)
+	var metadata map[string]interface{}
+	if webhook.CustomPayload != "" {
+		metadata = parseMetadata(webhook.PayloadType, webhook.CustomPayload, event.Email)
+	}
+
+	var payload interface{}
+	switch {
+	case event.Email != nil:
+		p := BuildPayload(event.Email, webhook)
+		p.Event = event.Type
+		p.Timestamp = timestamp
+		payload = p
+	case event.Mailbox != nil:
+		payload = &MailboxEventPayload{
+			Event:     event.Type,
+			Timestamp: timestamp,
+			Mailbox: MailboxPayload{
+				ID:            event.Mailbox.ID,
+				Address:       event.Mailbox.EmailAddress(),
+				Slug:          event.Mailbox.Slug,
+				DomainID:      event.Mailbox.DomainID,
+				Description:   event.Mailbox.Description,
+				IsActive:      event.Mailbox.IsActive,
+				RetentionDays: event.Mailbox.RetentionDays,
+			},
+			Metadata: metadata,
+		}
+	case event.Domain != nil:
+		payload = &DomainEventPayload{
+			Event:     event.Type,
+			Timestamp: timestamp,
+			Domain: DomainPayload{
+				ID:         event.Domain.ID,
+				Name:       event.Domain.Name,
+				IsVerified: event.Domain.IsVerified,
+				IsActive:   event.Domain.IsActive,
+			},
+			Metadata: metadata,
+		}
+	case event.Delivery != nil:
+		delivery := DeliveryPayload{
+			ID:         event.Delivery.ID,
+			WebhookID:  event.Delivery.WebhookID,
+			Event:      event.Delivery.Event,
+			EmailID:    event.Delivery.EmailID,
+			Attempt:    event.Delivery.Attempt,
+			StatusCode: event.Delivery.StatusCode,
+			Error:      event.Delivery.ErrorMessage,
+		}
+		// Connection errors are recorded with status code 0
+		if delivery.StatusCode != nil && *delivery.StatusCode == 0 {
+			delivery.StatusCode = nil
+		}
+		if event.Delivery.Webhook != nil {
+			delivery.WebhookName = event.Delivery.Webhook.Name
+		}
+		payload = &DeliveryFailedPayload{
+			Event:     event.Type,
+			Timestamp: timestamp,
+			Delivery:  delivery,
+			Metadata:  metadata,
+		}
+	case event.Retention != nil:
+		payload = &RetentionPurgedPayload{
+			Event:     event.Type,
+			Timestamp: timestamp,
+			Retention: *event.Retention,
+			Metadata:  metadata,
+		}
+	default:
+		return nil, fmt.Errorf("event %s has no subject", event.Type)
+	}
+
+	return json.Marshal(payload)
+}
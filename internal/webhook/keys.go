@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+// SigningKeys are the installation-wide keys of the signature schemes that
+// use public-key cryptography. They are generated on first start and kept
+// in the settings table, so receivers can pin the public keys.
+type SigningKeys struct {
+	ECDSA   *ecdsa.PrivateKey
+	Ed25519 ed25519.PrivateKey
+}
+
+// LoadSigningKeys reads the signing keys from the settings, creating the
+// missing ones.
+func LoadSigningKeys(ctx context.Context, settings *service.SettingsService) (*SigningKeys, error) {
+	ecdsaKey, err := loadPrivateKey(ctx, settings, service.SettingWebhookECDSAKey, func() (any, error) {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ed25519Key, err := loadPrivateKey(ctx, settings, service.SettingWebhookEd25519Key, func() (any, error) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &SigningKeys{}
+	var ok bool
+	if keys.ECDSA, ok = ecdsaKey.(*ecdsa.PrivateKey); !ok {
+		return nil, fmt.Errorf("webhook ECDSA key has type %T", ecdsaKey)
+	}
+	if keys.Ed25519, ok = ed25519Key.(ed25519.PrivateKey); !ok {
+		return nil, fmt.Errorf("webhook Ed25519 key has type %T", ed25519Key)
+	}
+	return keys, nil
+}
+
+// loadPrivateKey reads a PKCS8 PEM key from a setting, storing a key made by
+// generate when the setting does not exist yet.
+func loadPrivateKey(ctx context.Context, settings *service.SettingsService, name string, generate func() (any, error)) (any, error) {
+	encoded, err := settings.Get(ctx, name)
+	if errors.Is(err, service.ErrSettingNotFound) {
+		key, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := settings.Set(ctx, name, string(pemKey)); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, fmt.Errorf("setting %s is not a PEM encoded key", name)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// ECDSAPublicKey returns the public half of the ECDSA key as base64 DER, the
+// format SendGrid shows as its verification key.
+func (k *SigningKeys) ECDSAPublicKey() string {
+	der, err := x509.MarshalPKIXPublicKey(&k.ECDSA.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+// Ed25519PublicKey returns the raw public half of the Ed25519 key.
+func (k *SigningKeys) Ed25519PublicKey() ed25519.PublicKey {
+	return k.Ed25519.Public().(ed25519.PublicKey)
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// PublicJWKs returns the public keys receivers verify signatures with: the
+// Ed25519 key of the X-Mailgress-Signature header and the ECDSA key of the
+// SendGrid preset.
+func (k *SigningKeys) PublicJWKs() []JWK {
+	edPublic := k.Ed25519PublicKey()
+	keys := []JWK{{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(edPublic),
+		Kid: KeyID(edPublic),
+		Use: "sig",
+		Alg: "EdDSA",
+	}}
+
+	if point, err := k.ECDSA.PublicKey.ECDH(); err == nil {
+		// Uncompressed point: 0x04 || X || Y
+		raw := point.Bytes()
+		der, _ := x509.MarshalPKIXPublicKey(&k.ECDSA.PublicKey)
+		keys = append(keys, JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(raw[1:33]),
+			Y:   base64.RawURLEncoding.EncodeToString(raw[33:]),
+			Kid: KeyID(der),
+			Use: "sig",
+			Alg: "ES256",
+		})
+	}
+	return keys
+}
+
+// KeyID identifies a public key: the first 8 bytes of the SHA-256 of its raw
+// or DER encoding, in hex.
+func KeyID(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a webhook request would connect to a
+// loopback, private, link-local or otherwise internal address that the
+// allowlist does not open.
+var ErrBlockedAddress = errors.New("address is not allowed for webhooks")
+
+// blockedNetworks are refused unless allowlisted. Cloud metadata endpoints
+// (169.254.169.254, fd00:ec2::254, 100.100.100.200) fall inside them.
+// IPv6 addresses embedding an IPv4 address are checked by that address,
+// see embeddedIPv4.
+var blockedNetworks = mustParsePrefixes(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved and broadcast
+	"::/96",          // unspecified, loopback and IPv4-compatible
+	"64:ff9b:1::/48", // local-use NAT64
+	"2001::/32",      // Teredo
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// Prefixes of IPv6 addresses that carry an IPv4 address a packet ends up
+// going to.
+var (
+	nat64Prefix      = netip.MustParsePrefix("64:ff9b::/96")    // well-known NAT64
+	translatedPrefix = netip.MustParsePrefix("::ffff:0:0:0/96") // IPv4-translated
+	sixToFourPrefix  = netip.MustParsePrefix("2002::/16")       // 6to4
+)
+
+func mustParsePrefixes(prefixes ...string) []netip.Prefix {
+	parsed := make([]netip.Prefix, len(prefixes))
+	for i, p := range prefixes {
+		parsed[i] = netip.MustParsePrefix(p)
+	}
+	return parsed
+}
+
+// allowlist opens internal destinations to webhooks. Entries are IPs, CIDRs,
+// host names, or "*.example.internal" to match every subdomain.
+type allowlist struct {
+	prefixes []netip.Prefix
+	hosts    []string
+}
+
+var networkPolicy = struct {
+	sync.RWMutex
+	allow allowlist
+}{}
+
+// SetAllowlist replaces the admin allowlist with the comma-separated entries
+// of WEBHOOK_ALLOWLIST.
+func SetAllowlist(entries string) error {
+	var allow allowlist
+	for _, entry := range strings.Split(entries, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			allow.prefixes = append(allow.prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			allow.prefixes = append(allow.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		if _, _, err := net.SplitHostPort(entry); err == nil {
+			return fmt.Errorf("invalid entry %q, allowlist entries take no port", entry)
+		}
+		if strings.ContainsAny(entry, ":[] ") {
+			return fmt.Errorf("invalid host %q", entry)
+		}
+		allow.hosts = append(allow.hosts, strings.TrimSuffix(entry, "."))
+	}
+
+	networkPolicy.Lock()
+	networkPolicy.allow = allow
+	networkPolicy.Unlock()
+	return nil
+}
+
+// hostAllowed reports whether a host name is on the allowlist. Connections to
+// an allowlisted name skip the address checks.
+func hostAllowed(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	networkPolicy.RLock()
+	defer networkPolicy.RUnlock()
+
+	for _, allowed := range networkPolicy.allow.hosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// addrAllowed reports whether a webhook may connect to addr.
+func addrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addrAllowlisted(addr) {
+		return true
+	}
+	if v4, ok := embeddedIPv4(addr); ok {
+		return addrAllowed(v4)
+	}
+	for _, prefix := range blockedNetworks {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func addrAllowlisted(addr netip.Addr) bool {
+	networkPolicy.RLock()
+	defer networkPolicy.RUnlock()
+
+	for _, prefix := range networkPolicy.allow.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedIPv4 returns the IPv4 address that NAT64, IPv4-translated and 6to4
+// addresses route to, so 64:ff9b::a9fe:a9fe is checked as 169.254.169.254.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case !addr.Is6():
+		return netip.Addr{}, false
+	case nat64Prefix.Contains(addr), translatedPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// guardedDialer wraps a dialer so every connection is checked against the
+// address it actually connects to, after DNS resolution. A name that resolves
+// to an internal address, or is rebound to one after an earlier check, is
+// refused all the same.
+func guardedDialer(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	guarded := *dialer
+	guarded.Control = func(network, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+		}
+		if !addrAllowed(addrPort.Addr()) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr().Unmap())
+		}
+		return nil
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if hostAllowed(host) {
+			return dialer.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+}
+
+// checkTarget resolves the host of u and checks every address it resolves to.
+// It covers requests sent through a proxy, where the dialer only sees the
+// proxy. The proxy resolves the name again, so it should not be trusted
+// with destinations the allowlist does not open.
+func checkTarget(ctx context.Context, u *url.URL) error {
+	host := u.Hostname()
+	if hostAllowed(host) {
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !addrAllowed(addr) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, addr.Unmap())
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !addrAllowed(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// validateURL checks a webhook URL before it is saved. Host names are not
+// resolved here, only literal addresses and localhost are refused; the
+// dialer checks every address when the webhook is sent.
+func validateURL(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		return errors.New("URL must use http or https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("URL has no host")
+	}
+	return validateHost("URL", host)
+}
+
+// validateHost refuses localhost and literal internal addresses that are
+// not allowlisted. what names the setting in the error.
+func validateHost(what, host string) error {
+	if hostAllowed(host) {
+		return nil
+	}
+
+	lower := strings.TrimSuffix(strings.ToLower(host), ".")
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return fmt.Errorf("%s points to a local address, ask an administrator to allowlist it", what)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !addrAllowed(addr) {
+		return fmt.Errorf("%s points to a private or local address, ask an administrator to allowlist it", what)
+	}
+	return nil
+}
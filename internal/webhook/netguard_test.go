@@ -0,0 +1,287 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// allowlistForTest replaces the allowlist until the test ends.
+func allowlistForTest(t *testing.T, entries string) {
+	t.Helper()
+	if err := SetAllowlist(entries); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetAllowlist("") })
+}
+
+func TestAddrAllowed(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"0.0.0.0", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::127.0.0.1", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"ff02::1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::5db8:d822", true},
+		{"64:ff9b:1::a9fe:a9fe", false},
+		{"::ffff:0:a9fe:a9fe", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2002:7f00:1::1", false},
+		{"2002:5db8:d822::1", true},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", false},
+	}
+	for _, tt := range tests {
+		if got := addrAllowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("addrAllowed(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestSetAllowlist(t *testing.T) {
+	allowlistForTest(t, " 10.0.4.0/24 , 192.168.1.10, fd00::1, hooks.internal., *.svc.cluster.local ")
+
+	for addr, want := range map[string]bool{
+		"10.0.4.7":            true,
+		"10.0.5.7":            false,
+		"192.168.1.10":        true,
+		"::ffff:192.168.1.10": true,
+		"192.168.1.11":        false,
+		"fd00::1":             true,
+		"fd00::2":             false,
+		"64:ff9b::a00:407":    true,
+		"64:ff9b::a9fe:a9fe":  false,
+		"2002:c0a8:10a::1":    true,
+		"2002:c0a8:10b::1":    false,
+	} {
+		if got := addrAllowed(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("addrAllowed(%s) = %v, want %v", addr, got, want)
+		}
+	}
+	for host, want := range map[string]bool{
+		"hooks.internal":          true,
+		"HOOKS.internal.":         true,
+		"other.internal":          false,
+		"api.svc.cluster.local":   true,
+		"svc.cluster.local":       false,
+		"evil-svc.cluster.local":  false,
+		"api.svc.cluster.local.x": false,
+	} {
+		if got := hostAllowed(host); got != want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", host, got, want)
+		}
+	}
+
+	for _, entries := range []string{
+		"10.0.0.0/33",
+		"minio:9000",
+		"10.0.0.1:80",
+		"[fd00::1]:443",
+		"bad host",
+	} {
+		if err := SetAllowlist(entries); err == nil {
+			t.Errorf("SetAllowlist(%q) accepted an invalid entry", entries)
+		}
+	}
+}
+
+func TestGuardedDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	dial := guardedDialer(&net.Dialer{})
+	for _, address := range []string{"localhost:" + port, "127.0.0.1:" + port} {
+		if conn, err := dial(context.Background(), "tcp", address); !errors.Is(err, ErrBlockedAddress) {
+			if conn != nil {
+				conn.Close()
+			}
+			t.Errorf("dialing %s = %v, want ErrBlockedAddress", address, err)
+		}
+	}
+
+	allowlistForTest(t, "localhost")
+	conn, err := dial(context.Background(), "tcp", "localhost:"+port)
+	if err != nil {
+		t.Fatalf("dialing an allowlisted name: %v", err)
+	}
+	conn.Close()
+}
+
+func TestValidateURL(t *testing.T) {
+	for rawURL, ok := range map[string]bool{
+		"https://hooks.example.com/in":  true,
+		"http://93.184.216.34/":         true,
+		"ftp://hooks.example.com/":      false,
+		"https:///path":                 false,
+		"http://localhost:8080/":        false,
+		"http://api.localhost/":         false,
+		"http://127.0.0.1/":             false,
+		"http://169.254.169.254/latest": false,
+		"http://[::1]/":                 false,
+		"http://[64:ff9b::a9fe:a9fe]/":  false,
+		"http://[2002:a9fe:a9fe::1]/":   false,
+	} {
+		if err := validateURL(rawURL); (err == nil) != ok {
+			t.Errorf("validateURL(%q) = %v, want ok %v", rawURL, err, ok)
+		}
+	}
+}
+
+// blockedTarget starts a server on 127.0.0.2, which the guard refuses,
+// and counts the requests that reach it.
+func blockedTarget(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("cannot listen on 127.0.0.2: %v", err)
+	}
+	hits := &atomic.Int32{}
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+		})},
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server.URL, hits
+}
+
+// redirector starts a server on 127.0.0.1 redirecting every request to
+// target, and allowlists 127.0.0.1 only.
+func redirector(t *testing.T, target string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target+"/internal", http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+	allowlistForTest(t, "127.0.0.1")
+	return server.URL
+}
+
+func TestRedirectsToBlockedHosts(t *testing.T) {
+	target, hits := blockedTarget(t)
+	start := redirector(t, target)
+
+	for _, policy := range []string{domain.WebhookRedirectFollow, domain.WebhookRedirectSameHost, domain.WebhookRedirectNone} {
+		t.Run(policy, func(t *testing.T) {
+			client, err := clientFor(&domain.Webhook{URL: start, RedirectPolicy: policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Get(start)
+			if policy == domain.WebhookRedirectNone {
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusFound {
+					t.Errorf("status %d, want the redirect itself", resp.StatusCode)
+				}
+			} else {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("the redirect to a blocked host was followed")
+				}
+				if policy == domain.WebhookRedirectFollow && !errors.Is(err, ErrBlockedAddress) {
+					t.Errorf("error = %v, want ErrBlockedAddress", err)
+				}
+			}
+			if n := hits.Load(); n != 0 {
+				t.Errorf("the blocked host got %d requests", n)
+			}
+		})
+	}
+}
+
+func TestProxiedRedirectsToBlockedHosts(t *testing.T) {
+	target, hits := blockedTarget(t)
+	// The proxy answers the request itself with a redirect
+	proxy := redirector(t, target)
+
+	client, err := clientFor(&domain.Webhook{URL: "http://hooks.example.com/", ProxyURL: proxy, RedirectPolicy: domain.WebhookRedirectFollow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://hooks.example.com/")
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("error = %v, want ErrBlockedAddress", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("the blocked host got %d requests", n)
+	}
+}
+
+func TestBlockedProxy(t *testing.T) {
+	proxy, hits := blockedTarget(t)
+
+	client, err := clientFor(&domain.Webhook{URL: "http://hooks.example.com/", ProxyURL: proxy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://hooks.example.com/")
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("error = %v, want ErrBlockedAddress", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("the blocked proxy got %d requests", n)
+	}
+
+	for _, proxyURL := range []string{proxy, "http://169.254.169.254:3128", "socks5://localhost:1080", "http://[64:ff9b::a00:1]:3128"} {
+		if err := ValidateTransport("", "", "", proxyURL); err == nil || !strings.Contains(err.Error(), "proxy URL") {
+			t.Errorf("ValidateTransport accepted the proxy %s: %v", proxyURL, err)
+		}
+	}
+	if err := ValidateTransport("", "", "", "http://proxy.example.com:3128"); err != nil {
+		t.Errorf("ValidateTransport refused a public proxy: %v", err)
+	}
+}
@@ -16,19 +16,48 @@ type Payload struct {
 }
 
 type EmailPayload struct {
-	ID          int64                 `json:"id"`
-	MailboxID   int64                 `json:"mailbox_id"`
-	MessageID   string                `json:"message_id,omitempty"`
-	From        string                `json:"from"`
-	To          string                `json:"to"`
-	Subject     string                `json:"subject"`
-	Date        *time.Time            `json:"date,omitempty"`
-	ReceivedAt  time.Time             `json:"received_at"`
-	Size        int64                 `json:"size"`
-	TextBody    string                `json:"text_body,omitempty"`
-	HTMLBody    string                `json:"html_body,omitempty"`
-	Headers     map[string]string     `json:"headers,omitempty"`
-	Attachments []AttachmentPayload   `json:"attachments,omitempty"`
+	ID          int64               `json:"id"`
+	MailboxID   int64               `json:"mailbox_id"`
+	MessageID   string              `json:"message_id,omitempty"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Subject     string              `json:"subject"`
+	Date        *time.Time          `json:"date,omitempty"`
+	ReceivedAt  time.Time           `json:"received_at"`
+	Size        int64               `json:"size"`
+	TextBody    string              `json:"text_body,omitempty"`
+	HTMLBody    string              `json:"html_body,omitempty"`
+	Headers     map[string]string   `json:"headers,omitempty"`
+	Attachments []AttachmentPayload `json:"attachments,omitempty"`
+	Raw         string              `json:"raw,omitempty"`
+	RawURL      string              `json:"raw_url,omitempty"`
+	Auth        *AuthPayload        `json:"auth,omitempty"`
+	Alias       string              `json:"alias,omitempty"`
+	Subaddress  string              `json:"subaddress,omitempty"`
+}
+
+type AuthPayload struct {
+	SPF         string `json:"spf"`
+	SPFDomain   string `json:"spf_domain,omitempty"`
+	DKIM        string `json:"dkim"`
+	DKIMDomain  string `json:"dkim_domain,omitempty"`
+	DMARC       string `json:"dmarc"`
+	DMARCPolicy string `json:"dmarc_policy,omitempty"`
+}
+
+// buildAuthPayload returns nil for mail stored without authentication checks.
+func buildAuthPayload(auth domain.EmailAuth) *AuthPayload {
+	if auth.SPF == "" && auth.DKIM == "" && auth.DMARC == "" {
+		return nil
+	}
+	return &AuthPayload{
+		SPF:         auth.SPF,
+		SPFDomain:   auth.SPFDomain,
+		DKIM:        auth.DKIM,
+		DKIMDomain:  auth.DKIMDomain,
+		DMARC:       auth.DMARC,
+		DMARCPolicy: auth.DMARCPolicy,
+	}
 }
 
 type AttachmentPayload struct {
@@ -36,11 +65,13 @@ type AttachmentPayload struct {
 	Filename    string `json:"filename"`
 	ContentType string `json:"content_type"`
 	Size        int64  `json:"size"`
+	Content     string `json:"content,omitempty"`
+	URL         string `json:"url,omitempty"`
 }
 
 func BuildPayload(email *domain.Email, webhook *domain.Webhook) *Payload {
 	payload := &Payload{
-		Event:     "email.received",
+		Event:     domain.EventEmailReceived,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Email: EmailPayload{
 			ID:         email.ID,
@@ -52,6 +83,9 @@ func BuildPayload(email *domain.Email, webhook *domain.Webhook) *Payload {
 			Date:       email.Date,
 			ReceivedAt: email.ReceivedAt,
 			Size:       email.RawSize,
+			Auth:       buildAuthPayload(email.Auth),
+			Alias:      email.Alias,
+			Subaddress: email.Subaddress,
 		},
 	}
 
@@ -122,16 +156,21 @@ func parseMetadata(payloadType string, customPayload string, email *domain.Email
 
 func substituteTemplateVars(metadata map[string]interface{}, email *domain.Email) map[string]interface{} {
 	replacer := map[string]string{
-		"{{email.id}}":          fmt.Sprintf("%d", email.ID),
-		"{{email.mailbox_id}}":  fmt.Sprintf("%d", email.MailboxID),
-		"{{email.message_id}}":  email.MessageID,
-		"{{email.from}}":        email.FromAddress,
-		"{{email.to}}":          email.ToAddress,
-		"{{email.subject}}":     email.Subject,
-		"{{email.address}}":     email.ToAddress,
-		"{{email.size}}":        fmt.Sprintf("%d", email.RawSize),
-		"{{email.text_body}}":   email.TextBody,
-		"{{email.html_body}}":   email.HTMLBody,
+		"{{email.id}}":         fmt.Sprintf("%d", email.ID),
+		"{{email.mailbox_id}}": fmt.Sprintf("%d", email.MailboxID),
+		"{{email.message_id}}": email.MessageID,
+		"{{email.from}}":       email.FromAddress,
+		"{{email.to}}":         email.ToAddress,
+		"{{email.subject}}":    email.Subject,
+		"{{email.address}}":    email.ToAddress,
+		"{{email.size}}":       fmt.Sprintf("%d", email.RawSize),
+		"{{email.text_body}}":  email.TextBody,
+		"{{email.html_body}}":  email.HTMLBody,
+		"{{email.spf}}":        email.Auth.SPF,
+		"{{email.dkim}}":       email.Auth.DKIM,
+		"{{email.dmarc}}":      email.Auth.DMARC,
+		"{{email.alias}}":      email.Alias,
+		"{{email.subaddress}}": email.Subaddress,
 	}
 
 	for key, value := range metadata {
@@ -169,9 +208,10 @@ func (p *Payload) JSON() ([]byte, error) {
 	return json.Marshal(p)
 }
 
-func BuildTestPayload(webhook *domain.Webhook) *Payload {
-	now := time.Now()
-	testEmail := &domain.Email{
+// SampleEmail is the email test deliveries and template previews use when
+// no stored email is available.
+func SampleEmail(now time.Time) *domain.Email {
+	return &domain.Email{
 		ID:          0,
 		MailboxID:   0,
 		MessageID:   "<test@mailgress.local>",
@@ -183,7 +223,35 @@ func BuildTestPayload(webhook *domain.Webhook) *Payload {
 		RawSize:     1024,
 		TextBody:    "This is a test webhook delivery from Mailgress.",
 		HTMLBody:    "<p>This is a test webhook delivery from Mailgress.</p>",
+		Auth: domain.EmailAuth{
+			SPF:         domain.AuthResultPass,
+			SPFDomain:   "example.com",
+			DKIM:        domain.AuthResultPass,
+			DKIMDomain:  "example.com",
+			DMARC:       domain.AuthResultPass,
+			DMARCPolicy: "none",
+		},
+		Headers: map[string]string{
+			"Content-Type": "text/plain",
+		},
 	}
+}
+
+// sampleRawMessage renders the sample email as a minimal RFC 822 message.
+func sampleRawMessage(email *domain.Email) []byte {
+	return []byte("Message-ID: " + email.MessageID + "\r\n" +
+		"Date: " + email.ReceivedAt.Format(time.RFC1123Z) + "\r\n" +
+		"From: " + email.FromAddress + "\r\n" +
+		"To: " + email.ToAddress + "\r\n" +
+		"Subject: " + email.Subject + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		email.TextBody + "\r\n")
+}
+
+func BuildTestPayload(webhook *domain.Webhook) *Payload {
+	now := time.Now()
+	testEmail := SampleEmail(now)
 
 	payload := &Payload{
 		Event:     "test",
@@ -200,6 +268,7 @@ func BuildTestPayload(webhook *domain.Webhook) *Payload {
 			Size:       testEmail.RawSize,
 			TextBody:   testEmail.TextBody,
 			HTMLBody:   testEmail.HTMLBody,
+			Auth:       buildAuthPayload(testEmail.Auth),
 			Headers: map[string]string{
 				"Content-Type": "text/plain",
 			},
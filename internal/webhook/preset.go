@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// Payload presets reproduce the inbound webhooks of other providers, so
+// receivers written for them work unchanged. Presets only describe incoming
+// mail and always send multipart/form-data with attachments as file parts.
+const (
+	// PayloadTypeMailgun is a Mailgun route forwarding the parsed message,
+	// signed with the webhook's HMAC secret as the Mailgun signing key.
+	PayloadTypeMailgun = "mailgun"
+	// PayloadTypeSendGrid is a SendGrid Inbound Parse post, signed with the
+	// installation's ECDSA key in the X-Twilio-Email-Event-Webhook headers.
+	PayloadTypeSendGrid = "sendgrid"
+)
+
+// IsPreset reports whether the payload type is a provider preset.
+func IsPreset(payloadType string) bool {
+	return payloadType == PayloadTypeMailgun || payloadType == PayloadTypeSendGrid
+}
+
+// BuildPreset renders an email as the webhook's provider preset. raw is the
+// original message, if stored, and files are the attachments to send. It
+// returns the fields as a JSON object, for the delivery record, and the body.
+func BuildPreset(webhook *domain.Webhook, email *domain.Email, raw []byte, files []File, keys *SigningKeys, now time.Time) ([]byte, *Body, error) {
+	headers := messageHeaders(email, raw)
+
+	var fields []Field
+	switch webhook.PayloadType {
+	case PayloadTypeMailgun:
+		fields, files = mailgunFields(email, headers, files, webhook.HMACSecret, now)
+	case PayloadTypeSendGrid:
+		fields, files = sendGridFields(email, headers, raw, files)
+	default:
+		return nil, nil, fmt.Errorf("unknown payload preset: %s", webhook.PayloadType)
+	}
+
+	body, err := encodeMultipart(fields, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if webhook.PayloadType == PayloadTypeSendGrid {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		signature, err := sendGridSignature(keys.ECDSA, timestamp, body.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		body.Header = http.Header{}
+		body.Header.Set("X-Twilio-Email-Event-Webhook-Signature", signature)
+		body.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+	}
+
+	return fieldsJSON(fields), body, nil
+}
+
+// mailgunFields lays an email out like a Mailgun route forward: the parsed
+// bodies, every header as a field of its own and attachment-N files. The
+// signature is the hex HMAC-SHA256 of timestamp and token.
+func mailgunFields(email *domain.Email, headers [][2]string, files []File, signingKey string, now time.Time) ([]Field, []File) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	token := randomToken()
+
+	headerPairs, _ := json.Marshal(headers)
+
+	fields := []Field{
+		{Name: "recipient", Value: email.ToAddress},
+		{Name: "sender", Value: email.FromAddress},
+		{Name: "from", Value: headerOr(email, "From", email.FromAddress)},
+		{Name: "subject", Value: email.Subject},
+		{Name: "body-plain", Value: email.TextBody},
+		{Name: "stripped-text", Value: email.TextBody},
+		{Name: "stripped-signature", Value: ""},
+		{Name: "body-html", Value: email.HTMLBody},
+		{Name: "stripped-html", Value: email.HTMLBody},
+		{Name: "attachment-count", Value: strconv.Itoa(len(files))},
+		{Name: "timestamp", Value: timestamp},
+		{Name: "token", Value: token},
+		{Name: "signature", Value: mailgunSignature(signingKey, timestamp, token)},
+		{Name: "message-headers", Value: string(headerPairs)},
+		{Name: "content-id-map", Value: "{}"},
+	}
+	for _, h := range headers {
+		fields = append(fields, Field{Name: h[0], Value: h[1]})
+	}
+
+	named := make([]File, len(files))
+	for i, f := range files {
+		f.Field = fmt.Sprintf("attachment-%d", i+1)
+		named[i] = f
+	}
+	return fields, named
+}
+
+func mailgunSignature(signingKey, timestamp, token string) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendGridFields lays an email out like SendGrid Inbound Parse: the raw
+// header block, the SMTP envelope as JSON and attachmentN files described
+// in attachment-info.
+func sendGridFields(email *domain.Email, headers [][2]string, raw []byte, files []File) ([]Field, []File) {
+	envelope, _ := json.Marshal(map[string]interface{}{
+		"to":   []string{email.ToAddress},
+		"from": email.FromAddress,
+	})
+
+	dkim := "{}"
+	if email.Auth.DKIMDomain != "" {
+		dkim = fmt.Sprintf("{@%s : %s}", email.Auth.DKIMDomain, email.Auth.DKIM)
+	}
+	spf := email.Auth.SPF
+	if spf == "" {
+		spf = domain.AuthResultNone
+	}
+
+	named := make([]File, len(files))
+	info := make(map[string]map[string]string, len(files))
+	for i, f := range files {
+		f.Field = fmt.Sprintf("attachment%d", i+1)
+		named[i] = f
+		info[f.Field] = map[string]string{
+			"filename": f.Filename,
+			"name":     f.Filename,
+			"type":     f.ContentType,
+		}
+	}
+	attachmentInfo, _ := json.Marshal(info)
+
+	fields := []Field{
+		{Name: "headers", Value: headerBlock(headers, raw)},
+		{Name: "dkim", Value: dkim},
+		{Name: "content-ids", Value: "{}"},
+		{Name: "to", Value: headerOr(email, "To", email.ToAddress)},
+		{Name: "from", Value: headerOr(email, "From", email.FromAddress)},
+	}
+	if cc := templateHeader(email.Headers, "Cc"); cc != "" {
+		fields = append(fields, Field{Name: "cc", Value: cc})
+	}
+	fields = append(fields,
+		Field{Name: "text", Value: email.TextBody},
+		Field{Name: "html", Value: email.HTMLBody},
+		Field{Name: "envelope", Value: string(envelope)},
+		Field{Name: "attachments", Value: strconv.Itoa(len(files))},
+		Field{Name: "subject", Value: email.Subject},
+		Field{Name: "attachment-info", Value: string(attachmentInfo)},
+		Field{Name: "charsets", Value: `{"to":"UTF-8","html":"UTF-8","subject":"UTF-8","from":"UTF-8","text":"UTF-8"}`},
+		Field{Name: "SPF", Value: spf},
+	)
+	return fields, named
+}
+
+// sendGridSignature signs timestamp and body the way SendGrid signs its
+// webhooks: ECDSA over the SHA-256 digest, ASN.1 encoded, in base64.
+func sendGridSignature(key *ecdsa.PrivateKey, timestamp string, body []byte) (string, error) {
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// messageHeaders lists the headers of the message in their original order
+// when the raw message is stored, otherwise sorted by name.
+func messageHeaders(email *domain.Email, raw []byte) [][2]string {
+	var headers [][2]string
+	if raw != nil {
+		for _, line := range unfoldHeaders(rawHeaderBlock(raw)) {
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				headers = append(headers, [2]string{strings.TrimSpace(name), strings.TrimSpace(value)})
+			}
+		}
+		return headers
+	}
+
+	names := make([]string, 0, len(email.Headers))
+	for name := range email.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		headers = append(headers, [2]string{name, email.Headers[name]})
+	}
+	return headers
+}
+
+// headerBlock returns the header section of the message as received, or
+// rebuilds it from the parsed headers.
+func headerBlock(headers [][2]string, raw []byte) string {
+	if raw != nil {
+		return rawHeaderBlock(raw)
+	}
+	var buf strings.Builder
+	for _, h := range headers {
+		buf.WriteString(h[0] + ": " + h[1] + "\n")
+	}
+	return buf.String()
+}
+
+func rawHeaderBlock(raw []byte) string {
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		return string(raw[:i+2])
+	}
+	if i := bytes.Index(raw, []byte("\n\n")); i >= 0 {
+		return string(raw[:i+1])
+	}
+	return string(raw)
+}
+
+// unfoldHeaders joins folded continuation lines to their header.
+func unfoldHeaders(block string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(block, "\r\n", "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func headerOr(email *domain.Email, name, fallback string) string {
+	if v := templateHeader(email.Headers, name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func randomToken() string {
+	b := make([]byte, 25)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// fieldsJSON encodes fields as a JSON object in their original order.
+func fieldsJSON(fields []Field) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		value, _ := json.Marshal(f.Value)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// maxRetryAfter bounds how far a receiver can push a retry back with
+// Retry-After.
+const maxRetryAfter = 24 * time.Hour
+
+// retryable reports whether a non-2xx response is worth another attempt.
+// Other client errors mean the receiver refused the request itself, which
+// sending it again will not change.
+func retryable(statusCode int) bool {
+	if statusCode < 400 || statusCode >= 500 {
+		return true
+	}
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay after the given failed attempt: the webhook's
+// base delay doubled for every earlier attempt, capped, then shortened by a
+// random jitter so receivers recovering from an outage are not hit by every
+// queued retry at once.
+func backoff(webhook *domain.Webhook, attempt int) time.Duration {
+	maxDelay := time.Duration(webhook.RetryMaxDelaySec) * time.Second
+	delay := time.Duration(webhook.RetryBaseDelaySec) * time.Second
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if webhook.RetryJitterPercent > 0 && delay > 0 {
+		spread := int64(delay) * int64(webhook.RetryJitterPercent) / 100
+		delay -= time.Duration(rand.Int63n(spread + 1))
+	}
+	return delay
+}
+
+// retryAfter reads the Retry-After header of 429 and 503 responses, given
+// either as seconds or as an HTTP date.
+func retryAfter(resp *Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		// Clamp before converting, a huge value would overflow Duration
+		secs = min(secs, int64(maxRetryAfter/time.Second))
+		delay = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = at.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
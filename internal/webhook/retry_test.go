@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := map[int]bool{
+		200: true, // a failure without a status, such as a timeout
+		301: true,
+		400: false,
+		401: false,
+		404: false,
+		408: true,
+		410: false,
+		422: false,
+		425: true,
+		429: true,
+		500: true,
+		502: true,
+		503: true,
+	}
+	for status, want := range tests {
+		if got := retryable(status); got != want {
+			t.Errorf("retryable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base, max, attempt int
+		want               time.Duration
+	}{
+		{10, 3600, 1, 10 * time.Second},
+		{10, 3600, 2, 20 * time.Second},
+		{10, 3600, 3, 40 * time.Second},
+		{10, 3600, 9, 2560 * time.Second},
+		{10, 3600, 10, time.Hour},
+		{10, 3600, 1000, time.Hour},
+		{60, 30, 1, 30 * time.Second},
+		{0, 3600, 5, 0},
+	}
+	for _, tt := range tests {
+		wh := &domain.Webhook{RetryBaseDelaySec: tt.base, RetryMaxDelaySec: tt.max}
+		if got := backoff(wh, tt.attempt); got != tt.want {
+			t.Errorf("backoff(base %d, max %d, attempt %d) = %v, want %v", tt.base, tt.max, tt.attempt, got, tt.want)
+		}
+	}
+
+	// Jitter only ever shortens the delay, by at most the percentage
+	for _, jitter := range []int{10, 50, 100} {
+		wh := &domain.Webhook{RetryBaseDelaySec: 10, RetryMaxDelaySec: 3600, RetryJitterPercent: jitter}
+		full := 40 * time.Second
+		floor := full - full*time.Duration(jitter)/100
+		varied := false
+		for i := 0; i < 200; i++ {
+			got := backoff(wh, 3)
+			if got < floor || got > full {
+				t.Fatalf("backoff with %d%% jitter = %v, want within [%v, %v]", jitter, got, floor, full)
+			}
+			varied = varied || got != full
+		}
+		if !varied {
+			t.Errorf("backoff with %d%% jitter never varied", jitter)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		status int
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{429, "120", 2 * time.Minute, true},
+		{503, " 0 ", 0, true},
+		{429, strconv.FormatInt(int64(maxRetryAfter/time.Second)+1, 10), maxRetryAfter, true},
+		{429, "9223372036854775807", maxRetryAfter, true},
+		{429, "-5", 0, false},
+		{429, "1.5", 0, false},
+		{429, "soon", 0, false},
+		{429, "", 0, false},
+		{503, now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{503, now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{503, now.Add(72 * time.Hour).Format(http.TimeFormat), maxRetryAfter, true},
+		{503, "Sunday, 01-Mar-26 12:01:00 GMT", time.Minute, true},
+		{500, "120", 0, false},
+		{408, "120", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%d, %q) = %v, %v, want %v, %v", tt.status, tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := retryAfter(nil, now); ok {
+		t.Error("retryAfter(nil) reported a delay")
+	}
+}
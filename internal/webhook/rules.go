@@ -52,6 +52,8 @@ func (e *RuleEvaluator) evaluateRule(rule domain.WebhookRule, email *domain.Emai
 		fieldValue = email.FromAddress
 	case domain.RuleFieldTo:
 		fieldValue = email.ToAddress
+	case domain.RuleFieldSubaddress:
+		fieldValue = email.Subaddress
 	case domain.RuleFieldBody:
 		fieldValue = email.TextBody
 		if fieldValue == "" {
@@ -61,6 +63,12 @@ func (e *RuleEvaluator) evaluateRule(rule domain.WebhookRule, email *domain.Emai
 		if email.Headers != nil {
 			fieldValue = email.Headers[rule.HeaderName]
 		}
+	case domain.RuleFieldSPF:
+		fieldValue = email.Auth.SPF
+	case domain.RuleFieldDKIM:
+		fieldValue = email.Auth.DKIM
+	case domain.RuleFieldDMARC:
+		fieldValue = email.Auth.DMARC
 	case domain.RuleFieldHasAttachments:
 		hasAttachments := email.HasAttachments || len(email.Attachments) > 0
 		expected := strings.ToLower(rule.Value) == "true"
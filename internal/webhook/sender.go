@@ -3,6 +3,7 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,16 +12,18 @@ import (
 	"github.com/jr-k/mailgress/internal/domain"
 )
 
-var httpClient = &http.Client{
-	Timeout: 60 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-	},
+// Response is what a receiver answered. Header is kept for Retry-After.
+type Response struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
 }
 
-func SendWebhook(ctx context.Context, webhook *domain.Webhook, payload []byte, timeout time.Duration) (statusCode int, responseBody string, err error) {
+// SendWebhook delivers an encoded payload, announcing its type in the
+// X-Mailgress-Event header. deliveryID is sent as X-Mailgress-Delivery-Id
+// and is the same for every attempt at delivering an event, so receivers
+// can drop duplicates.
+func SendWebhook(ctx context.Context, webhook *domain.Webhook, event, deliveryID string, body *Body, keys *SigningKeys, timeout time.Duration) (*Response, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -30,50 +33,119 @@ func SendWebhook(ctx context.Context, webhook *domain.Webhook, payload []byte, t
 	}
 
 	var req *http.Request
+	var err error
 	if method == "GET" {
 		req, err = http.NewRequestWithContext(ctx, method, webhook.URL, nil)
 	} else {
-		req, err = http.NewRequestWithContext(ctx, method, webhook.URL, bytes.NewReader(payload))
+		req, err = http.NewRequestWithContext(ctx, method, webhook.URL, bytes.NewReader(body.Data))
 	}
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if len(body.Query) > 0 {
+		query := req.URL.Query()
+		for key, values := range body.Query {
+			query[key] = values
+		}
+		req.URL.RawQuery = query.Encode()
 	}
 
 	if method != "GET" {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", body.ContentType)
 	}
 	req.Header.Set("User-Agent", "Mailgress/1.0")
-	req.Header.Set("X-Mailgress-Event", "email.received")
+	req.Header.Set("X-Mailgress-Event", event)
+	req.Header.Set("X-Mailgress-Delivery-Id", deliveryID)
 
-	if webhook.HMACSecret != "" && method != "GET" {
-		signature := SignPayload(payload, webhook.HMACSecret)
+	var signedBody []byte
+	if method != "GET" {
+		signedBody = body.Data
+	}
+	var ed25519Key ed25519.PrivateKey
+	if webhook.SignEd25519 && keys != nil {
+		ed25519Key = keys.Ed25519
+	}
+	signature := SignRequest(time.Now().Unix(), method, req.URL.String(), deliveryID, signedBody, webhook.SigningSecrets(), ed25519Key)
+	if signature != "" {
 		req.Header.Set("X-Mailgress-Signature", signature)
 	}
 
+	for key, values := range body.Header {
+		req.Header[key] = values
+	}
+
 	for key, value := range webhook.Headers {
 		req.Header.Set(key, value)
 	}
 
-	resp, err := httpClient.Do(req)
+	client, err := clientFor(webhook)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transport settings: %w", err)
+	}
+
+	if webhook.ProxyURL != "" {
+		if err := checkTarget(ctx, req.URL); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return 0, "", fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024))
+	result := &Response{StatusCode: resp.StatusCode, Header: resp.Header}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024))
 	if err != nil {
-		return resp.StatusCode, "", fmt.Errorf("failed to read response: %w", err)
+		return result, fmt.Errorf("failed to read response: %w", err)
 	}
+	result.Body = string(respBody)
 
-	return resp.StatusCode, string(body), nil
+	return result, nil
 }
 
-func TestWebhook(ctx context.Context, webhook *domain.Webhook) (statusCode int, responseBody string, err error) {
+// TestWebhook sends a sample email to the webhook's target. Body templates
+// are rendered against the sample email and the given mailbox, and the
+// rfc822 encoding, provider presets and non-HTTP targets use a sample
+// message.
+func (d *Dispatcher) TestWebhook(ctx context.Context, webhook *domain.Webhook, mailbox *domain.Mailbox) (statusCode int, responseBody string, err error) {
 	payload := BuildTestPayload(webhook)
 	payloadBytes, err := payload.JSON()
 	if err != nil {
 		return 0, "", err
 	}
 
-	return SendWebhook(ctx, webhook, payloadBytes, time.Duration(webhook.TimeoutSec)*time.Second)
+	now := time.Now()
+	email := SampleEmail(now)
+	if webhook.PayloadType == PayloadTypeTemplate {
+		payloadBytes, err = RenderTemplate(webhook.CustomPayload, NewTemplateData(domain.Event{
+			Type:       payload.Event,
+			OccurredAt: now,
+			Email:      email,
+			Mailbox:    mailbox,
+		}))
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	raw := sampleRawMessage(email)
+	var body *Body
+	if IsPreset(webhook.PayloadType) {
+		payloadBytes, body, err = BuildPreset(webhook, email, raw, nil, d.keys, now)
+	} else {
+		body, err = EncodeBody(webhook, payloadBytes, nil, raw)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	msg := &Message{Event: payload.Event, DeliveryID: "test-" + randomToken(), Payload: payloadBytes, Body: body, Raw: raw}
+	resp, err := d.deliver(ctx, webhook, msg)
+	if resp == nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, resp.Body, err
 }
@@ -1,49 +1,130 @@
 package webhook
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func SignPayload(payload []byte, secret string) string {
-	if secret == "" {
+// Deliveries are signed over a canonical string made of the timestamp, the
+// request method, the full URL including the query string, the delivery ID
+// and the body, one per line:
+//
+//	1700000000\nPOST\nhttps://example.com/hook?a=1\n3f2a...\n{"event":...}
+//
+// so GET deliveries, which carry their data in the query string, are covered
+// as well. The X-Mailgress-Signature header holds the timestamp, one v1=
+// HMAC-SHA256 signature per active secret and, when enabled, an ed25519=
+// signature made with the installation key:
+//
+//	t=1700000000,v1=5257a8...,v1=9f1c3e...,ed25519=base64...
+//
+// A receiver accepts the request when any v1 signature matches one of its
+// secrets, which lets secrets be rotated without dropping deliveries.
+
+// CanonicalString builds the string the signatures of a delivery cover.
+func CanonicalString(timestamp int64, method, url, deliveryID string, body []byte) []byte {
+	var b strings.Builder
+	b.WriteString(strconv.FormatInt(timestamp, 10))
+	b.WriteByte('\n')
+	b.WriteString(method)
+	b.WriteByte('\n')
+	b.WriteString(url)
+	b.WriteByte('\n')
+	b.WriteString(deliveryID)
+	b.WriteByte('\n')
+	b.Write(body)
+	return []byte(b.String())
+}
+
+// SignRequest builds the X-Mailgress-Signature header value. It returns an
+// empty string when there is neither a secret nor an Ed25519 key to sign
+// with.
+func SignRequest(timestamp int64, method, url, deliveryID string, body []byte, secrets []string, key ed25519.PrivateKey) string {
+	if len(secrets) == 0 && key == nil {
 		return ""
 	}
 
-	timestamp := time.Now().Unix()
-	signedPayload := fmt.Sprintf("%d.%s", timestamp, string(payload))
+	canonical := CanonicalString(timestamp, method, url, deliveryID, body)
+	parts := []string{fmt.Sprintf("t=%d", timestamp)}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+hmacHex(canonical, secret))
+	}
+	if key != nil {
+		parts = append(parts, "ed25519="+base64.StdEncoding.EncodeToString(ed25519.Sign(key, canonical)))
+	}
+	return strings.Join(parts, ",")
+}
 
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(signedPayload))
-	signature := hex.EncodeToString(mac.Sum(nil))
+// VerifySignature checks a signature header against one secret. The request
+// is accepted when the timestamp is within tolerance and any of the v1
+// signatures matches.
+func VerifySignature(header, method, url, deliveryID string, body []byte, secret string, tolerance time.Duration) bool {
+	timestamp, values, ok := parseSignature(header, tolerance)
+	if !ok || secret == "" {
+		return false
+	}
 
-	return fmt.Sprintf("t=%d,v1=%s", timestamp, signature)
+	expected := hmacHex(CanonicalString(timestamp, method, url, deliveryID, body), secret)
+	for _, sig := range values["v1"] {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return true
+		}
+	}
+	return false
 }
 
-func VerifySignature(payload []byte, signature, secret string, tolerance time.Duration) bool {
-	if secret == "" || signature == "" {
-		return true
+// VerifyEd25519Signature checks the ed25519 signature of a header against the
+// published installation key.
+func VerifyEd25519Signature(header, method, url, deliveryID string, body []byte, publicKey ed25519.PublicKey, tolerance time.Duration) bool {
+	timestamp, values, ok := parseSignature(header, tolerance)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return false
 	}
 
-	var timestamp int64
-	var providedSig string
+	canonical := CanonicalString(timestamp, method, url, deliveryID, body)
+	for _, encoded := range values["ed25519"] {
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil && ed25519.Verify(publicKey, canonical, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSignature(header string, tolerance time.Duration) (int64, map[string][]string, bool) {
+	values := make(map[string][]string)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		values[key] = append(values[key], value)
+	}
 
-	_, err := fmt.Sscanf(signature, "t=%d,v1=%s", &timestamp, &providedSig)
+	if len(values["t"]) != 1 {
+		return 0, nil, false
+	}
+	timestamp, err := strconv.ParseInt(values["t"][0], 10, 64)
 	if err != nil {
-		return false
+		return 0, nil, false
 	}
 
-	if time.Since(time.Unix(timestamp, 0)) > tolerance {
-		return false
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return 0, nil, false
 	}
+	return timestamp, values, true
+}
 
-	signedPayload := fmt.Sprintf("%d.%s", timestamp, string(payload))
+func hmacHex(data []byte, secret string) string {
 	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(signedPayload))
-	expectedSig := hex.EncodeToString(mac.Sum(nil))
-
-	return hmac.Equal([]byte(providedSig), []byte(expectedSig))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
 }
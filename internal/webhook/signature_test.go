@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/domain"
+	"github.com/jr-k/mailgress/internal/service"
+)
+
+// The expected signatures were computed independently, with Python's hmac
+// module and with OpenSSL using the first Ed25519 test key of RFC 8032.
+const (
+	vectorTimestamp  = 1700000000
+	vectorURL        = "https://example.com/hook?a=1"
+	vectorDeliveryID = "3f2a9c1e-0000-4000-8000-000000000001"
+	vectorBody       = `{"event":"email.received"}`
+	vectorSeed       = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	vectorPublicKey  = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	vectorHMAC       = "0f07f47789907af32457c341bb77d967c0353efdfde89196a141e226aaaecb38"
+	vectorHMACOld    = "a80ddfd6b14be1a819ea907cc019095a8ac305519e36b2139164a8e856d82efb"
+	vectorEd25519    = "WVpO2A36bs9XcUKsAumru+OH7HP156O48f2DlLIr+gd/w7/zVrk0RMxXY33ANw6CI4rfN0q9mNFA2f0XJbKsAg=="
+)
+
+func vectorKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	seed, err := hex.DecodeString(vectorSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	if got := hex.EncodeToString(key.Public().(ed25519.PublicKey)); got != vectorPublicKey {
+		t.Fatalf("public key = %s, want %s", got, vectorPublicKey)
+	}
+	return key
+}
+
+func TestCanonicalString(t *testing.T) {
+	got := CanonicalString(vectorTimestamp, "POST", vectorURL, vectorDeliveryID, []byte(vectorBody))
+	want := "1700000000\nPOST\nhttps://example.com/hook?a=1\n" + vectorDeliveryID + "\n" + vectorBody
+	if string(got) != want {
+		t.Errorf("CanonicalString() = %q, want %q", got, want)
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	key := vectorKey(t)
+	tests := []struct {
+		secrets []string
+		key     ed25519.PrivateKey
+		want    string
+	}{
+		{nil, nil, ""},
+		{[]string{"whsec_current"}, nil, "t=1700000000,v1=" + vectorHMAC},
+		{[]string{"whsec_current", "whsec_previous"}, nil, "t=1700000000,v1=" + vectorHMAC + ",v1=" + vectorHMACOld},
+		{nil, key, "t=1700000000,ed25519=" + vectorEd25519},
+		{[]string{"whsec_current"}, key, "t=1700000000,v1=" + vectorHMAC + ",ed25519=" + vectorEd25519},
+	}
+	for _, tt := range tests {
+		got := SignRequest(vectorTimestamp, "POST", vectorURL, vectorDeliveryID, []byte(vectorBody), tt.secrets, tt.key)
+		if got != tt.want {
+			t.Errorf("SignRequest(%v, key %v) =\n%s\nwant\n%s", tt.secrets, tt.key != nil, got, tt.want)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	key := vectorKey(t)
+	public := key.Public().(ed25519.PublicKey)
+	now := time.Now().Unix()
+	body := []byte(vectorBody)
+	header := SignRequest(now, "POST", vectorURL, vectorDeliveryID, body, []string{"whsec_current", "whsec_previous"}, key)
+
+	verify := func(header, method, url, deliveryID string, body []byte, secret string) bool {
+		return VerifySignature(header, method, url, deliveryID, body, secret, 5*time.Minute)
+	}
+	if !verify(header, "POST", vectorURL, vectorDeliveryID, body, "whsec_current") {
+		t.Error("the current secret does not verify")
+	}
+	if !verify(header, "POST", vectorURL, vectorDeliveryID, body, "whsec_previous") {
+		t.Error("the previous secret does not verify")
+	}
+	if !VerifyEd25519Signature(header, "POST", vectorURL, vectorDeliveryID, body, public, 5*time.Minute) {
+		t.Error("the Ed25519 signature does not verify")
+	}
+
+	// Anything the signature covers fails verification once changed
+	tampered := []struct {
+		name                    string
+		header, method, url, id string
+		body                    []byte
+		secret                  string
+	}{
+		{"secret", header, "POST", vectorURL, vectorDeliveryID, body, "whsec_other"},
+		{"empty secret", header, "POST", vectorURL, vectorDeliveryID, body, ""},
+		{"method", header, "GET", vectorURL, vectorDeliveryID, body, "whsec_current"},
+		{"query", header, "POST", "https://example.com/hook?a=2", vectorDeliveryID, body, "whsec_current"},
+		{"delivery ID", header, "POST", vectorURL, "other", body, "whsec_current"},
+		{"body", header, "POST", vectorURL, vectorDeliveryID, []byte(`{"event":"email.deleted"}`), "whsec_current"},
+		{"timestamp", strings.Replace(header, "t="+strconv.FormatInt(now, 10), "t="+strconv.FormatInt(now+1, 10), 1), "POST", vectorURL, vectorDeliveryID, body, "whsec_current"},
+		{"two timestamps", "t=" + strconv.FormatInt(now, 10) + "," + header, "POST", vectorURL, vectorDeliveryID, body, "whsec_current"},
+		{"no timestamp", "v1=" + hmacHex(CanonicalString(now, "POST", vectorURL, vectorDeliveryID, body), "whsec_current"), "POST", vectorURL, vectorDeliveryID, body, "whsec_current"},
+	}
+	for _, tt := range tampered {
+		if verify(tt.header, tt.method, tt.url, tt.id, tt.body, tt.secret) {
+			t.Errorf("a request with another %s verified", tt.name)
+		}
+		if tt.secret != "whsec_other" && tt.secret != "" && VerifyEd25519Signature(tt.header, tt.method, tt.url, tt.id, tt.body, public, 5*time.Minute) {
+			t.Errorf("a request with another %s verified with Ed25519", tt.name)
+		}
+	}
+
+	// Replayed requests are refused once outside the tolerance
+	for _, age := range []time.Duration{-10 * time.Minute, 10 * time.Minute} {
+		old := time.Now().Add(-age).Unix()
+		header := SignRequest(old, "GET", vectorURL, vectorDeliveryID, nil, []string{"whsec_current"}, key)
+		if verify(header, "GET", vectorURL, vectorDeliveryID, nil, "whsec_current") {
+			t.Errorf("a request signed %v ago verified", age)
+		}
+		if VerifyEd25519Signature(header, "GET", vectorURL, vectorDeliveryID, nil, public, 5*time.Minute) {
+			t.Errorf("a request signed %v ago verified with Ed25519", age)
+		}
+	}
+	if VerifyEd25519Signature(header, "POST", vectorURL, vectorDeliveryID, body, public[:16], 5*time.Minute) {
+		t.Error("a truncated public key verified")
+	}
+}
+
+// TestRotatedSecretSigning delivers to a receiver that verifies the
+// signatures, before and after the rotated secret expires.
+func TestRotatedSecretSigning(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, &config.Config{})
+	allowlistForTest(t, "127.0.0.1")
+
+	var mu sync.Mutex
+	var last *http.Request
+	var lastBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		last, lastBody = r, body
+	}))
+	t.Cleanup(receiver.Close)
+
+	wh, err := env.webhooks.Create(ctx, service.CreateWebhookParams{
+		MailboxID:  env.mailbox.ID,
+		Name:       "signed",
+		URL:        receiver.URL + "/hook",
+		HMACSecret: "whsec_first",
+		Events:     []string{domain.EventEmailReceived},
+		MaxRetries: 1,
+		TimeoutSec: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// send delivers the email and returns which secrets its request was
+	// signed with, and how many v1 signatures it carried
+	send := func(secrets ...string) (map[string]bool, int) {
+		t.Helper()
+		if err := env.dispatcher.Dispatch(env.mailbox.ID, env.email); err != nil {
+			t.Fatal(err)
+		}
+		if !env.dispatcher.runNext() {
+			t.Fatal("no job was run")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		header := last.Header.Get("X-Mailgress-Signature")
+		signed := make(map[string]bool)
+		for _, secret := range secrets {
+			signed[secret] = VerifySignature(header, last.Method, receiver.URL+last.URL.RequestURI(),
+				last.Header.Get("X-Mailgress-Delivery-Id"), lastBody, secret, time.Minute)
+		}
+		return signed, strings.Count(header, "v1=")
+	}
+
+	if signed, n := send("whsec_first"); !signed["whsec_first"] || n != 1 {
+		t.Fatalf("before the rotation: signed %v with %d signatures", signed, n)
+	}
+
+	rotated, err := env.webhooks.RotateSecret(ctx, wh.ID, 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rotated.HMACSecret, "whsec_") || rotated.HMACSecret == "whsec_first" {
+		t.Fatalf("rotated secret = %q", rotated.HMACSecret)
+	}
+	if len(rotated.PreviousSecrets) != 1 || rotated.PreviousSecrets[0].Secret != "whsec_first" {
+		t.Fatalf("previous secrets = %+v, want whsec_first", rotated.PreviousSecrets)
+	}
+	if got := rotated.SigningSecrets(); len(got) != 2 || got[0] != rotated.HMACSecret || got[1] != "whsec_first" {
+		t.Errorf("SigningSecrets() = %v, want the new secret then the old one", got)
+	}
+	if signed, n := send(rotated.HMACSecret, "whsec_first"); !signed[rotated.HMACSecret] || !signed["whsec_first"] || n != 2 {
+		t.Errorf("during the grace period: signed %v with %d signatures, want both secrets", signed, n)
+	}
+
+	// The old secret stops signing once it expires
+	time.Sleep(time.Until(rotated.PreviousSecrets[0].ExpiresAt) + 50*time.Millisecond)
+	current, err := env.webhooks.GetByID(ctx, wh.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(current.PreviousSecrets) != 0 {
+		t.Errorf("after the grace period the previous secrets are %+v", current.PreviousSecrets)
+	}
+	if signed, n := send(rotated.HMACSecret, "whsec_first"); !signed[rotated.HMACSecret] || signed["whsec_first"] || n != 1 {
+		t.Errorf("after the grace period: signed %v with %d signatures, want only the new secret", signed, n)
+	}
+}
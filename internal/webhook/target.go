@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// ErrPermanent marks target errors that another attempt would not fix, such
+// as a recipient rejected by the receiving mail server. Such deliveries are
+// failed without retrying.
+var ErrPermanent = errors.New("permanent failure")
+
+func permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// Message is what a target delivers for one job.
+type Message struct {
+	Event string
+	// DeliveryID is the same for every attempt at delivering the event.
+	DeliveryID string
+	// Payload is the JSON payload, or the rendered body template.
+	Payload []byte
+	// Body is the payload encoded for an HTTP request. It is only built
+	// for HTTP targets.
+	Body *Body
+	// Raw is the original message of email.received events, nil for other
+	// events or when it could not be read.
+	Raw []byte
+}
+
+// Target delivers messages somewhere. A returned Response is recorded with
+// the delivery; non-HTTP targets leave its StatusCode at 0 and describe the
+// outcome in Body. Errors are retried unless they wrap ErrPermanent or
+// ErrBlockedAddress.
+type Target interface {
+	Deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error)
+	// Validate checks the target settings of a webhook before it is saved.
+	Validate(webhook *domain.Webhook) error
+}
+
+// httpTarget sends the webhook request with SendWebhook.
+type httpTarget struct {
+	keys *SigningKeys
+}
+
+func (t *httpTarget) Deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error) {
+	return SendWebhook(ctx, webhook, msg.Event, msg.DeliveryID, msg.Body, t.keys, time.Duration(webhook.TimeoutSec)*time.Second)
+}
+
+func (t *httpTarget) Validate(webhook *domain.Webhook) error {
+	if webhook.URL == "" {
+		return errors.New("URL is required")
+	}
+	return validateURL(webhook.URL)
+}
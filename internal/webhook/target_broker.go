@@ -0,0 +1,294 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// BrokerMessage is what the broker target publishes: the payload, with the
+// event and delivery ID as headers.
+type BrokerMessage struct {
+	Topic   string
+	Headers map[string]string
+	Body    []byte
+}
+
+// BrokerDriver publishes to one kind of message broker. url is the
+// webhook's broker URL.
+type BrokerDriver interface {
+	Publish(ctx context.Context, url string, msg *BrokerMessage) error
+}
+
+// DefaultBrokerDrivers returns the drivers built into Mailgress, by name.
+func DefaultBrokerDrivers() map[string]BrokerDriver {
+	return map[string]BrokerDriver{
+		"nats":  natsDriver{},
+		"redis": redisDriver{},
+	}
+}
+
+// brokerTarget publishes the payload through one of its drivers, picked by
+// the webhook's broker driver name.
+type brokerTarget struct {
+	drivers map[string]BrokerDriver
+}
+
+func (t *brokerTarget) Deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error) {
+	config := webhook.TargetConfig
+	driver, ok := t.drivers[config.BrokerDriver]
+	if !ok {
+		return nil, permanent(fmt.Errorf("unknown broker driver %q", config.BrokerDriver))
+	}
+
+	err := driver.Publish(ctx, config.BrokerURL, &BrokerMessage{
+		Topic: config.BrokerTopic,
+		Headers: map[string]string{
+			"Content-Type":            "application/json",
+			"X-Mailgress-Event":       msg.Event,
+			"X-Mailgress-Delivery-Id": msg.DeliveryID,
+		},
+		Body: msg.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Body: fmt.Sprintf("Published to %s", config.BrokerTopic)}, nil
+}
+
+func (t *brokerTarget) Validate(webhook *domain.Webhook) error {
+	config := webhook.TargetConfig
+	if _, ok := t.drivers[config.BrokerDriver]; !ok {
+		return fmt.Errorf("unknown broker driver %q", config.BrokerDriver)
+	}
+	if config.BrokerTopic == "" {
+		return errors.New("broker topic is required")
+	}
+	switch config.BrokerDriver {
+	case "nats":
+		if !natsSubjectValid(config.BrokerTopic) {
+			return errors.New("NATS subjects cannot contain whitespace or wildcards")
+		}
+		fallthrough
+	case "redis":
+		if _, err := parseBrokerURL(config.BrokerURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseBrokerURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, errors.New("broker URL has no host")
+	}
+	return u, nil
+}
+
+// dialBroker connects to a broker through the guarded dialer, so internal
+// brokers need to be allowlisted like any other destination.
+func dialBroker(ctx context.Context, u *url.URL, defaultPort string, useTLS bool) (net.Conn, error) {
+	addr := withDefaultPort(u.Host, defaultPort)
+	dial := guardedDialer(&net.Dialer{Timeout: 30 * time.Second})
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+	return conn, nil
+}
+
+// redisDriver pushes the payload onto the list named by the topic with
+// RPUSH, for workers popping it with BLPOP. URLs are
+// redis://[user:password@]host[:port][/db], or rediss:// for TLS.
+type redisDriver struct{}
+
+func (redisDriver) Publish(ctx context.Context, rawURL string, msg *BrokerMessage) error {
+	u, err := parseBrokerURL(rawURL)
+	if err != nil {
+		return permanent(err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return permanent(errors.New("redis URL must use redis or rediss"))
+	}
+
+	conn, err := dialBroker(ctx, u, "6379", u.Scheme == "rediss")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		args := []string{"AUTH", password}
+		if name := u.User.Username(); name != "" {
+			args = []string{"AUTH", name, password}
+		}
+		if err := redisCommand(conn, r, args...); err != nil {
+			return permanent(err)
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if err := redisCommand(conn, r, "SELECT", db); err != nil {
+			return permanent(err)
+		}
+	}
+	return redisCommand(conn, r, "RPUSH", msg.Topic, string(msg.Body))
+}
+
+// redisCommand sends a command and reads a simple reply.
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) error {
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(cmd.String())); err != nil {
+		return err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return fmt.Errorf("redis: %s", line[1:])
+	}
+	return nil
+}
+
+// natsDriver publishes the payload to the subject named by the topic, with
+// the headers when the server supports them. URLs are
+// nats://[user:password@|token@]host[:port], or tls:// for TLS.
+type natsDriver struct{}
+
+type natsInfo struct {
+	Headers     bool `json:"headers"`
+	TLSRequired bool `json:"tls_required"`
+}
+
+func (natsDriver) Publish(ctx context.Context, rawURL string, msg *BrokerMessage) error {
+	u, err := parseBrokerURL(rawURL)
+	if err != nil {
+		return permanent(err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return permanent(errors.New("NATS URL must use nats or tls"))
+	}
+
+	conn, err := dialBroker(ctx, u, "4222", false)
+	if err != nil {
+		return err
+	}
+	defer func() { conn.Close() }()
+	r := bufio.NewReader(conn)
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	var info natsInfo
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats: unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("nats: invalid INFO: %w", err)
+	}
+
+	if u.Scheme == "tls" || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "headers": info.Headers}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"] = u.User.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "CONNECT %s\r\n", connect)
+	if info.Headers {
+		var headers strings.Builder
+		headers.WriteString("NATS/1.0\r\n")
+		for _, key := range sortedHeaderKeys(msg.Headers) {
+			fmt.Fprintf(&headers, "%s: %s\r\n", key, msg.Headers[key])
+		}
+		// Lets JetStream drop duplicates of retried deliveries
+		fmt.Fprintf(&headers, "Nats-Msg-Id: %s\r\n\r\n", msg.Headers["X-Mailgress-Delivery-Id"])
+		fmt.Fprintf(&out, "HPUB %s %d %d\r\n%s%s\r\n", msg.Topic, headers.Len(), headers.Len()+len(msg.Body), headers.String(), msg.Body)
+	} else {
+		fmt.Fprintf(&out, "PUB %s %d\r\n%s\r\n", msg.Topic, len(msg.Body), msg.Body)
+	}
+	out.WriteString("PING\r\n")
+	if _, err := conn.Write([]byte(out.String())); err != nil {
+		return err
+	}
+
+	// The PONG confirms the server processed everything sent before it
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case line == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		}
+	}
+}
+
+func sortedHeaderKeys(headers map[string]string) []string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// natsSubjectValid reports whether a subject can be published to: no
+// whitespace and no wildcards.
+func natsSubjectValid(subject string) bool {
+	return subject != "" && !strings.ContainsAny(subject, " \t\r\n*>")
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jr-k/mailgress/internal/config"
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// memoryBroker is a broker driver that keeps what is published.
+type memoryBroker struct {
+	mu       sync.Mutex
+	messages []BrokerMessage
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, url string, msg *BrokerMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages published so far.
+func (b *memoryBroker) Messages() []BrokerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]BrokerMessage(nil), b.messages...)
+}
+
+func TestBrokerDrivers(t *testing.T) {
+	env := newTestEnv(t, &config.Config{})
+	if got := env.dispatcher.BrokerDrivers(); !reflect.DeepEqual(got, []string{"memory", "nats", "redis"}) {
+		t.Errorf("BrokerDrivers() = %v, want the injected drivers", got)
+	}
+
+	for driver, ok := range map[string]bool{"memory": true, "redis": true, "kafka": false} {
+		err := env.dispatcher.ValidateTarget(&domain.Webhook{
+			Target: domain.WebhookTargetBroker,
+			TargetConfig: domain.WebhookTargetConfig{
+				BrokerDriver: driver,
+				BrokerURL:    "redis://redis.example.com:6379",
+				BrokerTopic:  "mail",
+			},
+		})
+		if (err == nil) != ok {
+			t.Errorf("ValidateTarget with the %s driver = %v, want ok %v", driver, err, ok)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// directoryTarget writes each delivery as <delivery id>.eml and
+// <delivery id>.json for batch jobs to pick up. Files are renamed into
+// place, and the .json file is written last, so a job that waits for it
+// never sees a partial delivery. Retries overwrite the same files.
+type directoryTarget struct {
+	root string
+}
+
+func (t *directoryTarget) Deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error) {
+	dir, err := t.dir(webhook.TargetConfig.Directory)
+	if err != nil {
+		return nil, permanent(err)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var written []string
+	if msg.Raw != nil {
+		name := msg.DeliveryID + ".eml"
+		if err := writeFileAtomic(filepath.Join(dir, name), msg.Raw); err != nil {
+			return nil, err
+		}
+		written = append(written, name)
+	}
+	name := msg.DeliveryID + ".json"
+	if err := writeFileAtomic(filepath.Join(dir, name), msg.Payload); err != nil {
+		return nil, err
+	}
+	written = append(written, name)
+
+	return &Response{Body: fmt.Sprintf("Wrote %s to %s", strings.Join(written, " and "), dir)}, nil
+}
+
+func (t *directoryTarget) Validate(webhook *domain.Webhook) error {
+	_, err := t.dir(webhook.TargetConfig.Directory)
+	return err
+}
+
+// dir resolves a webhook's directory, which must stay inside the root.
+func (t *directoryTarget) dir(sub string) (string, error) {
+	if t.root == "" {
+		return "", errors.New("directory targets are disabled, WEBHOOK_DIRECTORY_ROOT is not set")
+	}
+	if sub == "" {
+		return t.root, nil
+	}
+	if !filepath.IsLocal(sub) {
+		return "", fmt.Errorf("directory %q must be a relative path inside the directory root", sub)
+	}
+	return filepath.Join(t.root, sub), nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,239 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// smtpTarget re-sends the original message to other mailboxes, so a mailbox
+// can forward to a real one. Other events are sent as a short message
+// carrying the JSON payload.
+type smtpTarget struct {
+	hostname string
+	from     string
+}
+
+func (t *smtpTarget) Deliver(ctx context.Context, webhook *domain.Webhook, msg *Message) (*Response, error) {
+	config := webhook.TargetConfig
+	if len(config.ForwardTo) == 0 {
+		return nil, permanent(errors.New("no forwarding address"))
+	}
+
+	data := msg.Raw
+	if data == nil {
+		data = t.eventMessage(config.ForwardTo, msg)
+	}
+	data = append(forwardHeaders(msg), data...)
+
+	if config.SMTPServer != "" {
+		host, err := t.send(ctx, withDefaultPort(config.SMTPServer, "25"), config.ForwardTo, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Body: "Accepted by " + host}, nil
+	}
+
+	// Without a server each recipient domain is delivered to its own MX.
+	// A failure fails the delivery, so a retry may repeat the message for
+	// domains that had accepted it.
+	byDomain := recipientsByDomain(config.ForwardTo)
+	var accepted []string
+	for _, domainName := range sortedKeys(byDomain) {
+		host, err := t.sendToMX(ctx, domainName, byDomain[domainName], data)
+		if err != nil {
+			return nil, err
+		}
+		accepted = append(accepted, host)
+	}
+	return &Response{Body: "Accepted by " + strings.Join(accepted, ", ")}, nil
+}
+
+func (t *smtpTarget) Validate(webhook *domain.Webhook) error {
+	config := webhook.TargetConfig
+	if len(config.ForwardTo) == 0 {
+		return errors.New("at least one forwarding address is required")
+	}
+	for _, addr := range config.ForwardTo {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil || parsed.Address != addr {
+			return fmt.Errorf("invalid forwarding address %q", addr)
+		}
+	}
+	if config.SMTPServer != "" {
+		if _, _, err := net.SplitHostPort(withDefaultPort(config.SMTPServer, "25")); err != nil {
+			return fmt.Errorf("invalid SMTP server: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendToMX tries the MX hosts of a domain in preference order until one
+// accepts the message. Permanent rejections are not retried on other hosts.
+func (t *smtpTarget) sendToMX(ctx context.Context, domainName string, rcpts []string, data []byte) (string, error) {
+	hosts, err := lookupMX(ctx, domainName)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for _, host := range hosts {
+		accepted, err := t.send(ctx, net.JoinHostPort(host, "25"), rcpts, data)
+		if err == nil {
+			return accepted, nil
+		}
+		if errors.Is(err, ErrPermanent) {
+			return "", err
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// lookupMX returns the mail hosts of a domain, falling back to the domain
+// itself when it has no MX records.
+func lookupMX(ctx context.Context, domainName string) ([]string, error) {
+	records, err := net.DefaultResolver.LookupMX(ctx, domainName)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return []string{domainName}, nil
+		}
+		return nil, fmt.Errorf("failed to look up MX of %s: %w", domainName, err)
+	}
+	if len(records) == 1 && records[0].Host == "." {
+		return nil, permanent(fmt.Errorf("%s does not accept mail", domainName))
+	}
+
+	hosts := make([]string, len(records))
+	for i, mx := range records {
+		hosts[i] = strings.TrimSuffix(mx.Host, ".")
+	}
+	return hosts, nil
+}
+
+// send hands the message to one server. Connections go through the guarded
+// dialer, so internal mail servers need to be allowlisted. STARTTLS is used
+// when offered, without verifying the certificate, as mail servers do
+// between each other.
+func (t *smtpTarget) send(ctx context.Context, addr string, rcpts []string, data []byte) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", permanent(fmt.Errorf("invalid SMTP server: %w", err))
+	}
+
+	dial := guardedDialer(&net.Dialer{Timeout: 30 * time.Second})
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return "", smtpError(host, err)
+	}
+	defer client.Close()
+
+	if err := client.Hello(t.hostname); err != nil {
+		return "", smtpError(host, err)
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host, InsecureSkipVerify: true}); err != nil {
+			return "", smtpError(host, err)
+		}
+	}
+	if err := client.Mail(t.from); err != nil {
+		return "", smtpError(host, err)
+	}
+	for _, rcpt := range rcpts {
+		if err := client.Rcpt(rcpt); err != nil {
+			return "", smtpError(host, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return "", smtpError(host, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", smtpError(host, err)
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpError(host, err)
+	}
+	client.Quit()
+
+	return host, nil
+}
+
+// smtpError marks 5xx replies as permanent.
+func smtpError(host string, err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return permanent(fmt.Errorf("%s: %w", host, err))
+	}
+	return fmt.Errorf("%s: %w", host, err)
+}
+
+// eventMessage is the message sent for events without an original message.
+func (t *smtpTarget) eventMessage(to []string, msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", t.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Mailgress "+msg.Event))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(string(msg.Payload), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// forwardHeaders are prepended to forwarded messages, like the headers of
+// HTTP deliveries.
+func forwardHeaders(msg *Message) []byte {
+	return []byte(fmt.Sprintf("X-Mailgress-Event: %s\r\nX-Mailgress-Delivery-Id: %s\r\n", msg.Event, msg.DeliveryID))
+}
+
+func recipientsByDomain(addrs []string) map[string][]string {
+	byDomain := make(map[string][]string)
+	for _, addr := range addrs {
+		at := strings.LastIndex(addr, "@")
+		domainName := strings.ToLower(addr[at+1:])
+		byDomain[domainName] = append(byDomain[domainName], addr)
+	}
+	return byDomain
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func withDefaultPort(addr, port string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(addr, port)
+}
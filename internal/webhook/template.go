@@ -0,0 +1,251 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// PayloadTypeTemplate renders the whole request body from the webhook's
+// custom payload, a text/template, instead of sending the JSON payload.
+const PayloadTypeTemplate = "template"
+
+const (
+	maxTemplateSize = 64 * 1024
+	maxRenderedSize = 1024 * 1024
+)
+
+var errRenderedTooLarge = fmt.Errorf("rendered body is larger than %d bytes", maxRenderedSize)
+
+// TemplateData is what a body template is executed against. Only the fields
+// that concern the event are set; storage paths and webhook secrets are
+// never exposed.
+type TemplateData struct {
+	Event       string
+	Timestamp   time.Time
+	Email       *domain.Email
+	Headers     map[string]string
+	Attachments []domain.Attachment
+	Mailbox     *domain.Mailbox
+	Domain      *domain.Domain
+	Delivery    *domain.WebhookDelivery
+	Retention   *domain.RetentionPurge
+}
+
+// NewTemplateData copies the event into template data, leaving out
+// internal fields.
+func NewTemplateData(event domain.Event) *TemplateData {
+	data := &TemplateData{
+		Event:     event.Type,
+		Timestamp: event.OccurredAt.UTC(),
+		Domain:    event.Domain,
+		Retention: event.Retention,
+	}
+
+	if event.Email != nil {
+		email := *event.Email
+		email.RawPath = ""
+		email.Attachments = make([]domain.Attachment, len(event.Email.Attachments))
+		for i, att := range event.Email.Attachments {
+			att.StoragePath = ""
+			email.Attachments[i] = att
+		}
+		data.Email = &email
+		data.Headers = email.Headers
+		data.Attachments = email.Attachments
+	}
+
+	if event.Mailbox != nil {
+		mailbox := *event.Mailbox
+		mailbox.Owner = nil
+		data.Mailbox = &mailbox
+	}
+
+	if event.Delivery != nil {
+		delivery := *event.Delivery
+		delivery.Email = nil
+		delivery.RequestBody = ""
+		if event.Delivery.Webhook != nil {
+			delivery.Webhook = &domain.Webhook{
+				ID:        event.Delivery.Webhook.ID,
+				MailboxID: event.Delivery.Webhook.MailboxID,
+				Name:      event.Delivery.Webhook.Name,
+			}
+		}
+		data.Delivery = &delivery
+	}
+
+	return data
+}
+
+var templateFuncs = template.FuncMap{
+	"json":       templateJSON,
+	"truncate":   templateTruncate,
+	"regexFind":  templateRegexFind,
+	"regexMatch": templateRegexMatch,
+	"date":       templateDate,
+	"header":     templateHeader,
+	"default":    templateDefault,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"replace":    strings.ReplaceAll,
+	"join":       strings.Join,
+}
+
+// ParseTemplate parses a body template. Templates only see TemplateData and
+// the helpers above, and missing map keys render as empty values.
+func ParseTemplate(src string) (*template.Template, error) {
+	if len(src) > maxTemplateSize {
+		return nil, fmt.Errorf("template is larger than %d bytes", maxTemplateSize)
+	}
+	return template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(src)
+}
+
+// RenderTemplate executes a body template. Output beyond maxRenderedSize
+// aborts the execution.
+func RenderTemplate(src string, data *TemplateData) ([]byte, error) {
+	tmpl, err := ParseTemplate(src)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &limitedBuffer{max: maxRenderedSize}
+	if err := tmpl.Execute(out, data); err != nil {
+		if errors.Is(err, errRenderedTooLarge) {
+			return nil, errRenderedTooLarge
+		}
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// validateTemplate checks the body template of a webhook before it is saved.
+func validateTemplate(payloadType, customPayload string) error {
+	if payloadType != PayloadTypeTemplate {
+		return nil
+	}
+	if strings.TrimSpace(customPayload) == "" {
+		return errors.New("body template is empty")
+	}
+	if _, err := ParseTemplate(customPayload); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	return nil
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, errRenderedTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// templateJSON encodes v as a JSON value, so strings come out quoted and
+// escaped: {"text": {{json .Email.Subject}}}.
+func templateJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// templateTruncate shortens s to at most n characters, ending in "..." when
+// anything was cut.
+func templateTruncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// templateRegexFind returns the first capture group of the first match, or
+// the whole match when the pattern has no groups.
+func templateRegexFind(pattern, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return "", nil
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+func templateRegexMatch(pattern, s string) (bool, error) {
+	return regexp.MatchString(pattern, s)
+}
+
+// templateDate formats a time with a Go layout; "rfc3339" and "unix" are
+// accepted as shorthands. Nil times render as an empty string.
+func templateDate(layout string, v interface{}) (string, error) {
+	var t time.Time
+	switch tv := v.(type) {
+	case time.Time:
+		t = tv
+	case *time.Time:
+		if tv == nil {
+			return "", nil
+		}
+		t = *tv
+	default:
+		return "", fmt.Errorf("date: unsupported value of type %T", v)
+	}
+
+	switch layout {
+	case "rfc3339":
+		return t.UTC().Format(time.RFC3339), nil
+	case "unix":
+		return fmt.Sprintf("%d", t.Unix()), nil
+	}
+	return t.Format(layout), nil
+}
+
+// templateHeader looks a header up case-insensitively.
+func templateHeader(headers map[string]string, name string) string {
+	if v, ok := headers[name]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// templateDefault returns fallback when value is empty: {{default "n/a" .Email.Subject}}.
+func templateDefault(fallback string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return fallback
+	case string:
+		if v == "" {
+			return fallback
+		}
+	}
+	return value
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jr-k/mailgress/internal/domain"
+)
+
+// maxRedirects is how many redirects a webhook request follows at most.
+const maxRedirects = 5
+
+// maxCachedTransports bounds the transport cache. Past it the cache is
+// emptied, which only costs the idle connections of the dropped transports.
+const maxCachedTransports = 256
+
+// transportKey is the combination of settings a transport is built from.
+// Webhooks with the same settings share a transport and its connections.
+type transportKey struct {
+	clientCert string
+	clientKey  string
+	caBundle   string
+	skipVerify bool
+	proxyURL   string
+}
+
+func newTransportKey(webhook *domain.Webhook) transportKey {
+	return transportKey{
+		clientCert: webhook.TLSClientCert,
+		clientKey:  webhook.TLSClientKey,
+		caBundle:   webhook.TLSCABundle,
+		skipVerify: webhook.TLSSkipVerify,
+		proxyURL:   webhook.ProxyURL,
+	}
+}
+
+var transports = struct {
+	sync.Mutex
+	cache map[transportKey]*http.Transport
+}{cache: make(map[transportKey]*http.Transport)}
+
+// clientFor returns an HTTP client using the cached transport for the
+// webhook's settings, building the transport on first use.
+func clientFor(webhook *domain.Webhook) (*http.Client, error) {
+	key := newTransportKey(webhook)
+
+	transports.Lock()
+	defer transports.Unlock()
+
+	transport, ok := transports.cache[key]
+	if !ok {
+		var err error
+		if transport, err = newTransport(key); err != nil {
+			return nil, err
+		}
+		if len(transports.cache) >= maxCachedTransports {
+			for k, t := range transports.cache {
+				t.CloseIdleConnections()
+				delete(transports.cache, k)
+			}
+		}
+		transports.cache[key] = transport
+		if key.skipVerify {
+			log.Printf("Warning: webhook %d does not verify TLS certificates of %s", webhook.ID, webhook.URL)
+		}
+	}
+
+	return &http.Client{
+		Timeout:       60 * time.Second,
+		Transport:     transport,
+		CheckRedirect: redirectPolicy(webhook),
+	}, nil
+}
+
+// redirectPolicy applies the webhook's redirect policy. Followed redirects
+// go through the guarded dialer again; proxied ones are checked here since
+// the dialer only sees the proxy.
+func redirectPolicy(webhook *domain.Webhook) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		switch webhook.RedirectPolicy {
+		case domain.WebhookRedirectNone:
+			return http.ErrUseLastResponse
+		case domain.WebhookRedirectSameHost:
+			if !strings.EqualFold(req.URL.Hostname(), via[0].URL.Hostname()) {
+				return fmt.Errorf("redirect to %s refused, the webhook only follows redirects to %s", req.URL.Hostname(), via[0].URL.Hostname())
+			}
+		}
+
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		switch req.URL.Scheme {
+		case "http", "https":
+		default:
+			return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+		}
+		if webhook.ProxyURL != "" {
+			return checkTarget(req.Context(), req.URL)
+		}
+		return nil
+	}
+}
+
+func newTransport(key transportKey) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		DialContext:         guardedDialer(dialer),
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+
+	tlsConfig, err := buildTLSConfig(key.clientCert, key.clientKey, key.caBundle, key.skipVerify)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+		// A custom TLS config turns HTTP/2 off unless asked for
+		transport.ForceAttemptHTTP2 = true
+	}
+
+	if key.proxyURL != "" {
+		proxy, err := parseProxyURL(key.proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return transport, nil
+}
+
+// buildTLSConfig returns nil when the webhook uses the default TLS settings.
+func buildTLSConfig(clientCert, clientKey, caBundle string, skipVerify bool) (*tls.Config, error) {
+	if clientCert == "" && clientKey == "" && caBundle == "" && !skipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: skipVerify}
+
+	if clientCert != "" || clientKey != "" {
+		if clientCert == "" || clientKey == "" {
+			return nil, errors.New("client certificate and key must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caBundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, errors.New("CA bundle contains no PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch strings.ToLower(proxy.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.New("proxy URL must use http, https, socks5 or socks5h")
+	}
+	if proxy.Host == "" {
+		return nil, errors.New("proxy URL has no host")
+	}
+	return proxy, nil
+}
+
+// ValidateTransport checks the transport settings of a webhook before it is
+// saved.
+func ValidateTransport(clientCert, clientKey, caBundle, proxyURL string) error {
+	clientCert, clientKey = strings.TrimSpace(clientCert), strings.TrimSpace(clientKey)
+	caBundle, proxyURL = strings.TrimSpace(caBundle), strings.TrimSpace(proxyURL)
+
+	if _, err := buildTLSConfig(clientCert, clientKey, caBundle, false); err != nil {
+		return err
+	}
+	if proxyURL != "" {
+		proxy, err := parseProxyURL(proxyURL)
+		if err != nil {
+			return err
+		}
+		if err := validateHost("proxy URL", proxy.Hostname()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+import { useState } from 'react';
+import { Button } from '@/components/Button';
+import * as S from './styled';
+
+export const DEFAULT_BODY_TEMPLATE = `{
+  "text": {{json (printf "New mail from %s: %s" .Email.FromAddress .Email.Subject)}},
+  "preview": {{json (truncate 200 .Email.TextBody)}},
+  "received_at": "{{date "rfc3339" .Email.ReceivedAt}}"
+}`;
+
+interface TemplateEditorProps {
+  mailboxId: number;
+  value: string;
+  onChange: (value: string) => void;
+}
+
+interface PreviewResult {
+  loading: boolean;
+  body?: string;
+  email_id?: number | null;
+  error?: string;
+}
+
+export const TemplateEditor = ({ mailboxId, value, onChange }: TemplateEditorProps) => {
+  const [preview, setPreview] = useState<PreviewResult | null>(null);
+
+  const handlePreview = async () => {
+    setPreview({ loading: true });
+    try {
+      const response = await fetch(`/mailboxes/${mailboxId}/webhooks/preview`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ template: value }),
+      });
+      const data = await response.json();
+      setPreview({ loading: false, ...data });
+    } catch (error) {
+      setPreview({ loading: false, error: 'Failed to render preview' });
+    }
+  };
+
+  return (
+    <div>
+      <S.Editor value={value} onChange={(e) => onChange(e.target.value)} spellCheck={false} />
+      <S.HelperText>
+        The whole request body is rendered from this Go template. Available fields: <code>.Event</code>,{' '}
+        <code>.Timestamp</code>, <code>.Email</code>, <code>.Headers</code>, <code>.Attachments</code>,{' '}
+        <code>.Mailbox</code>, <code>.Domain</code>, <code>.Delivery</code> and <code>.Retention</code>. Helpers:{' '}
+        <code>json</code>, <code>truncate</code>, <code>regexFind</code>, <code>regexMatch</code>, <code>date</code>,{' '}
+        <code>header</code>, <code>default</code>, <code>lower</code>, <code>upper</code>, <code>trim</code>,{' '}
+        <code>replace</code> and <code>join</code>.
+      </S.HelperText>
+
+      <S.PreviewBar>
+        <Button type="button" size="sm" variant="secondary" onClick={handlePreview} disabled={preview?.loading}>
+          {preview?.loading ? 'Rendering...' : 'Preview'}
+        </Button>
+        {preview && !preview.loading && (
+          <S.PreviewSource>
+            {preview.email_id ? `Rendered with email #${preview.email_id}` : 'Rendered with a sample email'}
+          </S.PreviewSource>
+        )}
+      </S.PreviewBar>
+
+      {preview?.error && <S.PreviewError>{preview.error}</S.PreviewError>}
+      {preview && !preview.loading && !preview.error && <S.PreviewBody>{preview.body}</S.PreviewBody>}
+    </div>
+  );
+};
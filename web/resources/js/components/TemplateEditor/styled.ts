@@ -0,0 +1,56 @@
+import styled from 'styled-components';
+
+export const Editor = styled.textarea`
+  width: 100%;
+  min-height: 200px;
+  padding: ${({ theme }) => theme.spacing[3]};
+  font-family: 'Fira Code', 'Roboto Mono', monospace;
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.primary};
+  background-color: ${({ theme }) => theme.colors.surface.secondary};
+  border: 1px solid ${({ theme }) => theme.colors.border.secondary};
+  border-radius: ${({ theme }) => theme.radii.md};
+  resize: vertical;
+  line-height: 1.5;
+`;
+
+export const HelperText = styled.p`
+  margin-top: ${({ theme }) => theme.spacing[1]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+
+  code {
+    font-family: 'Fira Code', 'Roboto Mono', monospace;
+  }
+`;
+
+export const PreviewBar = styled.div`
+  display: flex;
+  align-items: center;
+  gap: ${({ theme }) => theme.spacing[3]};
+  margin-top: ${({ theme }) => theme.spacing[3]};
+`;
+
+export const PreviewSource = styled.span`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const PreviewBody = styled.pre`
+  margin-top: ${({ theme }) => theme.spacing[3]};
+  padding: ${({ theme }) => theme.spacing[3]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.secondary};
+  background-color: ${({ theme }) => theme.colors.surface.secondary};
+  border-radius: ${({ theme }) => theme.radii.md};
+  white-space: pre-wrap;
+  word-break: break-all;
+  overflow: auto;
+  max-height: 20rem;
+`;
+
+export const PreviewError = styled.p`
+  margin-top: ${({ theme }) => theme.spacing[3]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.red[600]};
+`;
@@ -82,6 +82,9 @@ export default function AppLayout({ children }: PropsWithChildren) {
               <S.NavLink as={Link} href="/mailboxes" $active={isActive('/mailboxes')}>
                 Mailboxes
               </S.NavLink>
+              <S.NavLink as={Link} href="/search" $active={isActive('/search')}>
+                Search
+              </S.NavLink>
               {auth?.user.is_admin && (
                 <S.DropdownContainer ref={dropdownRef}>
                   <S.DropdownTrigger
@@ -23,6 +23,11 @@ export default function DomainLayout({ children, domain, allDomains = [] }: Doma
       isActive: (currentUrl: string) => currentUrl.includes('/mailboxes'),
       count: domain.mailbox_count,
     },
+    {
+      label: 'Routing',
+      href: `/domains/${domain.id}/routing`,
+      isActive: (currentUrl: string) => currentUrl.endsWith('/routing'),
+    },
     {
       label: 'Settings',
       href: `/domains/${domain.id}/edit`,
@@ -25,6 +25,7 @@ export default function DomainsEdit({ domain, allDomains, allTags, domainTags, e
     name: domain.name,
     is_verified: domain.is_verified,
     is_active: domain.is_active,
+    require_tls: domain.require_tls,
   });
 
   const handleSubmit = async (e: React.FormEvent) => {
@@ -111,6 +112,21 @@ export default function DomainsEdit({ domain, allDomains, allTags, domainTags, e
                 </S.HelpText>
               </div>
 
+              <div>
+                <S.CheckboxWrapper>
+                  <Checkbox
+                    id="require_tls"
+                    type="checkbox"
+                    checked={data.require_tls}
+                    onChange={(e) => setData('require_tls', e.target.checked)}
+                  />
+                  <S.CheckboxText>Require TLS</S.CheckboxText>
+                </S.CheckboxWrapper>
+                <S.HelpText>
+                  Reject senders that have not issued STARTTLS (or connected over implicit TLS).
+                </S.HelpText>
+              </div>
+
               <S.FormActions>
                 <Button type="submit" disabled={processing || tagsSaving}>
                   {processing || tagsSaving ? 'Saving...' : 'Save Changes'}
@@ -0,0 +1,183 @@
+import { useState } from 'react';
+import { router } from '@inertiajs/react';
+import DomainLayout from '@/layouts/DomainLayout';
+import { Card } from '@/components/Card';
+import { Badge } from '@/components/Badge';
+import { Button } from '@/components/Button';
+import { Input, Select } from '@/components/Input';
+import { Domain, DomainRoute, PageProps } from '@/types';
+import * as S from './styled';
+
+interface MailboxOption {
+  id: number;
+  address: string;
+}
+
+interface Props extends PageProps {
+  domain: Domain;
+  allDomains: Domain[];
+  routes: DomainRoute[];
+  mailboxes: MailboxOption[];
+}
+
+export default function DomainsRouting({ domain, allDomains, routes, mailboxes }: Props) {
+  const [catchAll, setCatchAll] = useState(domain.catch_all_mailbox_id?.toString() ?? '');
+  const [patternType, setPatternType] = useState<'glob' | 'regex'>('glob');
+  const [pattern, setPattern] = useState('');
+  const [mailboxId, setMailboxId] = useState(mailboxes[0]?.id.toString() ?? '');
+  const [processing, setProcessing] = useState(false);
+
+  const mailboxAddress = (id: number) =>
+    mailboxes.find((m) => m.id === id)?.address ?? `#${id}`;
+
+  const handleCatchAll = (e: React.FormEvent) => {
+    e.preventDefault();
+    router.put(
+      `/domains/${domain.id}/catch-all`,
+      { mailbox_id: catchAll ? parseInt(catchAll, 10) : null },
+      { preserveScroll: true }
+    );
+  };
+
+  const handleAddRoute = (e: React.FormEvent) => {
+    e.preventDefault();
+    setProcessing(true);
+    router.post(
+      `/domains/${domain.id}/routes`,
+      { pattern_type: patternType, pattern, mailbox_id: parseInt(mailboxId, 10) },
+      {
+        preserveScroll: true,
+        onSuccess: () => setPattern(''),
+        onFinish: () => setProcessing(false),
+      }
+    );
+  };
+
+  const moveRoute = (index: number, offset: number) => {
+    const ids = routes.map((r) => r.id);
+    const target = index + offset;
+    if (target < 0 || target >= ids.length) return;
+    [ids[index], ids[target]] = [ids[target], ids[index]];
+    router.put(`/domains/${domain.id}/routes/order`, { route_ids: ids }, { preserveScroll: true });
+  };
+
+  const deleteRoute = (route: DomainRoute) => {
+    if (!confirm(`Delete route "${route.pattern}"?`)) return;
+    router.delete(`/domains/${domain.id}/routes/${route.id}`, { preserveScroll: true });
+  };
+
+  return (
+    <DomainLayout domain={domain} allDomains={allDomains}>
+      <S.PageTitle>Routing</S.PageTitle>
+
+      <Card>
+        <S.Section>
+          <S.SectionTitle>Pattern routes</S.SectionTitle>
+          <S.SectionDescription>
+            Recipients that do not match a mailbox exactly are checked against these patterns,
+            top to bottom. Globs match the whole local part and support <code>*</code> and{' '}
+            <code>?</code>; regular expressions need <code>^</code> and <code>$</code> to be anchored.
+            Matching is case-insensitive.
+          </S.SectionDescription>
+
+          {routes.length === 0 ? (
+            <S.EmptyRoutes>No routes yet</S.EmptyRoutes>
+          ) : (
+            <S.RouteList>
+              {routes.map((route, index) => (
+                <S.RouteRow key={route.id}>
+                  <S.RoutePosition>{index + 1}</S.RoutePosition>
+                  <Badge variant={route.pattern_type === 'regex' ? 'info' : 'gray'}>
+                    {route.pattern_type}
+                  </Badge>
+                  <S.RoutePattern>
+                    {route.pattern}@{domain.name}
+                  </S.RoutePattern>
+                  <S.RouteTarget>&rarr; {mailboxAddress(route.mailbox_id)}</S.RouteTarget>
+                  <S.RouteActions>
+                    <Button
+                      type="button"
+                      variant="ghost"
+                      size="sm"
+                      onClick={() => moveRoute(index, -1)}
+                      disabled={index === 0}
+                    >
+                      &uarr;
+                    </Button>
+                    <Button
+                      type="button"
+                      variant="ghost"
+                      size="sm"
+                      onClick={() => moveRoute(index, 1)}
+                      disabled={index === routes.length - 1}
+                    >
+                      &darr;
+                    </Button>
+                    <Button
+                      type="button"
+                      variant="danger"
+                      size="sm"
+                      onClick={() => deleteRoute(route)}
+                    >
+                      Delete
+                    </Button>
+                  </S.RouteActions>
+                </S.RouteRow>
+              ))}
+            </S.RouteList>
+          )}
+
+          <S.InlineForm onSubmit={handleAddRoute}>
+            <Select
+              value={patternType}
+              onChange={(e) => setPatternType(e.target.value as 'glob' | 'regex')}
+              style={{ flex: '0 0 7rem' }}
+            >
+              <option value="glob">Glob</option>
+              <option value="regex">Regex</option>
+            </Select>
+            <Input
+              type="text"
+              value={pattern}
+              onChange={(e) => setPattern(e.target.value)}
+              placeholder={patternType === 'glob' ? 'customer-*' : '^order-[0-9]+$'}
+              required
+            />
+            <Select value={mailboxId} onChange={(e) => setMailboxId(e.target.value)} required>
+              {mailboxes.map((m) => (
+                <option key={m.id} value={m.id}>
+                  {m.address}
+                </option>
+              ))}
+            </Select>
+            <Button type="submit" disabled={processing || !pattern || !mailboxId}>
+              Add Route
+            </Button>
+          </S.InlineForm>
+        </S.Section>
+
+        <S.Divider />
+
+        <S.Section>
+          <S.SectionTitle>Catch-all</S.SectionTitle>
+          <S.SectionDescription>
+            Mail for any other address at {domain.name} is delivered to this mailbox. Without a
+            catch-all, unknown recipients are rejected.
+          </S.SectionDescription>
+
+          <S.InlineForm onSubmit={handleCatchAll}>
+            <Select value={catchAll} onChange={(e) => setCatchAll(e.target.value)}>
+              <option value="">Disabled</option>
+              {mailboxes.map((m) => (
+                <option key={m.id} value={m.id}>
+                  {m.address}
+                </option>
+              ))}
+            </Select>
+            <Button type="submit">Save</Button>
+          </S.InlineForm>
+        </S.Section>
+      </Card>
+    </DomainLayout>
+  );
+}
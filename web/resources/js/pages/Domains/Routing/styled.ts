@@ -0,0 +1,96 @@
+import styled from 'styled-components';
+
+export const PageTitle = styled.h1`
+  font-size: ${({ theme }) => theme.fontSizes['2xl']};
+  font-weight: ${({ theme }) => theme.fontWeights.bold};
+  color: ${({ theme }) => theme.colors.text.primary};
+  margin-bottom: ${({ theme }) => theme.spacing[6]};
+`;
+
+export const Section = styled.div`
+  padding: ${({ theme }) => theme.spacing[6]};
+`;
+
+export const SectionTitle = styled.h2`
+  font-size: ${({ theme }) => theme.fontSizes.lg};
+  font-weight: ${({ theme }) => theme.fontWeights.semibold};
+  color: ${({ theme }) => theme.colors.text.primary};
+  margin-bottom: ${({ theme }) => theme.spacing[2]};
+`;
+
+export const SectionDescription = styled.p`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.secondary};
+  margin-bottom: ${({ theme }) => theme.spacing[4]};
+`;
+
+export const InlineForm = styled.form`
+  display: flex;
+  align-items: center;
+  gap: ${({ theme }) => theme.spacing[3]};
+
+  select,
+  input {
+    flex: 1;
+  }
+`;
+
+export const RouteList = styled.div`
+  display: flex;
+  flex-direction: column;
+  margin-bottom: ${({ theme }) => theme.spacing[4]};
+  border: 1px solid ${({ theme }) => theme.colors.border.primary};
+  border-radius: ${({ theme }) => theme.radii.md};
+`;
+
+export const RouteRow = styled.div`
+  display: flex;
+  align-items: center;
+  gap: ${({ theme }) => theme.spacing[3]};
+  padding: ${({ theme }) => `${theme.spacing[3]} ${theme.spacing[4]}`};
+  border-bottom: 1px solid ${({ theme }) => theme.colors.border.primary};
+
+  &:last-child {
+    border-bottom: none;
+  }
+`;
+
+export const RoutePosition = styled.span`
+  width: 1.5rem;
+  font-size: ${({ theme }) => theme.fontSizes.xs};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const RoutePattern = styled.code`
+  flex: 1;
+  font-family: ${({ theme }) => theme.fonts.mono};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.primary};
+  word-break: break-all;
+`;
+
+export const RouteTarget = styled.span`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.secondary};
+`;
+
+export const RouteActions = styled.div`
+  display: flex;
+  gap: ${({ theme }) => theme.spacing[1]};
+`;
+
+export const EmptyRoutes = styled.p`
+  padding: ${({ theme }) => theme.spacing[4]};
+  margin-bottom: ${({ theme }) => theme.spacing[4]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+  border: 1px dashed ${({ theme }) => theme.colors.border.primary};
+  border-radius: ${({ theme }) => theme.radii.md};
+  text-align: center;
+`;
+
+export const Divider = styled.hr`
+  border: none;
+  border-top: 1px solid ${({ theme }) => theme.colors.border.primary};
+  margin: 0;
+`;
@@ -0,0 +1,117 @@
+import { useState } from 'react';
+import { Link, router } from '@inertiajs/react';
+import AppLayout from '@/layouts/AppLayout';
+import { Badge } from '@/components/Badge';
+import { Button } from '@/components/Button';
+import { Card } from '@/components/Card';
+import { Input, HelperText, ErrorText } from '@/components/Input';
+import { Email, Mailbox, Pagination, PageProps } from '@/types';
+import * as S from './styled';
+
+interface Props extends PageProps {
+  query: string;
+  searchError: string;
+  emails: Email[];
+  mailboxes: Mailbox[];
+  pagination: Pagination;
+}
+
+export default function EmailSearch({ query, searchError, emails, mailboxes, pagination }: Props) {
+  const [searchQuery, setSearchQuery] = useState(query || '');
+
+  const mailboxSlugs = new Map((mailboxes || []).map((mailbox) => [mailbox.id, mailbox.slug]));
+  const totalPages = Math.ceil(pagination.total / pagination.per_page);
+
+  const handleSearch = (e: React.FormEvent) => {
+    e.preventDefault();
+    router.get('/search', { q: searchQuery }, { preserveState: true });
+  };
+
+  const formatDate = (dateStr: string) => {
+    return new Date(dateStr).toLocaleString();
+  };
+
+  return (
+    <AppLayout>
+      <S.Header>
+        <S.Title>Search</S.Title>
+        <S.Subtitle>Search the emails of every mailbox you can access</S.Subtitle>
+      </S.Header>
+
+      <S.SearchForm onSubmit={handleSearch}>
+        <Input
+          type="text"
+          placeholder='from:acme subject:"invoice" has:attachment before:2026-01-01 -is:read'
+          value={searchQuery}
+          onChange={(e) => setSearchQuery(e.target.value)}
+          autoFocus
+        />
+        <Button type="submit">Search</Button>
+      </S.SearchForm>
+      {searchError ? (
+        <ErrorText>{searchError}</ErrorText>
+      ) : (
+        <HelperText>
+          Words match subject, sender, recipients, body and attachment names. Narrow down with from:, to:,
+          subject:, body:, filename:, mailbox:, has:attachment, is:read, is:unread, before: and after:
+          (YYYY-MM-DD). Prefix with - to exclude.
+        </HelperText>
+      )}
+
+      {query && !searchError && (
+        <S.Results>
+          <Card>
+            {emails.length === 0 ? (
+              <S.EmptyState>No emails found</S.EmptyState>
+            ) : (
+              emails.map((email) => (
+                <S.ResultItem
+                  key={email.id}
+                  as={Link}
+                  href={`/mailboxes/${email.mailbox_id}/emails/${email.id}`}
+                  $unread={!email.is_read}
+                >
+                  <S.ResultHeader>
+                    <S.ResultFrom>{email.from_address}</S.ResultFrom>
+                    <S.ResultDate>{formatDate(email.received_at)}</S.ResultDate>
+                  </S.ResultHeader>
+                  <S.ResultSubject>{email.subject || '(No subject)'}</S.ResultSubject>
+                  <S.ResultMeta>
+                    <Badge variant="info">{mailboxSlugs.get(email.mailbox_id) || `#${email.mailbox_id}`}</Badge>
+                    {email.has_attachments && <Badge variant="gray">Attachments</Badge>}
+                  </S.ResultMeta>
+                </S.ResultItem>
+              ))
+            )}
+          </Card>
+
+          {totalPages > 1 && (
+            <S.Pagination>
+              <S.PageInfo>
+                {pagination.total} results, page {pagination.current_page} of {totalPages}
+              </S.PageInfo>
+              <S.PageLinks>
+                {pagination.current_page > 1 && (
+                  <S.PageLink
+                    as={Link}
+                    href={`/search?q=${encodeURIComponent(query)}&page=${pagination.current_page - 1}`}
+                  >
+                    Previous
+                  </S.PageLink>
+                )}
+                {pagination.current_page < totalPages && (
+                  <S.PageLink
+                    as={Link}
+                    href={`/search?q=${encodeURIComponent(query)}&page=${pagination.current_page + 1}`}
+                  >
+                    Next
+                  </S.PageLink>
+                )}
+              </S.PageLinks>
+            </S.Pagination>
+          )}
+        </S.Results>
+      )}
+    </AppLayout>
+  );
+}
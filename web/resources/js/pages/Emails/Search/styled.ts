@@ -0,0 +1,111 @@
+import styled from 'styled-components';
+
+export const Header = styled.div`
+  margin-bottom: ${({ theme }) => theme.spacing[6]};
+`;
+
+export const Title = styled.h1`
+  font-size: ${({ theme }) => theme.fontSizes['2xl']};
+  font-weight: ${({ theme }) => theme.fontWeights.semibold};
+  color: ${({ theme }) => theme.colors.text.primary};
+  letter-spacing: -0.02em;
+`;
+
+export const Subtitle = styled.p`
+  margin-top: ${({ theme }) => theme.spacing[1]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const SearchForm = styled.form`
+  display: flex;
+  gap: ${({ theme }) => theme.spacing[2]};
+  margin-bottom: ${({ theme }) => theme.spacing[2]};
+`;
+
+export const Results = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[6]};
+`;
+
+export const ResultItem = styled.a<{ $unread?: boolean }>`
+  display: block;
+  padding: ${({ theme }) => `${theme.spacing[3]} ${theme.spacing[4]}`};
+  border-bottom: 1px solid ${({ theme }) => theme.colors.border.primary};
+  transition: background-color 0.15s ease;
+  font-weight: ${({ $unread, theme }) => ($unread ? theme.fontWeights.semibold : theme.fontWeights.normal)};
+
+  &:last-child {
+    border-bottom: none;
+  }
+
+  &:hover {
+    background-color: ${({ theme }) => theme.colors.surface.secondary};
+  }
+`;
+
+export const ResultHeader = styled.div`
+  display: flex;
+  justify-content: space-between;
+  gap: ${({ theme }) => theme.spacing[4]};
+`;
+
+export const ResultFrom = styled.span`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.primary};
+  overflow: hidden;
+  text-overflow: ellipsis;
+  white-space: nowrap;
+`;
+
+export const ResultDate = styled.span`
+  flex-shrink: 0;
+  font-size: ${({ theme }) => theme.fontSizes.xs};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const ResultSubject = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[1]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.secondary};
+  overflow: hidden;
+  text-overflow: ellipsis;
+  white-space: nowrap;
+`;
+
+export const ResultMeta = styled.div`
+  display: flex;
+  gap: ${({ theme }) => theme.spacing[2]};
+  margin-top: ${({ theme }) => theme.spacing[2]};
+`;
+
+export const EmptyState = styled.div`
+  padding: ${({ theme }) => theme.spacing[8]};
+  text-align: center;
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const Pagination = styled.div`
+  display: flex;
+  justify-content: space-between;
+  align-items: center;
+  margin-top: ${({ theme }) => theme.spacing[4]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+`;
+
+export const PageInfo = styled.span`
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const PageLinks = styled.div`
+  display: flex;
+  gap: ${({ theme }) => theme.spacing[4]};
+`;
+
+export const PageLink = styled.a`
+  color: ${({ theme }) => theme.colors.primary[600]};
+  transition: color 0.15s ease;
+
+  &:hover {
+    color: ${({ theme }) => theme.colors.primary[800]};
+  }
+`;
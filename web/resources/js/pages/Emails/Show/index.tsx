@@ -39,6 +39,11 @@ export default function EmailShow({ email, mailbox }: Props) {
               <S.MetaItem>
                 <S.MetaLabel>To:</S.MetaLabel> <S.MetaValue>{email.to_address}</S.MetaValue>
               </S.MetaItem>
+              {email.alias && (
+                <S.MetaItem>
+                  <S.MetaLabel>Via alias:</S.MetaLabel> <S.MetaValue>{email.alias}</S.MetaValue>
+                </S.MetaItem>
+              )}
               <S.MetaItem>
                 <S.MetaLabel>Date:</S.MetaLabel>{' '}
                 <S.MetaValue>
@@ -48,6 +53,33 @@ export default function EmailShow({ email, mailbox }: Props) {
               <S.MetaItem>
                 <S.MetaLabel>Size:</S.MetaLabel> <S.MetaValue>{formatSize(email.raw_size)}</S.MetaValue>
               </S.MetaItem>
+              {email.has_raw && (
+                <S.MetaItem>
+                  <S.MetaLabel>Original:</S.MetaLabel>{' '}
+                  <S.RawLink href={`/mailboxes/${mailbox.id}/emails/${email.id}/raw`}>
+                    Download .eml
+                  </S.RawLink>
+                </S.MetaItem>
+              )}
+              {email.auth?.spf && (
+                <S.MetaItem $fullWidth>
+                  <S.MetaLabel>Authentication:</S.MetaLabel>{' '}
+                  <S.AuthResults>
+                    <S.AuthBadge $result={email.auth.spf} title={email.auth.spf_domain}>
+                      SPF {email.auth.spf}
+                    </S.AuthBadge>
+                    <S.AuthBadge $result={email.auth.dkim} title={email.auth.dkim_domain}>
+                      DKIM {email.auth.dkim}
+                    </S.AuthBadge>
+                    <S.AuthBadge
+                      $result={email.auth.dmarc}
+                      title={email.auth.dmarc_policy ? `p=${email.auth.dmarc_policy}` : undefined}
+                    >
+                      DMARC {email.auth.dmarc}
+                    </S.AuthBadge>
+                  </S.AuthResults>
+                </S.MetaItem>
+              )}
               {email.message_id && (
                 <S.MetaItem $fullWidth>
                   <S.MetaLabel>Message-ID:</S.MetaLabel>{' '}
@@ -58,7 +90,14 @@ export default function EmailShow({ email, mailbox }: Props) {
 
             {email.attachments && email.attachments.length > 0 && (
               <S.AttachmentsSection>
-                <S.AttachmentsTitle>Attachments</S.AttachmentsTitle>
+                <S.AttachmentsHeader>
+                  <S.AttachmentsTitle>Attachments</S.AttachmentsTitle>
+                  {email.attachments.length > 1 && (
+                    <S.RawLink href={`/mailboxes/${mailbox.id}/emails/${email.id}/attachments.zip`}>
+                      Download all (.zip)
+                    </S.RawLink>
+                  )}
+                </S.AttachmentsHeader>
                 <S.AttachmentsList>
                   {email.attachments.map((att) => (
                     <S.AttachmentLink key={att.id} href={att.download_url}>
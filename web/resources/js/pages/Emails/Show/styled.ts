@@ -58,16 +58,59 @@ export const MessageId = styled.span`
   word-break: break-all;
 `;
 
+export const RawLink = styled.a`
+  color: ${({ theme }) => theme.colors.primary[600]};
+
+  &:hover {
+    text-decoration: underline;
+  }
+`;
+
+export const AuthResults = styled.span`
+  display: inline-flex;
+  flex-wrap: wrap;
+  gap: ${({ theme }) => theme.spacing[2]};
+`;
+
+export const AuthBadge = styled.span<{ $result: string }>`
+  padding: 0 ${({ theme }) => theme.spacing[2]};
+  font-size: ${({ theme }) => theme.fontSizes.xs};
+  border-radius: ${({ theme }) => theme.radii.full};
+  background-color: ${({ theme, $result }) =>
+    $result === 'pass'
+      ? theme.colors.green[50]
+      : $result === 'fail' || $result === 'permerror'
+        ? theme.colors.red[50]
+        : $result === 'softfail' || $result === 'temperror'
+          ? theme.colors.yellow[50]
+          : theme.colors.surface.tertiary};
+  color: ${({ theme, $result }) =>
+    $result === 'pass'
+      ? theme.colors.green[700]
+      : $result === 'fail' || $result === 'permerror'
+        ? theme.colors.red[700]
+        : $result === 'softfail' || $result === 'temperror'
+          ? theme.colors.yellow[700]
+          : theme.colors.text.secondary};
+`;
+
 export const AttachmentsSection = styled.div`
   border-top: 1px solid ${({ theme }) => theme.colors.border.primary};
   padding-top: ${({ theme }) => theme.spacing[4]};
   margin-bottom: ${({ theme }) => theme.spacing[6]};
 `;
 
+export const AttachmentsHeader = styled.div`
+  display: flex;
+  align-items: baseline;
+  justify-content: space-between;
+  margin-bottom: ${({ theme }) => theme.spacing[2]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+`;
+
 export const AttachmentsTitle = styled.h3`
   font-weight: ${({ theme }) => theme.fontWeights.medium};
   color: ${({ theme }) => theme.colors.text.secondary};
-  margin-bottom: ${({ theme }) => theme.spacing[2]};
 `;
 
 export const AttachmentsList = styled.div`
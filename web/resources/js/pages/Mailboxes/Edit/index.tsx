@@ -24,6 +24,9 @@ export default function MailboxEdit({ mailbox, allMailboxes, users, domains, all
   const [selectedTagIds, setSelectedTagIds] = useState<number[]>(mailboxTags.map((t) => t.id));
   const [tagsSaving, setTagsSaving] = useState(false);
   const [deleteModalOpen, setDeleteModalOpen] = useState(false);
+  const [aliasSlug, setAliasSlug] = useState('');
+  const [aliasDomainId, setAliasDomainId] = useState(mailbox.domain_id?.toString() || '');
+  const [aliasSaving, setAliasSaving] = useState(false);
 
   const { data, setData, put, processing } = useForm({
     slug: mailbox.slug,
@@ -62,6 +65,25 @@ export default function MailboxEdit({ mailbox, allMailboxes, users, domains, all
     });
   };
 
+  const handleAddAlias = (e: React.FormEvent) => {
+    e.preventDefault();
+    setAliasSaving(true);
+    router.post(
+      `/mailboxes/${mailbox.id}/aliases`,
+      { slug: aliasSlug, domain_id: aliasDomainId },
+      {
+        preserveScroll: true,
+        onSuccess: () => setAliasSlug(''),
+        onFinish: () => setAliasSaving(false),
+      }
+    );
+  };
+
+  const handleDeleteAlias = (aliasId: number) => {
+    router.delete(`/mailboxes/${mailbox.id}/aliases/${aliasId}`, { preserveScroll: true });
+  };
+
+  const aliases = mailbox.aliases || [];
   const selectedDomain = domains.find((d) => String(d.id) === data.domain_id);
   const selectedTags = allTags.filter((t) => selectedTagIds.includes(t.id));
 
@@ -216,6 +238,60 @@ export default function MailboxEdit({ mailbox, allMailboxes, users, domains, all
             </S.FormActionsRight>
           </S.FormActions>
         </S.Form>
+
+        <S.AliasesCard>
+          <Card>
+            <S.FormCard>
+              <div>
+                <S.SectionTitle>Aliases</S.SectionTitle>
+                <S.HelperText>
+                  Extra addresses that deliver to this mailbox. They can live on any domain.
+                </S.HelperText>
+              </div>
+
+              {aliases.length > 0 && (
+                <S.AliasList>
+                  {aliases.map((alias) => (
+                    <S.AliasRow key={alias.id}>
+                      <S.AliasAddress>
+                        {alias.slug}@{alias.domain_name}
+                      </S.AliasAddress>
+                      <Button
+                        type="button"
+                        variant="danger"
+                        size="sm"
+                        onClick={() => handleDeleteAlias(alias.id)}
+                      >
+                        Remove
+                      </Button>
+                    </S.AliasRow>
+                  ))}
+                </S.AliasList>
+              )}
+
+              <S.AliasForm onSubmit={handleAddAlias}>
+                <Input
+                  type="text"
+                  value={aliasSlug}
+                  onChange={(e) => setAliasSlug(e.target.value.toLowerCase().replace(/[^a-z0-9-]/g, ''))}
+                  placeholder="billing"
+                  required
+                />
+                <S.AliasAt>@</S.AliasAt>
+                <Select value={aliasDomainId} onChange={(e) => setAliasDomainId(e.target.value)} required>
+                  {domains.map((domain) => (
+                    <option key={domain.id} value={domain.id}>
+                      {domain.name}
+                    </option>
+                  ))}
+                </Select>
+                <Button type="submit" disabled={aliasSaving || !aliasSlug || !aliasDomainId}>
+                  Add Alias
+                </Button>
+              </S.AliasForm>
+            </S.FormCard>
+          </Card>
+        </S.AliasesCard>
       </S.Container>
 
       <ConfirmModal
@@ -113,3 +113,48 @@ export const FormActionsRight = styled.div`
   display: flex;
   gap: ${({ theme }) => theme.spacing[3]};
 `;
+
+export const AliasesCard = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[8]};
+
+  h2 {
+    margin-bottom: 0;
+  }
+`;
+
+export const AliasList = styled.div`
+  display: flex;
+  flex-direction: column;
+  border: 1px solid ${({ theme }) => theme.colors.border.secondary};
+  border-radius: ${({ theme }) => theme.radii.md};
+`;
+
+export const AliasRow = styled.div`
+  display: flex;
+  align-items: center;
+  justify-content: space-between;
+  padding: ${({ theme }) => `${theme.spacing[2]} ${theme.spacing[3]}`};
+
+  & + & {
+    border-top: 1px solid ${({ theme }) => theme.colors.border.secondary};
+  }
+`;
+
+export const AliasAddress = styled.code`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.primary};
+`;
+
+export const AliasForm = styled.form`
+  display: flex;
+  align-items: center;
+  gap: ${({ theme }) => theme.spacing[2]};
+
+  @media (max-width: ${({ theme }) => theme.breakpoints.sm}) {
+    flex-wrap: wrap;
+  }
+`;
+
+export const AliasAt = styled.span`
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
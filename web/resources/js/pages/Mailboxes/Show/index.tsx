@@ -3,10 +3,10 @@ import { Link, router } from '@inertiajs/react';
 import MailboxLayout from '@/layouts/MailboxLayout';
 import { Badge } from '@/components/Badge';
 import { Button } from '@/components/Button';
-import { Input } from '@/components/Input';
+import { Input, Select, ErrorText } from '@/components/Input';
 import { ConfirmModal } from '@/components/ConfirmModal';
 import { useToast } from '@/contexts/ToastContext';
-import { Mailbox, Email, Pagination, PageProps } from '@/types';
+import { Mailbox, Email, Pagination, SubaddressCount, PageProps } from '@/types';
 import * as S from './styled';
 
 interface Props extends PageProps {
@@ -15,10 +15,23 @@ interface Props extends PageProps {
   emails: Email[];
   pagination: Pagination;
   search: string;
+  searchError: string;
+  subaddress: string;
+  subaddresses: SubaddressCount[];
   emailId?: string;
 }
 
-export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmails, pagination, search, emailId }: Props) {
+export default function MailboxShow({
+  mailbox,
+  allMailboxes,
+  emails: initialEmails,
+  pagination,
+  search,
+  searchError,
+  subaddress,
+  subaddresses,
+  emailId,
+}: Props) {
   const [emails, setEmails] = useState<Email[]>(initialEmails);
   const [selectedEmail, setSelectedEmail] = useState<Email | null>(() => {
     if (emailId) {
@@ -47,6 +60,15 @@ export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmai
     router.get(`/mailboxes/${mailbox.id}`, { search: searchQuery }, { preserveState: true });
   };
 
+  const handleSubaddressFilter = (value: string) => {
+    setSearchQuery('');
+    router.get(`/mailboxes/${mailbox.id}`, value ? { subaddress: value } : {}, { preserveState: true });
+  };
+
+  const pageQuery = subaddress
+    ? `subaddress=${encodeURIComponent(subaddress)}`
+    : `search=${encodeURIComponent(search || '')}`;
+
   const handleRefresh = () => {
     router.reload({
       only: ['emails', 'pagination'],
@@ -141,11 +163,24 @@ export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmai
             <form onSubmit={handleSearch}>
               <Input
                 type="text"
-                placeholder="Search emails..."
+                placeholder="Search, e.g. from:acme has:attachment"
                 value={searchQuery}
                 onChange={(e) => setSearchQuery(e.target.value)}
               />
             </form>
+            {searchError && <ErrorText>{searchError}</ErrorText>}
+            {subaddresses?.length > 0 && (
+              <S.SubaddressFilter>
+                <Select value={subaddress || ''} onChange={(e) => handleSubaddressFilter(e.target.value)}>
+                  <option value="">All addresses</option>
+                  {subaddresses.map((item) => (
+                    <option key={item.subaddress} value={item.subaddress}>
+                      +{item.subaddress} ({item.email_count})
+                    </option>
+                  ))}
+                </Select>
+              </S.SubaddressFilter>
+            )}
           </S.SearchBox>
           <S.EmailListItems>
             {emails.length === 0 ? (
@@ -213,9 +248,7 @@ export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmai
                 {pagination.current_page > 1 && (
                   <S.PageLink
                     as={Link}
-                    href={`/mailboxes/${mailbox.id}?page=${pagination.current_page - 1}&search=${
-                      search || ''
-                    }`}
+                    href={`/mailboxes/${mailbox.id}?page=${pagination.current_page - 1}&${pageQuery}`}
                   >
                     Previous
                   </S.PageLink>
@@ -223,9 +256,7 @@ export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmai
                 {pagination.current_page < Math.ceil(pagination.total / pagination.per_page) && (
                   <S.PageLink
                     as={Link}
-                    href={`/mailboxes/${mailbox.id}?page=${pagination.current_page + 1}&search=${
-                      search || ''
-                    }`}
+                    href={`/mailboxes/${mailbox.id}?page=${pagination.current_page + 1}&${pageQuery}`}
                   >
                     Next
                   </S.PageLink>
@@ -272,6 +303,11 @@ export default function MailboxShow({ mailbox, allMailboxes, emails: initialEmai
                   <S.MetaItem>
                     <S.MetaLabel>To:</S.MetaLabel> <S.MetaValue>{selectedEmail.to_address}</S.MetaValue>
                   </S.MetaItem>
+                  {selectedEmail.alias && (
+                    <S.MetaItem>
+                      <S.MetaLabel>Via alias:</S.MetaLabel> <S.MetaValue>{selectedEmail.alias}</S.MetaValue>
+                    </S.MetaItem>
+                  )}
                   <S.MetaItem>
                     <S.MetaLabel>Date:</S.MetaLabel>{' '}
                     <S.MetaValue>
@@ -79,6 +79,10 @@ export const SearchBox = styled.div`
   border-bottom: 1px solid ${({ theme }) => theme.colors.border.primary};
 `;
 
+export const SubaddressFilter = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[2]};
+`;
+
 export const EmailListItems = styled.div`
   flex: 1;
   overflow-y: auto;
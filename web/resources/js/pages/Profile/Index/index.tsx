@@ -7,16 +7,24 @@ import { Button, LinkButton } from '@/components/Button';
 import { FormGroup, Input } from '@/components/Input';
 import { Avatar } from '@/components/Avatar';
 import { useToast } from '@/contexts/ToastContext';
-import { User, PageProps } from '@/types';
+import { User, ApiToken, PageProps } from '@/types';
 import * as S from './styled';
 
 interface Props extends PageProps {
   user: User;
+  apiTokens: ApiToken[];
+  apiScopes: string[];
   error?: string;
 }
 
-export default function Profile({ user, error }: Props) {
+export default function Profile({ user, apiTokens, apiScopes, error }: Props) {
   const { showToast } = useToast();
+  const [tokenName, setTokenName] = useState('');
+  const [tokenScopes, setTokenScopes] = useState<string[]>([]);
+  const [tokenExpiresInDays, setTokenExpiresInDays] = useState('90');
+  const [tokenError, setTokenError] = useState('');
+  const [creatingToken, setCreatingToken] = useState(false);
+  const [newToken, setNewToken] = useState('');
   const [showDisableModal, setShowDisableModal] = useState(false);
   const [disablePassword, setDisablePassword] = useState('');
   const [disableError, setDisableError] = useState('');
@@ -104,6 +112,67 @@ export default function Profile({ user, error }: Props) {
     }
   };
 
+  const toggleTokenScope = (scope: string) => {
+    setTokenScopes((scopes) =>
+      scopes.includes(scope) ? scopes.filter((s) => s !== scope) : [...scopes, scope]
+    );
+  };
+
+  const handleCreateToken = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setCreatingToken(true);
+    setTokenError('');
+
+    const token = document.cookie.match(/XSRF-TOKEN=([^;]+)/)?.[1];
+
+    try {
+      const response = await fetch('/profile/tokens', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-XSRF-TOKEN': token ? decodeURIComponent(token) : '',
+        },
+        body: JSON.stringify({
+          name: tokenName,
+          scopes: tokenScopes,
+          expires_in_days: parseInt(tokenExpiresInDays, 10) || 0,
+        }),
+      });
+
+      const data = await response.json();
+      if (response.ok) {
+        setNewToken(data.token);
+        setTokenName('');
+        setTokenScopes([]);
+        router.reload({ only: ['apiTokens'] });
+      } else {
+        setTokenError(data.error || 'Failed to create token');
+      }
+    } catch {
+      setTokenError('An error occurred');
+    } finally {
+      setCreatingToken(false);
+    }
+  };
+
+  const handleDeleteToken = async (apiToken: ApiToken) => {
+    if (!confirm(`Revoke token "${apiToken.name}"? Applications using it will stop working.`)) return;
+
+    const token = document.cookie.match(/XSRF-TOKEN=([^;]+)/)?.[1];
+
+    const response = await fetch(`/profile/tokens/${apiToken.id}`, {
+      method: 'DELETE',
+      headers: {
+        'X-XSRF-TOKEN': token ? decodeURIComponent(token) : '',
+      },
+    });
+
+    if (response.ok) {
+      showToast('Token revoked');
+      router.reload({ only: ['apiTokens'] });
+    }
+  };
+
   return (
     <AppLayout>
       <S.Container>
@@ -230,6 +299,111 @@ export default function Profile({ user, error }: Props) {
           </S.SecurityCard>
         </Card>
 
+        <S.SectionHeader>
+          <S.SectionTitle>API Tokens</S.SectionTitle>
+        </S.SectionHeader>
+
+        <Card>
+          <S.FormCard>
+            <S.Form onSubmit={handleCreateToken}>
+              <S.SecurityDescription style={{ marginBottom: 0 }}>
+                Personal access tokens authenticate requests to the <code>/api/v1</code> REST API with
+                an <code>Authorization: Bearer</code> header. A token only grants the selected scopes
+                and never more than your own account can do.
+              </S.SecurityDescription>
+
+              {tokenError && <Alert variant="error">{tokenError}</Alert>}
+
+              {newToken && (
+                <FormGroup label="New token">
+                  <S.NewToken>{newToken}</S.NewToken>
+                  <S.HelperText>Copy this token now. It will not be shown again.</S.HelperText>
+                </FormGroup>
+              )}
+
+              <S.FieldRow>
+                <FormGroup label="Name" htmlFor="token_name">
+                  <Input
+                    id="token_name"
+                    type="text"
+                    value={tokenName}
+                    onChange={(e) => setTokenName(e.target.value)}
+                    placeholder="CI pipeline"
+                  />
+                </FormGroup>
+                <FormGroup label="Expires in (days)" htmlFor="token_expires">
+                  <Input
+                    id="token_expires"
+                    type="number"
+                    min="0"
+                    value={tokenExpiresInDays}
+                    onChange={(e) => setTokenExpiresInDays(e.target.value)}
+                  />
+                  <S.HelperText>0 means the token never expires.</S.HelperText>
+                </FormGroup>
+              </S.FieldRow>
+
+              <FormGroup label="Scopes">
+                <S.ScopeGrid>
+                  {apiScopes.map((scope) => (
+                    <S.ScopeOption key={scope}>
+                      <input
+                        type="checkbox"
+                        checked={tokenScopes.includes(scope)}
+                        onChange={() => toggleTokenScope(scope)}
+                      />
+                      {scope}
+                    </S.ScopeOption>
+                  ))}
+                </S.ScopeGrid>
+              </FormGroup>
+
+              <S.FormActions>
+                <Button type="submit" disabled={creatingToken || !tokenName || tokenScopes.length === 0}>
+                  {creatingToken ? 'Creating...' : 'Create Token'}
+                </Button>
+              </S.FormActions>
+            </S.Form>
+          </S.FormCard>
+        </Card>
+
+        <Card style={{ marginTop: '1rem' }}>
+          {apiTokens.length === 0 ? (
+            <S.EmptyTokens>No API tokens yet.</S.EmptyTokens>
+          ) : (
+            <S.TokenList>
+              {apiTokens.map((apiToken) => (
+                <S.TokenRow key={apiToken.id}>
+                  <S.TokenInfo>
+                    <S.TokenName>
+                      {apiToken.name} <S.TokenPrefix>{apiToken.prefix}…</S.TokenPrefix>
+                    </S.TokenName>
+                    <S.TokenMeta>{apiToken.scopes.join(', ')}</S.TokenMeta>
+                    <S.TokenMeta>
+                      {apiToken.expires_at
+                        ? `Expires ${new Date(apiToken.expires_at).toLocaleDateString()}`
+                        : 'Never expires'}
+                      {' · '}
+                      {apiToken.last_used_at
+                        ? `Last used ${new Date(apiToken.last_used_at).toLocaleString()}`
+                        : 'Never used'}
+                    </S.TokenMeta>
+                  </S.TokenInfo>
+                  <Button
+                    type="button"
+                    variant="ghost"
+                    size="sm"
+                    onClick={() => handleDeleteToken(apiToken)}
+                    style={{ color: '#dc2626' }}
+                  >
+                    Revoke
+                  </Button>
+                </S.TokenRow>
+              ))}
+            </S.TokenList>
+          )}
+        </Card>
+
         {showDisableModal && (
           <S.ModalOverlay onClick={() => setShowDisableModal(false)}>
             <S.Modal onClick={(e) => e.stopPropagation()}>
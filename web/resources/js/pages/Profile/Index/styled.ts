@@ -157,3 +157,73 @@ export const ModalActions = styled.div`
   justify-content: flex-end;
   margin-top: ${({ theme }) => theme.spacing[4]};
 `;
+
+export const TokenList = styled.div`
+  display: flex;
+  flex-direction: column;
+`;
+
+export const TokenRow = styled.div`
+  display: flex;
+  align-items: center;
+  justify-content: space-between;
+  gap: ${({ theme }) => theme.spacing[4]};
+  padding: ${({ theme }) => `${theme.spacing[4]} ${theme.spacing[6]}`};
+  border-bottom: 1px solid ${({ theme }) => theme.colors.border.primary};
+
+  &:last-child {
+    border-bottom: none;
+  }
+`;
+
+export const TokenInfo = styled.div`
+  min-width: 0;
+`;
+
+export const TokenName = styled.div`
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  font-weight: ${({ theme }) => theme.fontWeights.medium};
+  color: ${({ theme }) => theme.colors.text.primary};
+`;
+
+export const TokenMeta = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[1]};
+  font-size: ${({ theme }) => theme.fontSizes.xs};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const TokenPrefix = styled.code`
+  font-family: ${({ theme }) => theme.fonts.mono};
+`;
+
+export const EmptyTokens = styled.p`
+  padding: ${({ theme }) => theme.spacing[6]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.tertiary};
+`;
+
+export const ScopeGrid = styled.div`
+  display: grid;
+  grid-template-columns: repeat(2, 1fr);
+  gap: ${({ theme }) => theme.spacing[2]};
+`;
+
+export const ScopeOption = styled.label`
+  display: flex;
+  align-items: center;
+  gap: ${({ theme }) => theme.spacing[2]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.secondary};
+  cursor: pointer;
+`;
+
+export const NewToken = styled.div`
+  padding: ${({ theme }) => theme.spacing[3]};
+  background-color: ${({ theme }) => theme.colors.surface.secondary};
+  border: 1px solid ${({ theme }) => theme.colors.border.primary};
+  border-radius: ${({ theme }) => theme.radii.md};
+  font-family: ${({ theme }) => theme.fonts.mono};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: ${({ theme }) => theme.colors.text.primary};
+  word-break: break-all;
+`;
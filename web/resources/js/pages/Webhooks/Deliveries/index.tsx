@@ -87,22 +87,27 @@ export default function WebhookDeliveries({ mailbox, allMailboxes, webhook, deli
             <S.TableHead>
               <tr>
                 <S.TableHeader>Date</S.TableHeader>
+                <S.TableHeader>Event</S.TableHeader>
                 <S.TableHeader>Status</S.TableHeader>
                 <S.TableHeader>HTTP Code</S.TableHeader>
                 <S.TableHeader>Duration</S.TableHeader>
                 <S.TableHeader>Attempt</S.TableHeader>
+                <S.TableHeader>Next Attempt</S.TableHeader>
                 <S.TableHeader $align="right">Actions</S.TableHeader>
               </tr>
             </S.TableHead>
             <S.TableBody>
               {deliveries.length === 0 ? (
                 <tr>
-                  <S.EmptyCell colSpan={6}>No deliveries yet</S.EmptyCell>
+                  <S.EmptyCell colSpan={8}>No deliveries yet</S.EmptyCell>
                 </tr>
               ) : (
                 deliveries.map((delivery) => (
                   <S.TableRow key={delivery.id}>
                     <S.TableCell>{formatDate(delivery.created_at)}</S.TableCell>
+                    <S.TableCell title={delivery.idempotency_key ? `Delivery ID ${delivery.idempotency_key}` : undefined}>
+                      <S.GrayText>{delivery.event}</S.GrayText>
+                    </S.TableCell>
                     <S.TableCell>
                       <Badge variant={getStatusVariant(delivery.status)}>{delivery.status}</Badge>
                     </S.TableCell>
@@ -117,11 +122,20 @@ export default function WebhookDeliveries({ mailbox, allMailboxes, webhook, deli
                     <S.TableCell>
                       <S.GrayText>{delivery.attempt}</S.GrayText>
                     </S.TableCell>
+                    <S.TableCell>
+                      <S.GrayText>
+                        {delivery.status === 'retrying' && delivery.next_attempt_at
+                          ? formatDate(delivery.next_attempt_at)
+                          : '-'}
+                      </S.GrayText>
+                    </S.TableCell>
                     <S.TableCell $align="right">
                       <S.ActionLinks>
-                        <S.ViewEmailLink href={`/mailboxes/${mailbox.id}?email_id=${delivery.email_id}`}>
-                          View Email
-                        </S.ViewEmailLink>
+                        {delivery.email_id && (
+                          <S.ViewEmailLink href={`/mailboxes/${mailbox.id}?email_id=${delivery.email_id}`}>
+                            View Email
+                          </S.ViewEmailLink>
+                        )}
                         {delivery.status === 'failed' && (
                           <S.RetryButton onClick={() => handleRetry(delivery.id)}>
                             Retry
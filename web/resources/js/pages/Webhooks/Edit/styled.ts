@@ -49,6 +49,12 @@ export const HelperText = styled.p`
   color: ${({ theme }) => theme.colors.text.tertiary};
 `;
 
+export const WarningText = styled.p`
+  margin-top: ${({ theme }) => theme.spacing[1]};
+  font-size: ${({ theme }) => theme.fontSizes.sm};
+  color: #ca8a04;
+`;
+
 export const FieldRow = styled.div`
   display: grid;
   grid-template-columns: repeat(2, 1fr);
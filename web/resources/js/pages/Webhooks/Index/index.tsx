@@ -5,7 +5,7 @@ import { Card } from '@/components/Card';
 import { LinkButton } from '@/components/Button';
 import { ConfirmModal } from '@/components/ConfirmModal';
 import { ToggleSwitch } from '@/components/ToggleSwitch';
-import { Mailbox, Webhook, PageProps } from '@/types';
+import { Mailbox, Webhook, PageProps, webhookDestination } from '@/types';
 import * as S from './styled';
 
 interface Props extends PageProps {
@@ -80,7 +80,7 @@ export default function WebhooksIndex({ mailbox, allMailboxes, webhooks }: Props
                       </S.WebhookLink>
                     </S.TableCell>
                     <S.TableCell>
-                      <S.UrlText title={webhook.url}>{webhook.url}</S.UrlText>
+                      <S.UrlText title={webhookDestination(webhook)}>{webhookDestination(webhook)}</S.UrlText>
                     </S.TableCell>
                     <S.TableCell>
                       <ToggleSwitch
@@ -1,18 +1,47 @@
 import { useState } from 'react';
+import { router } from '@inertiajs/react';
 import MailboxLayout from '@/layouts/MailboxLayout';
 import { Card } from '@/components/Card';
 import { Badge } from '@/components/Badge';
 import { Button, LinkButton } from '@/components/Button';
-import { Mailbox, Webhook, PageProps } from '@/types';
+import { ConfirmModal } from '@/components/ConfirmModal';
+import { Mailbox, Webhook, PageProps, webhookDestination } from '@/types';
 import * as S from './styled';
 
 interface Props extends PageProps {
   mailbox: Mailbox;
   allMailboxes: Mailbox[];
   webhook: Webhook;
+  nextProbeAt: string | null;
+  verificationKey?: string;
+  ed25519PublicKey?: string;
+  secretGraceHours: number;
 }
 
-export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
+const circuitLabels: Record<Webhook['circuit_state'], string> = {
+  closed: 'Closed',
+  open: 'Open',
+  half_open: 'Probing',
+};
+
+const circuitVariants: Record<Webhook['circuit_state'], 'success' | 'error' | 'warning'> = {
+  closed: 'success',
+  open: 'error',
+  half_open: 'warning',
+};
+
+export default function WebhookShow({
+  mailbox,
+  allMailboxes,
+  webhook,
+  nextProbeAt,
+  verificationKey,
+  ed25519PublicKey,
+  secretGraceHours,
+}: Props) {
+  const [rotateModal, setRotateModal] = useState(false);
+  const [rotating, setRotating] = useState(false);
+
   const [testResult, setTestResult] = useState<{
     loading: boolean;
     status_code?: number;
@@ -34,6 +63,25 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
     }
   };
 
+  const handleRotate = () => {
+    setRotating(true);
+    router.post(`/mailboxes/${mailbox.id}/webhooks/${webhook.id}/rotate-secret`, {}, {
+      preserveScroll: true,
+      onFinish: () => {
+        setRotating(false);
+        setRotateModal(false);
+      },
+    });
+  };
+
+  const handleRevokeSecrets = () => {
+    router.delete(`/mailboxes/${mailbox.id}/webhooks/${webhook.id}/previous-secrets`, {
+      preserveScroll: true,
+    });
+  };
+
+  const previousSecrets = webhook.previous_secrets ?? [];
+
   return (
     <MailboxLayout mailbox={mailbox} allMailboxes={allMailboxes}>
       <S.Header>
@@ -43,6 +91,11 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
             <Badge variant={webhook.is_active ? 'success' : 'gray'}>
               {webhook.is_active ? 'Active' : 'Inactive'}
             </Badge>
+            {webhook.circuit_state !== 'closed' && (
+              <Badge variant={circuitVariants[webhook.circuit_state]}>
+                Circuit {circuitLabels[webhook.circuit_state].toLowerCase()}
+              </Badge>
+            )}
           </S.TitleSection>
           <S.Actions>
             <Button variant="secondary" onClick={handleTest} disabled={testResult.loading}>
@@ -64,7 +117,7 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
       {testResult.status_code !== undefined && (
         <S.TestResult $success={!testResult.error && testResult.status_code < 400}>
           <S.TestResultTitle>
-            Test Result: HTTP {testResult.status_code}
+            Test Result: {testResult.status_code ? `HTTP ${testResult.status_code}` : testResult.error ? 'Failed' : 'Delivered'}
             {testResult.error && ` - ${testResult.error}`}
           </S.TestResultTitle>
           {testResult.response && <S.TestResultBody>{testResult.response}</S.TestResultBody>}
@@ -77,13 +130,26 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
             <S.CardTitle>Configuration</S.CardTitle>
             <S.DefinitionList>
               <div>
-                <S.DefinitionTerm>URL</S.DefinitionTerm>
-                <S.DefinitionValue>{webhook.url}</S.DefinitionValue>
+                <S.DefinitionTerm>{webhook.target === 'http' ? 'URL' : 'Target'}</S.DefinitionTerm>
+                <S.DefinitionValue>{webhookDestination(webhook)}</S.DefinitionValue>
               </div>
               <div>
                 <S.DefinitionTerm>Method</S.DefinitionTerm>
                 <S.DefinitionValue>{webhook.method}</S.DefinitionValue>
               </div>
+              <div>
+                {webhook.method === 'GET' ? (
+                  <>
+                    <S.DefinitionTerm>Query Parameters</S.DefinitionTerm>
+                    <S.DefinitionValue>{webhook.query_fields?.length ? webhook.query_fields.join(', ') : 'None'}</S.DefinitionValue>
+                  </>
+                ) : (
+                  <>
+                    <S.DefinitionTerm>Body Encoding</S.DefinitionTerm>
+                    <S.DefinitionValue>{webhook.encoding}</S.DefinitionValue>
+                  </>
+                )}
+              </div>
               <div>
                 <S.DefinitionTerm>Timeout</S.DefinitionTerm>
                 <S.DefinitionValue>{webhook.timeout_sec} seconds</S.DefinitionValue>
@@ -92,12 +158,70 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
                 <S.DefinitionTerm>Max Retries</S.DefinitionTerm>
                 <S.DefinitionValue>{webhook.max_retries}</S.DefinitionValue>
               </div>
+              <div>
+                <S.DefinitionTerm>Events</S.DefinitionTerm>
+                <S.DefinitionValue>{(webhook.events ?? ['email.received']).join(', ')}</S.DefinitionValue>
+              </div>
+              <div>
+                <S.DefinitionTerm>Retry Backoff</S.DefinitionTerm>
+                <S.DefinitionValue>
+                  {webhook.retry_base_delay_sec}s doubling up to {webhook.retry_max_delay_sec}s,{' '}
+                  {webhook.retry_jitter_percent}% jitter
+                </S.DefinitionValue>
+              </div>
               <div>
                 <S.DefinitionTerm>HMAC Secret</S.DefinitionTerm>
                 <S.DefinitionValue>
-                  {webhook.hmac_secret ? '(configured)' : '(not set)'}
+                  {webhook.has_hmac_secret ? '(configured)' : '(not set)'}
+                </S.DefinitionValue>
+                {previousSecrets.map((secret) => (
+                  <S.DefinitionValue key={secret.id}>
+                    Previous secret valid until {new Date(secret.expires_at).toLocaleString()}
+                  </S.DefinitionValue>
+                ))}
+                <S.SecretActions>
+                  <Button size="sm" variant="secondary" onClick={() => setRotateModal(true)}>
+                    Rotate secret
+                  </Button>
+                  {previousSecrets.length > 0 && (
+                    <Button size="sm" variant="ghost" onClick={handleRevokeSecrets}>
+                      Revoke previous
+                    </Button>
+                  )}
+                </S.SecretActions>
+              </div>
+              <div>
+                <S.DefinitionTerm>Connection</S.DefinitionTerm>
+                <S.DefinitionValue>
+                  {[
+                    webhook.proxy_url ? `Proxy ${webhook.proxy_url}` : 'Direct',
+                    webhook.tls_client_cert ? 'client certificate' : null,
+                    webhook.tls_ca_bundle ? 'custom CA bundle' : null,
+                    webhook.redirect_policy === 'none'
+                      ? 'redirects not followed'
+                      : webhook.redirect_policy === 'same_host'
+                        ? 'same-host redirects only'
+                        : null,
+                  ]
+                    .filter(Boolean)
+                    .join(', ')}
                 </S.DefinitionValue>
+                {webhook.tls_skip_verify && (
+                  <Badge variant="warning">TLS certificates are not verified</Badge>
+                )}
               </div>
+              {ed25519PublicKey && (
+                <div>
+                  <S.DefinitionTerm>Ed25519 Public Key</S.DefinitionTerm>
+                  <S.KeyValue>{ed25519PublicKey}</S.KeyValue>
+                </div>
+              )}
+              {verificationKey && (
+                <div>
+                  <S.DefinitionTerm>SendGrid Verification Key</S.DefinitionTerm>
+                  <S.KeyValue>{verificationKey}</S.KeyValue>
+                </div>
+              )}
               <div>
                 <S.DefinitionTerm>Include Body</S.DefinitionTerm>
                 <S.DefinitionValue>{webhook.include_body ? 'Yes' : 'No'}</S.DefinitionValue>
@@ -141,6 +265,35 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
             ) : (
               <S.EmptyText>No deliveries yet</S.EmptyText>
             )}
+
+            <S.CircuitSection>
+              <S.DefinitionList>
+                <div>
+                  <S.DefinitionTerm>Circuit Breaker</S.DefinitionTerm>
+                  <S.DefinitionValue>
+                    <Badge variant={circuitVariants[webhook.circuit_state]}>
+                      {circuitLabels[webhook.circuit_state]}
+                    </Badge>
+                  </S.DefinitionValue>
+                </div>
+                <div>
+                  <S.DefinitionTerm>Consecutive Failures</S.DefinitionTerm>
+                  <S.DefinitionValue>{webhook.consecutive_failures}</S.DefinitionValue>
+                </div>
+                {webhook.failing_since && (
+                  <div>
+                    <S.DefinitionTerm>Failing Since</S.DefinitionTerm>
+                    <S.DefinitionValue>{new Date(webhook.failing_since).toLocaleString()}</S.DefinitionValue>
+                  </div>
+                )}
+                {nextProbeAt && (
+                  <div>
+                    <S.DefinitionTerm>Next Probe</S.DefinitionTerm>
+                    <S.DefinitionValue>{new Date(nextProbeAt).toLocaleString()}</S.DefinitionValue>
+                  </div>
+                )}
+              </S.DefinitionList>
+            </S.CircuitSection>
           </S.CardContent>
         </Card>
       </S.Grid>
@@ -165,6 +318,21 @@ export default function WebhookShow({ mailbox, allMailboxes, webhook }: Props) {
           </S.CardContent>
         </Card>
       </S.RulesCard>
+
+      <ConfirmModal
+        isOpen={rotateModal}
+        onClose={() => setRotateModal(false)}
+        onConfirm={handleRotate}
+        title="Rotate Secret"
+        description={
+          webhook.has_hmac_secret && secretGraceHours > 0
+            ? `A new secret will sign deliveries right away. The current secret keeps signing them too for ${secretGraceHours} hours, so the receiver can switch over.`
+            : 'A new random secret will sign deliveries from now on.'
+        }
+        confirmText="Rotate"
+        variant="warning"
+        isLoading={rotating}
+      />
     </MailboxLayout>
   );
 }
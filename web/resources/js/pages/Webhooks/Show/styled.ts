@@ -88,6 +88,17 @@ export const DefinitionValue = styled.dd`
   word-break: break-all;
 `;
 
+export const KeyValue = styled(DefinitionValue)`
+  font-family: 'Fira Code', 'Roboto Mono', monospace;
+  font-size: ${({ theme }) => theme.fontSizes.xs};
+`;
+
+export const SecretActions = styled.div`
+  display: flex;
+  gap: ${({ theme }) => theme.spacing[2]};
+  margin-top: ${({ theme }) => theme.spacing[2]};
+`;
+
 export const StatsGrid = styled.div`
   display: grid;
   grid-template-columns: repeat(2, 1fr);
@@ -135,6 +146,12 @@ export const StatLabel = styled.div`
   color: ${({ theme }) => theme.colors.text.tertiary};
 `;
 
+export const CircuitSection = styled.div`
+  margin-top: ${({ theme }) => theme.spacing[6]};
+  padding-top: ${({ theme }) => theme.spacing[6]};
+  border-top: 1px solid ${({ theme }) => theme.colors.border.primary};
+`;
+
 export const RulesCard = styled.div`
   margin-top: ${({ theme }) => theme.spacing[6]};
 `;
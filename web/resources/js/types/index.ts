@@ -10,16 +10,39 @@ export interface User {
   updated_at: string;
 }
 
+export interface ApiToken {
+  id: number;
+  user_id: number;
+  name: string;
+  prefix: string;
+  scopes: string[];
+  expires_at?: string | null;
+  last_used_at?: string | null;
+  created_at: string;
+}
+
 export interface Domain {
   id: number;
   name: string;
   is_verified: boolean;
   is_active: boolean;
+  require_tls: boolean;
+  catch_all_mailbox_id: number | null;
   created_at: string;
   updated_at: string;
   mailbox_count?: number;
 }
 
+export interface DomainRoute {
+  id: number;
+  domain_id: number;
+  mailbox_id: number;
+  pattern_type: 'glob' | 'regex';
+  pattern: string;
+  position: number;
+  created_at: string;
+}
+
 export interface DNSRecord {
   type: string;
   name: string;
@@ -55,6 +78,16 @@ export interface Mailbox {
   owner?: User;
   domain?: Domain;
   stats?: MailboxStats;
+  aliases?: MailboxAlias[];
+}
+
+export interface MailboxAlias {
+  id: number;
+  mailbox_id: number;
+  domain_id: number;
+  slug: string;
+  domain_name: string;
+  created_at: string;
 }
 
 export interface MailboxStats {
@@ -77,10 +110,28 @@ export interface Email {
   raw_size: number;
   received_at: string;
   is_read: boolean;
+  has_raw: boolean;
+  auth: EmailAuth;
+  alias: string;
+  subaddress: string;
   attachments: Attachment[];
   has_attachments: boolean;
 }
 
+export interface SubaddressCount {
+  subaddress: string;
+  email_count: number;
+}
+
+export interface EmailAuth {
+  spf: string;
+  spf_domain: string;
+  dkim: string;
+  dkim_domain: string;
+  dmarc: string;
+  dmarc_policy: string;
+}
+
 export interface Attachment {
   id: number;
   email_id: number;
@@ -90,6 +141,23 @@ export interface Attachment {
   download_url?: string;
 }
 
+export type WebhookPayloadType = 'default' | 'json' | 'key_value' | 'template' | 'mailgun' | 'sendgrid';
+
+export type WebhookEncoding = 'json' | 'form' | 'multipart' | 'rfc822';
+
+export type WebhookRedirectPolicy = 'follow' | 'same_host' | 'none';
+
+export type WebhookTarget = 'http' | 'smtp' | 'broker' | 'directory';
+
+export interface WebhookTargetConfig {
+  forward_to?: string[];
+  smtp_server?: string;
+  broker_driver?: string;
+  broker_url?: string;
+  broker_topic?: string;
+  directory?: string;
+}
+
 export interface Webhook {
   id: number;
   mailbox_id: number;
@@ -97,20 +165,91 @@ export interface Webhook {
   url: string;
   method: string;
   headers: Record<string, string>;
-  payload_type: string;
+  payload_type: WebhookPayloadType;
   custom_payload?: string;
-  hmac_secret?: string;
+  has_hmac_secret: boolean;
   timeout_sec: number;
   max_retries: number;
   include_body: boolean;
   include_attachments: boolean;
+  raw_mode: 'none' | 'inline' | 'url';
+  attachment_mode: 'metadata' | 'inline' | 'url';
+  attachment_max_inline_bytes: number;
+  retry_base_delay_sec: number;
+  retry_max_delay_sec: number;
+  retry_jitter_percent: number;
   is_active: boolean;
   created_at: string;
   updated_at: string;
+  circuit_state: 'closed' | 'open' | 'half_open';
+  consecutive_failures: number;
+  failing_since: string | null;
+  circuit_changed_at: string | null;
+  events: WebhookEvent[];
+  encoding: WebhookEncoding;
+  query_fields: string[] | null;
+  sign_ed25519: boolean;
+  tls_client_cert?: string;
+  has_client_key: boolean;
+  tls_ca_bundle?: string;
+  tls_skip_verify: boolean;
+  proxy_url: string;
+  has_proxy_credentials: boolean;
+  redirect_policy: WebhookRedirectPolicy;
+  target: WebhookTarget;
+  target_config: WebhookTargetConfig;
+  has_broker_credentials: boolean;
+  previous_secrets?: WebhookSecret[];
   rules: WebhookRule[];
   delivery_stats?: WebhookDeliveryStats;
 }
 
+export type WebhookEvent =
+  | 'email.received'
+  | 'email.deleted'
+  | 'email.read'
+  | 'mailbox.created'
+  | 'mailbox.updated'
+  | 'mailbox.deleted'
+  | 'domain.verified'
+  | 'delivery.failed'
+  | 'retention.purged';
+
+export const WEBHOOK_EVENTS: { value: WebhookEvent; description: string }[] = [
+  { value: 'email.received', description: 'An email arrives in this mailbox' },
+  { value: 'email.deleted', description: 'An email of this mailbox is deleted' },
+  { value: 'email.read', description: 'An email of this mailbox is opened for the first time' },
+  { value: 'mailbox.created', description: 'A mailbox is created in this domain' },
+  { value: 'mailbox.updated', description: 'A mailbox of this domain is changed' },
+  { value: 'mailbox.deleted', description: 'A mailbox of this domain is deleted' },
+  { value: 'domain.verified', description: 'This domain passes DNS verification' },
+  { value: 'delivery.failed', description: 'Another webhook of this mailbox gives up on a delivery' },
+  { value: 'retention.purged', description: 'Retention removes old emails from this mailbox' },
+];
+
+// webhookDestination describes where a webhook delivers to, for lists and
+// summaries.
+export const webhookDestination = (webhook: Webhook): string => {
+  const config = webhook.target_config || {};
+  switch (webhook.target) {
+    case 'smtp':
+      return `Forward to ${(config.forward_to || []).join(', ')}`;
+    case 'broker':
+      return `${config.broker_driver} ${config.broker_topic}`;
+    case 'directory':
+      return `Directory ${config.directory || '/'}`;
+    default:
+      return webhook.url;
+  }
+};
+
+export interface WebhookSecret {
+  id: number;
+  webhook_id: number;
+  expires_at: string;
+  created_at: string;
+}
+
 export interface WebhookRule {
   id: number;
   webhook_id: number;
@@ -124,7 +263,9 @@ export interface WebhookRule {
 export interface WebhookDelivery {
   id: number;
   webhook_id: number;
-  email_id: number;
+  event: WebhookEvent;
+  idempotency_key: string;
+  email_id: number | null;
   attempt: number;
   status: 'pending' | 'retrying' | 'success' | 'failed';
   status_code: number | null;
@@ -133,6 +274,7 @@ export interface WebhookDelivery {
   error_message?: string;
   duration_ms: number | null;
   created_at: string;
+  next_attempt_at: string | null;
 }
 
 export interface WebhookDeliveryStats {